@@ -0,0 +1,148 @@
+package autorun
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/apex/log"
+	"github.com/ooni/probe-cli/v3/internal/fsx"
+	"github.com/ooni/probe-cli/v3/internal/shellx"
+	"golang.org/x/sys/unix"
+)
+
+type managerLinux struct{}
+
+// systemdUserDir returns the directory containing the systemd user units.
+func systemdUserDir() string {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "systemd", "user")
+	}
+	return os.ExpandEnv("$HOME/.config/systemd/user")
+}
+
+var (
+	unitDir     = systemdUserDir()
+	servicePath = filepath.Join(unitDir, systemdServiceName)
+	timerPath   = filepath.Join(unitDir, systemdTimerName)
+)
+
+func runQuiteQuietly(name string, arg ...string) error {
+	log.Infof("exec: %s %s", name, strings.Join(arg, " "))
+	return shellx.RunQuiet(name, arg...)
+}
+
+func (managerLinux) LogShow() error {
+	return shellx.Run(log.Log, "journalctl", "--user", "--no-pager",
+		"--output", "short-iso", "--unit", systemdServiceName)
+}
+
+func (managerLinux) LogStream() error {
+	return shellx.Run(log.Log, "journalctl", "--user", "--no-pager",
+		"--output", "short-iso", "--follow", "--unit", systemdServiceName)
+}
+
+func (managerLinux) mustNotHaveUnits() error {
+	log.Infof("exec: test -f %s && already_registered()", timerPath)
+	if fsx.RegularFileExists(timerPath) {
+		// This is not atomic. Do we need atomicity here?
+		return errors.New("autorun: service already registered")
+	}
+	return nil
+}
+
+func (managerLinux) writeUnits() error {
+	executable, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	service, err := systemdServiceUnit(executable)
+	if err != nil {
+		return err
+	}
+	log.Infof("exec: mkdir -p %s", unitDir)
+	if err := os.MkdirAll(unitDir, 0700); err != nil {
+		return err
+	}
+	log.Infof("exec: writeUnit(%s)", servicePath)
+	if err := os.WriteFile(servicePath, service, 0600); err != nil {
+		return err
+	}
+	log.Infof("exec: writeUnit(%s)", timerPath)
+	return os.WriteFile(timerPath, systemdTimerUnit(), 0600)
+}
+
+func (managerLinux) daemonReload() error {
+	return runQuiteQuietly("systemctl", "--user", "daemon-reload")
+}
+
+func (managerLinux) start() error {
+	return runQuiteQuietly("systemctl", "--user", "enable", "--now", systemdTimerName)
+}
+
+func (m managerLinux) Start() error {
+	operations := []func() error{m.mustNotHaveUnits, m.writeUnits, m.daemonReload, m.start}
+	for _, op := range operations {
+		if err := op(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (managerLinux) stop() error {
+	if !fsx.RegularFileExists(timerPath) {
+		return nil
+	}
+	if err := runQuiteQuietly("systemctl", "--user", "disable", "--now", systemdTimerName); err != nil {
+		return err
+	}
+	return runQuiteQuietly("systemctl", "--user", "stop", systemdServiceName)
+}
+
+func (managerLinux) removeFiles() error {
+	for _, path := range []string{timerPath, servicePath} {
+		log.Infof("exec: rm -f %s", path)
+		if err := os.Remove(path); err != nil && !errors.Is(err, unix.ENOENT) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m managerLinux) Stop() error {
+	operations := []func() error{m.stop, m.removeFiles, m.daemonReload}
+	for _, op := range operations {
+		if err := op(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (managerLinux) show(unit string) (map[string]string, error) {
+	out, err := shellx.OutputQuiet("systemctl", "--user", "show",
+		"--property=LoadState,ActiveState", unit)
+	if err != nil {
+		return nil, err
+	}
+	return systemdParseShow(out), nil
+}
+
+func (m managerLinux) Status() (string, error) {
+	service, err := m.show(systemdServiceName)
+	if err != nil {
+		return "", fmt.Errorf("autorun: unexpected error: %w", err)
+	}
+	timer, err := m.show(systemdTimerName)
+	if err != nil {
+		return "", fmt.Errorf("autorun: unexpected error: %w", err)
+	}
+	return systemdStatus(service, timer)
+}
+
+func init() {
+	register("linux", managerLinux{})
+}
@@ -0,0 +1,107 @@
+package autorun
+
+//
+// systemd support code that does not depend on the platform, so
+// that we can test it without having systemd running.
+//
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"strings"
+	"text/template"
+)
+
+const (
+	// systemdServiceName is the name of the systemd service unit.
+	systemdServiceName = "org.ooni.cli.service"
+
+	// systemdTimerName is the name of the systemd timer unit.
+	systemdTimerName = "org.ooni.cli.timer"
+)
+
+var systemdServiceTemplate = `[Unit]
+Description=OONI Probe unattended run
+Wants=network-online.target
+After=network-online.target
+
+[Service]
+Type=oneshot
+ExecStart={{ .Executable }} --log-handler=syslog run unattended
+`
+
+// Like the launchd plist, we run once when we're loaded and then every hour.
+var systemdTimerTemplate = `[Unit]
+Description=Periodically run OONI Probe in the background
+
+[Timer]
+OnActiveSec=1min
+OnUnitActiveSec=1h
+RandomizedDelaySec=5min
+
+[Install]
+WantedBy=timers.target
+`
+
+// systemdQuote quotes a string for using it inside a systemd unit
+// command line, escaping the specifiers introduced by %.
+func systemdQuote(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, `%`, `%%`)
+	return `"` + r.Replace(s) + `"`
+}
+
+// systemdServiceUnit returns the service unit for the given executable.
+func systemdServiceUnit(executable string) ([]byte, error) {
+	var out bytes.Buffer
+	t := template.Must(template.New("service").Parse(systemdServiceTemplate))
+	in := struct{ Executable string }{Executable: systemdQuote(executable)}
+	if err := t.Execute(&out, in); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// systemdTimerUnit returns the timer unit.
+func systemdTimerUnit() []byte {
+	return []byte(systemdTimerTemplate)
+}
+
+// systemdParseShow parses the output of `systemctl show --property=...`,
+// which consists of KEY=VALUE lines, and returns the properties.
+func systemdParseShow(data []byte) map[string]string {
+	out := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		key, value, found := strings.Cut(scanner.Text(), "=")
+		if found {
+			out[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+	}
+	return out
+}
+
+var errSystemdMissingProperty = errors.New("autorun: missing systemd unit property")
+
+// systemdStatus computes the autorun status given the properties
+// of the service unit and of the timer unit.
+func systemdStatus(service, timer map[string]string) (string, error) {
+	for _, props := range []map[string]string{service, timer} {
+		for _, key := range []string{"LoadState", "ActiveState"} {
+			if _, found := props[key]; !found {
+				return "", errSystemdMissingProperty
+			}
+		}
+	}
+	switch service["ActiveState"] {
+	case "active", "activating", "deactivating", "reloading":
+		return StatusRunning, nil
+	}
+	if timer["LoadState"] == "loaded" {
+		switch timer["ActiveState"] {
+		case "active", "activating":
+			return StatusScheduled, nil
+		}
+	}
+	return StatusStopped, nil
+}
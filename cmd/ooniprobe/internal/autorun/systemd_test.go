@@ -0,0 +1,93 @@
+package autorun
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSystemdServiceUnit(t *testing.T) {
+	data, err := systemdServiceUnit(`/home/user/my "bin"/100%/ooniprobe`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := `ExecStart="/home/user/my \"bin\"/100%%/ooniprobe" --log-handler=syslog run unattended`
+	if !strings.Contains(string(data), expect+"\n") {
+		t.Fatal("unexpected service unit", string(data))
+	}
+	if !strings.HasPrefix(string(data), "[Unit]\n") {
+		t.Fatal("unexpected service unit", string(data))
+	}
+}
+
+func TestSystemdTimerUnit(t *testing.T) {
+	data := string(systemdTimerUnit())
+	for _, expect := range []string{"OnUnitActiveSec=1h\n", "WantedBy=timers.target\n"} {
+		if !strings.Contains(data, expect) {
+			t.Fatal("missing", expect, "in", data)
+		}
+	}
+}
+
+func TestSystemdParseShow(t *testing.T) {
+	data := []byte("LoadState=loaded\nActiveState=active\ninvalid line\n\nDescription=a=b\n")
+	expect := map[string]string{
+		"LoadState":   "loaded",
+		"ActiveState": "active",
+		"Description": "a=b",
+	}
+	if diff := cmp.Diff(expect, systemdParseShow(data)); diff != "" {
+		t.Fatal(diff)
+	}
+}
+
+func TestSystemdStatus(t *testing.T) {
+	type testcase struct {
+		name    string
+		service string
+		timer   string
+		expect  string
+		err     error
+	}
+
+	cases := []testcase{{
+		name:    "when the units are not installed",
+		service: "LoadState=not-found\nActiveState=inactive\n",
+		timer:   "LoadState=not-found\nActiveState=inactive\n",
+		expect:  StatusStopped,
+	}, {
+		name:    "when the timer is loaded but inactive",
+		service: "LoadState=loaded\nActiveState=inactive\n",
+		timer:   "LoadState=loaded\nActiveState=inactive\n",
+		expect:  StatusStopped,
+	}, {
+		name:    "when the timer is active",
+		service: "LoadState=loaded\nActiveState=inactive\n",
+		timer:   "LoadState=loaded\nActiveState=active\n",
+		expect:  StatusScheduled,
+	}, {
+		name:    "when the service is running",
+		service: "LoadState=loaded\nActiveState=activating\n",
+		timer:   "LoadState=loaded\nActiveState=active\n",
+		expect:  StatusRunning,
+	}, {
+		name:    "when the output is missing properties",
+		service: "LoadState=loaded\n",
+		timer:   "LoadState=loaded\nActiveState=active\n",
+		err:     errSystemdMissingProperty,
+	}}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			status, err := systemdStatus(systemdParseShow([]byte(tc.service)), systemdParseShow([]byte(tc.timer)))
+			if !errors.Is(err, tc.err) {
+				t.Fatal("unexpected error", err)
+			}
+			if status != tc.expect {
+				t.Fatal("expected", tc.expect, "got", status)
+			}
+		})
+	}
+}
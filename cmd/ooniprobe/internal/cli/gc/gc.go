@@ -0,0 +1,45 @@
+package gc
+
+import (
+	"time"
+
+	"github.com/alecthomas/kingpin/v2"
+	"github.com/apex/log"
+	"github.com/ooni/probe-cli/v3/cmd/ooniprobe/internal/cli/root"
+)
+
+func init() {
+	cmd := root.Command("gc", "Prune results according to the retention policy")
+	maxAgeDays := cmd.Flag(
+		"max-age-days", "Delete results older than the given number of days (overrides the config)",
+	).Int64()
+	maxResults := cmd.Flag(
+		"max-results", "Keep at most the given number of results (overrides the config)",
+	).Int64()
+	keepOnlyNotUploaded := cmd.Flag(
+		"keep-only-not-uploaded", "Delete results that have been uploaded (overrides the config)",
+	).Bool()
+
+	cmd.Action(func(_ *kingpin.ParseContext) error {
+		probeCLI, err := root.Init()
+		if err != nil {
+			log.WithError(err).Error("failed to initialize root context")
+			return err
+		}
+		policy := probeCLI.RetentionPolicy()
+		if *maxAgeDays > 0 {
+			policy.MaxAge = time.Duration(*maxAgeDays) * 24 * time.Hour
+		}
+		if *maxResults > 0 {
+			policy.MaxResults = *maxResults
+		}
+		if *keepOnlyNotUploaded {
+			policy.KeepOnlyNotUploaded = true
+		}
+		if policy.IsEmpty() {
+			log.Warn("no retention policy configured: use flags or the retention config section")
+			return nil
+		}
+		return probeCLI.PruneResults(policy)
+	})
+}
@@ -7,11 +7,31 @@ import (
 	"github.com/apex/log"
 	"github.com/ooni/probe-cli/v3/cmd/ooniprobe/internal/cli/root"
 	"github.com/ooni/probe-cli/v3/cmd/ooniprobe/internal/output"
+	"github.com/ooni/probe-cli/v3/internal/model"
 )
 
+// filterResultsByNetwork only keeps the results matching the given network
+// label and network type. An empty label or type matches any value.
+func filterResultsByNetwork(
+	results []model.DatabaseResultNetwork, label, typ string) []model.DatabaseResultNetwork {
+	var out []model.DatabaseResultNetwork
+	for _, result := range results {
+		if label != "" && result.DatabaseNetwork.NetworkLabel != label {
+			continue
+		}
+		if typ != "" && result.DatabaseNetwork.NetworkType != typ {
+			continue
+		}
+		out = append(out, result)
+	}
+	return out
+}
+
 func init() {
 	cmd := root.Command("list", "List results")
 	resultID := cmd.Arg("id", "the id of the result to list measurements for").Int64()
+	networkLabel := cmd.Flag("network", "Only list results with the given network label").String()
+	networkType := cmd.Flag("network-type", "Only list results with the given network type").String()
 	cmd.Action(func(_ *kingpin.ParseContext) error {
 		probeCLI, err := root.Init()
 		if err != nil {
@@ -67,6 +87,8 @@ func init() {
 				log.WithError(err).Error("failed to list results")
 				return err
 			}
+			doneResults = filterResultsByNetwork(doneResults, *networkLabel, *networkType)
+			incompleteResults = filterResultsByNetwork(incompleteResults, *networkLabel, *networkType)
 			if len(incompleteResults) > 0 {
 				output.SectionTitle("Incomplete results")
 			}
@@ -78,6 +100,7 @@ func init() {
 					Name:                    result.TestGroupName,
 					StartTime:               result.StartTime,
 					NetworkName:             result.DatabaseNetwork.NetworkName,
+					NetworkLabel:            result.DatabaseNetwork.NetworkLabel,
 					Country:                 result.DatabaseNetwork.CountryCode,
 					ASN:                     result.DatabaseNetwork.ASN,
 					MeasurementCount:        0,
@@ -117,6 +140,7 @@ func init() {
 					Name:                    result.TestGroupName,
 					StartTime:               result.StartTime,
 					NetworkName:             result.DatabaseNetwork.NetworkName,
+					NetworkLabel:            result.DatabaseNetwork.NetworkLabel,
 					Country:                 result.DatabaseNetwork.CountryCode,
 					ASN:                     result.DatabaseNetwork.ASN,
 					TestKeys:                testKeys,
@@ -33,6 +33,14 @@ func init() {
 		return nil
 	})
 
+	// maybePruneResults applies the configured retention policy
+	// after we have finished running nettests.
+	maybePruneResults := func() {
+		if err := probe.PruneResults(probe.RetentionPolicy()); err != nil {
+			log.WithError(err).Warn("failed to prune results")
+		}
+	}
+
 	functionalRun := func(runType model.RunType, pred func(name string, gr nettests.Group) bool) error {
 		for name, group := range nettests.All {
 			if !pred(name, group) {
@@ -48,6 +56,7 @@ func init() {
 				log.WithError(err).Errorf("failed to run %s", name)
 			}
 		}
+		maybePruneResults()
 		return nil
 	}
 
@@ -64,13 +73,15 @@ func init() {
 	input := websitesCmd.Flag("input", "Test the specified URL").Strings()
 	websitesCmd.Action(func(_ *kingpin.ParseContext) error {
 		log.Infof("Running %s tests", color.BlueString("websites"))
-		return nettests.RunGroup(nettests.RunGroupConfig{
+		err := nettests.RunGroup(nettests.RunGroupConfig{
 			GroupName:  "websites",
 			Probe:      probe,
 			InputFiles: *inputFile,
 			Inputs:     *input,
 			RunType:    model.RunTypeManual,
 		})
+		maybePruneResults()
+		return err
 	})
 
 	easyRuns := []string{
@@ -81,6 +92,18 @@ func init() {
 
 	unattendedCmd := cmd.Command("unattended", "")
 	unattendedCmd.Action(func(_ *kingpin.ParseContext) error {
+		// When running in the background, we take advantage of having
+		// connectivity again to retry submitting measurements we could
+		// not submit during previous runs.
+		if probe.Config().Sharing.UploadResults {
+			_, err := nettests.UploadPending(nettests.UploadConfig{
+				Probe:   probe,
+				RunType: model.RunTypeTimed,
+			})
+			if err != nil {
+				log.WithError(err).Warn("failed to upload pending measurements")
+			}
+		}
 		return functionalRun(model.RunTypeTimed, func(name string, gr nettests.Group) bool {
 			return gr.UnattendedOK
 		})
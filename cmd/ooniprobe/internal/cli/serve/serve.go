@@ -0,0 +1,113 @@
+package serve
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sort"
+	"syscall"
+	"time"
+
+	"github.com/alecthomas/kingpin/v2"
+	"github.com/apex/log"
+	"github.com/ooni/probe-cli/v3/cmd/ooniprobe/internal/cli/onboard"
+	"github.com/ooni/probe-cli/v3/cmd/ooniprobe/internal/cli/root"
+	"github.com/ooni/probe-cli/v3/cmd/ooniprobe/internal/nettests"
+	"github.com/ooni/probe-cli/v3/cmd/ooniprobe/internal/serve"
+	"github.com/ooni/probe-cli/v3/internal/model"
+)
+
+func init() {
+	cmd := root.Command("serve", "Serve a local JSON API and dashboard for results")
+	address := cmd.Flag("address", "Address where to listen for requests").Default("127.0.0.1:8080").String()
+	token := cmd.Flag("token", "Token clients must use to access the API (mandatory with non-loopback addresses)").String()
+
+	cmd.Action(func(_ *kingpin.ParseContext) error {
+		// when not using loopback, anyone in the network could reach us, so we want
+		// the user to configure a token we require for all the requests
+		isLoopback := serve.IsLoopbackAddress(*address)
+		if !isLoopback && *token == "" {
+			err := errors.New("refusing to listen on a non-loopback address without --token")
+			log.WithError(err).Error("insecure configuration")
+			return err
+		}
+		sessionToken := *token
+		if sessionToken == "" {
+			var err error
+			if sessionToken, err = serve.NewToken(); err != nil {
+				log.WithError(err).Error("failed to generate token")
+				return err
+			}
+		}
+
+		probe, err := root.Init()
+		if err != nil {
+			log.WithError(err).Error("failed to initialize root context")
+			return err
+		}
+		if err := onboard.MaybeOnboarding(probe); err != nil {
+			log.WithError(err).Error("failed to perform onboarding")
+			return err
+		}
+
+		// publish the progress events emitted by nettests to API clients
+		broker := &serve.ProgressBroker{}
+		if logger, ok := log.Log.(*log.Logger); ok {
+			log.SetHandler(&serve.LogHandler{Broker: broker, Handler: logger.Handler})
+		}
+
+		var groups []string
+		for name := range nettests.All {
+			groups = append(groups, name)
+		}
+		sort.Strings(groups)
+
+		listener, err := net.Listen("tcp", *address)
+		if err != nil {
+			log.WithError(err).Error("failed to listen")
+			return err
+		}
+		var allowedHosts []string
+		if isLoopback {
+			_, port, _ := net.SplitHostPort(listener.Addr().String())
+			allowedHosts = serve.LoopbackAllowedHosts(port)
+		}
+		server := serve.NewServer(serve.Config{
+			AllowedHosts: allowedHosts,
+			DB:           probe.DB(),
+			Groups:       groups,
+			Progress:     broker,
+			Run: func(groupName string) error {
+				return nettests.RunGroup(nettests.RunGroupConfig{
+					GroupName: groupName,
+					Probe:     probe,
+					RunType:   model.RunTypeManual,
+				})
+			},
+			Token:                 sessionToken,
+			TokenRequiredForReads: !isLoopback,
+		})
+		srv := &http.Server{
+			Handler:           server.Handler(),
+			ReadHeaderTimeout: 8 * time.Second,
+		}
+		go srv.Serve(listener)
+		if isLoopback {
+			log.Infof("serving the dashboard at http://%s/", listener.Addr().String())
+		} else {
+			log.Infof("serving the dashboard at http://%s/?token=<token>", listener.Addr().String())
+		}
+
+		sigs := make(chan os.Signal, 1)
+		signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
+		sig := <-sigs
+		log.Infof("interrupted by signal: %v", sig)
+		probe.Terminate()
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		return srv.Shutdown(ctx)
+	})
+}
@@ -1,17 +1,43 @@
 package upload
 
 import (
+	"errors"
+
 	"github.com/alecthomas/kingpin/v2"
 	"github.com/apex/log"
 	"github.com/ooni/probe-cli/v3/cmd/ooniprobe/internal/cli/root"
+	"github.com/ooni/probe-cli/v3/cmd/ooniprobe/internal/nettests"
+	"github.com/ooni/probe-cli/v3/internal/model"
 )
 
 func init() {
-	cmd := root.Command("upload", "Upload a specific measurement")
+	cmd := root.Command("upload", "Upload a specific measurement or all pending measurements")
+	allPending := cmd.Flag("all-pending", "Upload all the measurements pending upload").Bool()
+	msmtID := cmd.Arg("id", "the id of the measurement to upload").Int64()
 
 	cmd.Action(func(_ *kingpin.ParseContext) error {
-		log.Info("Uploading")
-		log.Error("this function is not implemented")
+		if !*allPending && *msmtID <= 0 {
+			return errors.New("specify either a measurement id or --all-pending")
+		}
+		probe, err := root.Init()
+		if err != nil {
+			log.WithError(err).Error("failed to initialize root context")
+			return err
+		}
+		config := nettests.UploadConfig{
+			Probe:   probe,
+			RunType: model.RunTypeManual,
+		}
+		if !*allPending {
+			config.MeasurementIDs = []int64{*msmtID}
+		}
+		stats, err := nettests.UploadPending(config)
+		if err != nil {
+			return err
+		}
+		if stats.Failed > 0 {
+			return errors.New("failed to upload some measurements")
+		}
 		return nil
 	})
 }
@@ -51,9 +51,11 @@ type Config struct {
 	Version         int64  `json:"_version"`
 	InformedConsent bool   `json:"_informed_consent"`
 
-	Sharing  Sharing  `json:"sharing"`
-	Nettests Nettests `json:"nettests"`
-	Advanced Advanced `json:"advanced"`
+	Sharing   Sharing   `json:"sharing"`
+	Nettests  Nettests  `json:"nettests"`
+	Advanced  Advanced  `json:"advanced"`
+	Retention Retention `json:"retention"`
+	Network   Network   `json:"network"`
 
 	mutex sync.Mutex
 	path  string
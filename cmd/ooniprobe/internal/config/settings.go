@@ -14,3 +14,17 @@ type Nettests struct {
 	WebsitesURLLimit             int64    `json:"websites_url_limit"`
 	WebsitesEnabledCategoryCodes []string `json:"websites_enabled_category_codes"`
 }
+
+// Retention settings
+type Retention struct {
+	MaxAgeDays          int64 `json:"max_age_days"`
+	MaxResults          int64 `json:"max_results"`
+	KeepOnlyNotUploaded bool  `json:"keep_only_not_uploaded"`
+}
+
+// Network settings
+type Network struct {
+	Label           string            `json:"label"`
+	Type            string            `json:"type"`
+	InterfaceLabels map[string]string `json:"interface_labels"`
+}
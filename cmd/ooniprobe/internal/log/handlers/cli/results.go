@@ -96,6 +96,9 @@ func logResultItem(w io.Writer, f log.Fields) error {
 	isDone := f.Get("is_done").(bool)
 	startTime := f.Get("start_time").(time.Time)
 	networkName := f.Get("network_name").(string)
+	if label, _ := f.Get("network_label").(string); label != "" {
+		networkName = fmt.Sprintf("%s [%s]", networkName, label)
+	}
 	asn := fmt.Sprintf("AS%d (%s)", f.Get("asn").(uint), f.Get("network_country_code").(string))
 	//runtime := f.Get("runtime").(float64)
 	//dataUsageUp := f.Get("dataUsageUp").(int64)
@@ -0,0 +1,131 @@
+// Package netinfo contains heuristics to determine the network
+// interface and the network type used to run measurements.
+package netinfo
+
+import (
+	"bufio"
+	"bytes"
+	"strconv"
+	"strings"
+)
+
+const (
+	// TypeWifi indicates a wifi network.
+	TypeWifi = "wifi"
+
+	// TypeMobile indicates a mobile network.
+	TypeMobile = "mobile"
+
+	// TypeWired indicates a wired network.
+	TypeWired = "wired"
+
+	// TypeVPN indicates a VPN or tunnel interface.
+	TypeVPN = "vpn"
+)
+
+// Info contains information about the network we're using.
+type Info struct {
+	// Interface is the name of the interface with the default route
+	// or an empty string if we could not determine it.
+	Interface string
+
+	// Type is the network type or an empty string if unknown.
+	Type string
+}
+
+// Detect uses heuristics to determine the network we're using. We return
+// empty fields for the information we could not determine.
+func Detect() *Info {
+	return detect()
+}
+
+// typeFromInterfaceName guesses the network type from the conventional
+// names used by operating systems for network interfaces.
+func typeFromInterfaceName(name string) string {
+	type prefixType struct {
+		prefix string
+		typ    string
+	}
+	// Note: the order matters because we stop at the first match, so,
+	// e.g., "wwan" must come before "wl" and "wlan".
+	prefixes := []prefixType{
+		{"wwan", TypeMobile},
+		{"wwp", TypeMobile},
+		{"rmnet", TypeMobile},
+		{"ccmni", TypeMobile},
+		{"pdp_ip", TypeMobile},
+		{"wlan", TypeWifi},
+		{"wlp", TypeWifi},
+		{"wlx", TypeWifi},
+		{"wl", TypeWifi},
+		{"ath", TypeWifi},
+		{"eth", TypeWired},
+		{"enp", TypeWired},
+		{"eno", TypeWired},
+		{"ens", TypeWired},
+		{"enx", TypeWired},
+		{"tun", TypeVPN},
+		{"tap", TypeVPN},
+		{"wg", TypeVPN},
+		{"utun", TypeVPN},
+		{"ppp", TypeMobile},
+	}
+	for _, entry := range prefixes {
+		if strings.HasPrefix(name, entry.prefix) {
+			return entry.typ
+		}
+	}
+	return ""
+}
+
+// parseProcNetRoute parses the content of /proc/net/route and returns the
+// interface used by the default route with the lowest metric.
+func parseProcNetRoute(data []byte) string {
+	var (
+		iface  string
+		metric = -1
+	)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		// Iface Destination Gateway Flags RefCnt Use Metric Mask ...
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 8 || fields[1] != "00000000" || fields[7] != "00000000" {
+			continue // not a default route (or the header line)
+		}
+		value, err := strconv.Atoi(fields[6])
+		if err != nil || value < 0 {
+			continue
+		}
+		if metric < 0 || value < metric {
+			iface, metric = fields[0], value
+		}
+	}
+	return iface
+}
+
+// parseSysfsUevent returns the DEVTYPE inside a /sys/class/net/IFACE/uevent file.
+func parseSysfsUevent(data []byte) string {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		if value, found := strings.CutPrefix(scanner.Text(), "DEVTYPE="); found {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
+
+// typeFromDevtype maps a sysfs DEVTYPE to a network type.
+func typeFromDevtype(devtype string) string {
+	switch devtype {
+	case "wlan":
+		return TypeWifi
+	case "wwan":
+		return TypeMobile
+	case "ppp":
+		return TypeMobile
+	case "wireguard":
+		return TypeVPN
+	default:
+		return ""
+	}
+}
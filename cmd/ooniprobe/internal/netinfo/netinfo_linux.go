@@ -0,0 +1,34 @@
+package netinfo
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// sysfsRoot is the root of the filesystem containing /proc and /sys, which
+// we can override when testing.
+var sysfsRoot = "/"
+
+func detect() *Info {
+	info := &Info{}
+	data, err := os.ReadFile(filepath.Join(sysfsRoot, "proc", "net", "route"))
+	if err != nil {
+		return info
+	}
+	info.Interface = parseProcNetRoute(data)
+	if info.Interface == "" {
+		return info
+	}
+	ifaceDir := filepath.Join(sysfsRoot, "sys", "class", "net", info.Interface)
+	if _, err := os.Stat(filepath.Join(ifaceDir, "wireless")); err == nil {
+		info.Type = TypeWifi
+		return info
+	}
+	if data, err := os.ReadFile(filepath.Join(ifaceDir, "uevent")); err == nil {
+		info.Type = typeFromDevtype(parseSysfsUevent(data))
+	}
+	if info.Type == "" {
+		info.Type = typeFromInterfaceName(info.Interface)
+	}
+	return info
+}
@@ -0,0 +1,60 @@
+package netinfo
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDetect(t *testing.T) {
+	writeFile := func(t *testing.T, path, content string) {
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	withRoot := func(t *testing.T, root string) {
+		saved := sysfsRoot
+		sysfsRoot = root
+		t.Cleanup(func() { sysfsRoot = saved })
+	}
+
+	t.Run("without /proc/net/route", func(t *testing.T) {
+		withRoot(t, t.TempDir())
+		if info := Detect(); info.Interface != "" || info.Type != "" {
+			t.Fatal("unexpected info", info)
+		}
+	})
+
+	t.Run("with a wireless interface", func(t *testing.T) {
+		root := t.TempDir()
+		writeFile(t, filepath.Join(root, "proc", "net", "route"), procNetRoute)
+		writeFile(t, filepath.Join(root, "sys", "class", "net", "eth0", "wireless", "x"), "")
+		withRoot(t, root)
+		if info := Detect(); info.Interface != "eth0" || info.Type != TypeWifi {
+			t.Fatal("unexpected info", info)
+		}
+	})
+
+	t.Run("with a wwan device type", func(t *testing.T) {
+		root := t.TempDir()
+		writeFile(t, filepath.Join(root, "proc", "net", "route"), procNetRoute)
+		writeFile(t, filepath.Join(root, "sys", "class", "net", "eth0", "uevent"), "DEVTYPE=wwan\n")
+		withRoot(t, root)
+		if info := Detect(); info.Interface != "eth0" || info.Type != TypeMobile {
+			t.Fatal("unexpected info", info)
+		}
+	})
+
+	t.Run("falling back to the interface name", func(t *testing.T) {
+		root := t.TempDir()
+		writeFile(t, filepath.Join(root, "proc", "net", "route"), procNetRoute)
+		withRoot(t, root)
+		if info := Detect(); info.Interface != "eth0" || info.Type != TypeWired {
+			t.Fatal("unexpected info", info)
+		}
+	})
+}
@@ -0,0 +1,9 @@
+//go:build !linux
+
+package netinfo
+
+// detect is not implemented outside of Linux, where there is
+// no portable way of discovering the default route.
+func detect() *Info {
+	return &Info{}
+}
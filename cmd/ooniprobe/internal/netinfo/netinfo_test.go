@@ -0,0 +1,73 @@
+package netinfo
+
+import "testing"
+
+func TestTypeFromInterfaceName(t *testing.T) {
+	cases := map[string]string{
+		"wlan0":           TypeWifi,
+		"wlp3s0":          TypeWifi,
+		"wwan0":           TypeMobile,
+		"wwp0s20f0u6":     TypeMobile,
+		"rmnet_data0":     TypeMobile,
+		"eth0":            TypeWired,
+		"enp0s31f6":       TypeWired,
+		"tun0":            TypeVPN,
+		"wg0":             TypeVPN,
+		"lo":              "",
+		"docker0":         "",
+		"":                "",
+		"enx00e04c680001": TypeWired,
+	}
+	for name, expect := range cases {
+		if got := typeFromInterfaceName(name); got != expect {
+			t.Errorf("%s: expected %q, got %q", name, expect, got)
+		}
+	}
+}
+
+const procNetRoute = `Iface	Destination	Gateway 	Flags	RefCnt	Use	Metric	Mask		MTU	Window	IRTT
+wlan0	00000000	0101A8C0	0003	0	0	600	00000000	0	0	0
+wlan0	0001A8C0	00000000	0001	0	0	600	00FFFFFF	0	0	0
+eth0	00000000	0100000A	0003	0	0	100	00000000	0	0	0
+eth0	0000000A	00000000	0001	0	0	100	00FFFFFF	0	0	0
+`
+
+func TestParseProcNetRoute(t *testing.T) {
+	t.Run("we select the default route with the lowest metric", func(t *testing.T) {
+		if iface := parseProcNetRoute([]byte(procNetRoute)); iface != "eth0" {
+			t.Fatal("unexpected interface", iface)
+		}
+	})
+
+	t.Run("we return an empty string without a default route", func(t *testing.T) {
+		data := "Iface	Destination	Gateway 	Flags	RefCnt	Use	Metric	Mask\n" +
+			"eth0	0000000A	00000000	0001	0	0	100	00FFFFFF\n"
+		if iface := parseProcNetRoute([]byte(data)); iface != "" {
+			t.Fatal("unexpected interface", iface)
+		}
+	})
+}
+
+func TestParseSysfsUevent(t *testing.T) {
+	data := "DEVTYPE=wwan\nINTERFACE=wwan0\nIFINDEX=3\n"
+	if devtype := parseSysfsUevent([]byte(data)); devtype != "wwan" {
+		t.Fatal("unexpected devtype", devtype)
+	}
+	if devtype := parseSysfsUevent([]byte("INTERFACE=eth0\n")); devtype != "" {
+		t.Fatal("unexpected devtype", devtype)
+	}
+}
+
+func TestTypeFromDevtype(t *testing.T) {
+	cases := map[string]string{
+		"wlan":      TypeWifi,
+		"wwan":      TypeMobile,
+		"wireguard": TypeVPN,
+		"bridge":    "",
+	}
+	for devtype, expect := range cases {
+		if got := typeFromDevtype(devtype); got != expect {
+			t.Errorf("%s: expected %q, got %q", devtype, expect, got)
+		}
+	}
+}
@@ -53,7 +53,7 @@ func TestRun(t *testing.T) {
 		t.Fatal(err)
 	}
 	db := probe.DB()
-	network, err := db.CreateNetwork(sess)
+	network, err := db.CreateNetwork(sess, model.DatabaseNetworkInfo{})
 	if err != nil {
 		t.Fatal(err)
 	}
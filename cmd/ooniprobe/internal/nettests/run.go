@@ -72,7 +72,7 @@ func RunGroup(config RunGroupConfig) error {
 		return err
 	}
 	db := config.Probe.DB()
-	network, err := db.CreateNetwork(sess)
+	network, err := db.CreateNetwork(sess, config.Probe.NetworkInfo())
 	if err != nil {
 		log.WithError(err).Error("Failed to create the network row")
 		return err
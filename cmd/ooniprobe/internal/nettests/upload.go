@@ -0,0 +1,238 @@
+package nettests
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"os"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/ooni/probe-cli/v3/cmd/ooniprobe/internal/ooni"
+	"github.com/ooni/probe-cli/v3/internal/model"
+	"github.com/pkg/errors"
+)
+
+// UploadConfig contains the settings for uploading pending measurements.
+type UploadConfig struct {
+	// MeasurementIDs optionally restricts the upload to the given
+	// measurements. When empty, we upload all pending measurements.
+	MeasurementIDs []int64
+
+	// Probe is the probe instance to use.
+	Probe *ooni.Probe
+
+	// RunType contains the run_type hint for the check-in API.
+	RunType model.RunType
+}
+
+// UploadStats contains statistics about uploading pending measurements.
+type UploadStats struct {
+	// Failed is the number of measurements we could not upload.
+	Failed int
+
+	// Uploaded is the number of measurements we uploaded.
+	Uploaded int
+}
+
+// UploadPending resubmits the measurements that are done but have not been
+// uploaded yet, using exponential backoff between attempts.
+func UploadPending(config UploadConfig) (*UploadStats, error) {
+	db := config.Probe.DB()
+	msmts, err := db.ListPendingUploads()
+	if err != nil {
+		log.WithError(err).Error("Failed to list measurements pending upload")
+		return nil, err
+	}
+	msmts = filterPendingUploads(msmts, config.MeasurementIDs)
+	if len(msmts) <= 0 {
+		log.Info("No measurements pending upload")
+		return &UploadStats{}, nil
+	}
+	log.Infof("Found %d measurement(s) pending upload", len(msmts))
+
+	sess, err := config.Probe.NewSession(context.Background(), config.RunType)
+	if err != nil {
+		log.WithError(err).Error("Failed to create a measurement session")
+		return nil, err
+	}
+	defer sess.Close()
+	submitter, err := sess.NewSubmitter(context.Background())
+	if err != nil {
+		log.WithError(err).Error("Failed to create a submitter")
+		return nil, err
+	}
+
+	config.Probe.ListenForSignals()
+	ctx, cancel := newTerminationContext(config.Probe.IsTerminated, pendingUploadTerminationPollInterval)
+	defer cancel()
+	uploader := &pendingUploader{
+		db:             db,
+		initialBackoff: pendingUploadInitialBackoff,
+		isTerminated:   config.Probe.IsTerminated,
+		maxAttempts:    pendingUploadMaxAttempts,
+		sleep:          sleepWithContext,
+		submitter:      submitter,
+	}
+	return uploader.run(ctx, msmts)
+}
+
+// newTerminationContext returns a context that is canceled as soon as isTerminated
+// returns true. We need to poll because the probe only exposes a flag.
+func newTerminationContext(
+	isTerminated func() bool, interval time.Duration) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if isTerminated() {
+					cancel()
+					return
+				}
+			}
+		}
+	}()
+	return ctx, cancel
+}
+
+// sleepWithContext sleeps for the given duration unless the context is done
+// first, in which case it returns the context error.
+func sleepWithContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// filterPendingUploads only keeps the measurements whose ID is inside
+// the given list of IDs, unless the list is empty.
+func filterPendingUploads(msmts []model.DatabaseMeasurement, ids []int64) []model.DatabaseMeasurement {
+	if len(ids) <= 0 {
+		return msmts
+	}
+	wanted := make(map[int64]bool)
+	for _, id := range ids {
+		wanted[id] = true
+	}
+	var out []model.DatabaseMeasurement
+	for _, msmt := range msmts {
+		if wanted[msmt.ID] {
+			out = append(out, msmt)
+		}
+	}
+	return out
+}
+
+const (
+	// pendingUploadMaxAttempts is the maximum number of times we
+	// attempt to submit each pending measurement.
+	pendingUploadMaxAttempts = 4
+
+	// pendingUploadInitialBackoff is the time we wait after the first
+	// failure to submit a measurement. We double this time after each
+	// subsequent failure to submit the same measurement.
+	pendingUploadInitialBackoff = 2 * time.Second
+
+	// pendingUploadTerminationPollInterval is how often we check whether
+	// the user asked us to terminate while uploading.
+	pendingUploadTerminationPollInterval = 250 * time.Millisecond
+)
+
+// pendingUploaderDB is the database used by pendingUploader.
+type pendingUploaderDB interface {
+	GetResult(resultID int64) (*model.DatabaseResult, error)
+	UpdateUploadedStatus(result *model.DatabaseResult) error
+	UploadFailed(msmt *model.DatabaseMeasurement, failure string) error
+	UploadSucceeded(msmt *model.DatabaseMeasurement) error
+}
+
+// pendingUploader uploads pending measurements.
+type pendingUploader struct {
+	db             pendingUploaderDB
+	initialBackoff time.Duration
+	isTerminated   func() bool
+	maxAttempts    int
+	sleep          func(ctx context.Context, d time.Duration) error
+	submitter      model.Submitter
+}
+
+// run uploads the given measurements. Because ListPendingUploads sorts the measurements
+// by report ID, measurements belonging to the same original report are submitted one after
+// the other, which allows the submitter to reuse the same report for all of them.
+func (pu *pendingUploader) run(ctx context.Context, msmts []model.DatabaseMeasurement) (*UploadStats, error) {
+	stats := &UploadStats{}
+	results := make(map[int64]bool)
+	for idx := range msmts {
+		if pu.isTerminated() {
+			log.Info("user requested us to terminate using Ctrl-C")
+			break
+		}
+		msmt := &msmts[idx]
+		if err := pu.upload(ctx, msmt); err != nil {
+			log.WithError(err).Warnf("Failed to upload measurement #%d", msmt.ID)
+			if err := pu.db.UploadFailed(msmt, err.Error()); err != nil {
+				return stats, errors.Wrap(err, "failed to mark upload as failed")
+			}
+			stats.Failed++
+			continue
+		}
+		if err := pu.db.UploadSucceeded(msmt); err != nil {
+			return stats, errors.Wrap(err, "failed to mark upload as succeeded")
+		}
+		// Like Controller.Run, we don't keep uploaded measurements on disk
+		if err := os.Remove(msmt.MeasurementFilePath.String); err != nil {
+			log.WithError(err).Debugf("cannot remove %s", msmt.MeasurementFilePath.String)
+		}
+		results[msmt.ResultID] = true
+		stats.Uploaded++
+	}
+	for resultID := range results {
+		result, err := pu.db.GetResult(resultID)
+		if err != nil {
+			return stats, errors.Wrap(err, "failed to get result")
+		}
+		if err := pu.db.UpdateUploadedStatus(result); err != nil {
+			return stats, errors.Wrap(err, "failed to update result uploaded status")
+		}
+	}
+	log.Infof("Uploaded %d measurement(s), failed to upload %d measurement(s)", stats.Uploaded, stats.Failed)
+	return stats, nil
+}
+
+// upload loads the measurement from disk and submits it, retrying with
+// exponential backoff until we run out of attempts.
+func (pu *pendingUploader) upload(ctx context.Context, msmt *model.DatabaseMeasurement) error {
+	data, err := os.ReadFile(msmt.MeasurementFilePath.String)
+	if err != nil {
+		return err
+	}
+	var measurement model.Measurement
+	if err := json.Unmarshal(data, &measurement); err != nil {
+		return err
+	}
+	backoff := pu.initialBackoff
+	for attempt := 1; ; attempt++ {
+		err = pu.submitter.Submit(ctx, &measurement)
+		if err == nil {
+			msmt.ReportID = sql.NullString{String: measurement.ReportID, Valid: true}
+			return nil
+		}
+		if attempt >= pu.maxAttempts || pu.isTerminated() {
+			return err
+		}
+		log.WithError(err).Infof("Failed to submit measurement #%d, retrying in %s", msmt.ID, backoff)
+		if pu.sleep(ctx, backoff) != nil || pu.isTerminated() {
+			return err
+		}
+		backoff *= 2
+	}
+}
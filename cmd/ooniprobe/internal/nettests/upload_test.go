@@ -0,0 +1,242 @@
+package nettests
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ooni/probe-cli/v3/internal/mocks"
+	"github.com/ooni/probe-cli/v3/internal/model"
+)
+
+func newPendingMeasurement(t *testing.T, id, resultID int64) model.DatabaseMeasurement {
+	path := filepath.Join(t.TempDir(), "msmt.json")
+	if err := os.WriteFile(path, []byte(`{"test_name":"antani"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return model.DatabaseMeasurement{
+		ID:                  id,
+		ResultID:            resultID,
+		MeasurementFilePath: sql.NullString{String: path, Valid: true},
+	}
+}
+
+func TestFilterPendingUploads(t *testing.T) {
+	msmts := []model.DatabaseMeasurement{{ID: 1}, {ID: 2}, {ID: 3}}
+	if out := filterPendingUploads(msmts, nil); len(out) != 3 {
+		t.Fatal("expected all measurements")
+	}
+	out := filterPendingUploads(msmts, []int64{2})
+	if len(out) != 1 || out[0].ID != 2 {
+		t.Fatal("unexpected measurements", out)
+	}
+}
+
+func TestPendingUploader(t *testing.T) {
+	newDB := func(failed, succeeded, updated map[int64]bool) *mocks.Database {
+		return &mocks.Database{
+			MockGetResult: func(resultID int64) (*model.DatabaseResult, error) {
+				return &model.DatabaseResult{ID: resultID}, nil
+			},
+			MockUpdateUploadedStatus: func(result *model.DatabaseResult) error {
+				updated[result.ID] = true
+				return nil
+			},
+			MockUploadFailed: func(msmt *model.DatabaseMeasurement, failure string) error {
+				failed[msmt.ID] = true
+				return nil
+			},
+			MockUploadSucceeded: func(msmt *model.DatabaseMeasurement) error {
+				succeeded[msmt.ID] = true
+				return nil
+			},
+		}
+	}
+
+	t.Run("we retry with exponential backoff", func(t *testing.T) {
+		failed, succeeded, updated := map[int64]bool{}, map[int64]bool{}, map[int64]bool{}
+		var sleeps []time.Duration
+		count := 0
+		pu := &pendingUploader{
+			db:             newDB(failed, succeeded, updated),
+			initialBackoff: time.Second,
+			isTerminated:   func() bool { return false },
+			maxAttempts:    4,
+			sleep: func(ctx context.Context, d time.Duration) error {
+				sleeps = append(sleeps, d)
+				return nil
+			},
+			submitter: &mocks.Submitter{
+				MockSubmit: func(ctx context.Context, m *model.Measurement) error {
+					count++
+					if count < 3 {
+						return errors.New("mocked error")
+					}
+					m.ReportID = "xyz"
+					return nil
+				},
+			},
+		}
+		msmts := []model.DatabaseMeasurement{newPendingMeasurement(t, 1, 10)}
+		stats, err := pu.run(context.Background(), msmts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stats.Uploaded != 1 || stats.Failed != 0 {
+			t.Fatal("unexpected stats", stats)
+		}
+		if len(sleeps) != 2 || sleeps[0] != time.Second || sleeps[1] != 2*time.Second {
+			t.Fatal("unexpected sleeps", sleeps)
+		}
+		if !succeeded[1] || !updated[10] {
+			t.Fatal("expected the measurement and the result to be updated")
+		}
+		if msmts[0].ReportID.String != "xyz" {
+			t.Fatal("expected the report ID to be updated")
+		}
+		if _, err := os.Stat(msmts[0].MeasurementFilePath.String); !os.IsNotExist(err) {
+			t.Fatal("expected the measurement file to be removed")
+		}
+	})
+
+	t.Run("we give up after the maximum number of attempts", func(t *testing.T) {
+		failed, succeeded, updated := map[int64]bool{}, map[int64]bool{}, map[int64]bool{}
+		count := 0
+		pu := &pendingUploader{
+			db:             newDB(failed, succeeded, updated),
+			initialBackoff: time.Second,
+			isTerminated:   func() bool { return false },
+			maxAttempts:    3,
+			sleep:          func(ctx context.Context, d time.Duration) error { return nil },
+			submitter: &mocks.Submitter{
+				MockSubmit: func(ctx context.Context, m *model.Measurement) error {
+					count++
+					return errors.New("mocked error")
+				},
+			},
+		}
+		msmts := []model.DatabaseMeasurement{newPendingMeasurement(t, 1, 10)}
+		stats, err := pu.run(context.Background(), msmts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stats.Uploaded != 0 || stats.Failed != 1 {
+			t.Fatal("unexpected stats", stats)
+		}
+		if count != 3 {
+			t.Fatal("unexpected number of attempts", count)
+		}
+		if !failed[1] || len(updated) != 0 {
+			t.Fatal("expected the measurement to be marked as failed")
+		}
+		if _, err := os.Stat(msmts[0].MeasurementFilePath.String); err != nil {
+			t.Fatal("expected the measurement file to still exist")
+		}
+	})
+
+	t.Run("we fail if we cannot read the measurement", func(t *testing.T) {
+		failed, succeeded, updated := map[int64]bool{}, map[int64]bool{}, map[int64]bool{}
+		pu := &pendingUploader{
+			db:           newDB(failed, succeeded, updated),
+			isTerminated: func() bool { return false },
+			maxAttempts:  3,
+			submitter: &mocks.Submitter{
+				MockSubmit: func(ctx context.Context, m *model.Measurement) error {
+					panic("should not be called")
+				},
+			},
+		}
+		msmts := []model.DatabaseMeasurement{{
+			ID:                  1,
+			MeasurementFilePath: sql.NullString{String: filepath.Join(t.TempDir(), "nonexistent.json"), Valid: true},
+		}}
+		stats, err := pu.run(context.Background(), msmts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stats.Failed != 1 || !failed[1] {
+			t.Fatal("expected the measurement to be marked as failed")
+		}
+	})
+
+	t.Run("we stop when terminated", func(t *testing.T) {
+		failed, succeeded, updated := map[int64]bool{}, map[int64]bool{}, map[int64]bool{}
+		pu := &pendingUploader{
+			db:           newDB(failed, succeeded, updated),
+			isTerminated: func() bool { return true },
+			maxAttempts:  3,
+		}
+		msmts := []model.DatabaseMeasurement{newPendingMeasurement(t, 1, 10)}
+		stats, err := pu.run(context.Background(), msmts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stats.Failed != 0 || stats.Uploaded != 0 {
+			t.Fatal("unexpected stats", stats)
+		}
+	})
+	t.Run("we stop retrying when terminated while waiting", func(t *testing.T) {
+		failed, succeeded, updated := map[int64]bool{}, map[int64]bool{}, map[int64]bool{}
+		terminated, count := false, 0
+		pu := &pendingUploader{
+			db:             newDB(failed, succeeded, updated),
+			initialBackoff: time.Hour,
+			isTerminated:   func() bool { return terminated },
+			maxAttempts:    4,
+			sleep: func(ctx context.Context, d time.Duration) error {
+				terminated = true // simulate Ctrl-C while waiting
+				return context.Canceled
+			},
+			submitter: &mocks.Submitter{
+				MockSubmit: func(ctx context.Context, m *model.Measurement) error {
+					count++
+					return errors.New("mocked error")
+				},
+			},
+		}
+		msmts := []model.DatabaseMeasurement{newPendingMeasurement(t, 1, 10)}
+		stats, err := pu.run(context.Background(), msmts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if count != 1 {
+			t.Fatal("unexpected number of attempts", count)
+		}
+		if stats.Failed != 1 || !failed[1] {
+			t.Fatal("expected the measurement to be marked as failed")
+		}
+	})
+}
+
+func TestSleepWithContext(t *testing.T) {
+	t.Run("we return early when the context is done", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		if err := sleepWithContext(ctx, time.Hour); !errors.Is(err, context.Canceled) {
+			t.Fatal("unexpected error", err)
+		}
+	})
+
+	t.Run("we return nil after sleeping", func(t *testing.T) {
+		if err := sleepWithContext(context.Background(), time.Millisecond); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+func TestNewTerminationContext(t *testing.T) {
+	var terminated atomic.Bool
+	ctx, cancel := newTerminationContext(terminated.Load, time.Millisecond)
+	defer cancel()
+	terminated.Store(true)
+	select {
+	case <-ctx.Done():
+	case <-time.After(10 * time.Second):
+		t.Fatal("expected the context to be canceled")
+	}
+}
@@ -9,9 +9,11 @@ import (
 	"os/signal"
 	"sync/atomic"
 	"syscall"
+	"time"
 
 	"github.com/apex/log"
 	"github.com/ooni/probe-cli/v3/cmd/ooniprobe/internal/config"
+	"github.com/ooni/probe-cli/v3/cmd/ooniprobe/internal/netinfo"
 	"github.com/ooni/probe-cli/v3/cmd/ooniprobe/internal/utils"
 	"github.com/ooni/probe-cli/v3/internal/database"
 	"github.com/ooni/probe-cli/v3/internal/engine"
@@ -211,6 +213,61 @@ func (p *Probe) Init(softwareName, softwareVersion, proxy string) error {
 	return nil
 }
 
+// RetentionPolicy returns the results retention policy
+// according to the current configuration.
+func (p *Probe) RetentionPolicy() database.RetentionPolicy {
+	retention := p.config.Retention
+	return database.RetentionPolicy{
+		MaxAge:              time.Duration(retention.MaxAgeDays) * 24 * time.Hour,
+		MaxResults:          retention.MaxResults,
+		KeepOnlyNotUploaded: retention.KeepOnlyNotUploaded,
+	}
+}
+
+// PruneResults deletes the results not matching the given retention
+// policy and then vacuums the database to reclaim disk space.
+func (p *Probe) PruneResults(policy database.RetentionPolicy) error {
+	if policy.IsEmpty() {
+		log.Debug("no retention policy configured, not pruning results")
+		return nil
+	}
+	count, err := p.db.PruneResults(policy, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+	log.Infof("Pruned %d result(s) according to the retention policy", count)
+	if count <= 0 {
+		return nil
+	}
+	return p.db.Vacuum()
+}
+
+// NetworkInfo returns the label and the type of the network we're using
+// according to the configuration and to heuristics.
+func (p *Probe) NetworkInfo() model.DatabaseNetworkInfo {
+	return newNetworkInfo(&p.config.Network, netinfo.Detect())
+}
+
+// newNetworkInfo returns the network information giving priority to the
+// configuration and falling back to the detected network information.
+func newNetworkInfo(cfg *config.Network, detected *netinfo.Info) model.DatabaseNetworkInfo {
+	info := model.DatabaseNetworkInfo{
+		Label: cfg.Label,
+		Type:  cfg.Type,
+	}
+	if info.Label == "" {
+		info.Label = cfg.InterfaceLabels[detected.Interface]
+	}
+	if info.Label == "" {
+		info.Label = detected.Interface
+	}
+	if info.Type == "" {
+		info.Type = detected.Type
+	}
+	log.Debugf("network label: %q; network type: %q", info.Label, info.Type)
+	return info
+}
+
 // NewSession creates a new ooni/probe-engine session using the
 // current configuration inside the context. The caller must close
 // the session when done using it, by calling sess.Close().
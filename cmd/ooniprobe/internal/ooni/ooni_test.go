@@ -5,6 +5,9 @@ import (
 	"os"
 	"path"
 	"testing"
+
+	"github.com/ooni/probe-cli/v3/cmd/ooniprobe/internal/config"
+	"github.com/ooni/probe-cli/v3/cmd/ooniprobe/internal/netinfo"
 )
 
 func TestInit(t *testing.T) {
@@ -27,3 +30,35 @@ func TestInit(t *testing.T) {
 		t.Fatal("config file was not created")
 	}
 }
+
+func TestNewNetworkInfo(t *testing.T) {
+	t.Run("the configuration takes precedence", func(t *testing.T) {
+		cfg := &config.Network{Label: "office", Type: "mobile"}
+		info := newNetworkInfo(cfg, &netinfo.Info{Interface: "eth0", Type: "wired"})
+		if info.Label != "office" || info.Type != "mobile" {
+			t.Fatal("unexpected info", info)
+		}
+	})
+
+	t.Run("we use the interface labels", func(t *testing.T) {
+		cfg := &config.Network{InterfaceLabels: map[string]string{"wwan0": "backup"}}
+		info := newNetworkInfo(cfg, &netinfo.Info{Interface: "wwan0", Type: "mobile"})
+		if info.Label != "backup" || info.Type != "mobile" {
+			t.Fatal("unexpected info", info)
+		}
+	})
+
+	t.Run("we fall back to the detected interface", func(t *testing.T) {
+		info := newNetworkInfo(&config.Network{}, &netinfo.Info{Interface: "eth0", Type: "wired"})
+		if info.Label != "eth0" || info.Type != "wired" {
+			t.Fatal("unexpected info", info)
+		}
+	})
+
+	t.Run("we leave fields empty when we know nothing", func(t *testing.T) {
+		info := newNetworkInfo(&config.Network{}, &netinfo.Info{})
+		if info.Label != "" || info.Type != "" {
+			t.Fatal("unexpected info", info)
+		}
+	})
+}
@@ -100,6 +100,7 @@ type ResultItemData struct {
 	Runtime                 float64
 	Country                 string
 	NetworkName             string
+	NetworkLabel            string
 	ASN                     uint
 	Done                    bool
 	IsUploaded              bool
@@ -121,6 +122,7 @@ func ResultItem(result ResultItemData) {
 		"measurement_anomaly_count": result.MeasurementAnomalyCount,
 		"network_country_code":      result.Country,
 		"network_name":              result.NetworkName,
+		"network_label":             result.NetworkLabel,
 		"asn":                       result.ASN,
 		"runtime":                   result.Runtime,
 		"is_done":                   result.Done,
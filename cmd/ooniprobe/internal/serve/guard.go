@@ -0,0 +1,130 @@
+package serve
+
+//
+// Protecting the API from other web pages and from the network
+//
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// TokenHeader is the header clients use to send the per-session token.
+const TokenHeader = "X-OONI-Probe-Token"
+
+// tokenQueryParam is the query parameter clients could use to send the per-session
+// token when they cannot set headers (e.g., EventSource or opening the dashboard).
+const tokenQueryParam = "token"
+
+var (
+	// errForbiddenHost indicates that the Host header is not allowed.
+	errForbiddenHost = errors.New("serve: forbidden host")
+
+	// errForbiddenOrigin indicates that the request comes from another web page.
+	errForbiddenOrigin = errors.New("serve: forbidden origin")
+
+	// errInvalidToken indicates that the request has a missing or invalid token.
+	errInvalidToken = errors.New("serve: missing or invalid token")
+)
+
+// NewToken returns a new random per-session token.
+func NewToken() (string, error) {
+	data := make([]byte, 16)
+	if _, err := rand.Read(data); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(data), nil
+}
+
+// IsLoopbackAddress returns whether the given address for [net.Listen] only
+// listens on the loopback interface. An empty host means all interfaces.
+func IsLoopbackAddress(address string) bool {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// LoopbackAllowedHosts returns the values of the Host header that browsers
+// send when we're listening on the loopback interface on the given port.
+func LoopbackAllowedHosts(port string) []string {
+	return []string{
+		net.JoinHostPort("127.0.0.1", port),
+		net.JoinHostPort("::1", port),
+		net.JoinHostPort("localhost", port),
+	}
+}
+
+// guard wraps the given handler to reject requests with a Host we don't know, which
+// protects against DNS rebinding, requests coming from other web pages, and requests
+// lacking a valid token when the token is required.
+func (s *Server) guard(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if !s.isAllowedHost(req.Host) {
+			writeError(w, http.StatusForbidden, errForbiddenHost)
+			return
+		}
+		if origin := req.Header.Get("Origin"); origin != "" && !isSameOrigin(origin, req.Host) {
+			writeError(w, http.StatusForbidden, errForbiddenOrigin)
+			return
+		}
+		if s.isTokenRequired(req) && !s.hasValidToken(req) {
+			writeError(w, http.StatusUnauthorized, errInvalidToken)
+			return
+		}
+		next.ServeHTTP(w, req)
+	})
+}
+
+func (s *Server) isAllowedHost(host string) bool {
+	if len(s.config.AllowedHosts) <= 0 {
+		return true
+	}
+	for _, entry := range s.config.AllowedHosts {
+		if strings.EqualFold(entry, host) {
+			return true
+		}
+	}
+	return false
+}
+
+// isSameOrigin returns whether the Origin header matches the Host header. Because
+// we already checked the Host header, this is enough to exclude foreign origins.
+func isSameOrigin(origin, host string) bool {
+	URL, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	return (URL.Scheme == "http" || URL.Scheme == "https") && strings.EqualFold(URL.Host, host)
+}
+
+// isTokenRequired returns whether we need a token, which is always the case for
+// requests changing the state and, if configured, also for any other request.
+func (s *Server) isTokenRequired(req *http.Request) bool {
+	if s.config.TokenRequiredForReads {
+		return true
+	}
+	return req.Method != http.MethodGet && req.Method != http.MethodHead
+}
+
+func (s *Server) hasValidToken(req *http.Request) bool {
+	if s.config.Token == "" {
+		return false // never accept requests when we don't have a token
+	}
+	token := req.Header.Get(TokenHeader)
+	if token == "" {
+		token = req.URL.Query().Get(tokenQueryParam)
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(s.config.Token)) == 1
+}
@@ -0,0 +1,181 @@
+package serve
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ooni/probe-cli/v3/internal/mocks"
+	"github.com/ooni/probe-cli/v3/internal/model"
+)
+
+func TestGuard(t *testing.T) {
+	newServer := func(config Config) *Server {
+		config.DB = &mocks.Database{
+			MockListResults: func() ([]model.DatabaseResultNetwork, []model.DatabaseResultNetwork, error) {
+				return nil, nil, nil
+			},
+		}
+		config.Groups = []string{"im"}
+		config.Progress = &ProgressBroker{}
+		config.Run = func(groupName string) error {
+			return nil
+		}
+		return NewServer(config)
+	}
+
+	type testcase struct {
+		name    string
+		config  Config
+		method  string
+		path    string
+		host    string
+		headers map[string]string
+		status  int
+	}
+
+	loopback := Config{
+		AllowedHosts: LoopbackAllowedHosts("8080"),
+		Token:        "xyz",
+	}
+	remote := Config{
+		Token:                 "xyz",
+		TokenRequiredForReads: true,
+	}
+
+	cases := []testcase{{
+		name:   "we accept reads from an allowed host",
+		config: loopback,
+		method: http.MethodGet,
+		path:   "/api/v1/results",
+		host:   "localhost:8080",
+		status: http.StatusOK,
+	}, {
+		name:   "we reject a foreign host",
+		config: loopback,
+		method: http.MethodGet,
+		path:   "/api/v1/results",
+		host:   "attacker.example.com:8080",
+		status: http.StatusForbidden,
+	}, {
+		name:    "we reject a foreign origin",
+		config:  loopback,
+		method:  http.MethodPost,
+		path:    "/api/v1/run/im",
+		host:    "127.0.0.1:8080",
+		headers: map[string]string{"Origin": "http://attacker.example.com", TokenHeader: "xyz"},
+		status:  http.StatusForbidden,
+	}, {
+		name:    "we reject the null origin",
+		config:  loopback,
+		method:  http.MethodGet,
+		path:    "/api/v1/results",
+		host:    "127.0.0.1:8080",
+		headers: map[string]string{"Origin": "null"},
+		status:  http.StatusForbidden,
+	}, {
+		name:    "we accept the same origin",
+		config:  loopback,
+		method:  http.MethodPost,
+		path:    "/api/v1/run/im",
+		host:    "127.0.0.1:8080",
+		headers: map[string]string{"Origin": "http://127.0.0.1:8080", TokenHeader: "xyz"},
+		status:  http.StatusAccepted,
+	}, {
+		name:    "we reject a simple form POST without token",
+		config:  loopback,
+		method:  http.MethodPost,
+		path:    "/api/v1/run/im",
+		host:    "127.0.0.1:8080",
+		headers: map[string]string{"Content-Type": "application/x-www-form-urlencoded"},
+		status:  http.StatusUnauthorized,
+	}, {
+		name:    "we reject a POST with an invalid token",
+		config:  loopback,
+		method:  http.MethodPost,
+		path:    "/api/v1/run/im",
+		host:    "127.0.0.1:8080",
+		headers: map[string]string{TokenHeader: "abc"},
+		status:  http.StatusUnauthorized,
+	}, {
+		name:    "we reject a POST when we don't have a token",
+		config:  Config{},
+		method:  http.MethodPost,
+		path:    "/api/v1/run/im",
+		host:    "127.0.0.1:8080",
+		headers: map[string]string{TokenHeader: ""},
+		status:  http.StatusUnauthorized,
+	}, {
+		name:   "we require the token for reads when configured",
+		config: remote,
+		method: http.MethodGet,
+		path:   "/api/v1/results",
+		host:   "10.0.0.1:8080",
+		status: http.StatusUnauthorized,
+	}, {
+		name:   "we accept the token in the query string",
+		config: remote,
+		method: http.MethodGet,
+		path:   "/api/v1/results?token=xyz",
+		host:   "10.0.0.1:8080",
+		status: http.StatusOK,
+	}}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, tc.path, nil)
+			req.Host = tc.host
+			for key, value := range tc.headers {
+				req.Header.Set(key, value)
+			}
+			w := httptest.NewRecorder()
+			newServer(tc.config).Handler().ServeHTTP(w, req)
+			if w.Code != tc.status {
+				t.Fatal("expected", tc.status, "got", w.Code, w.Body.String())
+			}
+		})
+	}
+
+	t.Run("the dashboard contains the token", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Host = "127.0.0.1:8080"
+		w := httptest.NewRecorder()
+		newServer(loopback).Handler().ServeHTTP(w, req)
+		if !strings.Contains(w.Body.String(), `<meta name="ooni-probe-token" content="xyz">`) {
+			t.Fatal("unexpected body", w.Body.String())
+		}
+	})
+}
+
+func TestIsLoopbackAddress(t *testing.T) {
+	cases := map[string]bool{
+		"127.0.0.1:8080":  true,
+		"[::1]:8080":      true,
+		"localhost:8080":  true,
+		":8080":           false,
+		"0.0.0.0:8080":    false,
+		"10.0.0.1:8080":   false,
+		"example.com:80":  false,
+		"invalid-address": false,
+	}
+	for address, expect := range cases {
+		if got := IsLoopbackAddress(address); got != expect {
+			t.Fatal("unexpected result for", address, got)
+		}
+	}
+}
+
+func TestNewToken(t *testing.T) {
+	first, err := NewToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := NewToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(first) != 32 || first == second {
+		t.Fatal("unexpected tokens", first, second)
+	}
+}
@@ -0,0 +1,144 @@
+package serve
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/apex/log"
+)
+
+// ProgressEvent is a progress event emitted while running nettests.
+type ProgressEvent struct {
+	// Key identifies the nettest emitting the event.
+	Key string `json:"key"`
+
+	// Percentage is the progress percentage between 0 and 1.
+	Percentage float64 `json:"percentage"`
+
+	// ETA is the estimated time to completion in seconds or -1.
+	ETA float64 `json:"eta"`
+
+	// Message is the progress message.
+	Message string `json:"message"`
+
+	// Time is when we observed this event.
+	Time time.Time `json:"t"`
+}
+
+// ProgressBroker dispatches progress events to subscribers and serves
+// them as a stream of server-sent events. The zero value is ready to use.
+type ProgressBroker struct {
+	// last is the last event we have seen.
+	last *ProgressEvent
+
+	// mu protects last and subscribers.
+	mu sync.Mutex
+
+	// subscribers contains the subscribers channels.
+	subscribers map[chan *ProgressEvent]bool
+}
+
+// progressBufferSize is the buffer size of each subscriber channel. When a
+// subscriber is slow, we drop events rather than blocking the nettests.
+const progressBufferSize = 64
+
+// Publish publishes the given event to all the subscribers.
+func (pb *ProgressBroker) Publish(ev *ProgressEvent) {
+	pb.mu.Lock()
+	defer pb.mu.Unlock()
+	pb.last = ev
+	for ch := range pb.subscribers {
+		select {
+		case ch <- ev:
+		default:
+		}
+	}
+}
+
+// Last returns the last event we have seen or nil.
+func (pb *ProgressBroker) Last() *ProgressEvent {
+	pb.mu.Lock()
+	defer pb.mu.Unlock()
+	return pb.last
+}
+
+// Subscribe returns a channel receiving progress events. You MUST call
+// Unsubscribe when you are not interested in events anymore.
+func (pb *ProgressBroker) Subscribe() chan *ProgressEvent {
+	pb.mu.Lock()
+	defer pb.mu.Unlock()
+	if pb.subscribers == nil {
+		pb.subscribers = make(map[chan *ProgressEvent]bool)
+	}
+	ch := make(chan *ProgressEvent, progressBufferSize)
+	pb.subscribers[ch] = true
+	return ch
+}
+
+// Unsubscribe stops sending events to a channel returned by Subscribe.
+func (pb *ProgressBroker) Unsubscribe(ch chan *ProgressEvent) {
+	pb.mu.Lock()
+	defer pb.mu.Unlock()
+	delete(pb.subscribers, ch)
+}
+
+// ServeHTTP implements http.Handler by streaming progress events
+// to the client using the server-sent events format.
+func (pb *ProgressBroker) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if !allowMethod(w, req, http.MethodGet) {
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+	ch := pb.Subscribe()
+	defer pb.Unsubscribe(ch)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+	for {
+		select {
+		case <-req.Context().Done():
+			return
+		case ev := <-ch:
+			data, err := json.Marshal(ev)
+			if err != nil {
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "event: progress\ndata: %s\n\n", data); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// LogHandler is a [log.Handler] that publishes the progress events emitted
+// by the output package and forwards all the entries to the wrapped handler.
+type LogHandler struct {
+	// Broker is the broker to publish progress events to.
+	Broker *ProgressBroker
+
+	// Handler is the wrapped handler.
+	Handler log.Handler
+}
+
+var _ log.Handler = &LogHandler{}
+
+// HandleLog implements log.Handler.
+func (h *LogHandler) HandleLog(e *log.Entry) error {
+	if e.Fields.Get("type") == "progress" {
+		ev := &ProgressEvent{Message: e.Message, Time: e.Timestamp}
+		ev.Key, _ = e.Fields.Get("key").(string)
+		ev.Percentage, _ = e.Fields.Get("percentage").(float64)
+		ev.ETA, _ = e.Fields.Get("eta").(float64)
+		h.Broker.Publish(ev)
+	}
+	return h.Handler.HandleLog(e)
+}
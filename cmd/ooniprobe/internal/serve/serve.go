@@ -0,0 +1,310 @@
+// Package serve implements a localhost JSON API and a minimal web
+// dashboard exposing the results stored in the database.
+package serve
+
+import (
+	"bytes"
+	_ "embed" // because we embed a file
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/ooni/probe-cli/v3/internal/model"
+)
+
+// ErrAlreadyRunning indicates that we cannot start a run because
+// another run triggered through the API is still in progress.
+var ErrAlreadyRunning = errors.New("serve: a run is already in progress")
+
+// RunFunc runs the given group of nettests.
+type RunFunc func(groupName string) error
+
+// Config contains the [*Server] configuration.
+type Config struct {
+	// AllowedHosts OPTIONALLY contains the values of the Host header we
+	// accept. When not empty, we reject any other Host header.
+	AllowedHosts []string
+
+	// DB is the MANDATORY database to read results from.
+	DB model.ReadableDatabase
+
+	// Groups is the MANDATORY list of groups that Run can run.
+	Groups []string
+
+	// Progress is the MANDATORY progress broker.
+	Progress *ProgressBroker
+
+	// Run is the MANDATORY function to run a group of nettests.
+	Run RunFunc
+
+	// Token is the MANDATORY per-session token that clients must send
+	// using the [TokenHeader] header to start runs.
+	Token string
+
+	// TokenRequiredForReads OPTIONALLY requires the token for all the
+	// requests, which is what we want when not listening on loopback.
+	TokenRequiredForReads bool
+}
+
+// Server serves the JSON API and the dashboard. The zero value
+// is invalid; please, use [NewServer] to construct.
+type Server struct {
+	config Config
+
+	// mu protects running and lastRun.
+	mu sync.Mutex
+
+	// running is the name of the group that is currently running.
+	running string
+
+	// lastRun contains information about the last run.
+	lastRun *RunInfo
+}
+
+// RunInfo contains information about a run triggered using the API.
+type RunInfo struct {
+	// Group is the name of the group we're running.
+	Group string `json:"group"`
+
+	// StartTime is when we started running.
+	StartTime time.Time `json:"start_time"`
+
+	// EndTime is when we finished running.
+	EndTime *time.Time `json:"end_time"`
+
+	// Failure is the failure that occurred, if any.
+	Failure *string `json:"failure"`
+}
+
+// StatusResponse is the response returned by the status API.
+type StatusResponse struct {
+	// Running indicates whether a run is in progress.
+	Running bool `json:"running"`
+
+	// LastRun contains info about the last (or current) run.
+	LastRun *RunInfo `json:"last_run"`
+
+	// LastProgress is the last progress event we have seen.
+	LastProgress *ProgressEvent `json:"last_progress"`
+}
+
+// ResultsResponse is the response returned by the results API.
+type ResultsResponse struct {
+	// Done contains the results that are done.
+	Done []*Result `json:"done"`
+
+	// Incomplete contains the results that are not done yet.
+	Incomplete []*Result `json:"incomplete"`
+}
+
+// NewServer creates a new [*Server] instance.
+func NewServer(config Config) *Server {
+	return &Server{config: config}
+}
+
+//go:embed dashboard.html
+var dashboard []byte
+
+// Handler returns the [http.Handler] serving the API and the dashboard.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", s.handleDashboard)
+	mux.HandleFunc("/api/v1/status", s.handleStatus)
+	mux.HandleFunc("/api/v1/groups", s.handleGroups)
+	mux.HandleFunc("/api/v1/results", s.handleResults)
+	mux.HandleFunc("/api/v1/results/", s.handleMeasurements)
+	mux.HandleFunc("/api/v1/measurements/", s.handleMeasurementJSON)
+	mux.HandleFunc("/api/v1/run/", s.handleRun)
+	mux.Handle("/api/v1/progress", s.config.Progress)
+	return s.guard(mux)
+}
+
+// dashboardTokenPlaceholder is replaced with the token when serving the dashboard.
+var dashboardTokenPlaceholder = []byte("{{OONI_PROBE_TOKEN}}")
+
+func (s *Server) handleDashboard(w http.ResponseWriter, req *http.Request) {
+	if req.URL.Path != "/" {
+		http.NotFound(w, req)
+		return
+	}
+	if !allowMethod(w, req, http.MethodGet) {
+		return
+	}
+	// Note: other web pages cannot read the dashboard, hence the token, because we check
+	// the Host header and we do not allow cross-origin requests
+	page := bytes.ReplaceAll(dashboard, dashboardTokenPlaceholder, []byte(html.EscapeString(s.config.Token)))
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write(page)
+}
+
+func (s *Server) handleStatus(w http.ResponseWriter, req *http.Request) {
+	if !allowMethod(w, req, http.MethodGet) {
+		return
+	}
+	s.mu.Lock()
+	resp := &StatusResponse{
+		Running:      s.running != "",
+		LastRun:      s.lastRun,
+		LastProgress: s.config.Progress.Last(),
+	}
+	writeJSON(w, http.StatusOK, resp)
+	s.mu.Unlock()
+}
+
+func (s *Server) handleGroups(w http.ResponseWriter, req *http.Request) {
+	if !allowMethod(w, req, http.MethodGet) {
+		return
+	}
+	writeJSON(w, http.StatusOK, s.config.Groups)
+}
+
+func (s *Server) handleResults(w http.ResponseWriter, req *http.Request) {
+	if !allowMethod(w, req, http.MethodGet) {
+		return
+	}
+	done, incomplete, err := s.config.DB.ListResults()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	resp := &ResultsResponse{
+		Done:       newResultList(done),
+		Incomplete: newResultList(incomplete),
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// handleMeasurements serves /api/v1/results/{id}/measurements.
+func (s *Server) handleMeasurements(w http.ResponseWriter, req *http.Request) {
+	if !allowMethod(w, req, http.MethodGet) {
+		return
+	}
+	path := strings.TrimPrefix(req.URL.Path, "/api/v1/results/")
+	value, found := strings.CutSuffix(path, "/measurements")
+	if !found {
+		http.NotFound(w, req)
+		return
+	}
+	resultID, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	msmts, err := s.config.DB.ListMeasurements(resultID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, newMeasurementList(msmts))
+}
+
+// handleMeasurementJSON serves /api/v1/measurements/{id}.
+func (s *Server) handleMeasurementJSON(w http.ResponseWriter, req *http.Request) {
+	if !allowMethod(w, req, http.MethodGet) {
+		return
+	}
+	value := strings.TrimPrefix(req.URL.Path, "/api/v1/measurements/")
+	msmtID, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	msmt, err := s.config.DB.GetMeasurementJSON(msmtID)
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, msmt)
+}
+
+// handleRun serves /api/v1/run/{group}.
+func (s *Server) handleRun(w http.ResponseWriter, req *http.Request) {
+	if !allowMethod(w, req, http.MethodPost) {
+		return
+	}
+	group := strings.TrimPrefix(req.URL.Path, "/api/v1/run/")
+	info, err := s.Start(group)
+	switch {
+	case errors.Is(err, ErrAlreadyRunning):
+		writeError(w, http.StatusConflict, err)
+	case err != nil:
+		writeError(w, http.StatusBadRequest, err)
+	default:
+		writeJSON(w, http.StatusAccepted, info)
+	}
+}
+
+// Start starts running the given group in the background and returns
+// information about the run or an error if we cannot start running.
+func (s *Server) Start(group string) (*RunInfo, error) {
+	if !s.isValidGroup(group) {
+		return nil, fmt.Errorf("serve: no such group: %s", group)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.running != "" {
+		return nil, ErrAlreadyRunning
+	}
+	s.running = group
+	info := &RunInfo{Group: group, StartTime: time.Now().UTC()}
+	s.lastRun = info
+	go s.run(info)
+	copied := *info // the background goroutine is going to modify info
+	return &copied, nil
+}
+
+func (s *Server) run(info *RunInfo) {
+	err := s.config.Run(info.Group)
+	if err != nil {
+		log.WithError(err).Warnf("serve: failed to run %s", info.Group)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	endTime := time.Now().UTC()
+	info.EndTime = &endTime
+	if err != nil {
+		failure := err.Error()
+		info.Failure = &failure
+	}
+	s.running = ""
+}
+
+func (s *Server) isValidGroup(group string) bool {
+	for _, entry := range s.config.Groups {
+		if entry == group {
+			return true
+		}
+	}
+	return false
+}
+
+func allowMethod(w http.ResponseWriter, req *http.Request, method string) bool {
+	if req.Method != method {
+		w.Header().Set("Allow", method)
+		writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return false
+	}
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, status int, value any) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write(data)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,264 @@
+package serve
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/ooni/probe-cli/v3/internal/mocks"
+	"github.com/ooni/probe-cli/v3/internal/model"
+)
+
+func newTestServer(db model.ReadableDatabase, run RunFunc) (*Server, *ProgressBroker) {
+	broker := &ProgressBroker{}
+	server := NewServer(Config{
+		DB:       db,
+		Groups:   []string{"im", "websites"},
+		Progress: broker,
+		Run:      run,
+		Token:    testToken,
+	})
+	return server, broker
+}
+
+// testToken is the token used by newTestServer.
+const testToken = "xyz"
+
+func doRequest(t *testing.T, server *Server, method, path string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	req.Header.Set(TokenHeader, testToken)
+	w := httptest.NewRecorder()
+	server.Handler().ServeHTTP(w, req)
+	return w
+}
+
+func TestServerReadAPI(t *testing.T) {
+	db := &mocks.Database{
+		MockListResults: func() ([]model.DatabaseResultNetwork, []model.DatabaseResultNetwork, error) {
+			done := []model.DatabaseResultNetwork{{DatabaseResult: model.DatabaseResult{ID: 1}}}
+			return done, nil, nil
+		},
+		MockListMeasurements: func(resultID int64) ([]model.DatabaseMeasurementURLNetwork, error) {
+			if resultID != 1 {
+				return nil, errors.New("no such result")
+			}
+			out := []model.DatabaseMeasurementURLNetwork{{
+				DatabaseMeasurement: model.DatabaseMeasurement{ID: 7, ResultID: 1},
+			}}
+			return out, nil
+		},
+		MockGetMeasurementJSON: func(msmtID int64) (map[string]interface{}, error) {
+			if msmtID != 7 {
+				return nil, errors.New("no such measurement")
+			}
+			return map[string]interface{}{"test_name": "antani"}, nil
+		},
+	}
+	server, _ := newTestServer(db, nil)
+
+	type testcase struct {
+		name   string
+		method string
+		path   string
+		status int
+		body   string
+	}
+
+	cases := []testcase{{
+		name:   "dashboard",
+		method: http.MethodGet,
+		path:   "/",
+		status: http.StatusOK,
+		body:   "<title>OONI Probe</title>",
+	}, {
+		name:   "unknown path",
+		method: http.MethodGet,
+		path:   "/antani",
+		status: http.StatusNotFound,
+	}, {
+		name:   "groups",
+		method: http.MethodGet,
+		path:   "/api/v1/groups",
+		status: http.StatusOK,
+		body:   `["im","websites"]`,
+	}, {
+		name:   "results",
+		method: http.MethodGet,
+		path:   "/api/v1/results",
+		status: http.StatusOK,
+		body:   `"incomplete":[]`,
+	}, {
+		name:   "results with wrong method",
+		method: http.MethodPost,
+		path:   "/api/v1/results",
+		status: http.StatusMethodNotAllowed,
+	}, {
+		name:   "measurements",
+		method: http.MethodGet,
+		path:   "/api/v1/results/1/measurements",
+		status: http.StatusOK,
+		body:   `"id":7,"result_id":1`,
+	}, {
+		name:   "measurements with invalid result ID",
+		method: http.MethodGet,
+		path:   "/api/v1/results/x/measurements",
+		status: http.StatusBadRequest,
+	}, {
+		name:   "measurements with database error",
+		method: http.MethodGet,
+		path:   "/api/v1/results/2/measurements",
+		status: http.StatusInternalServerError,
+		body:   "no such result",
+	}, {
+		name:   "measurements without suffix",
+		method: http.MethodGet,
+		path:   "/api/v1/results/1",
+		status: http.StatusNotFound,
+	}, {
+		name:   "measurement JSON",
+		method: http.MethodGet,
+		path:   "/api/v1/measurements/7",
+		status: http.StatusOK,
+		body:   `{"test_name":"antani"}`,
+	}, {
+		name:   "measurement JSON with unknown measurement",
+		method: http.MethodGet,
+		path:   "/api/v1/measurements/8",
+		status: http.StatusNotFound,
+	}, {
+		name:   "status",
+		method: http.MethodGet,
+		path:   "/api/v1/status",
+		status: http.StatusOK,
+		body:   `{"running":false,"last_run":null,"last_progress":null}`,
+	}}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := doRequest(t, server, tc.method, tc.path)
+			if w.Code != tc.status {
+				t.Fatal("expected", tc.status, "got", w.Code)
+			}
+			if !strings.Contains(w.Body.String(), tc.body) {
+				t.Fatal("unexpected body", w.Body.String())
+			}
+		})
+	}
+}
+
+func TestServerRun(t *testing.T) {
+	started, unblock := make(chan string), make(chan error)
+	server, _ := newTestServer(&mocks.Database{}, func(groupName string) error {
+		started <- groupName
+		return <-unblock
+	})
+
+	t.Run("we cannot use GET", func(t *testing.T) {
+		w := doRequest(t, server, http.MethodGet, "/api/v1/run/im")
+		if w.Code != http.StatusMethodNotAllowed {
+			t.Fatal("unexpected status", w.Code)
+		}
+	})
+
+	t.Run("we cannot run an unknown group", func(t *testing.T) {
+		w := doRequest(t, server, http.MethodPost, "/api/v1/run/antani")
+		if w.Code != http.StatusBadRequest {
+			t.Fatal("unexpected status", w.Code)
+		}
+	})
+
+	t.Run("we run a group and refuse concurrent runs", func(t *testing.T) {
+		w := doRequest(t, server, http.MethodPost, "/api/v1/run/im")
+		if w.Code != http.StatusAccepted {
+			t.Fatal("unexpected status", w.Code)
+		}
+		if group := <-started; group != "im" {
+			t.Fatal("unexpected group", group)
+		}
+		w = doRequest(t, server, http.MethodPost, "/api/v1/run/websites")
+		if w.Code != http.StatusConflict {
+			t.Fatal("unexpected status", w.Code)
+		}
+		unblock <- errors.New("mocked error")
+
+		// wait for the run to complete
+		for {
+			var status StatusResponse
+			w = doRequest(t, server, http.MethodGet, "/api/v1/status")
+			if err := json.Unmarshal(w.Body.Bytes(), &status); err != nil {
+				t.Fatal(err)
+			}
+			if !status.Running {
+				if status.LastRun.Failure == nil || *status.LastRun.Failure != "mocked error" {
+					t.Fatal("unexpected last run", status.LastRun)
+				}
+				break
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	})
+}
+
+func TestProgress(t *testing.T) {
+	broker := &ProgressBroker{}
+	var forwarded []*log.Entry
+	handler := &LogHandler{
+		Broker: broker,
+		Handler: log.HandlerFunc(func(e *log.Entry) error {
+			forwarded = append(forwarded, e)
+			return nil
+		}),
+	}
+	logger := &log.Logger{Handler: handler, Level: log.InfoLevel}
+
+	srv := httptest.NewServer(broker)
+	defer srv.Close()
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatal("unexpected content type")
+	}
+
+	logger.Info("this is not a progress event")
+	logger.WithFields(log.Fields{
+		"type":       "progress",
+		"key":        "nettests.Telegram",
+		"percentage": 0.5,
+		"eta":        10.0,
+	}).Info("processing input")
+
+	reader := bufio.NewReader(resp.Body)
+	var data string
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.HasPrefix(line, "data: ") {
+			data = strings.TrimPrefix(strings.TrimSpace(line), "data: ")
+			break
+		}
+	}
+	var ev ProgressEvent
+	if err := json.Unmarshal([]byte(data), &ev); err != nil {
+		t.Fatal(err)
+	}
+	if ev.Key != "nettests.Telegram" || ev.Percentage != 0.5 || ev.ETA != 10 || ev.Message != "processing input" {
+		t.Fatal("unexpected event", ev)
+	}
+	if last := broker.Last(); last == nil || last.Message != "processing input" {
+		t.Fatal("unexpected last event", last)
+	}
+	if len(forwarded) != 2 {
+		t.Fatal("expected all entries to be forwarded")
+	}
+}
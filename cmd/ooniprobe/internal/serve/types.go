@@ -0,0 +1,125 @@
+package serve
+
+import (
+	"time"
+
+	"github.com/ooni/probe-cli/v3/internal/model"
+)
+
+// We cannot directly serialize the database models because they embed
+// several structs having fields with the same name (e.g., ID), which
+// encoding/json silently omits. So, we define the API types here.
+
+// Result is a result returned by the API.
+type Result struct {
+	ID                 int64     `json:"id"`
+	TestGroupName      string    `json:"test_group_name"`
+	StartTime          time.Time `json:"start_time"`
+	Runtime            float64   `json:"runtime"`
+	IsDone             bool      `json:"is_done"`
+	IsUploaded         bool      `json:"is_uploaded"`
+	DataUsageUp        float64   `json:"data_usage_up"`
+	DataUsageDown      float64   `json:"data_usage_down"`
+	MeasurementCount   uint64    `json:"measurement_count"`
+	AnomalyCount       uint64    `json:"anomaly_count"`
+	NetworkName        string    `json:"network_name"`
+	NetworkType        string    `json:"network_type"`
+	NetworkLabel       string    `json:"network_label"`
+	ASN                uint      `json:"asn"`
+	NetworkCountryCode string    `json:"network_country_code"`
+}
+
+// newResult converts a [model.DatabaseResultNetwork] to a [*Result].
+func newResult(r *model.DatabaseResultNetwork) *Result {
+	return &Result{
+		ID:                 r.DatabaseResult.ID,
+		TestGroupName:      r.TestGroupName,
+		StartTime:          r.StartTime,
+		Runtime:            r.Runtime,
+		IsDone:             r.IsDone,
+		IsUploaded:         r.IsUploaded,
+		DataUsageUp:        r.DataUsageUp,
+		DataUsageDown:      r.DataUsageDown,
+		MeasurementCount:   r.TotalCount,
+		AnomalyCount:       r.AnomalyCount,
+		NetworkName:        r.NetworkName,
+		NetworkType:        r.NetworkType,
+		NetworkLabel:       r.NetworkLabel,
+		ASN:                r.ASN,
+		NetworkCountryCode: r.DatabaseNetwork.CountryCode,
+	}
+}
+
+// newResultList converts a list of [model.DatabaseResultNetwork] to a list of [*Result].
+func newResultList(in []model.DatabaseResultNetwork) []*Result {
+	out := []*Result{}
+	for idx := range in {
+		out = append(out, newResult(&in[idx]))
+	}
+	return out
+}
+
+// Measurement is a measurement returned by the API.
+type Measurement struct {
+	ID               int64     `json:"id"`
+	ResultID         int64     `json:"result_id"`
+	TestName         string    `json:"test_name"`
+	StartTime        time.Time `json:"start_time"`
+	Runtime          float64   `json:"runtime"`
+	IsDone           bool      `json:"is_done"`
+	IsFailed         bool      `json:"is_failed"`
+	FailureMsg       *string   `json:"failure_msg"`
+	IsUploaded       bool      `json:"is_uploaded"`
+	IsUploadFailed   bool      `json:"is_upload_failed"`
+	UploadFailureMsg *string   `json:"upload_failure_msg"`
+	IsAnomaly        *bool     `json:"is_anomaly"`
+	ReportID         *string   `json:"report_id"`
+	Input            *string   `json:"input"`
+	CategoryCode     *string   `json:"category_code"`
+	TestKeys         string    `json:"test_keys"`
+}
+
+// newMeasurement converts a [model.DatabaseMeasurementURLNetwork] to a [*Measurement].
+func newMeasurement(m *model.DatabaseMeasurementURLNetwork) *Measurement {
+	out := &Measurement{
+		ID:             m.DatabaseMeasurement.ID,
+		ResultID:       m.DatabaseMeasurement.ResultID,
+		TestName:       m.TestName,
+		StartTime:      m.DatabaseMeasurement.StartTime,
+		Runtime:        m.DatabaseMeasurement.Runtime,
+		IsDone:         m.DatabaseMeasurement.IsDone,
+		IsFailed:       m.IsFailed,
+		IsUploaded:     m.DatabaseMeasurement.IsUploaded,
+		IsUploadFailed: m.IsUploadFailed,
+		TestKeys:       m.TestKeys,
+	}
+	if m.FailureMsg.Valid {
+		out.FailureMsg = &m.FailureMsg.String
+	}
+	if m.UploadFailureMsg.Valid {
+		out.UploadFailureMsg = &m.UploadFailureMsg.String
+	}
+	if m.IsAnomaly.Valid {
+		out.IsAnomaly = &m.IsAnomaly.Bool
+	}
+	if m.ReportID.Valid {
+		out.ReportID = &m.ReportID.String
+	}
+	if m.URL.Valid {
+		out.Input = &m.URL.String
+	}
+	if m.CategoryCode.Valid {
+		out.CategoryCode = &m.CategoryCode.String
+	}
+	return out
+}
+
+// newMeasurementList converts a list of [model.DatabaseMeasurementURLNetwork]
+// to a list of [*Measurement].
+func newMeasurementList(in []model.DatabaseMeasurementURLNetwork) []*Measurement {
+	out := []*Measurement{}
+	for idx := range in {
+		out = append(out, newMeasurement(&in[idx]))
+	}
+	return out
+}
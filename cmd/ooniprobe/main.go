@@ -3,6 +3,7 @@ package main
 import (
 	"github.com/ooni/probe-cli/v3/cmd/ooniprobe/internal/cli/app"
 	_ "github.com/ooni/probe-cli/v3/cmd/ooniprobe/internal/cli/autorun"
+	_ "github.com/ooni/probe-cli/v3/cmd/ooniprobe/internal/cli/gc"
 	_ "github.com/ooni/probe-cli/v3/cmd/ooniprobe/internal/cli/geoip"
 	_ "github.com/ooni/probe-cli/v3/cmd/ooniprobe/internal/cli/info"
 	_ "github.com/ooni/probe-cli/v3/cmd/ooniprobe/internal/cli/list"
@@ -10,6 +11,7 @@ import (
 	_ "github.com/ooni/probe-cli/v3/cmd/ooniprobe/internal/cli/reset"
 	_ "github.com/ooni/probe-cli/v3/cmd/ooniprobe/internal/cli/rm"
 	_ "github.com/ooni/probe-cli/v3/cmd/ooniprobe/internal/cli/run"
+	_ "github.com/ooni/probe-cli/v3/cmd/ooniprobe/internal/cli/serve"
 	_ "github.com/ooni/probe-cli/v3/cmd/ooniprobe/internal/cli/show"
 	_ "github.com/ooni/probe-cli/v3/cmd/ooniprobe/internal/cli/upload"
 	_ "github.com/ooni/probe-cli/v3/cmd/ooniprobe/internal/cli/version"
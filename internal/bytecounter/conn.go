@@ -29,6 +29,11 @@ func (c *wrappedConn) Write(p []byte) (int, error) {
 	return count, err
 }
 
+// NetConn returns the underlying net.Conn.
+func (c *wrappedConn) NetConn() net.Conn {
+	return c.Conn
+}
+
 // WrapConn returns a new conn that uses the given counter.
 func WrapConn(conn net.Conn, counter *Counter) net.Conn {
 	return &wrappedConn{Conn: conn, Counter: counter}
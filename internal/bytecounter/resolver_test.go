@@ -6,6 +6,7 @@ import (
 	"net"
 	"testing"
 
+	"github.com/miekg/dns"
 	"github.com/ooni/probe-cli/v3/internal/mocks"
 	"github.com/ooni/probe-cli/v3/internal/model"
 	"github.com/ooni/probe-cli/v3/internal/netxlite"
@@ -196,6 +197,80 @@ func TestMaybeWrapSystemResolver(t *testing.T) {
 		})
 	})
 
+	t.Run("LookupRaw works as intended", func(t *testing.T) {
+		t.Run("on success", func(t *testing.T) {
+			underlying := &mocks.Resolver{
+				MockLookupRaw: func(ctx context.Context, domain string, qtype uint16) ([]*model.DNSRecord, error) {
+					out := make([]*model.DNSRecord, 3)
+					return out, nil
+				},
+			}
+			counter := New()
+			reso := MaybeWrapSystemResolver(underlying, counter)
+			got, err := reso.LookupRaw(context.Background(), "dns.google", dns.TypeTXT)
+			if err != nil {
+				t.Fatal("unexpected error", err)
+			}
+			if len(got) != 3 {
+				t.Fatal("invalid result")
+			}
+			if nsent := counter.BytesSent(); nsent != 10 {
+				t.Fatal("unexpected nsent", nsent)
+			}
+			if nrecv := counter.BytesReceived(); nrecv != 256 {
+				t.Fatal("unexpected nrecv")
+			}
+		})
+
+		t.Run("on non-DNS failure", func(t *testing.T) {
+			expected := errors.New("mocked error")
+			underlying := &mocks.Resolver{
+				MockLookupRaw: func(ctx context.Context, domain string, qtype uint16) ([]*model.DNSRecord, error) {
+					return nil, expected
+				},
+			}
+			counter := New()
+			reso := MaybeWrapSystemResolver(underlying, counter)
+			got, err := reso.LookupRaw(context.Background(), "dns.google", dns.TypeTXT)
+			if !errors.Is(err, expected) {
+				t.Fatal("unexpected error", err)
+			}
+			if len(got) != 0 {
+				t.Fatal("invalid result")
+			}
+			if nsent := counter.BytesSent(); nsent != 10 {
+				t.Fatal("unexpected nsent", nsent)
+			}
+			if nrecv := counter.BytesReceived(); nrecv != 0 {
+				t.Fatal("unexpected nrecv")
+			}
+		})
+
+		t.Run("on DNS failure", func(t *testing.T) {
+			expected := errors.New(netxlite.FailureDNSNXDOMAINError)
+			underlying := &mocks.Resolver{
+				MockLookupRaw: func(ctx context.Context, domain string, qtype uint16) ([]*model.DNSRecord, error) {
+					return nil, expected
+				},
+			}
+			counter := New()
+			reso := MaybeWrapSystemResolver(underlying, counter)
+			got, err := reso.LookupRaw(context.Background(), "dns.google", dns.TypeTXT)
+			if !errors.Is(err, expected) {
+				t.Fatal("unexpected error", err)
+			}
+			if len(got) != 0 {
+				t.Fatal("invalid result")
+			}
+			if nsent := counter.BytesSent(); nsent != 10 {
+				t.Fatal("unexpected nsent", nsent)
+			}
+			if nrecv := counter.BytesReceived(); nrecv != 128 {
+				t.Fatal("unexpected nrecv")
+			}
+		})
+	})
+
 	t.Run("LookupHost works as intended", func(t *testing.T) {
 		t.Run("on success", func(t *testing.T) {
 			underlying := &mocks.Resolver{
@@ -465,6 +540,83 @@ func TestWrapWithContextAwareSystemResolver(t *testing.T) {
 		})
 	})
 
+	t.Run("LookupRaw works as intended", func(t *testing.T) {
+		t.Run("on success", func(t *testing.T) {
+			underlying := &mocks.Resolver{
+				MockLookupRaw: func(ctx context.Context, domain string, qtype uint16) ([]*model.DNSRecord, error) {
+					out := make([]*model.DNSRecord, 3)
+					return out, nil
+				},
+			}
+			counter := New()
+			reso := WrapWithContextAwareSystemResolver(underlying)
+			ctx := WithSessionByteCounter(context.Background(), counter)
+			got, err := reso.LookupRaw(ctx, "dns.google", dns.TypeTXT)
+			if err != nil {
+				t.Fatal("unexpected error", err)
+			}
+			if len(got) != 3 {
+				t.Fatal("invalid result")
+			}
+			if nsent := counter.BytesSent(); nsent != 10 {
+				t.Fatal("unexpected nsent", nsent)
+			}
+			if nrecv := counter.BytesReceived(); nrecv != 256 {
+				t.Fatal("unexpected nrecv")
+			}
+		})
+
+		t.Run("on non-DNS failure", func(t *testing.T) {
+			expected := errors.New("mocked error")
+			underlying := &mocks.Resolver{
+				MockLookupRaw: func(ctx context.Context, domain string, qtype uint16) ([]*model.DNSRecord, error) {
+					return nil, expected
+				},
+			}
+			counter := New()
+			reso := WrapWithContextAwareSystemResolver(underlying)
+			ctx := WithSessionByteCounter(context.Background(), counter)
+			got, err := reso.LookupRaw(ctx, "dns.google", dns.TypeTXT)
+			if !errors.Is(err, expected) {
+				t.Fatal("unexpected error", err)
+			}
+			if len(got) != 0 {
+				t.Fatal("invalid result")
+			}
+			if nsent := counter.BytesSent(); nsent != 10 {
+				t.Fatal("unexpected nsent", nsent)
+			}
+			if nrecv := counter.BytesReceived(); nrecv != 0 {
+				t.Fatal("unexpected nrecv")
+			}
+		})
+
+		t.Run("on DNS failure", func(t *testing.T) {
+			expected := errors.New(netxlite.FailureDNSNXDOMAINError)
+			underlying := &mocks.Resolver{
+				MockLookupRaw: func(ctx context.Context, domain string, qtype uint16) ([]*model.DNSRecord, error) {
+					return nil, expected
+				},
+			}
+			counter := New()
+			reso := WrapWithContextAwareSystemResolver(underlying)
+			ctx := WithSessionByteCounter(context.Background(), counter)
+			got, err := reso.LookupRaw(ctx, "dns.google", dns.TypeTXT)
+			if !errors.Is(err, expected) {
+				t.Fatal("unexpected error", err)
+			}
+			if len(got) != 0 {
+				t.Fatal("invalid result")
+			}
+			if nsent := counter.BytesSent(); nsent != 10 {
+				t.Fatal("unexpected nsent", nsent)
+			}
+			if nrecv := counter.BytesReceived(); nrecv != 128 {
+				t.Fatal("unexpected nrecv")
+			}
+		})
+	})
+
 	t.Run("LookupHost works as intended", func(t *testing.T) {
 		t.Run("on success", func(t *testing.T) {
 			underlying := &mocks.Resolver{
@@ -0,0 +1,91 @@
+package main
+
+//
+// Static checks for dsljson documents
+//
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/apex/log"
+	"github.com/ooni/probe-cli/v3/internal/must"
+	"github.com/ooni/probe-cli/v3/internal/runtimex"
+	"github.com/ooni/probe-cli/v3/internal/x/dsljson"
+	"github.com/spf13/cobra"
+)
+
+// registerDSL registers the validate and plan subcommands of the dsl command. We
+// attach them to the dsl experiment's command when it exists, so that "miniooni dsl"
+// keeps running the experiment while "miniooni dsl validate" checks a document.
+func registerDSL(rootCmd *cobra.Command, _ *Options) {
+	var dslCmd *cobra.Command
+	for _, cmd := range rootCmd.Commands() {
+		if cmd.Use == "dsl" {
+			dslCmd = cmd
+			break
+		}
+	}
+	if dslCmd == nil {
+		dslCmd = &cobra.Command{
+			Use:   "dsl",
+			Short: "Very experimental commands to work with JSON DSL documents",
+			Args:  cobra.NoArgs,
+		}
+		rootCmd.AddCommand(dslCmd)
+	}
+
+	validateCmd := &cobra.Command{
+		Use:   "validate FILE",
+		Short: "Statically checks a JSON DSL document without touching the network",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			runtimex.Assert(len(args) == 1, "expected exactly one argument")
+			plan := dslValidateOrExit(args[0])
+			for _, warning := range plan.Warnings {
+				log.Warn(warning)
+			}
+			fmt.Printf("%s: OK\n", args[0])
+		},
+	}
+	dslCmd.AddCommand(validateCmd)
+
+	var planJSON bool
+	planCmd := &cobra.Command{
+		Use:   "plan FILE",
+		Short: "Prints the execution plan of a JSON DSL document without touching the network",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			runtimex.Assert(len(args) == 1, "expected exactly one argument")
+			plan := dslValidateOrExit(args[0])
+			if planJSON {
+				fmt.Printf("%s\n", string(must.MarshalJSON(plan)))
+				return
+			}
+			fmt.Print(plan.String())
+		},
+	}
+	planCmd.Flags().BoolVar(&planJSON, "json", false, "emit the plan using the JSON format")
+	dslCmd.AddCommand(planCmd)
+}
+
+// dslValidateOrExit loads and validates the given document and exits on failure.
+func dslValidateOrExit(filename string) *dsljson.Plan {
+	data, err := os.ReadFile(filename)
+	runtimex.PanicOnError(err, "cannot read the JSON DSL document")
+	var root dsljson.RootNode
+	if err := json.Unmarshal(data, &root); err != nil {
+		log.Warnf("%s: cannot parse JSON: %s", filename, err.Error())
+		os.Exit(1)
+	}
+	plan, err := dsljson.Validate(&root)
+	if verr, good := err.(*dsljson.ValidationError); good {
+		for _, problem := range verr.Problems {
+			log.Warnf("%s: %s", filename, problem)
+		}
+		os.Exit(1)
+	}
+	runtimex.PanicOnError(err, "dsljson.Validate failed")
+	return plan
+}
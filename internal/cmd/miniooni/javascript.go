@@ -1,29 +1,41 @@
 package main
 
 import (
+	"context"
+	"os"
+	"path"
 	"path/filepath"
 
 	"github.com/apex/log"
+	"github.com/ooni/probe-cli/v3/internal/engine"
+	"github.com/ooni/probe-cli/v3/internal/oonirun"
 	"github.com/ooni/probe-cli/v3/internal/runtimex"
 	"github.com/ooni/probe-cli/v3/internal/x/dsljavascript"
 	"github.com/spf13/cobra"
 )
 
 // registerJavaScript registers the javascript subcommand
-func registerJavaScript(rootCmd *cobra.Command, _ *Options) {
+func registerJavaScript(rootCmd *cobra.Command, currentOptions *Options) {
+	var measure bool
 	subCmd := &cobra.Command{
 		Use:   "javascript",
 		Short: "Very experimental command to run JavaScript snippets",
 		Args:  cobra.ExactArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
 			runtimex.Assert(len(args) == 1, "expected exactly one argument")
-			javaScriptMain(args[0])
+			javaScriptMain(args[0], measure, currentOptions)
 		},
 	}
+	subCmd.Flags().BoolVar(
+		&measure,
+		"measure",
+		false,
+		"create a session allowing the script to save and submit measurements",
+	)
 	rootCmd.AddCommand(subCmd)
 }
 
-func javaScriptMain(scriptPath string) {
+func javaScriptMain(scriptPath string, measure bool, currentOptions *Options) {
 	// TODO(bassosimone): for an initial prototype, using a local directory is
 	// good, but, if we make this more production ready, we probably need to define
 	// a specific location under the $OONI_HOME.
@@ -36,5 +48,49 @@ func javaScriptMain(scriptPath string) {
 	log.Warnf("or heavily modified without prior notice. For more information, for now")
 	log.Warnf("see https://github.com/bassosimone/2023-12-09-ooni-javascript.")
 
+	if measure {
+		sess := javaScriptNewSessionOrPanic(currentOptions)
+		defer sess.Close()
+		config.Session = sess
+
+		saver, err := oonirun.NewSaver(oonirun.SaverConfig{
+			Enabled:  !currentOptions.NoJSON,
+			FilePath: javaScriptReportFile(currentOptions),
+			Logger:   log.Log,
+		})
+		runtimex.PanicOnError(err, "oonirun.NewSaver failed")
+		config.Saver = saver
+
+		submitter, err := oonirun.NewSubmitter(context.Background(), oonirun.SubmitterConfig{
+			Enabled: !currentOptions.NoCollector,
+			Session: sess,
+			Logger:  log.Log,
+		})
+		runtimex.PanicOnError(err, "oonirun.NewSubmitter failed")
+		config.Submitter = submitter
+	}
+
 	runtimex.Try0(dsljavascript.RunScript(config, scriptPath))
 }
+
+// javaScriptNewSessionOrPanic creates a session and looks up the probe location.
+func javaScriptNewSessionOrPanic(currentOptions *Options) *engine.Session {
+	ctx := context.Background()
+	homeDir := gethomedir(currentOptions.HomeDir)
+	runtimex.Assert(homeDir != "", "home directory is empty")
+	miniooniDir := path.Join(homeDir, ".miniooni")
+	runtimex.Try0(os.MkdirAll(miniooniDir, 0700))
+	acquireUserConsent(miniooniDir, currentOptions)
+	sess := newSessionOrPanic(ctx, currentOptions, miniooniDir, log.Log)
+	lookupBackendsOrPanic(ctx, sess)
+	lookupLocationOrPanic(ctx, sess)
+	return sess
+}
+
+// javaScriptReportFile returns the file where to save measurements.
+func javaScriptReportFile(currentOptions *Options) string {
+	if currentOptions.ReportFile == "" {
+		return "report.jsonl"
+	}
+	return currentOptions.ReportFile
+}
@@ -34,6 +34,7 @@ type Options struct {
 	MaxRuntime          int64
 	NoJSON              bool
 	NoCollector         bool
+	PCAPNGFile          string
 	ProbeServicesURL    string
 	Proxy               string
 	Random              bool
@@ -99,6 +100,13 @@ func main() {
 		"do not submit measurements to the OONI collector",
 	)
 
+	flags.StringVar(
+		&globalOptions.PCAPNGFile,
+		"pcapng",
+		"",
+		"write a PCAPNG capture of the traffic into the given file (for debugging)",
+	)
+
 	flags.StringVar(
 		&globalOptions.ProbeServicesURL,
 		"probe-services",
@@ -110,7 +118,7 @@ func main() {
 		&globalOptions.Proxy,
 		"proxy",
 		"",
-		"set proxy URL to communicate with the OONI backend (mutually exclusive with --tunnel)",
+		"set socks5, http, or https proxy URL to communicate with the OONI backend (mutually exclusive with --tunnel)",
 	)
 
 	flags.Int64Var(
@@ -191,6 +199,7 @@ func main() {
 	registerAllExperiments(rootCmd, &globalOptions)
 	registerOONIRun(rootCmd, &globalOptions)
 	registerJavaScript(rootCmd, &globalOptions)
+	registerDSL(rootCmd, &globalOptions)
 
 	if err := rootCmd.Execute(); err != nil {
 		os.Exit(1)
@@ -318,15 +327,19 @@ func MainWithConfiguration(experimentName string, currentOptions *Options) {
 		currentOptions.ReportFile = "report.jsonl"
 	}
 	log.Log = logger
-	for {
-		mainSingleIteration(logger, experimentName, currentOptions)
-		if currentOptions.RepeatEvery <= 0 {
-			break
+	capture := newPCAPNGCaptureOrPanic(currentOptions.PCAPNGFile)
+	capture.run(func() {
+		for {
+			mainSingleIteration(logger, experimentName, currentOptions)
+			if currentOptions.RepeatEvery <= 0 {
+				break
+			}
+			capture.flush()
+			log.Infof("waiting %ds before repeating the measurement", currentOptions.RepeatEvery)
+			log.Info("use Ctrl-C to interrupt miniooni")
+			time.Sleep(time.Duration(currentOptions.RepeatEvery) * time.Second)
 		}
-		log.Infof("waiting %ds before repeating the measurement", currentOptions.RepeatEvery)
-		log.Info("use Ctrl-C to interrupt miniooni")
-		time.Sleep(time.Duration(currentOptions.RepeatEvery) * time.Second)
-	}
+	})
 }
 
 // mainSingleIteration runs a single iteration. There may be multiple iterations
@@ -0,0 +1,60 @@
+package main
+
+//
+// Optional PCAPNG capture of the measurement traffic
+//
+
+import (
+	"os"
+
+	"github.com/apex/log"
+	"github.com/ooni/probe-cli/v3/internal/netxlite"
+	"github.com/ooni/probe-cli/v3/internal/runtimex"
+)
+
+// pcapngCapture captures the traffic into a PCAPNG file. A nil
+// *pcapngCapture is valid and does not capture anything.
+type pcapngCapture struct {
+	filep  *os.File
+	writer *netxlite.PCAPNGWriter
+}
+
+// newPCAPNGCaptureOrPanic creates a [*pcapngCapture] writing into the given
+// file, or panics on error. It returns nil if the filename is empty.
+func newPCAPNGCaptureOrPanic(filename string) *pcapngCapture {
+	if filename == "" {
+		return nil
+	}
+	filep, err := os.Create(filename)
+	runtimex.PanicOnError(err, "cannot create the PCAPNG file")
+	writer, err := netxlite.NewPCAPNGWriter(filep)
+	runtimex.PanicOnError(err, "cannot write the PCAPNG file")
+	log.Warnf("capturing the traffic into %s: the capture contains synthesized packets", filename)
+	return &pcapngCapture{filep: filep, writer: writer}
+}
+
+// run runs the given function while capturing the traffic, then flushes
+// and closes the capture such that we don't lose packets when we panic.
+func (pc *pcapngCapture) run(fx func()) {
+	if pc == nil {
+		fx()
+		return
+	}
+	defer func() {
+		pc.flush()
+		if err := pc.filep.Close(); err != nil {
+			log.Warnf("cannot close the PCAPNG file: %s", err.Error())
+		}
+	}()
+	netxlite.WithCustomTProxy(pc.writer.WrapUnderlyingNetwork(&netxlite.DefaultTProxy{}), fx)
+}
+
+// flush flushes the capture, which is useful between repetitions.
+func (pc *pcapngCapture) flush() {
+	if pc == nil {
+		return
+	}
+	if err := pc.writer.Flush(); err != nil {
+		log.Warnf("cannot flush the PCAPNG file: %s", err.Error())
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPCAPNGCapture(t *testing.T) {
+	t.Run("with an empty filename", func(t *testing.T) {
+		capture := newPCAPNGCaptureOrPanic("")
+		if capture != nil {
+			t.Fatal("expected nil capture")
+		}
+		var called bool
+		capture.run(func() {
+			capture.flush() // must not crash
+			called = true
+		})
+		if !called {
+			t.Fatal("did not call the function")
+		}
+	})
+
+	t.Run("we flush and close the file when done", func(t *testing.T) {
+		filename := filepath.Join(t.TempDir(), "capture.pcapng")
+		capture := newPCAPNGCaptureOrPanic(filename)
+		var called bool
+		capture.run(func() {
+			called = true
+		})
+		if !called {
+			t.Fatal("did not call the function")
+		}
+		if err := capture.filep.Close(); !errors.Is(err, os.ErrClosed) {
+			t.Fatal("expected the file to be already closed, got", err)
+		}
+		stat, err := os.Stat(filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stat.Size() <= 0 {
+			t.Fatal("expected to see the PCAPNG headers in the file")
+		}
+	})
+}
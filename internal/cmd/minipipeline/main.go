@@ -1,12 +1,15 @@
 package main
 
 import (
+	"bytes"
 	"flag"
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/ooni/probe-cli/v3/internal/geoipx"
+	"github.com/ooni/probe-cli/v3/internal/measurexlite"
 	"github.com/ooni/probe-cli/v3/internal/minipipeline"
 	"github.com/ooni/probe-cli/v3/internal/model"
 	"github.com/ooni/probe-cli/v3/internal/must"
@@ -31,13 +34,16 @@ var (
 
 	// osExitFn allows overwriting os.Exit in tests
 	osExitFn = os.Exit
+
+	// spansFlag is the -spans flag
+	spansFlag = flag.Bool("spans", false, "also export the measurement timeline as spans")
 )
 
 func main() {
 	flag.Parse()
 	if *helpFlag || *measurementFlag == "" {
 		fmt.Fprintf(os.Stderr, "\n")
-		fmt.Fprintf(os.Stderr, "usage: %s -measurement <file> [-destdir <dir>] [-prefix <prefix>]\n", filepath.Base(os.Args[0]))
+		fmt.Fprintf(os.Stderr, "usage: %s -measurement <file> [-destdir <dir>] [-prefix <prefix>] [-spans]\n", filepath.Base(os.Args[0]))
 		fmt.Fprintf(os.Stderr, "\n")
 		fmt.Fprintf(os.Stderr, "Mini measurement processing pipeline to reprocess recent probe measurements\n")
 		fmt.Fprintf(os.Stderr, "and align results calculation with ooni/data.\n")
@@ -48,6 +54,10 @@ func main() {
 		fmt.Fprintf(os.Stderr, "analysis like the one in Web Connectivity v0.4 and generate accordingly the\n")
 		fmt.Fprintf(os.Stderr, "observations_classic.json and analysis_classic.json files.\n")
 		fmt.Fprintf(os.Stderr, "\n")
+		fmt.Fprintf(os.Stderr, "Use -spans to also export the measurement timeline as spans in the\n")
+		fmt.Fprintf(os.Stderr, "trace_otlp.json (OTLP/JSON) and trace_chrome.json (Chrome trace event\n")
+		fmt.Fprintf(os.Stderr, "format) files.\n")
+		fmt.Fprintf(os.Stderr, "\n")
 		fmt.Fprintf(os.Stderr, "Use -prefix <prefix> to add <prefix> in front of the generated files names.\n")
 		fmt.Fprintf(os.Stderr, "\n")
 		osExitFn(1)
@@ -55,7 +65,8 @@ func main() {
 
 	// parse the measurement file
 	var parsed minipipeline.WebMeasurement
-	must.UnmarshalJSON(must.ReadFile(*measurementFlag), &parsed)
+	rawMeasurement := must.ReadFile(*measurementFlag)
+	must.UnmarshalJSON(rawMeasurement, &parsed)
 
 	// generate and write observations
 	lookupper := model.GeoIPASNLookupperFunc(geoipx.LookupASN)
@@ -77,4 +88,30 @@ func main() {
 	classicAnalysisPath := filepath.Join(*destdirFlag, *prefixFlag+"analysis_classic.json")
 	analysisClassic := minipipeline.AnalyzeWebObservationsWithLinearAnalysis(lookupper, containerClassic)
 	mustWriteFileFn(classicAnalysisPath, must.MarshalAndIndentJSON(analysisClassic, "", "  "), 0600)
+
+	// optionally generate and write the spans
+	if !*spansFlag {
+		return
+	}
+	spans := newSpans(rawMeasurement)
+	otlpBuffer := &bytes.Buffer{}
+	runtimex.Try0(spans.WriteOTLPJSON(otlpBuffer))
+	mustWriteFileFn(filepath.Join(*destdirFlag, *prefixFlag+"trace_otlp.json"), otlpBuffer.Bytes(), 0600)
+	chromeBuffer := &bytes.Buffer{}
+	runtimex.Try0(spans.WriteChromeTraceEvents(chromeBuffer))
+	mustWriteFileFn(filepath.Join(*destdirFlag, *prefixFlag+"trace_chrome.json"), chromeBuffer.Bytes(), 0600)
+}
+
+// newSpans creates the spans for the given raw measurement.
+func newSpans(rawMeasurement []byte) *measurexlite.Spans {
+	var measurement struct {
+		MeasurementStartTime string                         `json:"measurement_start_time"`
+		TestKeys             *measurexlite.SpanObservations `json:"test_keys"`
+	}
+	must.UnmarshalJSON(rawMeasurement, &measurement)
+	zeroTime := runtimex.Try1(time.Parse(model.MeasurementDateFormat, measurement.MeasurementStartTime))
+	if measurement.TestKeys == nil {
+		return measurexlite.NewSpans(zeroTime)
+	}
+	return measurexlite.NewSpans(zeroTime, measurement.TestKeys)
 }
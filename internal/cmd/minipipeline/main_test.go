@@ -34,6 +34,7 @@ func TestMainSuccess(t *testing.T) {
 	}
 	osExitFn = os.Exit
 	*prefixFlag = "y-"
+	*spansFlag = false
 
 	// run the main function
 	main()
@@ -65,6 +66,40 @@ func TestMainSuccess(t *testing.T) {
 	if diff := cmp.Diff(expectedAnalysisClassic, gotAnalysisClassic); diff != "" {
 		t.Fatal(diff)
 	}
+
+	// make sure we have not generated the spans by default
+	if len(contentmap) != 4 {
+		t.Fatal("expected exactly four files, got", len(contentmap))
+	}
+}
+
+func TestMainWithSpans(t *testing.T) {
+	// reconfigure the global options for main
+	*destdirFlag = "xo"
+	*measurementFlag = filepath.Join("testdata", "measurement.json")
+	contentmap := make(map[string][]byte)
+	mustWriteFileFn = func(filename string, content []byte, mode fs.FileMode) {
+		contentmap[filename] = content
+	}
+	osExitFn = os.Exit
+	*prefixFlag = "y-"
+	*spansFlag = true
+	defer func() {
+		*spansFlag = false
+	}()
+
+	// run the main function
+	main()
+
+	// make sure we have generated the spans
+	gotOTLP := mustloaddata(contentmap, filepath.Join("xo", "y-trace_otlp.json"))
+	if len(gotOTLP["resourceSpans"].([]any)) != 1 {
+		t.Fatal("expected a single resource spans entry")
+	}
+	gotChrome := mustloaddata(contentmap, filepath.Join("xo", "y-trace_chrome.json"))
+	if len(gotChrome["traceEvents"].([]any)) <= 1 {
+		t.Fatal("expected more than a single trace event")
+	}
 }
 
 func TestMainUsage(t *testing.T) {
@@ -78,6 +113,7 @@ func TestMainUsage(t *testing.T) {
 		panic(fmt.Errorf("osExit: %d", code))
 	}
 	*prefixFlag = ""
+	*spansFlag = false
 
 	// run the main function
 	var err error
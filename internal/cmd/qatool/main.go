@@ -82,6 +82,7 @@ func runWebConnectivityLTE(tc *webconnectivityqa.TestCase) {
 		tk.Do53 = nil
 		tk.DoH = nil
 		tk.DNSDuplicateResponses = nil
+		tk.DNSDuplicateResponsesCount = 0
 		tk.DNSWoami = nil
 		tk.ConnPriorityLog = nil
 
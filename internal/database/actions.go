@@ -131,6 +131,7 @@ func (d *Database) ListResults() ([]model.DatabaseResultNetwork, []model.Databas
 	req := d.sess.SQL().Select(
 		db.Raw("networks.network_name"),
 		db.Raw("networks.network_type"),
+		db.Raw("networks.network_label"),
 		db.Raw("networks.ip"),
 		db.Raw("networks.asn"),
 		db.Raw("networks.network_country_code"),
@@ -161,6 +162,7 @@ func (d *Database) ListResults() ([]model.DatabaseResultNetwork, []model.Databas
 		GroupBy(
 			db.Raw("networks.network_name"),
 			db.Raw("networks.network_type"),
+			db.Raw("networks.network_label"),
 			db.Raw("networks.ip"),
 			db.Raw("networks.asn"),
 			db.Raw("networks.network_country_code"),
@@ -292,14 +294,18 @@ func (d *Database) CreateResult(homePath string, testGroupName string, networkID
 }
 
 // CreateNetwork implements WritableDatabase.CreateNetwork
-func (d *Database) CreateNetwork(loc model.LocationProvider) (*model.DatabaseNetwork, error) {
+func (d *Database) CreateNetwork(
+	loc model.LocationProvider, info model.DatabaseNetworkInfo) (*model.DatabaseNetwork, error) {
 	network := model.DatabaseNetwork{
-		ASN:         loc.ProbeASN(),
-		CountryCode: loc.ProbeCC(),
-		NetworkName: loc.ProbeNetworkName(),
-		// On desktop we consider it to always be wifi
-		NetworkType: "wifi",
-		IP:          loc.ProbeIP(),
+		ASN:          loc.ProbeASN(),
+		CountryCode:  loc.ProbeCC(),
+		NetworkName:  loc.ProbeNetworkName(),
+		NetworkType:  info.Type,
+		NetworkLabel: info.Label,
+		IP:           loc.ProbeIP(),
+	}
+	if network.NetworkType == "" {
+		network.NetworkType = model.DatabaseNetworkTypeDefault
 	}
 	newID, err := d.sess.Collection("networks").Insert(network)
 	if err != nil {
@@ -377,6 +383,31 @@ func (d *Database) AddTestKeys(msmt *model.DatabaseMeasurement, sk model.Measure
 	return nil
 }
 
+// ListPendingUploads implements ReadableDatabase.ListPendingUploads
+func (d *Database) ListPendingUploads() ([]model.DatabaseMeasurement, error) {
+	measurements := []model.DatabaseMeasurement{}
+	req := d.sess.SQL().Select(db.Raw("measurements.*")).From("measurements").
+		Where("measurement_is_done = true").
+		And("measurement_is_uploaded = false").
+		And("measurement_is_failed = false").
+		And("measurement_file_path IS NOT NULL").
+		OrderBy("report_id", "measurement_start_time")
+	if err := req.All(&measurements); err != nil {
+		log.Errorf("failed to run query %s: %v", req.String(), err)
+		return measurements, err
+	}
+	return measurements, nil
+}
+
+// GetResult implements ReadableDatabase.GetResult
+func (d *Database) GetResult(resultID int64) (*model.DatabaseResult, error) {
+	var result model.DatabaseResult
+	if err := d.sess.Collection("results").Find("result_id", resultID).One(&result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
 var _ model.ReadableDatabase = &Database{}
 
 // Close implements Writable/ReadableDatabase.Close
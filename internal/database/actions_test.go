@@ -98,7 +98,7 @@ func TestMeasurementWorkflow(t *testing.T) {
 		networkName: "Unknown",
 	}
 	sess := database.Session()
-	network, err := database.CreateNetwork(&location)
+	network, err := database.CreateNetwork(&location, model.DatabaseNetworkInfo{})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -206,7 +206,7 @@ func TestDeleteResult(t *testing.T) {
 		networkName: "Unknown",
 	}
 	sess := database.Session()
-	network, err := database.CreateNetwork(&location)
+	network, err := database.CreateNetwork(&location, model.DatabaseNetworkInfo{})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -286,18 +286,80 @@ func TestNetworkCreate(t *testing.T) {
 		networkName: "Fufnet",
 	}
 
-	_, err = database.CreateNetwork(&l1)
+	_, err = database.CreateNetwork(&l1, model.DatabaseNetworkInfo{})
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	_, err = database.CreateNetwork(&l2)
+	_, err = database.CreateNetwork(&l2, model.DatabaseNetworkInfo{})
 	if err != nil {
 		t.Fatal(err)
 	}
 
 }
 
+func TestNetworkCreateWithInfo(t *testing.T) {
+	tmpfile, err := ioutil.TempFile("", "dbtest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(tmpfile.Name())
+
+	database, err := Open(tmpfile.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	loc := locationInfo{
+		asn:         2,
+		countryCode: "IT",
+		networkName: "Antaninet",
+	}
+
+	t.Run("with explicit label and type", func(t *testing.T) {
+		network, err := database.CreateNetwork(&loc, model.DatabaseNetworkInfo{
+			Label: "home",
+			Type:  "wired",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if network.NetworkLabel != "home" || network.NetworkType != "wired" {
+			t.Fatal("unexpected network", network)
+		}
+		result, err := database.CreateResult(t.TempDir(), "websites", network.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		reportID := sql.NullString{String: "", Valid: false}
+		urlID := sql.NullInt64{Int64: 0, Valid: false}
+		_, err = database.CreateMeasurement(reportID, "web_connectivity", "", 0, result.ID, urlID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := database.Finished(result); err != nil {
+			t.Fatal(err)
+		}
+		done, _, err := database.ListResults()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(done) != 1 || done[0].NetworkLabel != "home" || done[0].NetworkType != "wired" {
+			t.Fatal("unexpected results", done)
+		}
+	})
+
+	t.Run("with default type", func(t *testing.T) {
+		network, err := database.CreateNetwork(&loc, model.DatabaseNetworkInfo{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if network.NetworkLabel != "" || network.NetworkType != model.DatabaseNetworkTypeDefault {
+			t.Fatal("unexpected network", network)
+		}
+	})
+}
+
 func TestURLCreation(t *testing.T) {
 	tmpfile, err := ioutil.TempFile("", "dbtest")
 	if err != nil {
@@ -398,7 +460,7 @@ func TestGetMeasurementJSON(t *testing.T) {
 		countryCode: "IT",
 		networkName: "Unknown",
 	}
-	network, err := database.CreateNetwork(&location)
+	network, err := database.CreateNetwork(&location, model.DatabaseNetworkInfo{})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -531,3 +593,95 @@ func TestUpdateDatabaseMeasurementWithSummaryKeys(t *testing.T) {
 		}
 	})
 }
+
+func TestListPendingUploads(t *testing.T) {
+	tmpfile, err := ioutil.TempFile("", "dbtest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(tmpfile.Name())
+
+	tmpdir, err := ioutil.TempDir("", "oonitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpdir)
+
+	database, err := Open(tmpfile.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	location := locationInfo{
+		asn:         0,
+		countryCode: "IT",
+		networkName: "Unknown",
+	}
+	network, err := database.CreateNetwork(&location, model.DatabaseNetworkInfo{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := database.CreateResult(tmpdir, "websites", network.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reportID := sql.NullString{String: "", Valid: false}
+	urlID := sql.NullInt64{Int64: 0, Valid: false}
+
+	// m1 is not done yet, so it should not be pending upload
+	m1, err := database.CreateMeasurement(reportID, "antani", tmpdir, 0, result.ID, urlID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// m2 failed to upload, so it should be pending upload
+	m2, err := database.CreateMeasurement(reportID, "antani", tmpdir, 1, result.ID, urlID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := database.Done(m2); err != nil {
+		t.Fatal(err)
+	}
+	if err := database.UploadFailed(m2, "generic_timeout_error"); err != nil {
+		t.Fatal(err)
+	}
+
+	// m3 has been uploaded, so it should not be pending upload
+	m3, err := database.CreateMeasurement(reportID, "antani", tmpdir, 2, result.ID, urlID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := database.Done(m3); err != nil {
+		t.Fatal(err)
+	}
+	if err := database.UploadSucceeded(m3); err != nil {
+		t.Fatal(err)
+	}
+
+	pending, err := database.ListPendingUploads()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 1 {
+		t.Fatal("expected a single measurement pending upload, got", len(pending))
+	}
+	if pending[0].ID != m2.ID {
+		t.Fatal("unexpected pending measurement", pending[0].ID, m1.ID)
+	}
+	if !pending[0].IsUploadFailed || pending[0].UploadFailureMsg.String != "generic_timeout_error" {
+		t.Fatal("expected the measurement to be marked as upload failed")
+	}
+
+	got, err := database.GetResult(result.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.ID != result.ID || got.MeasurementDir != result.MeasurementDir {
+		t.Fatal("unexpected result", got)
+	}
+	if _, err := database.GetResult(result.ID + 1); err != db.ErrNoMoreRows {
+		t.Fatal("unexpected error", err)
+	}
+}
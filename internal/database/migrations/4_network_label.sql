@@ -0,0 +1,18 @@
+-- +migrate Down
+-- +migrate StatementBegin
+
+ALTER TABLE `networks`
+DROP COLUMN network_label;
+
+-- +migrate StatementEnd
+
+-- +migrate Up
+-- +migrate StatementBegin
+
+-- The network_label is a user-defined or heuristically-determined label
+-- allowing users to distinguish measurements taken from different uplinks
+-- (e.g., two distinct interfaces) having the same ASN.
+ALTER TABLE `networks`
+ADD COLUMN network_label VARCHAR(255) DEFAULT '' NOT NULL;
+
+-- +migrate StatementEnd
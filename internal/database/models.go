@@ -50,6 +50,7 @@ func (d *Database) Done(msmt *model.DatabaseMeasurement) error {
 func (d *Database) UploadFailed(msmt *model.DatabaseMeasurement, failure string) error {
 	msmt.UploadFailureMsg = sql.NullString{String: failure, Valid: true}
 	msmt.IsUploaded = false
+	msmt.IsUploadFailed = true
 	err := d.sess.Collection("measurements").Find("measurement_id", msmt.ID).Update(msmt)
 	if err != nil {
 		return errors.Wrap(err, "updating measurement")
@@ -60,6 +61,7 @@ func (d *Database) UploadFailed(msmt *model.DatabaseMeasurement, failure string)
 // UploadSucceeded implements WritableDatabase.UploadSucceeded
 func (d *Database) UploadSucceeded(msmt *model.DatabaseMeasurement) error {
 	msmt.IsUploaded = true
+	msmt.IsUploadFailed = false
 	err := d.sess.Collection("measurements").Find("measurement_id", msmt.ID).Update(msmt)
 	if err != nil {
 		return errors.Wrap(err, "updating measurement")
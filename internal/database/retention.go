@@ -0,0 +1,138 @@
+package database
+
+import (
+	"database/sql"
+	"os"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/ooni/probe-cli/v3/internal/model"
+	"github.com/pkg/errors"
+	"github.com/upper/db/v4"
+)
+
+// RetentionPolicy describes which results we should keep when pruning
+// the database. The zero value does not prune anything.
+type RetentionPolicy struct {
+	// MaxAge is the maximum age of a result. Results older than
+	// this value are pruned. Zero means no age limit.
+	MaxAge time.Duration
+
+	// MaxResults is the maximum number of results to keep. When there
+	// are more results, we prune the oldest ones. Zero means no limit.
+	MaxResults int64
+
+	// KeepOnlyNotUploaded indicates that we should prune results whose
+	// measurements have all been uploaded to the OONI collector.
+	KeepOnlyNotUploaded bool
+}
+
+// IsEmpty returns whether this policy would not prune any result.
+func (p *RetentionPolicy) IsEmpty() bool {
+	return p.MaxAge <= 0 && p.MaxResults <= 0 && !p.KeepOnlyNotUploaded
+}
+
+// shouldPrune returns whether to prune the result, which is the idx-th
+// result when sorting results from the newest to the oldest.
+func (p *RetentionPolicy) shouldPrune(result *model.DatabaseResult, idx int, now time.Time) bool {
+	if p.MaxResults > 0 && int64(idx) >= p.MaxResults {
+		return true
+	}
+	if p.MaxAge > 0 && now.Sub(result.StartTime) > p.MaxAge {
+		return true
+	}
+	return p.KeepOnlyNotUploaded && result.IsUploaded
+}
+
+// retentionStagingSuffix is the suffix we append to measurement directories
+// while we are deleting the corresponding results.
+const retentionStagingSuffix = ".pruning"
+
+// PruneResults deletes the results that do not satisfy the given policy along
+// with their measurements and their measurement directory. We only consider
+// results that are done, so we never prune a result that is still running.
+//
+// We first move each measurement directory aside, then we delete the results
+// inside a single transaction. If the transaction fails, we move back the
+// directories, otherwise we remove them. This way, we either delete both
+// the rows and the files or we leave the database and the disk untouched.
+//
+// The now argument is the current time, used to compute the age of results.
+//
+// Returns the number of pruned results or an error.
+func (d *Database) PruneResults(policy RetentionPolicy, now time.Time) (int, error) {
+	if policy.IsEmpty() {
+		return 0, nil
+	}
+	results := []model.DatabaseResult{}
+	req := d.sess.Collection("results").Find("result_is_done", true).OrderBy("-result_start_time")
+	if err := req.All(&results); err != nil {
+		log.WithError(err).Error("failed to list results")
+		return 0, errors.Wrap(err, "listing results")
+	}
+	var victims []model.DatabaseResult
+	for idx, result := range results {
+		if policy.shouldPrune(&result, idx, now) {
+			victims = append(victims, result)
+		}
+	}
+	if len(victims) <= 0 {
+		return 0, nil
+	}
+
+	var staged []model.DatabaseResult
+	for _, result := range victims {
+		if err := os.Rename(result.MeasurementDir, result.MeasurementDir+retentionStagingSuffix); err != nil {
+			if !os.IsNotExist(err) {
+				unstageMeasurementDirs(staged)
+				log.WithError(err).Errorf("failed to stage %s for deletion", result.MeasurementDir)
+				return 0, err
+			}
+			continue
+		}
+		staged = append(staged, result)
+	}
+
+	err := d.sess.Tx(func(tx db.Session) error {
+		for _, result := range victims {
+			if err := tx.Collection("results").Find("result_id", result.ID).Delete(); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		unstageMeasurementDirs(staged)
+		log.WithError(err).Error("failed to delete results")
+		return 0, errors.Wrap(err, "deleting results")
+	}
+
+	for _, result := range staged {
+		if err := os.RemoveAll(result.MeasurementDir + retentionStagingSuffix); err != nil {
+			log.WithError(err).Warnf("failed to remove %s", result.MeasurementDir)
+		}
+	}
+	return len(victims), nil
+}
+
+// unstageMeasurementDirs moves back the measurement directories
+// previously moved aside by PruneResults.
+func unstageMeasurementDirs(staged []model.DatabaseResult) {
+	for _, result := range staged {
+		if err := os.Rename(result.MeasurementDir+retentionStagingSuffix, result.MeasurementDir); err != nil {
+			log.WithError(err).Warnf("failed to restore %s", result.MeasurementDir)
+		}
+	}
+}
+
+// Vacuum rebuilds the database file to reclaim the space
+// left unused after deleting results.
+func (d *Database) Vacuum() error {
+	// Note: we use the underlying driver because VACUUM cannot run inside
+	// the transaction that upper/db would otherwise create for us.
+	if _, err := d.sess.Driver().(*sql.DB).Exec("VACUUM"); err != nil {
+		log.WithError(err).Error("failed to vacuum the database")
+		return errors.Wrap(err, "vacuuming database")
+	}
+	return nil
+}
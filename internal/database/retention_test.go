@@ -0,0 +1,174 @@
+package database
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ooni/probe-cli/v3/internal/model"
+)
+
+func TestRetentionPolicyIsEmpty(t *testing.T) {
+	if !(&RetentionPolicy{}).IsEmpty() {
+		t.Fatal("the zero value should be empty")
+	}
+	if (&RetentionPolicy{MaxResults: 1}).IsEmpty() {
+		t.Fatal("a policy with MaxResults should not be empty")
+	}
+	if (&RetentionPolicy{MaxAge: time.Hour}).IsEmpty() {
+		t.Fatal("a policy with MaxAge should not be empty")
+	}
+	if (&RetentionPolicy{KeepOnlyNotUploaded: true}).IsEmpty() {
+		t.Fatal("a policy with KeepOnlyNotUploaded should not be empty")
+	}
+}
+
+// newRetentionTestDatabase creates a database containing three done results, from
+// the oldest to the newest, and one incomplete result.
+func newRetentionTestDatabase(t *testing.T) (*Database, []int64, time.Time) {
+	tmpdir := t.TempDir()
+	database, err := Open(filepath.Join(tmpdir, "main.sqlite3"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { database.Close() })
+
+	network, err := database.CreateNetwork(&locationInfo{countryCode: "IT", networkName: "Unknown"}, model.DatabaseNetworkInfo{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now().UTC()
+	var ids []int64
+	for idx := 0; idx < 4; idx++ {
+		result, err := database.CreateResult(tmpdir, "websites", network.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := database.CreateMeasurement(sql.NullString{}, "antani", result.MeasurementDir,
+			0, result.ID, sql.NullInt64{}); err != nil {
+			t.Fatal(err)
+		}
+		result.StartTime = now.Add(-time.Duration(4-idx) * 24 * time.Hour)
+		result.IsUploaded = idx%2 == 0
+		result.IsDone = idx < 3
+		if err := database.Session().Collection("results").Find("result_id", result.ID).Update(result); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, result.ID)
+	}
+	return database, ids, now
+}
+
+func remainingResultIDs(t *testing.T, database *Database) map[int64]bool {
+	var results []struct {
+		ID             int64  `db:"result_id"`
+		MeasurementDir string `db:"measurement_dir"`
+	}
+	if err := database.Session().Collection("results").Find().All(&results); err != nil {
+		t.Fatal(err)
+	}
+	out := map[int64]bool{}
+	for _, result := range results {
+		if _, err := os.Stat(result.MeasurementDir); err != nil {
+			t.Fatal("the measurement dir of a remaining result is missing", err)
+		}
+		out[result.ID] = true
+	}
+	return out
+}
+
+func TestPruneResults(t *testing.T) {
+	type testcase struct {
+		name      string
+		policy    RetentionPolicy
+		expectNum int
+		expectIdx []int
+	}
+
+	cases := []testcase{{
+		name:      "with empty policy",
+		policy:    RetentionPolicy{},
+		expectNum: 0,
+		expectIdx: []int{0, 1, 2, 3},
+	}, {
+		name:      "with MaxResults",
+		policy:    RetentionPolicy{MaxResults: 1},
+		expectNum: 2,
+		expectIdx: []int{2, 3},
+	}, {
+		name:      "with MaxAge",
+		policy:    RetentionPolicy{MaxAge: 60 * time.Hour},
+		expectNum: 2,
+		expectIdx: []int{2, 3},
+	}, {
+		name:      "with KeepOnlyNotUploaded",
+		policy:    RetentionPolicy{KeepOnlyNotUploaded: true},
+		expectNum: 2,
+		expectIdx: []int{1, 3},
+	}, {
+		name:      "with everything combined",
+		policy:    RetentionPolicy{MaxResults: 2, MaxAge: 80 * time.Hour, KeepOnlyNotUploaded: true},
+		expectNum: 2,
+		expectIdx: []int{1, 3},
+	}}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			database, ids, now := newRetentionTestDatabase(t)
+			count, err := database.PruneResults(tc.policy, now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if count != tc.expectNum {
+				t.Fatal("expected", tc.expectNum, "got", count)
+			}
+			remaining := remainingResultIDs(t, database)
+			if len(remaining) != len(tc.expectIdx) {
+				t.Fatal("expected", len(tc.expectIdx), "results, got", len(remaining))
+			}
+			for _, idx := range tc.expectIdx {
+				if !remaining[ids[idx]] {
+					t.Fatal("expected result", ids[idx], "to be still there")
+				}
+			}
+			totalMeasurements, err := database.Session().Collection("measurements").Find().Count()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if int(totalMeasurements) != len(tc.expectIdx) {
+				t.Fatal("unexpected number of measurements", totalMeasurements)
+			}
+			if err := database.Vacuum(); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+
+	t.Run("we remove the measurement directories", func(t *testing.T) {
+		database, ids, now := newRetentionTestDatabase(t)
+		var dirs []string
+		for _, id := range ids[:2] {
+			var dir struct {
+				MeasurementDir string `db:"measurement_dir"`
+			}
+			if err := database.Session().Collection("results").Find("result_id", id).One(&dir); err != nil {
+				t.Fatal(err)
+			}
+			dirs = append(dirs, dir.MeasurementDir)
+		}
+		if _, err := database.PruneResults(RetentionPolicy{MaxResults: 1}, now); err != nil {
+			t.Fatal(err)
+		}
+		for _, dir := range dirs {
+			if _, err := os.Stat(dir); !os.IsNotExist(err) {
+				t.Fatal("expected the directory to be removed", dir)
+			}
+			if _, err := os.Stat(dir + retentionStagingSuffix); !os.IsNotExist(err) {
+				t.Fatal("expected the staging directory to be removed", dir)
+			}
+		}
+	})
+}
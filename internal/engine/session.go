@@ -15,6 +15,7 @@ import (
 	"github.com/ooni/probe-cli/v3/internal/engineresolver"
 	"github.com/ooni/probe-cli/v3/internal/kvstore"
 	"github.com/ooni/probe-cli/v3/internal/model"
+	"github.com/ooni/probe-cli/v3/internal/netxlite"
 	"github.com/ooni/probe-cli/v3/internal/platform"
 	"github.com/ooni/probe-cli/v3/internal/probeservices"
 	"github.com/ooni/probe-cli/v3/internal/runtimex"
@@ -27,7 +28,7 @@ type SessionConfig struct {
 	AvailableProbeServices []model.OOAPIService
 	KVStore                model.KeyValueStore
 	Logger                 model.Logger
-	ProxyURL               *url.URL
+	ProxyURL               *url.URL // socks5, http(s), or tunnel (e.g., psiphon:///)
 	SoftwareName           string
 	SoftwareVersion        string
 	TempDir                string
@@ -201,6 +202,10 @@ func NewSession(ctx context.Context, config SessionConfig) (*Session, error) {
 			proxyURL = tunnel.SOCKS5ProxyURL()
 		case "none":
 			proxyURL = nil // explicit way of saying we don't want to use a tunnel
+		case "socks5", "http", "https":
+			// nothing to do: netxlite knows how to use these proxies
+		default:
+			return nil, fmt.Errorf("%w: %s", netxlite.ErrProxyUnsupportedScheme, proxyURL.Scheme)
 		}
 	}
 	sess.proxyURL = proxyURL
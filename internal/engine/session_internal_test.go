@@ -16,6 +16,7 @@ import (
 	"github.com/ooni/probe-cli/v3/internal/experiment/webconnectivitylte"
 	"github.com/ooni/probe-cli/v3/internal/kvstore"
 	"github.com/ooni/probe-cli/v3/internal/model"
+	"github.com/ooni/probe-cli/v3/internal/netxlite"
 	"github.com/ooni/probe-cli/v3/internal/registry"
 )
 
@@ -321,6 +322,41 @@ func TestNewSessionWithFakeTunnelAndCancelledContext(t *testing.T) {
 	}
 }
 
+func TestNewSessionWithProxyURL(t *testing.T) {
+	t.Run("we accept HTTP proxies", func(t *testing.T) {
+		sess, err := NewSession(context.Background(), SessionConfig{
+			Logger:          log.Log,
+			ProxyURL:        &url.URL{Scheme: "https", Host: "proxy.local:4443", User: url.UserPassword("u", "p")},
+			SoftwareName:    "miniooni",
+			SoftwareVersion: "0.1.0-dev",
+			TunnelDir:       "testdata",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer sess.Close()
+		if URL := sess.ProxyURL(); URL == nil || URL.Host != "proxy.local:4443" {
+			t.Fatal("unexpected proxy URL", URL)
+		}
+	})
+
+	t.Run("we reject unsupported schemes", func(t *testing.T) {
+		sess, err := NewSession(context.Background(), SessionConfig{
+			Logger:          log.Log,
+			ProxyURL:        &url.URL{Scheme: "antani", Host: "proxy.local:4443"},
+			SoftwareName:    "miniooni",
+			SoftwareVersion: "0.1.0-dev",
+			TunnelDir:       "testdata",
+		})
+		if !errors.Is(err, netxlite.ErrProxyUnsupportedScheme) {
+			t.Fatal("not the error we expected", err)
+		}
+		if sess != nil {
+			t.Fatal("expected nil session here")
+		}
+	})
+}
+
 func TestSessionNewExperimentBuilder(t *testing.T) {
 	t.Run("for a normal experiment", func(t *testing.T) {
 		sess := &Session{
@@ -18,7 +18,7 @@
 // is failing us. (We will still occasionally probe for other working
 // resolvers and increase their score on success.)
 //
-// We also support a socks5 proxy. When such a proxy is configured,
+// We also support socks5, http, and https proxies. When a proxy is configured,
 // the code WILL skip http3 resolvers AS WELL AS the system
 // resolver, in an attempt to avoid leaking your queries.
 package engineresolver
@@ -47,7 +47,7 @@ type Resolver struct {
 	// to emit log messages.
 	Logger model.Logger
 
-	// ProxyURL is the OPTIONAL URL of the socks5 proxy
+	// ProxyURL is the OPTIONAL URL of the socks5 or http(s) proxy
 	// we should be using. If not set, then we WON'T use
 	// any proxy. If set, then we WON'T use any http3
 	// based resolvers and we WON'T use the system resolver.
@@ -93,6 +93,11 @@ func (r *Resolver) LookupNS(ctx context.Context, domain string) ([]*net.NS, erro
 	return nil, errLookupNotImplemented
 }
 
+// LookupRaw implements Resolver.LookupRaw.
+func (r *Resolver) LookupRaw(ctx context.Context, domain string, qtype uint16) ([]*model.DNSRecord, error) {
+	return nil, errLookupNotImplemented
+}
+
 // ErrLookupHost indicates that LookupHost failed.
 var ErrLookupHost = errors.New("sessionresolver: LookupHost failed")
 
@@ -12,6 +12,7 @@ import (
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/miekg/dns"
 	"github.com/ooni/probe-cli/v3/internal/bytecounter"
 	"github.com/ooni/probe-cli/v3/internal/kvstore"
 	"github.com/ooni/probe-cli/v3/internal/legacy/multierror"
@@ -399,6 +400,17 @@ func TestUnimplementedFunctions(t *testing.T) {
 			t.Fatal("expected empty result")
 		}
 	})
+
+	t.Run("LookupRaw", func(t *testing.T) {
+		r := &Resolver{}
+		records, err := r.LookupRaw(context.Background(), "dns.google", dns.TypeTXT)
+		if !errors.Is(err, errLookupNotImplemented) {
+			t.Fatal("unexpected error", err)
+		}
+		if len(records) > 0 {
+			t.Fatal("expected empty result")
+		}
+	})
 }
 
 func TestResolverWorkingAsIntendedWithMocks(t *testing.T) {
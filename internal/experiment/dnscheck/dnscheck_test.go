@@ -10,6 +10,7 @@ import (
 	"github.com/apex/log"
 	"github.com/ooni/probe-cli/v3/internal/legacy/mockable"
 	"github.com/ooni/probe-cli/v3/internal/model"
+	"github.com/ooni/probe-cli/v3/internal/netemx"
 )
 
 func TestHTTPHostWithOverride(t *testing.T) {
@@ -49,7 +50,7 @@ func TestExperimentNameAndVersion(t *testing.T) {
 	if measurer.ExperimentName() != "dnscheck" {
 		t.Error("unexpected experiment name")
 	}
-	if measurer.ExperimentVersion() != "0.9.2" {
+	if measurer.ExperimentVersion() != "0.9.4" {
 		t.Error("unexpected experiment version")
 	}
 }
@@ -135,6 +136,75 @@ func TestMakeResolverURL(t *testing.T) {
 	}
 }
 
+func TestMakeResolverURLKeepsQuery(t *testing.T) {
+	URL, err := url.Parse("odoh://odoh-proxy.example.org/proxy?targethost=odoh.example.com&targetpath=/dns-query")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resolver := makeResolverURL(URL, "45.76.113.31")
+	expected := "odoh://45.76.113.31/proxy?targethost=odoh.example.com&targetpath=/dns-query"
+	if resolver != expected {
+		t.Fatal("expected", expected, "got", resolver)
+	}
+}
+
+func TestDNSCheckWithODoH(t *testing.T) {
+	env := netemx.MustNewQAEnv(
+		netemx.QAEnvOptionNetStack(netemx.AddressODoHProxy, &netemx.HTTPSecureServerFactory{
+			Factory:          &netemx.ObliviousDoHProxyHandlerFactory{},
+			Ports:            []int{443},
+			ServerNameMain:   "odoh-proxy.example.org",
+			ServerNameExtras: []string{},
+		}),
+		netemx.QAEnvOptionNetStack(netemx.AddressODoHCloudflareDNSCom, &netemx.HTTPSecureServerFactory{
+			Factory:          &netemx.ObliviousDoHTargetHandlerFactory{},
+			Ports:            []int{443},
+			ServerNameMain:   "odoh.cloudflare-dns.com",
+			ServerNameExtras: []string{},
+		}),
+	)
+	defer env.Close()
+
+	env.AddRecordToAllResolvers("odoh-proxy.example.org", "", netemx.AddressODoHProxy)
+	env.AddRecordToAllResolvers("odoh.cloudflare-dns.com", "", netemx.AddressODoHCloudflareDNSCom)
+	env.AddRecordToAllResolvers(defaultDomain, "", netemx.AddressWwwExampleCom)
+
+	env.Do(func() {
+		measurer := NewExperimentMeasurer(Config{})
+		const input = "odoh://odoh-proxy.example.org/proxy?targethost=odoh.cloudflare-dns.com&targetpath=/dns-query"
+		measurement := &model.Measurement{Input: input}
+		args := &model.ExperimentArgs{
+			Callbacks:   model.NewPrinterCallbacks(log.Log),
+			Measurement: measurement,
+			Session:     newsession(),
+		}
+		if err := measurer.Run(context.Background(), args); err != nil {
+			t.Fatal(err)
+		}
+		tk := measurement.TestKeys.(*TestKeys)
+		if tk.BootstrapFailure != nil {
+			t.Fatal("unexpected bootstrap failure", *tk.BootstrapFailure)
+		}
+		const resolverURL = "odoh://45.76.113.31/proxy?targethost=odoh.cloudflare-dns.com&targetpath=/dns-query"
+		lookup, good := tk.Lookups[resolverURL]
+		if !good {
+			t.Fatal("missing lookup for", resolverURL)
+		}
+		if lookup.Failure != nil {
+			t.Fatal("unexpected failure", *lookup.Failure)
+		}
+		var found bool
+		for _, query := range lookup.Queries {
+			// note: there is also the query to resolve the target's domain
+			found = found || (query.Engine == "odoh" && len(query.Answers) > 0 &&
+				query.Answers[0].IPv4 == netemx.AddressWwwExampleCom)
+		}
+		if !found {
+			t.Fatal("missing the expected odoh query", lookup.Queries)
+		}
+	})
+}
+
 func TestDNSCheckValid(t *testing.T) {
 	if testing.Short() {
 		t.Skip("skip test in short mode")
@@ -20,7 +20,7 @@ import (
 
 const (
 	testName    = "dnsping"
-	testVersion = "0.4.0"
+	testVersion = "0.6.0"
 )
 
 // Config contains the experiment configuration.
@@ -28,6 +28,11 @@ type Config struct {
 	// Delay is the delay between each repetition (in milliseconds).
 	Delay int64 `ooni:"number of milliseconds to wait before sending each ping"`
 
+	// DelayedResponsesWindow is the number of milliseconds during which we collect
+	// delayed DNS-over-UDP responses after the first one. When zero, we use the default
+	// window. When negative, we do not collect any delayed response.
+	DelayedResponsesWindow int64 `ooni:"number of milliseconds to wait for delayed responses (negative to disable)"`
+
 	// Domains is the space-separated list of domains to measure.
 	Domains string `ooni:"space-separated list of domains to measure"`
 
@@ -42,6 +47,17 @@ func (c *Config) delay() time.Duration {
 	return time.Second
 }
 
+func (c *Config) delayedResponsesWindow() time.Duration {
+	switch {
+	case c.DelayedResponsesWindow > 0:
+		return time.Duration(c.DelayedResponsesWindow) * time.Millisecond
+	case c.DelayedResponsesWindow < 0:
+		return -1
+	default:
+		return 250 * time.Millisecond
+	}
+}
+
 func (c Config) repetitions() int64 {
 	if c.Repetitions > 0 {
 		return c.Repetitions
@@ -79,7 +95,7 @@ var (
 	errInputIsNotAnURL = errors.New("input is not an URL")
 
 	// errInvalidScheme indicates that the scheme is invalid
-	errInvalidScheme = errors.New("scheme must be udp")
+	errInvalidScheme = errors.New("scheme must be udp or quic")
 
 	// errMissingPort indicates that there is no port.
 	errMissingPort = errors.New("the URL must include a port")
@@ -100,7 +116,10 @@ func (m *Measurer) Run(ctx context.Context, args *model.ExperimentArgs) error {
 	if err != nil {
 		return fmt.Errorf("%w: %s", errInputIsNotAnURL, err.Error())
 	}
-	if parsed.Scheme != "udp" {
+	switch parsed.Scheme {
+	case "udp", "quic":
+		// all good
+	default:
 		return errInvalidScheme
 	}
 	if parsed.Port() == "" {
@@ -118,7 +137,7 @@ func (m *Measurer) Run(ctx context.Context, args *model.ExperimentArgs) error {
 	wg := new(sync.WaitGroup)
 	wg.Add(len(domains))
 	for _, domain := range domains {
-		go m.dnsPingLoop(ctx, measurement.MeasurementStartTimeSaved, sess.Logger(), parsed.Host, domain, wg, tk)
+		go m.dnsPingLoop(ctx, measurement.MeasurementStartTimeSaved, sess.Logger(), parsed, domain, wg, tk)
 	}
 
 	// block until all pingers are done
@@ -132,7 +151,7 @@ func (m *Measurer) Run(ctx context.Context, args *model.ExperimentArgs) error {
 
 // dnsPingLoop sends all the ping requests and emits the results onto the out channel.
 func (m *Measurer) dnsPingLoop(ctx context.Context, zeroTime time.Time, logger model.Logger,
-	address string, domain string, wg *sync.WaitGroup, tk *TestKeys) {
+	resolverURL *url.URL, domain string, wg *sync.WaitGroup, tk *TestKeys) {
 	// make sure the parent knows when we're done
 	defer wg.Done()
 
@@ -143,7 +162,7 @@ func (m *Measurer) dnsPingLoop(ctx context.Context, zeroTime time.Time, logger m
 	// start a goroutine for each ping repetition
 	for i := int64(0); i < m.config.repetitions(); i++ {
 		wg.Add(1)
-		go m.dnsRoundTrip(ctx, i, zeroTime, logger, address, domain, wg, tk)
+		go m.dnsRoundTrip(ctx, i, zeroTime, logger, resolverURL, domain, wg, tk)
 
 		// make sure we wait until it's time to send the next ping
 		<-ticker.C
@@ -152,7 +171,7 @@ func (m *Measurer) dnsPingLoop(ctx context.Context, zeroTime time.Time, logger m
 
 // dnsRoundTrip performs a round trip and returns the results to the caller.
 func (m *Measurer) dnsRoundTrip(ctx context.Context, index int64, zeroTime time.Time,
-	logger model.Logger, address string, domain string, wg *sync.WaitGroup, tk *TestKeys) {
+	logger model.Logger, resolverURL *url.URL, domain string, wg *sync.WaitGroup, tk *TestKeys) {
 	// create context bound to timeout
 	// TODO(bassosimone): make the timeout user-configurable
 	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
@@ -170,8 +189,17 @@ func (m *Measurer) dnsRoundTrip(ctx context.Context, index int64, zeroTime time.
 	// domain name in terms of saving its results? Shall we save also the system resolver's lookups?
 	// Shall we, otherwise, pre-resolve the domain name to IP addresses once and for all? In such
 	// a case, shall we use all the available IP addresses or just some of them?
-	dialer := netxlite.NewDialerWithStdlibResolver(logger)
-	resolver := trace.NewParallelUDPResolver(logger, dialer, address)
+	address := resolverURL.Host
+	window := m.config.delayedResponsesWindow()
+	var resolver model.Resolver
+	switch resolverURL.Scheme {
+	case "quic":
+		resolver = trace.NewParallelDNSOverQUICResolver(logger, address)
+	default:
+		dialer := netxlite.NewDialerWithStdlibResolver(logger)
+		resolver = trace.NewParallelUDPResolverWithOptions(logger, dialer, address,
+			netxlite.DNSOverUDPTransportOptionDelayedResponsesWindow(window))
+	}
 
 	// perform the lookup proper
 	ol := logx.NewOperationLogger(logger, "DNSPing #%d %s %s", index, address, domain)
@@ -179,7 +207,7 @@ func (m *Measurer) dnsRoundTrip(ctx context.Context, index int64, zeroTime time.
 	stopOperationLogger(ol, addrs, err)
 
 	// wait a bit for delayed responses
-	delayedResps := trace.DelayedDNSResponseWithTimeout(ctx, 250*time.Millisecond)
+	delayedResps := trace.DelayedDNSResponseWithTimeout(ctx, max(window, 0))
 
 	// assemble the results by inspecting ordinary and late responses
 	pings := []*SinglePing{}
@@ -37,21 +37,40 @@ func TestConfig_delay(t *testing.T) {
 	}
 }
 
+func TestConfig_delayedResponsesWindow(t *testing.T) {
+	t.Run("by default", func(t *testing.T) {
+		c := Config{}
+		if c.delayedResponsesWindow() != 250*time.Millisecond {
+			t.Fatal("invalid default delayed responses window")
+		}
+	})
+
+	t.Run("with a positive value", func(t *testing.T) {
+		c := Config{DelayedResponsesWindow: 1000}
+		if c.delayedResponsesWindow() != time.Second {
+			t.Fatal("invalid delayed responses window")
+		}
+	})
+
+	t.Run("with a negative value", func(t *testing.T) {
+		c := Config{DelayedResponsesWindow: -1}
+		if c.delayedResponsesWindow() >= 0 {
+			t.Fatal("expected a negative delayed responses window")
+		}
+	})
+}
+
 func TestMeasurer_run(t *testing.T) {
 	// expectedPings is the expected number of pings
 	const expectedPings = 4
 
-	// runHelper is an helper function to run this set of tests.
-	runHelper := func(input string) (*model.Measurement, model.ExperimentMeasurer, error) {
-		m := NewExperimentMeasurer(Config{
-			Domains:     "example.com",
-			Delay:       1, // millisecond
-			Repetitions: expectedPings,
-		})
+	// runHelperWithConfig is an helper function to run this set of tests.
+	runHelperWithConfig := func(input string, config Config) (*model.Measurement, model.ExperimentMeasurer, error) {
+		m := NewExperimentMeasurer(config)
 		if m.ExperimentName() != "dnsping" {
 			t.Fatal("invalid experiment name")
 		}
-		if m.ExperimentVersion() != "0.4.0" {
+		if m.ExperimentVersion() != "0.6.0" {
 			t.Fatal("invalid experiment version")
 		}
 		ctx := context.Background()
@@ -71,6 +90,18 @@ func TestMeasurer_run(t *testing.T) {
 		return meas, m, err
 	}
 
+	// defaultConfig is the configuration used by most tests.
+	defaultConfig := Config{
+		Domains:     "example.com",
+		Delay:       1, // millisecond
+		Repetitions: expectedPings,
+	}
+
+	// runHelper runs the experiment using the default configuration.
+	runHelper := func(input string) (*model.Measurement, model.ExperimentMeasurer, error) {
+		return runHelperWithConfig(input, defaultConfig)
+	}
+
 	t.Run("with empty input", func(t *testing.T) {
 		_, _, err := runHelper("")
 		if !errors.Is(err, errNoInputProvided) {
@@ -137,6 +168,43 @@ func TestMeasurer_run(t *testing.T) {
 		})
 	})
 
+	t.Run("with netem: using DNS-over-QUIC: expect success", func(t *testing.T) {
+		// create a new test environment
+		env := netemx.MustNewQAEnv(netemx.QAEnvOptionNetStack("8.8.8.8", &netemx.DNSOverQUICServerFactory{}))
+		defer env.Close()
+
+		// we use the same configuration for all resolvers
+		env.AddRecordToAllResolvers(
+			"example.com",
+			"example.com", // CNAME
+			"93.184.216.34",
+		)
+
+		env.Do(func() {
+			meas, _, err := runHelper("quic://8.8.8.8:853")
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+
+			tk, _ := (meas.TestKeys).(*TestKeys)
+			if len(tk.Pings) != expectedPings*2 { // account for A & AAAA pings
+				t.Fatal("unexpected number of pings", len(tk.Pings))
+			}
+
+			for _, p := range tk.Pings {
+				if p.Query == nil {
+					t.Fatal("Query should not be nil")
+				}
+				if p.Query.Engine != "doq" {
+					t.Fatal("unexpected engine", p.Query.Engine)
+				}
+				if p.Query.QueryType == "A" && p.Query.Failure != nil {
+					t.Fatal("unexpected error", *p.Query.Failure)
+				}
+			}
+		})
+	})
+
 	t.Run("with netem: with DNS spoofing: expect to see delayed responses", func(t *testing.T) {
 		// create a new test environment
 		env := netemx.MustNewQAEnv(netemx.QAEnvOptionNetStack("8.8.8.8", &netemx.DNSOverUDPServerFactory{}))
@@ -212,6 +280,60 @@ func TestMeasurer_run(t *testing.T) {
 					t.Fatal("expected to see delayed responses, found nothing")
 				}
 			}
+
+			var count int64
+			for _, p := range tk.Pings {
+				count += int64(len(p.DelayedResponses))
+			}
+			if count <= 0 || tk.DelayedResponsesCount != count {
+				t.Fatal("unexpected DelayedResponsesCount", tk.DelayedResponsesCount, count)
+			}
+		})
+	})
+
+	t.Run("with netem: with DNS spoofing: disabling the delayed responses window", func(t *testing.T) {
+		// create a new test environment
+		env := netemx.MustNewQAEnv(netemx.QAEnvOptionNetStack("8.8.8.8", &netemx.DNSOverUDPServerFactory{}))
+		defer env.Close()
+
+		// we use the same configuration for all resolvers
+		env.AddRecordToAllResolvers(
+			"example.com",
+			"example.com", // CNAME
+			"93.184.216.34",
+		)
+
+		// use DPI to create DNS spoofing
+		dpi := env.DPIEngine()
+		dpi.AddRule(&netem.DPISpoofDNSResponse{
+			Addresses: []string{
+				"10.10.34.35",
+				"10.10.34.36",
+			},
+			Logger: model.DiscardLogger,
+			Domain: "example.com",
+		})
+
+		env.Do(func() {
+			config := defaultConfig
+			config.DelayedResponsesWindow = -1
+			meas, _, err := runHelperWithConfig("udp://8.8.8.8:53", config)
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+
+			tk, _ := (meas.TestKeys).(*TestKeys)
+			if len(tk.Pings) != expectedPings*2 { // account for A & AAAA pings
+				t.Fatal("unexpected number of pings", len(tk.Pings))
+			}
+			for _, p := range tk.Pings {
+				if len(p.DelayedResponses) != 0 {
+					t.Fatal("expected to see no delayed responses, found", len(p.DelayedResponses))
+				}
+			}
+			if tk.DelayedResponsesCount != 0 {
+				t.Fatal("unexpected DelayedResponsesCount", tk.DelayedResponsesCount)
+			}
 		})
 	})
 }
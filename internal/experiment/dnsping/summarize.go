@@ -15,6 +15,9 @@ func summarize(tk *TestKeys) {
 	as := &addressSummarizer{}
 	as.load(tk)
 	as.printf(os.Stdout)
+
+	// print the number of delayed responses, which hints at DNS injection
+	fmt.Fprintf(os.Stdout, "Delayed responses: %d\n\n", tk.DelayedResponsesCount)
 }
 
 // summarizeAddressStats contains stats about a resolved IP address
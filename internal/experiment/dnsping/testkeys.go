@@ -10,6 +10,10 @@ import (
 type TestKeys struct {
 	Pings []*SinglePing `json:"pings"`
 
+	// DelayedResponsesCount is the total number of delayed responses
+	// across all pings, which hints at DNS injection when nonzero.
+	DelayedResponsesCount int64 `json:"delayed_responses_count"`
+
 	// mu provides mutual exclusion
 	mu sync.Mutex
 }
@@ -23,8 +27,9 @@ type SinglePing struct {
 // NewTestKeys creates new dnsping TestKeys
 func NewTestKeys() *TestKeys {
 	return &TestKeys{
-		Pings: []*SinglePing{},
-		mu:    sync.Mutex{},
+		Pings:                 []*SinglePing{},
+		DelayedResponsesCount: 0,
+		mu:                    sync.Mutex{},
 	}
 }
 
@@ -32,5 +37,8 @@ func NewTestKeys() *TestKeys {
 func (tk *TestKeys) addPings(pings []*SinglePing) {
 	tk.mu.Lock()
 	tk.Pings = append(tk.Pings, pings...)
+	for _, ping := range pings {
+		tk.DelayedResponsesCount += int64(len(ping.DelayedResponses))
+	}
 	tk.mu.Unlock()
 }
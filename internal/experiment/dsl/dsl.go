@@ -0,0 +1,183 @@
+// Package dsl implements the experimental dsl experiment, which runs a measurement
+// described using the JSON DSL implemented by the [dsljson] package.
+//
+// The measurement input, if present, is the URL from which to fetch the JSON
+// document. Otherwise, the JSON document must be provided inline using the DSL
+// option. This allows OONI Run v2 descriptors to ship measurement logic.
+package dsl
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+
+	"github.com/ooni/probe-cli/v3/internal/httpclientx"
+	"github.com/ooni/probe-cli/v3/internal/model"
+	"github.com/ooni/probe-cli/v3/internal/x/dslengine"
+	"github.com/ooni/probe-cli/v3/internal/x/dsljson"
+)
+
+const (
+	testName    = "dsl"
+	testVersion = "0.1.0"
+)
+
+// Config contains the experiment configuration.
+type Config struct {
+	// DSL is the inline JSON DSL document to run.
+	DSL string `ooni:"inline JSON document describing the measurement to run"`
+
+	// MaxActiveConns is the maximum number of endpoints to measure in parallel.
+	MaxActiveConns int64 `ooni:"maximum number of endpoints to measure in parallel"`
+
+	// MaxActiveDNSLookups is the maximum number of DNS lookups to run in parallel.
+	MaxActiveDNSLookups int64 `ooni:"maximum number of DNS lookups to run in parallel"`
+}
+
+// TestKeys contains the experiment results.
+type TestKeys struct {
+	// DSLURL is the URL from which we fetched the DSL or empty if the DSL was inline.
+	DSLURL string `json:"dsl_url"`
+
+	// Failure is the failure that prevented us from running the DSL or nil.
+	Failure *string `json:"failure"`
+
+	// NetworkEvents contains I/O events.
+	NetworkEvents []*model.ArchivalNetworkEvent `json:"network_events"`
+
+	// Queries contains the DNS queries results.
+	Queries []*model.ArchivalDNSLookupResult `json:"queries"`
+
+	// Requests contains HTTP request results.
+	Requests []*model.ArchivalHTTPRequestResult `json:"requests"`
+
+	// TCPConnect contains the TCP connect results.
+	TCPConnect []*model.ArchivalTCPConnectResult `json:"tcp_connect"`
+
+	// TLSHandshakes contains the TLS handshakes results.
+	TLSHandshakes []*model.ArchivalTLSOrQUICHandshakeResult `json:"tls_handshakes"`
+
+	// QUICHandshakes contains the QUIC handshakes results.
+	QUICHandshakes []*model.ArchivalTLSOrQUICHandshakeResult `json:"quic_handshakes"`
+}
+
+// Measurer performs the measurement.
+type Measurer struct {
+	config Config
+}
+
+// NewExperimentMeasurer creates a new ExperimentMeasurer.
+func NewExperimentMeasurer(config Config) model.ExperimentMeasurer {
+	return &Measurer{config: config}
+}
+
+// ExperimentName implements ExperimentMeasurer.ExperimentName.
+func (m *Measurer) ExperimentName() string {
+	return testName
+}
+
+// ExperimentVersion implements ExperimentMeasurer.ExperimentVersion.
+func (m *Measurer) ExperimentVersion() string {
+	return testVersion
+}
+
+var (
+	// errNoDSLProvided indicates that we have neither an input nor an inline DSL.
+	errNoDSLProvided = errors.New("dsl: no input and no inline DSL provided")
+
+	// errBothInputAndDSL indicates that we have both an input and an inline DSL.
+	errBothInputAndDSL = errors.New("dsl: cannot use both input and inline DSL")
+
+	// errInputIsNotAnURL indicates that input is not an URL
+	errInputIsNotAnURL = errors.New("dsl: input is not an URL")
+
+	// errInvalidScheme indicates that the input scheme is invalid
+	errInvalidScheme = errors.New("dsl: input scheme must be https or http")
+)
+
+// Run implements ExperimentMeasurer.Run.
+func (m *Measurer) Run(ctx context.Context, args *model.ExperimentArgs) error {
+	logger := args.Session.Logger()
+	measurement := args.Measurement
+
+	// obtain the root node
+	root, err := m.loadRootNode(ctx, args)
+	if err != nil {
+		return err
+	}
+
+	// prepare the test keys
+	tk := &TestKeys{
+		DSLURL:         string(measurement.Input),
+		Failure:        nil,
+		NetworkEvents:  []*model.ArchivalNetworkEvent{},
+		Queries:        []*model.ArchivalDNSLookupResult{},
+		Requests:       []*model.ArchivalHTTPRequestResult{},
+		TCPConnect:     []*model.ArchivalTCPConnectResult{},
+		TLSHandshakes:  []*model.ArchivalTLSOrQUICHandshakeResult{},
+		QUICHandshakes: []*model.ArchivalTLSOrQUICHandshakeResult{},
+	}
+	measurement.TestKeys = tk
+
+	// create the runtime
+	rtx := dslengine.NewRuntimeMeasurexLite(
+		logger,
+		measurement.MeasurementStartTimeSaved,
+		dslengine.OptionMaxActiveConns(int(m.config.MaxActiveConns)),
+		dslengine.OptionMaxActiveDNSLookups(int(m.config.MaxActiveDNSLookups)),
+	)
+
+	// run the DSL and archive the observations
+	if err := dsljson.Run(ctx, rtx, root); err != nil {
+		failure := err.Error()
+		tk.Failure = &failure
+	}
+	observations := rtx.Observations()
+	tk.NetworkEvents = append(tk.NetworkEvents, observations.NetworkEvents...)
+	tk.Queries = append(tk.Queries, observations.Queries...)
+	tk.Requests = append(tk.Requests, observations.Requests...)
+	tk.TCPConnect = append(tk.TCPConnect, observations.TCPConnect...)
+	tk.TLSHandshakes = append(tk.TLSHandshakes, observations.TLSHandshakes...)
+	tk.QUICHandshakes = append(tk.QUICHandshakes, observations.QUICHandshakes...)
+
+	return nil // return nil so we always submit the measurement
+}
+
+// loadRootNode loads the DSL root node either from the input URL or from the config.
+func (m *Measurer) loadRootNode(ctx context.Context, args *model.ExperimentArgs) (*dsljson.RootNode, error) {
+	input := string(args.Measurement.Input)
+	switch {
+	case input != "" && m.config.DSL != "":
+		return nil, errBothInputAndDSL
+
+	case input != "":
+		parsed, err := url.Parse(input)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", errInputIsNotAnURL, err.Error())
+		}
+		if parsed.Scheme != "https" && parsed.Scheme != "http" {
+			return nil, errInvalidScheme
+		}
+		return httpclientx.GetJSON[*dsljson.RootNode](
+			ctx,
+			httpclientx.NewEndpoint(input),
+			&httpclientx.Config{
+				Authorization: "", // not needed
+				Client:        args.Session.DefaultHTTPClient(),
+				Logger:        args.Session.Logger(),
+				UserAgent:     model.HTTPHeaderUserAgent,
+			})
+
+	case m.config.DSL != "":
+		var root dsljson.RootNode
+		if err := json.Unmarshal([]byte(m.config.DSL), &root); err != nil {
+			return nil, err
+		}
+		return &root, nil
+
+	default:
+		return nil, errNoDSLProvided
+	}
+}
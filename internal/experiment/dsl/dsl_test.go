@@ -0,0 +1,171 @@
+package dsl
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ooni/probe-cli/v3/internal/mocks"
+	"github.com/ooni/probe-cli/v3/internal/model"
+	"github.com/ooni/probe-cli/v3/internal/netemx"
+)
+
+// testDSL resolves www.example.com, connects to port 443 and closes the conns.
+const testDSL = `{
+	"stages": [{
+		"name": "getaddrinfo",
+		"value": {"domain": "www.example.com", "output": "addrs", "tags": ["dsl"]}
+	}, {
+		"name": "make_endpoints",
+		"value": {"input": "addrs", "output": "endpoints", "port": "443"}
+	}, {
+		"name": "tcp_connect",
+		"value": {"input": "endpoints", "output": "conns", "tags": ["dsl"]}
+	}, {
+		"name": "drop",
+		"value": {"input": "conns", "output": "done"}
+	}]
+}`
+
+// runHelper runs the experiment using the given config and input.
+func runHelper(ctx context.Context, config Config, input string) (*model.Measurement, error) {
+	m := NewExperimentMeasurer(config)
+	meas := &model.Measurement{
+		Input: model.MeasurementInput(input),
+	}
+	sess := &mocks.Session{
+		MockLogger: func() model.Logger {
+			return model.DiscardLogger
+		},
+		MockDefaultHTTPClient: func() model.HTTPClient {
+			return http.DefaultClient
+		},
+	}
+	args := &model.ExperimentArgs{
+		Callbacks:   model.NewPrinterCallbacks(model.DiscardLogger),
+		Measurement: meas,
+		Session:     sess,
+	}
+	err := m.Run(ctx, args)
+	return meas, err
+}
+
+func TestMeasurer(t *testing.T) {
+	m := NewExperimentMeasurer(Config{})
+	if m.ExperimentName() != "dsl" {
+		t.Fatal("invalid experiment name")
+	}
+	if m.ExperimentVersion() != "0.1.0" {
+		t.Fatal("invalid experiment version")
+	}
+}
+
+func TestMeasurerRun(t *testing.T) {
+	t.Run("without input and inline DSL", func(t *testing.T) {
+		_, err := runHelper(context.Background(), Config{}, "")
+		if !errors.Is(err, errNoDSLProvided) {
+			t.Fatal("unexpected error", err)
+		}
+	})
+
+	t.Run("with both input and inline DSL", func(t *testing.T) {
+		_, err := runHelper(context.Background(), Config{DSL: testDSL}, "https://example.com/dsl.json")
+		if !errors.Is(err, errBothInputAndDSL) {
+			t.Fatal("unexpected error", err)
+		}
+	})
+
+	t.Run("with input that is not an URL", func(t *testing.T) {
+		_, err := runHelper(context.Background(), Config{}, "\t")
+		if !errors.Is(err, errInputIsNotAnURL) {
+			t.Fatal("unexpected error", err)
+		}
+	})
+
+	t.Run("with input having an invalid scheme", func(t *testing.T) {
+		_, err := runHelper(context.Background(), Config{}, "ftp://example.com/dsl.json")
+		if !errors.Is(err, errInvalidScheme) {
+			t.Fatal("unexpected error", err)
+		}
+	})
+
+	t.Run("with invalid inline JSON", func(t *testing.T) {
+		_, err := runHelper(context.Background(), Config{DSL: "{"}, "")
+		if err == nil {
+			t.Fatal("expected an error")
+		}
+	})
+
+	t.Run("with a DSL that fails to load", func(t *testing.T) {
+		config := Config{DSL: `{"stages": [{"name": "nonexistent", "value": {}}]}`}
+		meas, err := runHelper(context.Background(), config, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		tk := meas.TestKeys.(*TestKeys)
+		if tk.Failure == nil || *tk.Failure != "dsljson: invalid DSL: stage #0: unknown instruction: nonexistent" {
+			t.Fatal("unexpected failure", tk.Failure)
+		}
+	})
+
+	t.Run("with inline DSL", func(t *testing.T) {
+		env := netemx.MustNewScenario(netemx.InternetScenario)
+		defer env.Close()
+
+		env.Do(func() {
+			meas, err := runHelper(context.Background(), Config{DSL: testDSL}, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			tk := meas.TestKeys.(*TestKeys)
+			if tk.Failure != nil {
+				t.Fatal("unexpected failure", *tk.Failure)
+			}
+			if tk.DSLURL != "" {
+				t.Fatal("unexpected DSL URL", tk.DSLURL)
+			}
+			if len(tk.Queries) < 1 {
+				t.Fatal("expected at least a DNS query")
+			}
+			if len(tk.TCPConnect) != 1 {
+				t.Fatal("expected a single TCP connect, got", len(tk.TCPConnect))
+			}
+			if tk.TCPConnect[0].IP != netemx.AddressWwwExampleCom || tk.TCPConnect[0].Status.Failure != nil {
+				t.Fatal("unexpected TCP connect result")
+			}
+		})
+	})
+
+	t.Run("with DSL fetched from an URL", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`{"stages": [{"name": "nonexistent", "value": {}}]}`))
+		}))
+		defer server.Close()
+
+		meas, err := runHelper(context.Background(), Config{}, server.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tk := meas.TestKeys.(*TestKeys)
+		if tk.DSLURL != server.URL {
+			t.Fatal("unexpected DSL URL", tk.DSLURL)
+		}
+		if tk.Failure == nil || *tk.Failure != "dsljson: invalid DSL: stage #0: unknown instruction: nonexistent" {
+			t.Fatal("unexpected failure", tk.Failure)
+		}
+	})
+
+	t.Run("when we cannot fetch the DSL", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+		}))
+		defer server.Close()
+
+		_, err := runHelper(context.Background(), Config{}, server.URL)
+		if err == nil {
+			t.Fatal("expected an error")
+		}
+	})
+}
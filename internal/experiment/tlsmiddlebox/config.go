@@ -58,7 +58,7 @@ func (c Config) maxttl() int64 {
 	return 20
 }
 
-func (c Config) testhelper(address string) (URL *url.URL, err error) {
+func (c Config) testhelper(address string, scheme string) (URL *url.URL, err error) {
 	// TODO(DecFox, bassosimone): We want to replace this with a generic input parser
 	// Issue: https://github.com/ooni/probe/issues/2239
 	if c.TestHelper != "" {
@@ -66,7 +66,7 @@ func (c Config) testhelper(address string) (URL *url.URL, err error) {
 	}
 	URL = &url.URL{
 		Host:   address,
-		Scheme: "tlshandshake",
+		Scheme: scheme,
 	}
 	return
 }
@@ -36,7 +36,7 @@ func TestConfig_snipass(t *testing.T) {
 func TestConfig_testhelper(t *testing.T) {
 	t.Run("without config", func(t *testing.T) {
 		c := Config{}
-		th, err := c.testhelper("example.com")
+		th, err := c.testhelper("example.com", "tlshandshake")
 		if err != nil {
 			t.Fatal("unexpected error")
 		}
@@ -52,7 +52,7 @@ func TestConfig_testhelper(t *testing.T) {
 		c := Config{
 			TestHelper: "tlshandshake://example.com:80",
 		}
-		th, err := c.testhelper("google.com")
+		th, err := c.testhelper("google.com", "tlshandshake")
 		if err != nil {
 			t.Fatal("unexpected error")
 		}
@@ -68,7 +68,7 @@ func TestConfig_testhelper(t *testing.T) {
 		c := Config{
 			TestHelper: "\t",
 		}
-		th, _ := c.testhelper("google.com")
+		th, _ := c.testhelper("google.com", "tlshandshake")
 		if th != nil {
 			t.Fatal("expected nil url")
 		}
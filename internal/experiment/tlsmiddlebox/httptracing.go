@@ -0,0 +1,104 @@
+package tlsmiddlebox
+
+//
+// Iterative network tracing using HTTP
+//
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/ooni/probe-cli/v3/internal/logx"
+	"github.com/ooni/probe-cli/v3/internal/measurexlite"
+	"github.com/ooni/probe-cli/v3/internal/model"
+	"github.com/ooni/probe-cli/v3/internal/netxlite"
+)
+
+// HTTPTrace performs tracing over HTTP using control and target Host header
+func (m *Measurer) HTTPTrace(ctx context.Context, index int64, zeroTime time.Time, logger model.Logger,
+	address string, targetSNI string, trace *CompleteTrace) {
+	// perform an iterative trace with the control Host header
+	trace.ControlTrace = m.runIterativeTrace(ctx, index, zeroTime, logger, address, m.config.snicontrol(), m.httpRoundTripWithTTL)
+	// perform an iterative trace with the target Host header
+	trace.TargetTrace = m.runIterativeTrace(ctx, index, zeroTime, logger, address, targetSNI, m.httpRoundTripWithTTL)
+}
+
+const (
+	// httpRoundTripTimeout is the timeout for each HTTP round trip
+	httpRoundTripTimeout = 10 * time.Second
+
+	// httpMaxBodySnapshotSize is the maximum body snapshot size
+	httpMaxBodySnapshotSize = 1 << 14
+)
+
+// httpRoundTripWithTTL performs an HTTP GET request using the passed ttl value
+// and using the passed sni as the Host header
+func (m *Measurer) httpRoundTripWithTTL(ctx context.Context, index int64, zeroTime time.Time, logger model.Logger,
+	address string, sni string, ttl int, tr *IterativeTrace, wg *sync.WaitGroup) {
+	defer wg.Done()
+	trace := measurexlite.NewTrace(index, zeroTime)
+	// 1. Connect to the target IP
+	d := NewDialerTTLWrapper()
+	ol := logx.NewOperationLogger(logger, "HTTP Trace #%d TTL %d %s %s", index, ttl, address, sni)
+	conn, err := d.DialContext(ctx, "tcp", address)
+	if err != nil {
+		iteration := newIterationFromRequest(ttl, err, nil, nil)
+		tr.addIterations(iteration)
+		ol.Stop(err)
+		return
+	}
+	defer conn.Close()
+	// 2. Set the TTL to the passed value
+	err = setConnTTL(conn, ttl)
+	if err != nil {
+		iteration := newIterationFromRequest(ttl, err, nil, nil)
+		tr.addIterations(iteration)
+		ol.Stop(err)
+		return
+	}
+	// 3. Perform the round trip and extract the SO_ERROR value (if any)
+	txp := netxlite.NewHTTPTransportWithOptions(logger, netxlite.NewSingleUseDialer(conn), netxlite.NewNullTLSDialer())
+	defer txp.CloseIdleConnections()
+	ctx, cancel := context.WithTimeout(ctx, httpRoundTripTimeout)
+	defer cancel()
+	req, err := genHTTPRequest(ctx, sni)
+	if err != nil {
+		iteration := newIterationFromRequest(ttl, err, nil, nil)
+		tr.addIterations(iteration)
+		ol.Stop(err)
+		return
+	}
+	started := trace.TimeSince(trace.ZeroTime())
+	resp, err := txp.RoundTrip(req)
+	var body []byte
+	if err == nil {
+		defer resp.Body.Close()
+		reader := io.LimitReader(resp.Body, httpMaxBodySnapshotSize)
+		body, err = netxlite.ReadAllContext(ctx, reader)
+	}
+	finished := trace.TimeSince(trace.ZeroTime())
+	ol.Stop(err)
+	soErr := extractSoError(conn)
+	// 4. reset the TTL value to ensure that conn closes successfully
+	// Note: Do not check for errors here
+	_ = setConnTTL(conn, 64)
+	request := measurexlite.NewArchivalHTTPRequestResult(trace.Index(), started, "tcp", address, "", txp.Network(),
+		req, resp, httpMaxBodySnapshotSize, body, err, finished)
+	iteration := newIterationFromRequest(ttl, nil, soErr, request)
+	tr.addIterations(iteration)
+}
+
+// genHTTPRequest generates an HTTP GET request using the given Host header
+func genHTTPRequest(ctx context.Context, host string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", "http://"+host+"/", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", model.HTTPHeaderAccept)
+	req.Header.Set("Accept-Language", model.HTTPHeaderAcceptLanguage)
+	req.Header.Set("User-Agent", model.HTTPHeaderUserAgent)
+	return req, nil
+}
@@ -0,0 +1,80 @@
+package tlsmiddlebox
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ooni/probe-cli/v3/internal/model"
+	"github.com/ooni/probe-cli/v3/internal/netxlite"
+	"github.com/ooni/probe-cli/v3/internal/testingx"
+)
+
+func TestHTTPRoundTripWithTTL(t *testing.T) {
+	t.Run("on success", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(r.Host))
+		}))
+		defer server.Close()
+		URL, err := url.Parse(server.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		m := NewExperimentMeasurer(Config{})
+		tr := &IterativeTrace{}
+		zeroTime := time.Now()
+		ctx := context.Background()
+		wg := new(sync.WaitGroup)
+		wg.Add(1)
+		m.httpRoundTripWithTTL(ctx, 0, zeroTime, model.DiscardLogger, URL.Host, "example.com", 3, tr, wg)
+		if len(tr.Iterations) != 1 {
+			t.Fatal("unexpected number of iterations")
+		}
+		iter := tr.Iterations[0]
+		if iter.TTL != 3 {
+			t.Fatal("unexpected TTL value")
+		}
+		if iter.Handshake != nil {
+			t.Fatal("expected nil handshake")
+		}
+		if iter.Request == nil || iter.Request.Failure != nil {
+			t.Fatal("unexpected request failure")
+		}
+		if iter.Request.Response.Code != 200 {
+			t.Fatal("unexpected status code")
+		}
+		if iter.Request.Response.Body != "example.com" {
+			t.Fatal("unexpected Host header")
+		}
+	})
+
+	t.Run("on failure", func(t *testing.T) {
+		server := httptest.NewServer(testingx.HTTPHandlerReset())
+		defer server.Close()
+		URL, err := url.Parse(server.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		m := NewExperimentMeasurer(Config{})
+		tr := &IterativeTrace{}
+		zeroTime := time.Now()
+		ctx := context.Background()
+		wg := new(sync.WaitGroup)
+		wg.Add(1)
+		m.httpRoundTripWithTTL(ctx, 0, zeroTime, model.DiscardLogger, URL.Host, "example.com", 3, tr, wg)
+		if len(tr.Iterations) != 1 {
+			t.Fatal("unexpected number of iterations")
+		}
+		iter := tr.Iterations[0]
+		if iter.Request == nil || iter.Request.Failure == nil {
+			t.Fatal("expected a request failure")
+		}
+		if *iter.Request.Failure != netxlite.FailureConnectionReset {
+			t.Fatal("unexpected error", *iter.Request.Failure)
+		}
+	})
+}
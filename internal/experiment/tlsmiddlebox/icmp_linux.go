@@ -0,0 +1,105 @@
+package tlsmiddlebox
+
+//
+// Reading ICMP errors from the socket error queue
+//
+
+import (
+	"encoding/binary"
+	"net"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// enableICMPErrors asks the kernel to queue the ICMP errors we receive
+// for the given conn, such that we can later call readICMPError.
+func enableICMPErrors(conn syscall.Conn, isIPv6 bool) error {
+	rawConn, err := conn.SyscallConn()
+	if err != nil {
+		return err
+	}
+	rawErr := rawConn.Control(func(fd uintptr) {
+		if isIPv6 {
+			err = unix.SetsockoptInt(int(fd), unix.SOL_IPV6, unix.IPV6_RECVERR, 1)
+		} else {
+			err = unix.SetsockoptInt(int(fd), unix.SOL_IP, unix.IP_RECVERR, 1)
+		}
+	})
+	// The syscall err is given a higher priority and returned early if non-nil
+	if err != nil {
+		return err
+	}
+	return rawErr
+}
+
+// readICMPError drains the socket error queue and returns the first ICMP
+// error we find or nil if there are no queued ICMP errors.
+func readICMPError(conn syscall.Conn) (out *icmpError) {
+	rawConn, err := conn.SyscallConn()
+	if err != nil {
+		return nil
+	}
+	_ = rawConn.Control(func(fd uintptr) {
+		buf := make([]byte, 1500)
+		oob := make([]byte, 512)
+		for out == nil {
+			_, oobn, _, _, err := unix.Recvmsg(int(fd), buf, oob, unix.MSG_ERRQUEUE|unix.MSG_DONTWAIT)
+			if err != nil {
+				return // the queue is empty
+			}
+			out = parseICMPError(oob[:oobn])
+		}
+	})
+	return
+}
+
+// sockExtendedErrSize is the size of the sock_extended_err struct.
+const sockExtendedErrSize = 16
+
+// parseICMPError parses the control messages returned by reading the
+// socket error queue and returns the first ICMP error or nil.
+//
+// See https://man7.org/linux/man-pages/man7/ip.7.html for details.
+func parseICMPError(oob []byte) *icmpError {
+	msgs, err := unix.ParseSocketControlMessage(oob)
+	if err != nil {
+		return nil
+	}
+	for _, msg := range msgs {
+		isIPv4Err := msg.Header.Level == unix.SOL_IP && msg.Header.Type == unix.IP_RECVERR
+		isIPv6Err := msg.Header.Level == unix.SOL_IPV6 && msg.Header.Type == unix.IPV6_RECVERR
+		if (!isIPv4Err && !isIPv6Err) || len(msg.Data) < sockExtendedErrSize {
+			continue
+		}
+		errno := binary.NativeEndian.Uint32(msg.Data[0:4])
+		origin := msg.Data[4]
+		if origin != unix.SO_EE_ORIGIN_ICMP && origin != unix.SO_EE_ORIGIN_ICMP6 {
+			continue
+		}
+		return &icmpError{
+			Errno:     syscall.Errno(errno),
+			Responder: parseICMPErrorOffender(msg.Data[sockExtendedErrSize:]),
+		}
+	}
+	return nil
+}
+
+// parseICMPErrorOffender parses the sockaddr following the sock_extended_err
+// struct, which contains the address of the host that sent the ICMP error.
+func parseICMPErrorOffender(data []byte) string {
+	if len(data) < 2 {
+		return ""
+	}
+	switch binary.NativeEndian.Uint16(data[0:2]) {
+	case unix.AF_INET:
+		if len(data) >= 8 {
+			return net.IP(data[4:8]).String()
+		}
+	case unix.AF_INET6:
+		if len(data) >= 24 {
+			return net.IP(data[8:24]).String()
+		}
+	}
+	return ""
+}
@@ -0,0 +1,19 @@
+//go:build !linux
+
+package tlsmiddlebox
+
+//
+// Reading ICMP errors is only implemented on Linux
+//
+
+import "syscall"
+
+// enableICMPErrors is a no-op on this platform.
+func enableICMPErrors(conn syscall.Conn, isIPv6 bool) error {
+	return nil
+}
+
+// readICMPError always returns nil on this platform.
+func readICMPError(conn syscall.Conn) *icmpError {
+	return nil
+}
@@ -16,7 +16,7 @@ import (
 
 const (
 	testName    = "tlsmiddlebox"
-	testVersion = "0.1.2"
+	testVersion = "0.2.0"
 )
 
 // Measurer performs the measurement.
@@ -42,15 +42,46 @@ var (
 	errInputIsNotAnURL = errors.New("input is not an URL")
 
 	// errInvalidInputScheme indicates that the input scheme is invalid
-	errInvalidInputScheme = errors.New("input scheme must be tlstrace")
+	errInvalidInputScheme = errors.New("input scheme must be one of tlstrace, quictrace, and httptrace")
 
 	// errInvalidTestHelper indicates that the testhelper is invalid
 	errInvalidTestHelper = errors.New("invalid testhelper")
 
 	// errInvalidTHScheme indicates that the TH scheme is invalid
-	errInvalidTHScheme = errors.New("th scheme must be tlshandshake")
+	errInvalidTHScheme = errors.New("th scheme must be tlshandshake, quichandshake, or http depending on the input scheme")
 )
 
+// traceProtocol describes a protocol we can use for tracing
+type traceProtocol struct {
+	// name is the name of the protocol
+	name string
+
+	// thScheme is the testhelper scheme for the protocol
+	thScheme string
+
+	// defaultPort is the port we use when the testhelper has none
+	defaultPort string
+}
+
+// traceProtocols maps an input scheme to the protocol used for tracing
+var traceProtocols = map[string]*traceProtocol{
+	"tlstrace": {
+		name:        "tls",
+		thScheme:    "tlshandshake",
+		defaultPort: "443",
+	},
+	"quictrace": {
+		name:        "quic",
+		thScheme:    "quichandshake",
+		defaultPort: "443",
+	},
+	"httptrace": {
+		name:        "http",
+		thScheme:    "http",
+		defaultPort: "80",
+	},
+}
+
 // // Run implements ExperimentMeasurer.Run.
 func (m *Measurer) Run(ctx context.Context, args *model.ExperimentArgs) error {
 	_ = args.Callbacks
@@ -63,14 +94,15 @@ func (m *Measurer) Run(ctx context.Context, args *model.ExperimentArgs) error {
 	if err != nil {
 		return errInputIsNotAnURL
 	}
-	if parsed.Scheme != "tlstrace" {
+	proto, found := traceProtocols[parsed.Scheme]
+	if !found {
 		return errInvalidInputScheme
 	}
-	th, err := m.config.testhelper(parsed.Host)
+	th, err := m.config.testhelper(parsed.Host, proto.thScheme)
 	if err != nil {
 		return errInvalidTestHelper
 	}
-	if th.Scheme != "tlshandshake" {
+	if th.Scheme != proto.thScheme {
 		return errInvalidTHScheme
 	}
 	tk := NewTestKeys()
@@ -82,10 +114,14 @@ func (m *Measurer) Run(ctx context.Context, args *model.ExperimentArgs) error {
 		return err
 	}
 	// 2. measure addresses
-	addrs = prepareAddrs(addrs, th.Port())
+	port := th.Port()
+	if port == "" {
+		port = proto.defaultPort
+	}
+	addrs = prepareAddrs(addrs, port)
 	for i, addr := range addrs {
 		wg.Add(1)
-		go m.TraceAddress(ctx, int64(i), measurement.MeasurementStartTimeSaved, sess.Logger(), addr, parsed.Hostname(), tk, wg)
+		go m.TraceAddress(ctx, int64(i), measurement.MeasurementStartTimeSaved, sess.Logger(), addr, parsed.Hostname(), proto, tk, wg)
 	}
 	wg.Wait()
 	return nil
@@ -93,17 +129,31 @@ func (m *Measurer) Run(ctx context.Context, args *model.ExperimentArgs) error {
 
 // TraceAddress measures a single address after the DNSLookup
 func (m *Measurer) TraceAddress(ctx context.Context, index int64, zeroTime time.Time, logger model.Logger,
-	address string, sni string, tk *TestKeys, wg *sync.WaitGroup) error {
+	address string, sni string, proto *traceProtocol, tk *TestKeys, wg *sync.WaitGroup) error {
 	defer wg.Done()
 	trace := &CompleteTrace{
-		Address: address,
+		Address:     address,
+		Protocol:    proto.name,
+		Differences: []*IterationDifference{},
 	}
 	tk.addTrace(trace)
-	err := m.TCPConnect(ctx, index, zeroTime, logger, address, tk)
-	if err != nil {
-		return err // skip tracing if we cannot connect with default TTL
+	// Note: there is no connect step for QUIC, hence we cannot skip
+	// tracing the addresses that do not work with the default TTL
+	if proto.name != "quic" {
+		err := m.TCPConnect(ctx, index, zeroTime, logger, address, tk)
+		if err != nil {
+			return err // skip tracing if we cannot connect with default TTL
+		}
+	}
+	switch proto.name {
+	case "quic":
+		m.QUICTrace(ctx, index, zeroTime, logger, address, sni, trace)
+	case "http":
+		m.HTTPTrace(ctx, index, zeroTime, logger, address, sni, trace)
+	default:
+		m.TLSTrace(ctx, index, zeroTime, logger, address, sni, trace)
 	}
-	m.TLSTrace(ctx, index, zeroTime, logger, address, sni, trace)
+	trace.Differences = computeDifferences(trace.ControlTrace, trace.TargetTrace)
 	return nil
 }
 
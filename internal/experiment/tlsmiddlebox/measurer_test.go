@@ -18,7 +18,7 @@ func TestMeasurerExperimentNameVersion(t *testing.T) {
 	if measurer.ExperimentName() != "tlsmiddlebox" {
 		t.Fatal("unexpected ExperimentName")
 	}
-	if measurer.ExperimentVersion() != "0.1.2" {
+	if measurer.ExperimentVersion() != "0.2.0" {
 		t.Fatal("unexpected ExperimentVersion")
 	}
 }
@@ -82,6 +82,44 @@ func TestMeasurer_input_failure(t *testing.T) {
 		}
 	})
 
+	t.Run("with TH scheme not matching the input scheme", func(t *testing.T) {
+		_, _, err := runHelper(context.Background(), "quictrace://example.com", "tlshandshake://google.com", "")
+		if !errors.Is(err, errInvalidTHScheme) {
+			t.Fatal("unexpected error", err)
+		}
+	})
+
+	t.Run("with httptrace and local listener", func(t *testing.T) {
+		if testing.Short() {
+			t.Skip("skip test in short mode")
+		}
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(200)
+		}))
+		defer server.Close()
+		meas, _, err := runHelper(context.Background(), "httptrace://google.com", server.URL, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		tk := meas.TestKeys.(*TestKeys)
+		if len(tk.IterativeTrace) != 1 {
+			t.Fatal("unexpected number of trace")
+		}
+		trace := tk.IterativeTrace[0]
+		if trace.Protocol != "http" {
+			t.Fatal("unexpected protocol", trace.Protocol)
+		}
+		if len(trace.ControlTrace.Iterations) != 1 || len(trace.TargetTrace.Iterations) != 1 {
+			t.Fatal("unexpected number of iterations")
+		}
+		if trace.TargetTrace.Iterations[0].Request == nil {
+			t.Fatal("expected non-nil request")
+		}
+		if len(trace.Differences) != 0 {
+			t.Fatal("expected no differences")
+		}
+	})
+
 	t.Run("with local listener and successful outcome", func(t *testing.T) {
 		if testing.Short() {
 			t.Skip("skip test in short mode")
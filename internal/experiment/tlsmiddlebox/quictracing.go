@@ -0,0 +1,168 @@
+package tlsmiddlebox
+
+//
+// Iterative network tracing using QUIC
+//
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/ooni/probe-cli/v3/internal/logx"
+	"github.com/ooni/probe-cli/v3/internal/measurexlite"
+	"github.com/ooni/probe-cli/v3/internal/model"
+	"github.com/ooni/probe-cli/v3/internal/netxlite"
+	"github.com/quic-go/quic-go"
+)
+
+// QUICTrace performs tracing over QUIC using control and target SNI
+func (m *Measurer) QUICTrace(ctx context.Context, index int64, zeroTime time.Time, logger model.Logger,
+	address string, targetSNI string, trace *CompleteTrace) {
+	// perform an iterative trace with the control SNI
+	trace.ControlTrace = m.runIterativeTrace(ctx, index, zeroTime, logger, address, m.config.snicontrol(), m.quicHandshakeWithTTL)
+	// perform an iterative trace with the target SNI
+	trace.TargetTrace = m.runIterativeTrace(ctx, index, zeroTime, logger, address, targetSNI, m.quicHandshakeWithTTL)
+}
+
+// quicHandshakeTimeout is the timeout for each QUIC handshake
+const quicHandshakeTimeout = 10 * time.Second
+
+// quicHandshakeWithTTL performs the QUIC Handshake using the passed ttl value
+func (m *Measurer) quicHandshakeWithTTL(ctx context.Context, index int64, zeroTime time.Time, logger model.Logger,
+	address string, sni string, ttl int, tr *IterativeTrace, wg *sync.WaitGroup) {
+	defer wg.Done()
+	trace := measurexlite.NewTrace(index, zeroTime)
+	ol := logx.NewOperationLogger(logger, "QUIC Handshake Trace #%d TTL %d %s %s", index, ttl, address, sni)
+	// 1. Create the listener setting the TTL on the UDP socket
+	listener := &udpListenerTTL{
+		isIPv6: strings.Contains(address, "["),
+		ttl:    ttl,
+	}
+	// 2. Perform the handshake and read the ICMP error (if any) when closing
+	ctx, cancel := context.WithTimeout(ctx, quicHandshakeTimeout)
+	defer cancel()
+	qd := trace.NewQUICDialerWithoutResolver(listener, logger)
+	defer qd.CloseIdleConnections()
+	qconn, err := qd.DialContext(ctx, address, genQUICTLSConfig(sni), &quic.Config{})
+	ol.Stop(err)
+	if err == nil {
+		_ = qconn.CloseWithError(0, "")
+	}
+	handshake := trace.FirstQUICHandshakeOrNil()
+	if handshake == nil {
+		// we failed before starting the handshake (e.g., creating the socket)
+		iteration := newIterationFromHandshake(ttl, err, nil, nil)
+		tr.addIterations(iteration)
+		return
+	}
+	iteration := newIterationFromQUICHandshake(ttl, nil, listener.icmpErr(), handshake)
+	tr.addIterations(iteration)
+}
+
+// genQUICTLSConfig generates tls.Config for QUIC from a given SNI
+func genQUICTLSConfig(sni string) *tls.Config {
+	return &tls.Config{ // #nosec G402 - we need to use a large TLS versions range for measuring
+		RootCAs:            nil,
+		ServerName:         sni,
+		NextProtos:         []string{"h3"},
+		InsecureSkipVerify: true, // #nosec G402 - it's fine to skip verify in a nettest
+	}
+}
+
+// icmpError is an ICMP error we have read from the socket error queue
+type icmpError struct {
+	// Errno is the errno corresponding to the ICMP error
+	Errno syscall.Errno
+
+	// Responder is the address of the host that sent the ICMP error
+	Responder string
+}
+
+// soError returns the classified errno or nil
+func (e *icmpError) soError() error {
+	if e == nil || e.Errno == 0 {
+		return nil
+	}
+	return netxlite.MaybeNewErrWrapper(netxlite.ClassifyGenericError, netxlite.QUICHandshakeOperation, e.Errno)
+}
+
+// responder returns the address of the responder or nil
+func (e *icmpError) responder() *string {
+	if e == nil || e.Responder == "" {
+		return nil
+	}
+	return &e.Responder
+}
+
+// udpListenerTTL is a model.UDPListener creating a single UDP
+// socket with the given TTL, from which we read ICMP errors
+type udpListenerTTL struct {
+	isIPv6 bool
+	ttl    int
+
+	icmp *icmpError
+	mu   sync.Mutex
+}
+
+var _ model.UDPListener = &udpListenerTTL{}
+
+// Listen implements model.UDPListener.Listen
+//
+// Note: we ignore the passed address, since we must create a
+// socket using the same address family of the remote address
+func (l *udpListenerTTL) Listen(addr *net.UDPAddr) (model.UDPLikeConn, error) {
+	network := "udp4"
+	if l.isIPv6 {
+		network = "udp6"
+	}
+	conn, err := net.ListenUDP(network, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := setSockoptTTL(conn, l.isIPv6, l.ttl); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if err := enableICMPErrors(conn, l.isIPv6); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return &udpConnTTL{UDPConn: conn, listener: l}, nil
+}
+
+// saveICMPErr saves the first ICMP error we have read
+func (l *udpListenerTTL) saveICMPErr(icmpErr *icmpError) {
+	l.mu.Lock()
+	if l.icmp == nil {
+		l.icmp = icmpErr
+	}
+	l.mu.Unlock()
+}
+
+// icmpErr returns the ICMP error we have read or nil
+func (l *udpListenerTTL) icmpErr() *icmpError {
+	defer l.mu.Unlock()
+	l.mu.Lock()
+	return l.icmp
+}
+
+// udpConnTTL reads the ICMP errors before closing the socket
+type udpConnTTL struct {
+	*net.UDPConn
+	listener *udpListenerTTL
+}
+
+var _ model.UDPLikeConn = &udpConnTTL{}
+
+// Close implements model.UDPLikeConn.Close
+func (c *udpConnTTL) Close() error {
+	if icmpErr := readICMPError(c.UDPConn); icmpErr != nil {
+		c.listener.saveICMPErr(icmpErr)
+	}
+	return c.UDPConn.Close()
+}
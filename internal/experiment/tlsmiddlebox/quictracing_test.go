@@ -0,0 +1,54 @@
+package tlsmiddlebox
+
+import (
+	"context"
+	"net"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ooni/probe-cli/v3/internal/model"
+)
+
+func TestQUICHandshakeWithTTL(t *testing.T) {
+	t.Run("with closed port", func(t *testing.T) {
+		// obtain the address of a closed UDP port
+		pconn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		address := pconn.LocalAddr().String()
+		pconn.Close()
+
+		m := NewExperimentMeasurer(Config{})
+		tr := &IterativeTrace{}
+		zeroTime := time.Now()
+		ctx := context.Background()
+		wg := new(sync.WaitGroup)
+		wg.Add(1)
+		m.quicHandshakeWithTTL(ctx, 0, zeroTime, model.DiscardLogger, address, "example.com", 3, tr, wg)
+		if len(tr.Iterations) != 1 {
+			t.Fatal("unexpected number of iterations")
+		}
+		iter := tr.Iterations[0]
+		if iter.TTL != 3 {
+			t.Fatal("unexpected TTL value")
+		}
+		if iter.Handshake == nil || iter.Handshake.ServerName != "example.com" {
+			t.Fatal("unexpected servername")
+		}
+		if iter.Handshake.Failure == nil {
+			t.Fatal("expected a handshake failure")
+		}
+		if runtime.GOOS != "linux" {
+			return // we only read ICMP errors on Linux
+		}
+		if iter.ICMPResponder == nil || *iter.ICMPResponder != "127.0.0.1" {
+			t.Fatal("unexpected ICMP responder", iter.ICMPResponder)
+		}
+		if iter.Handshake.SoError == nil {
+			t.Fatal("expected a non-nil SO_ERROR")
+		}
+	})
+}
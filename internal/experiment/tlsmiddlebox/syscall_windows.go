@@ -19,12 +19,17 @@ func (c *dialerTTLWrapperConn) SetTTL(ttl int) error {
 	if !ok {
 		return errInvalidConnWrapper
 	}
-	rawConn, err := tcpConn.SyscallConn()
+	isIPv6 := strings.Contains(tcpConn.RemoteAddr().String(), "[")
+	return setSockoptTTL(tcpConn, isIPv6, ttl)
+}
+
+// setSockoptTTL sets the IP TTL field (or the IPv6 hop limit) for the given conn
+func setSockoptTTL(conn syscall.Conn, isIPv6 bool, ttl int) error {
+	rawConn, err := conn.SyscallConn()
 	if err != nil {
 		return err
 	}
 	rawErr := rawConn.Control(func(fd uintptr) {
-		isIPv6 := strings.Contains(tcpConn.RemoteAddr().String(), "[")
 		if isIPv6 {
 			err = syscall.SetsockoptInt(syscall.Handle(fd), syscall.IPPROTO_IPV6, syscall.IPV6_UNICAST_HOPS, ttl)
 		} else {
@@ -10,9 +10,11 @@ import (
 // CompleteTrace records the result of the network trace
 // using a control SNI and a target SNI
 type CompleteTrace struct {
-	Address      string          `json:"address"`
-	ControlTrace *IterativeTrace `json:"control_trace"`
-	TargetTrace  *IterativeTrace `json:"target_trace"`
+	Address      string                 `json:"address"`
+	Protocol     string                 `json:"protocol"`
+	ControlTrace *IterativeTrace        `json:"control_trace"`
+	TargetTrace  *IterativeTrace        `json:"target_trace"`
+	Differences  []*IterationDifference `json:"differences"`
 }
 
 // Trace is an iterative trace for the corresponding servername and address
@@ -26,7 +28,44 @@ type IterativeTrace struct {
 // Iteration is a single network iteration with variable TTL
 type Iteration struct {
 	TTL       int                                     `json:"ttl"`
-	Handshake *model.ArchivalTLSOrQUICHandshakeResult `json:"handshake"`
+	Handshake *model.ArchivalTLSOrQUICHandshakeResult `json:"handshake,omitempty"`
+	Request   *model.ArchivalHTTPRequestResult        `json:"request,omitempty"`
+
+	// SoError is the SO_ERROR value for HTTP iterations, since, unlike
+	// the handshake result, the request result has no such field
+	SoError *string `json:"so_error,omitempty"`
+
+	// ICMPResponder is the address of the host that sent us an ICMP error,
+	// which we are only able to collect for QUIC on Linux
+	ICMPResponder *string `json:"icmp_responder,omitempty"`
+}
+
+// statusCode returns the HTTP response status code or zero
+func (it *Iteration) statusCode() int64 {
+	if it.Request == nil {
+		return 0
+	}
+	return it.Request.Response.Code
+}
+
+// bodyLength returns the length of the HTTP response body snapshot or zero
+func (it *Iteration) bodyLength() int {
+	if it.Request == nil {
+		return 0
+	}
+	return len(it.Request.Response.Body)
+}
+
+// failure returns the failure of the handshake or of the request
+func (it *Iteration) failure() *string {
+	switch {
+	case it.Handshake != nil:
+		return it.Handshake.Failure
+	case it.Request != nil:
+		return it.Request.Failure
+	default:
+		return nil
+	}
 }
 
 // NewIterationFromHandshake returns a new iteration from a model.ArchivalTLSOrQUICHandshakeResult
@@ -46,9 +85,97 @@ func newIterationFromHandshake(ttl int, err error, soErr error, handshake *model
 	}
 }
 
+// newIterationFromQUICHandshake returns a new iteration from a QUIC handshake
+// result and from the ICMP error we have read from the socket (if any)
+func newIterationFromQUICHandshake(ttl int, err error, icmpErr *icmpError,
+	handshake *model.ArchivalTLSOrQUICHandshakeResult) *Iteration {
+	iteration := newIterationFromHandshake(ttl, err, icmpErr.soError(), handshake)
+	iteration.ICMPResponder = icmpErr.responder()
+	return iteration
+}
+
+// newIterationFromRequest returns a new iteration from a model.ArchivalHTTPRequestResult
+func newIterationFromRequest(ttl int, err error, soErr error, request *model.ArchivalHTTPRequestResult) *Iteration {
+	if err != nil {
+		return &Iteration{
+			TTL: ttl,
+			Request: &model.ArchivalHTTPRequestResult{
+				Failure: tracex.NewFailure(err),
+			},
+		}
+	}
+	return &Iteration{
+		TTL:     ttl,
+		Request: request,
+		SoError: tracex.NewFailure(soErr),
+	}
+}
+
 // addIterations adds iterations to the trace
 func (t *IterativeTrace) addIterations(ev ...*Iteration) {
 	t.mu.Lock()
 	t.Iterations = append(t.Iterations, ev...)
 	t.mu.Unlock()
 }
+
+// IterationDifference describes how the control and the target
+// traces differ for a given TTL value
+type IterationDifference struct {
+	TTL                  int     `json:"ttl"`
+	ControlFailure       *string `json:"control_failure"`
+	TargetFailure        *string `json:"target_failure"`
+	ControlICMPResponder *string `json:"control_icmp_responder"`
+	TargetICMPResponder  *string `json:"target_icmp_responder"`
+
+	// The following fields are only meaningful for HTTP traces
+	ControlStatusCode int64 `json:"control_status_code,omitempty"`
+	TargetStatusCode  int64 `json:"target_status_code,omitempty"`
+	ControlBodyLength int   `json:"control_body_length,omitempty"`
+	TargetBodyLength  int   `json:"target_body_length,omitempty"`
+}
+
+// computeDifferences compares the iterations of the control and of the target
+// traces having the same TTL and returns the ones that differ.
+func computeDifferences(control, target *IterativeTrace) (out []*IterationDifference) {
+	out = []*IterationDifference{}
+	if control == nil || target == nil {
+		return
+	}
+	controlByTTL := make(map[int]*Iteration)
+	for _, iter := range control.Iterations {
+		controlByTTL[iter.TTL] = iter
+	}
+	for _, targetIter := range target.Iterations {
+		controlIter, found := controlByTTL[targetIter.TTL]
+		if !found {
+			continue // we can only compare TTLs present in both traces
+		}
+		diff := &IterationDifference{
+			TTL:                  targetIter.TTL,
+			ControlFailure:       controlIter.failure(),
+			TargetFailure:        targetIter.failure(),
+			ControlICMPResponder: controlIter.ICMPResponder,
+			TargetICMPResponder:  targetIter.ICMPResponder,
+			ControlStatusCode:    controlIter.statusCode(),
+			TargetStatusCode:     targetIter.statusCode(),
+			ControlBodyLength:    controlIter.bodyLength(),
+			TargetBodyLength:     targetIter.bodyLength(),
+		}
+		if stringPtrEqual(diff.ControlFailure, diff.TargetFailure) &&
+			stringPtrEqual(diff.ControlICMPResponder, diff.TargetICMPResponder) &&
+			diff.ControlStatusCode == diff.TargetStatusCode &&
+			diff.ControlBodyLength == diff.TargetBodyLength {
+			continue
+		}
+		out = append(out, diff)
+	}
+	return
+}
+
+// stringPtrEqual returns whether two optional strings are equal
+func stringPtrEqual(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
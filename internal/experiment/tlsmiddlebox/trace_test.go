@@ -0,0 +1,116 @@
+package tlsmiddlebox
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/ooni/probe-cli/v3/internal/model"
+	"github.com/ooni/probe-cli/v3/internal/netxlite"
+)
+
+func TestComputeDifferences(t *testing.T) {
+	newIteration := func(ttl int, failure string, responder string) *Iteration {
+		iter := &Iteration{
+			TTL:       ttl,
+			Handshake: &model.ArchivalTLSOrQUICHandshakeResult{},
+		}
+		if failure != "" {
+			iter.Handshake.Failure = &failure
+		}
+		if responder != "" {
+			iter.ICMPResponder = &responder
+		}
+		return iter
+	}
+	failure := netxlite.FailureGenericTimeoutError
+	responder := "10.0.0.1"
+
+	t.Run("with nil traces", func(t *testing.T) {
+		out := computeDifferences(nil, nil)
+		if len(out) != 0 {
+			t.Fatal("expected no differences")
+		}
+	})
+
+	t.Run("with equal traces", func(t *testing.T) {
+		control := &IterativeTrace{Iterations: []*Iteration{
+			newIteration(1, failure, responder),
+			newIteration(2, "", ""),
+		}}
+		target := &IterativeTrace{Iterations: []*Iteration{
+			newIteration(1, failure, responder),
+			newIteration(2, "", ""),
+		}}
+		out := computeDifferences(control, target)
+		if len(out) != 0 {
+			t.Fatal("expected no differences")
+		}
+	})
+
+	t.Run("with different traces", func(t *testing.T) {
+		control := &IterativeTrace{Iterations: []*Iteration{
+			newIteration(1, failure, responder),
+			newIteration(2, "", ""),
+		}}
+		target := &IterativeTrace{Iterations: []*Iteration{
+			newIteration(1, failure, ""),
+			newIteration(2, netxlite.FailureConnectionReset, ""),
+			newIteration(3, "", ""),
+		}}
+		out := computeDifferences(control, target)
+		reset := netxlite.FailureConnectionReset
+		expect := []*IterationDifference{{
+			TTL:                  1,
+			ControlFailure:       &failure,
+			TargetFailure:        &failure,
+			ControlICMPResponder: &responder,
+		}, {
+			TTL:           2,
+			TargetFailure: &reset,
+		}}
+		if diff := cmp.Diff(expect, out); diff != "" {
+			t.Fatal(diff)
+		}
+	})
+
+	t.Run("with different HTTP responses", func(t *testing.T) {
+		newRequestIteration := func(ttl int, code int64, body string) *Iteration {
+			return &Iteration{
+				TTL: ttl,
+				Request: &model.ArchivalHTTPRequestResult{
+					Response: model.ArchivalHTTPResponse{
+						Body: model.ArchivalScrubbedMaybeBinaryString(body),
+						Code: code,
+					},
+				},
+			}
+		}
+		control := &IterativeTrace{Iterations: []*Iteration{
+			newRequestIteration(1, 200, "hello"),
+			newRequestIteration(2, 200, "hello"),
+			newRequestIteration(3, 200, "hello"),
+		}}
+		target := &IterativeTrace{Iterations: []*Iteration{
+			newRequestIteration(1, 200, "hello"),
+			newRequestIteration(2, 403, "hello"),
+			newRequestIteration(3, 200, "blocked!"),
+		}}
+		out := computeDifferences(control, target)
+		expect := []*IterationDifference{{
+			TTL:               2,
+			ControlStatusCode: 200,
+			TargetStatusCode:  403,
+			ControlBodyLength: 5,
+			TargetBodyLength:  5,
+		}, {
+			TTL:               3,
+			ControlStatusCode: 200,
+			TargetStatusCode:  200,
+			ControlBodyLength: 5,
+			TargetBodyLength:  8,
+		}}
+		if diff := cmp.Diff(expect, out); diff != "" {
+			t.Fatal(diff)
+		}
+	})
+}
@@ -42,24 +42,36 @@ func (m *Measurer) TLSTrace(ctx context.Context, index int64, zeroTime time.Time
 // startIterativeTrace creates a Trace and calls iterativeTrace
 func (m *Measurer) startIterativeTrace(ctx context.Context, index int64, zeroTime time.Time, logger model.Logger,
 	address string, sni string) (tr *IterativeTrace) {
+	return m.runIterativeTrace(ctx, index, zeroTime, logger, address, sni, m.handshakeWithTTL)
+}
+
+// iterationFunc performs a single iteration using the passed ttl value
+type iterationFunc func(ctx context.Context, index int64, zeroTime time.Time, logger model.Logger,
+	address string, sni string, ttl int, tr *IterativeTrace, wg *sync.WaitGroup)
+
+// runIterativeTrace creates a Trace and calls traceWithIncreasingTTLs using the given iteration
+func (m *Measurer) runIterativeTrace(ctx context.Context, index int64, zeroTime time.Time, logger model.Logger,
+	address string, sni string, iterate iterationFunc) (tr *IterativeTrace) {
 	tr = &IterativeTrace{
 		SNI:        sni,
 		Iterations: []*Iteration{},
 	}
 	maxTTL := m.config.maxttl()
-	m.traceWithIncreasingTTLs(ctx, index, zeroTime, logger, address, sni, maxTTL, tr)
+	m.traceWithIncreasingTTLs(maxTTL, func(ttl int, wg *sync.WaitGroup) {
+		iterate(ctx, index, zeroTime, logger, address, sni, ttl, tr, wg)
+	})
 	tr.Iterations = alignIterations(tr.Iterations)
 	return
 }
 
 // traceWithIncreasingTTLs performs iterative tracing with increasing TTL values
-func (m *Measurer) traceWithIncreasingTTLs(ctx context.Context, index int64, zeroTime time.Time, logger model.Logger,
-	address string, sni string, maxTTL int64, trace *IterativeTrace) {
+func (m *Measurer) traceWithIncreasingTTLs(maxTTL int64, iterate func(ttl int, wg *sync.WaitGroup)) {
 	ticker := time.NewTicker(m.config.delay())
+	defer ticker.Stop()
 	wg := new(sync.WaitGroup)
 	for i := int64(1); i <= maxTTL; i++ {
 		wg.Add(1)
-		go m.handshakeWithTTL(ctx, index, zeroTime, logger, address, sni, int(i), trace, wg)
+		go iterate(int(i), wg)
 		<-ticker.C
 	}
 	wg.Wait()
@@ -141,7 +153,8 @@ func alignIterations(in []*Iteration) (out []*Iteration) {
 	})
 	for _, iter := range in {
 		out = append(out, iter)
-		if iter.Handshake.Failure == nil || *iter.Handshake.Failure == netxlite.FailureConnectionReset {
+		failure := iter.failure()
+		if failure == nil || *failure == netxlite.FailureConnectionReset {
 			break
 		}
 	}
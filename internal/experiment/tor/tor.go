@@ -12,7 +12,7 @@ import (
 	"sync/atomic"
 	"time"
 
-	"github.com/ooni/probe-cli/v3/internal/legacy/measurex"
+	"github.com/ooni/probe-cli/v3/internal/legacy/measurexshim"
 	"github.com/ooni/probe-cli/v3/internal/legacy/tracex"
 	"github.com/ooni/probe-cli/v3/internal/logx"
 	"github.com/ooni/probe-cli/v3/internal/model"
@@ -42,18 +42,18 @@ type Summary struct {
 
 // TargetResults contains the results of measuring a target.
 type TargetResults struct {
-	Agent          string                                    `json:"agent"`
-	Failure        *string                                   `json:"failure"`
-	NetworkEvents  []*measurex.ArchivalNetworkEvent          `json:"network_events"`
-	Queries        []*measurex.ArchivalDNSLookupEvent        `json:"queries"`
-	Requests       []*measurex.ArchivalHTTPRoundTripEvent    `json:"requests"`
-	Summary        map[string]Summary                        `json:"summary"`
-	TargetAddress  string                                    `json:"target_address"`
-	TargetName     string                                    `json:"target_name,omitempty"`
-	TargetProtocol string                                    `json:"target_protocol"`
-	TargetSource   string                                    `json:"target_source,omitempty"`
-	TCPConnect     []*measurex.ArchivalTCPConnect            `json:"tcp_connect"`
-	TLSHandshakes  []*measurex.ArchivalQUICTLSHandshakeEvent `json:"tls_handshakes"`
+	Agent          string                                        `json:"agent"`
+	Failure        *string                                       `json:"failure"`
+	NetworkEvents  []*measurexshim.ArchivalNetworkEvent          `json:"network_events"`
+	Queries        []*measurexshim.ArchivalDNSLookupEvent        `json:"queries"`
+	Requests       []*measurexshim.ArchivalHTTPRoundTripEvent    `json:"requests"`
+	Summary        map[string]Summary                            `json:"summary"`
+	TargetAddress  string                                        `json:"target_address"`
+	TargetName     string                                        `json:"target_name,omitempty"`
+	TargetProtocol string                                        `json:"target_protocol"`
+	TargetSource   string                                        `json:"target_source,omitempty"`
+	TCPConnect     []*measurexshim.ArchivalTCPConnect            `json:"tcp_connect"`
+	TLSHandshakes  []*measurexshim.ArchivalQUICTLSHandshakeEvent `json:"tls_handshakes"`
 
 	// Only for testing. We don't care about this field otherwise. We
 	// cannot make this private because otherwise the IP address sanitizer
@@ -243,7 +243,7 @@ func (m *Measurer) measureTargets(
 type resultsCollector struct {
 	callbacks       model.ExperimentCallbacks
 	completed       *atomic.Int64
-	flexibleConnect func(context.Context, keytarget) (*measurex.ArchivalMeasurement, *string)
+	flexibleConnect func(context.Context, keytarget) (*measurexshim.ArchivalMeasurement, *string)
 	measurement     *model.Measurement
 	mu              sync.Mutex
 	sess            model.ExperimentSession
@@ -286,7 +286,7 @@ func (rc *resultsCollector) measureSingleTarget(
 	ctx context.Context, kt keytarget, total int,
 ) {
 	tk, failure := rc.flexibleConnect(ctx, kt)
-	runtimex.PanicIfNil(tk, "measurex should guarantee non-nil here")
+	runtimex.PanicIfNil(tk, "measurexshim should guarantee non-nil here")
 	tr := TargetResults{
 		Agent:         "redirect",
 		Failure:       failure,
@@ -335,12 +335,12 @@ func maybeScrubbingLogger(input model.Logger, kt keytarget) model.Logger {
 // Returns:
 //
 // - tk is the measurement, which is always non nil because
-// the measurex "easy" API provides this guarantee;
+// the measurexshim "easy" API provides this guarantee;
 //
 // - failure is nil or an OONI failure string.
 func (rc *resultsCollector) defaultFlexibleConnect(ctx context.Context,
-	kt keytarget) (tk *measurex.ArchivalMeasurement, failure *string) {
-	mx := measurex.NewMeasurerWithDefaultSettings()
+	kt keytarget) (tk *measurexshim.ArchivalMeasurement, failure *string) {
+	mx := measurexshim.NewMeasurerWithDefaultSettings()
 	mx.Begin = rc.measurement.MeasurementStartTimeSaved
 	mx.Logger = maybeScrubbingLogger(rc.sess.Logger(), kt)
 	switch kt.target.Protocol {
@@ -355,7 +355,7 @@ func (rc *resultsCollector) defaultFlexibleConnect(ctx context.Context,
 		const timeout = 15 * time.Second
 		return mx.EasyHTTPRoundTripGET(ctx, timeout, URL.String())
 	case "or_port", "or_port_dirauth":
-		tlsConfig := measurex.NewEasyTLSConfig().InsecureSkipVerify(true)
+		tlsConfig := measurexshim.NewEasyTLSConfig().InsecureSkipVerify(true)
 		return mx.EasyTLSConnectAndHandshake(ctx, kt.target.Address, tlsConfig)
 	case "obfs4":
 		const timeout = 15 * time.Second
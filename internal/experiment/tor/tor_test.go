@@ -13,7 +13,7 @@ import (
 
 	"github.com/apex/log"
 	"github.com/google/go-cmp/cmp"
-	"github.com/ooni/probe-cli/v3/internal/legacy/measurex"
+	"github.com/ooni/probe-cli/v3/internal/legacy/measurexshim"
 	"github.com/ooni/probe-cli/v3/internal/legacy/mockable"
 	"github.com/ooni/probe-cli/v3/internal/logx"
 	"github.com/ooni/probe-cli/v3/internal/model"
@@ -250,8 +250,8 @@ func TestResultsCollectorMeasureSingleTargetGood(t *testing.T) {
 		new(model.Measurement),
 		model.NewPrinterCallbacks(log.Log),
 	)
-	rc.flexibleConnect = func(context.Context, keytarget) (*measurex.ArchivalMeasurement, *string) {
-		return &measurex.ArchivalMeasurement{}, nil
+	rc.flexibleConnect = func(context.Context, keytarget) (*measurexshim.ArchivalMeasurement, *string) {
+		return &measurexshim.ArchivalMeasurement{}, nil
 	}
 	rc.measureSingleTarget(
 		context.Background(), wrapTestingTarget(staticTestingTargets[0]),
@@ -284,9 +284,9 @@ func TestResultsCollectorMeasureSingleTargetWithFailure(t *testing.T) {
 		new(model.Measurement),
 		model.NewPrinterCallbacks(log.Log),
 	)
-	rc.flexibleConnect = func(context.Context, keytarget) (*measurex.ArchivalMeasurement, *string) {
+	rc.flexibleConnect = func(context.Context, keytarget) (*measurexshim.ArchivalMeasurement, *string) {
 		failure := "mocked error"
-		return &measurex.ArchivalMeasurement{}, &failure
+		return &measurexshim.ArchivalMeasurement{}, &failure
 	}
 	rc.measureSingleTarget(
 		context.Background(), keytarget{
@@ -430,8 +430,8 @@ func TestSummary(t *testing.T) {
 	t.Run("with a TCP connect and nothing else", func(t *testing.T) {
 		tr := new(TargetResults)
 		failure := "mocked_error"
-		tr.TCPConnect = append(tr.TCPConnect, &measurex.ArchivalTCPConnect{
-			Status: &measurex.ArchivalTCPConnectStatus{
+		tr.TCPConnect = append(tr.TCPConnect, &measurexshim.ArchivalTCPConnect{
+			Status: &measurexshim.ArchivalTCPConnectStatus{
 				Success: true,
 				Failure: &failure,
 			},
@@ -447,8 +447,8 @@ func TestSummary(t *testing.T) {
 
 	t.Run("for OBFS4", func(t *testing.T) {
 		tr := new(TargetResults)
-		tr.TCPConnect = append(tr.TCPConnect, &measurex.ArchivalTCPConnect{
-			Status: &measurex.ArchivalTCPConnectStatus{
+		tr.TCPConnect = append(tr.TCPConnect, &measurexshim.ArchivalTCPConnect{
+			Status: &measurexshim.ArchivalTCPConnectStatus{
 				Success: true,
 			},
 		})
@@ -468,10 +468,10 @@ func TestSummary(t *testing.T) {
 	})
 
 	t.Run("for or_port/or_port_dirauth", func(t *testing.T) {
-		doit := func(targetProtocol string, handshake *measurex.ArchivalQUICTLSHandshakeEvent) {
+		doit := func(targetProtocol string, handshake *measurexshim.ArchivalQUICTLSHandshakeEvent) {
 			tr := new(TargetResults)
-			tr.TCPConnect = append(tr.TCPConnect, &measurex.ArchivalTCPConnect{
-				Status: &measurex.ArchivalTCPConnectStatus{
+			tr.TCPConnect = append(tr.TCPConnect, &measurexshim.ArchivalTCPConnect{
+				Status: &measurexshim.ArchivalTCPConnectStatus{
 					Success: true,
 				},
 			})
@@ -501,7 +501,7 @@ func TestSummary(t *testing.T) {
 		}
 		doit("or_port_dirauth", nil)
 		doit("or_port", nil)
-		doit("or_port", &measurex.ArchivalQUICTLSHandshakeEvent{
+		doit("or_port", &measurexshim.ArchivalQUICTLSHandshakeEvent{
 			Failure: (func() *string {
 				s := io.EOF.Error()
 				return &s
@@ -779,10 +779,10 @@ func TestSummaryKeysWorksAsIntended(t *testing.T) {
 func TestTargetResultsFillSummaryDirPort(t *testing.T) {
 	tr := &TargetResults{
 		TargetProtocol: "dir_port",
-		TCPConnect: []*measurex.ArchivalTCPConnect{{
+		TCPConnect: []*measurexshim.ArchivalTCPConnect{{
 			IP:   "1.2.3.4",
 			Port: 443,
-			Status: &measurex.ArchivalTCPConnectStatus{
+			Status: &measurexshim.ArchivalTCPConnectStatus{
 				Failure: nil,
 			},
 		}},
@@ -0,0 +1,249 @@
+package urlgetter
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/ooni/netem"
+	"github.com/ooni/probe-cli/v3/internal/mocks"
+	"github.com/ooni/probe-cli/v3/internal/model"
+	"github.com/ooni/probe-cli/v3/internal/netemx"
+	"github.com/ooni/probe-cli/v3/internal/runtimex"
+)
+
+// compatSummary is the projection of the [TestKeys] that the legacy and the
+// measurexlite based implementations should agree upon. We do not compare
+// network events and timing information, which are implementation specific.
+type compatSummary struct {
+	Agent                 string
+	Failure               string
+	FailedOperation       string
+	Queries               []string
+	TCPConnect            []string
+	TLSHandshakes         []string
+	Requests              []string
+	HTTPResponseStatus    int64
+	HTTPResponseBodyLen   int
+	HTTPResponseLocations []string
+}
+
+// compatOptionalString converts an optional string to a string.
+func compatOptionalString(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
+
+// newCompatSummary creates a [compatSummary] from the given [TestKeys].
+func newCompatSummary(tk *TestKeys) *compatSummary {
+	summary := &compatSummary{
+		Agent:                 tk.Agent,
+		Failure:               compatOptionalString(tk.Failure),
+		FailedOperation:       compatOptionalString(tk.FailedOperation),
+		HTTPResponseStatus:    tk.HTTPResponseStatus,
+		HTTPResponseBodyLen:   len(tk.HTTPResponseBody), // DNS responses have random IDs
+		HTTPResponseLocations: tk.HTTPResponseLocations,
+	}
+
+	for _, q := range tk.Queries {
+		var answers []string
+		for _, a := range q.Answers {
+			switch a.AnswerType {
+			case "A":
+				answers = append(answers, a.IPv4)
+			case "AAAA":
+				answers = append(answers, a.IPv6)
+			}
+		}
+		sort.Strings(answers)
+		summary.Queries = append(summary.Queries, fmt.Sprintf(
+			"%s query_type=%s engine=%s answers=%s failure=%s", q.Hostname, q.QueryType,
+			q.Engine, strings.Join(answers, ","), compatOptionalString(q.Failure)))
+	}
+	sort.Strings(summary.Queries)
+
+	for _, c := range tk.TCPConnect {
+		summary.TCPConnect = append(summary.TCPConnect, fmt.Sprintf(
+			"%s:%d failure=%s", c.IP, c.Port, compatOptionalString(c.Status.Failure)))
+	}
+	sort.Strings(summary.TCPConnect)
+
+	for _, h := range tk.TLSHandshakes {
+		summary.TLSHandshakes = append(summary.TLSHandshakes, fmt.Sprintf(
+			"%s sni=%s alpn=%s version=%s certs=%v failure=%s",
+			h.Address, h.ServerName, h.NegotiatedProtocol, h.TLSVersion,
+			len(h.PeerCertificates) > 0, compatOptionalString(h.Failure)))
+	}
+	sort.Strings(summary.TLSHandshakes)
+
+	for _, r := range tk.Requests {
+		summary.Requests = append(summary.Requests, fmt.Sprintf(
+			"%s %s transport=%s code=%d location=%s body=%d truncated=%v failure=%s",
+			r.Request.Method, r.Request.URL, r.Request.Transport, r.Response.Code,
+			r.Response.Headers["Location"], len(r.Response.Body),
+			r.Response.BodyIsTruncated, compatOptionalString(r.Failure)))
+	}
+
+	return summary
+}
+
+// compatSortedKeys returns the sorted keys of a set as a comma separated string.
+func compatSortedKeys(set map[string]bool) string {
+	var keys []string
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, ",")
+}
+
+func TestCompatLegacyAndMeasurexLite(t *testing.T) {
+	type testcase struct {
+		// name is the name of the test case.
+		name string
+
+		// config is the configuration to use.
+		config Config
+
+		// target is the target URL.
+		target string
+
+		// configure OPTIONALLY configures the scenario.
+		configure func(env *netemx.QAEnv)
+
+		// expectFailure is the failure we expect.
+		expectFailure string
+	}
+
+	cases := []testcase{{
+		name:   "HTTPS",
+		target: "https://www.example.com/",
+	}, {
+		name:   "HTTP with redirect",
+		target: "http://example.com/",
+	}, {
+		name:   "HTTP with redirect and NoFollowRedirects",
+		config: Config{NoFollowRedirects: true},
+		target: "http://example.com/",
+	}, {
+		name:          "HTTP with FailOnHTTPError",
+		config:        Config{FailOnHTTPError: true, HTTPHost: "nonexistent.example.com"},
+		target:        "http://www.example.com/",
+		expectFailure: "http_request_failed",
+	}, {
+		name:   "HTTP3",
+		config: Config{HTTP3Enabled: true},
+		target: "https://www.example.com/",
+	}, {
+		name:   "HTTPS with DNS-over-HTTPS",
+		config: Config{ResolverURL: "https://dns.google/dns-query"},
+		target: "https://www.example.com/",
+	}, {
+		name:   "HTTPS with DNS-over-UDP",
+		config: Config{ResolverURL: "udp://8.8.8.8:53"},
+		target: "https://www.example.com/",
+	}, {
+		name:   "HTTPS with DNSCache",
+		config: Config{DNSCache: "www.example.com 93.184.216.34"},
+		target: "https://www.example.com/",
+	}, {
+		name:          "HTTPS with NXDOMAIN",
+		target:        "https://www.nonexistent.example.com/",
+		expectFailure: "dns_nxdomain_error",
+	}, {
+		name:   "HTTPS with TLS reset by DPI",
+		target: "https://www.example.com/",
+		configure: func(env *netemx.QAEnv) {
+			env.DPIEngine().AddRule(&netem.DPIResetTrafficForTLSSNI{
+				Logger: model.DiscardLogger,
+				SNI:    "www.example.com",
+			})
+		},
+		expectFailure: "connection_reset",
+	}, {
+		name:   "HTTPS with RejectDNSBogons",
+		config: Config{RejectDNSBogons: true},
+		target: "https://www.example.com/",
+		configure: func(env *netemx.QAEnv) {
+			env.ISPResolverConfig().RemoveRecord("www.example.com")
+			runtimex.Try0(env.ISPResolverConfig().AddRecord("www.example.com", "", "10.10.34.35"))
+		},
+		expectFailure: "dns_bogon_error",
+	}, {
+		name:   "dnslookup with the system resolver",
+		target: "dnslookup://www.example.com",
+	}, {
+		name:   "dnslookup with DNS-over-UDP",
+		config: Config{ResolverURL: "udp://8.8.8.8:53"},
+		target: "dnslookup://www.example.com",
+	}, {
+		name:   "dnslookup with DNS-over-HTTPS",
+		config: Config{ResolverURL: "https://dns.google/dns-query"},
+		target: "dnslookup://www.example.com",
+	}, {
+		name:   "tcpconnect",
+		target: "tcpconnect://93.184.216.34:443",
+	}, {
+		name:          "tcpconnect with connection refused",
+		target:        "tcpconnect://93.184.216.34:81",
+		expectFailure: "connection_refused",
+	}, {
+		name:   "tlshandshake",
+		target: "tlshandshake://www.example.com:443",
+	}, {
+		name:          "tlshandshake with invalid SNI",
+		config:        Config{TLSServerName: "www.nonexistent.example.com"},
+		target:        "tlshandshake://93.184.216.34:443",
+		expectFailure: "ssl_invalid_hostname",
+	}, {
+		name:   "tlshandshake with NoTLSVerify and ALPN",
+		config: Config{NoTLSVerify: true, TLSServerName: "www.example.com", TLSVersion: "TLSv1.3"},
+		target: "tlshandshake://93.184.216.34:443",
+	}}
+
+	// measure runs urlgetter inside a fresh scenario and returns the summary.
+	measure := func(tc testcase, lite bool) *compatSummary {
+		env := netemx.MustNewScenario(netemx.InternetScenario)
+		defer env.Close()
+		if tc.configure != nil {
+			tc.configure(env)
+		}
+		var tk TestKeys
+		env.Do(func() {
+			config := tc.config
+			config.MeasurexLite = lite
+			g := Getter{
+				Config: config,
+				Session: &mocks.Session{
+					MockLogger: func() model.Logger {
+						return model.DiscardLogger
+					},
+				},
+				Target: tc.target,
+			}
+			tk, _ = g.Get(context.Background())
+		})
+		return newCompatSummary(&tk)
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			legacy := measure(tc, false)
+			lite := measure(tc, true)
+			if !strings.HasPrefix(legacy.Failure, tc.expectFailure) {
+				t.Fatal("unexpected legacy failure", legacy.Failure)
+			}
+			if tc.expectFailure == "" && legacy.Failure != "" {
+				t.Fatal("unexpected legacy failure", legacy.Failure)
+			}
+			if diff := cmp.Diff(legacy, lite); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
@@ -49,26 +49,11 @@ func (c Configurer) NewConfiguration() (Configuration, error) {
 		},
 	}
 	// fill DNS cache
-	if c.Config.DNSCache != "" {
-		entry := strings.Split(c.Config.DNSCache, " ")
-		if len(entry) < 2 {
-			return configuration, errors.New("invalid DNSCache string")
-		}
-		domainregex := regexp.MustCompile(`^([a-z0-9]+(-[a-z0-9]+)*\.)+[a-z]{2,}$`)
-		if !domainregex.MatchString(entry[0]) {
-			return configuration, errors.New("invalid domain in DNSCache")
-		}
-		var addresses []string
-		for i := 1; i < len(entry); i++ {
-			if net.ParseIP(entry[i]) == nil {
-				return configuration, errors.New("invalid IP in DNSCache")
-			}
-			addresses = append(addresses, entry[i])
-		}
-		configuration.HTTPConfig.DNSCache = map[string][]string{
-			entry[0]: addresses,
-		}
+	dnsCache, err := newDNSCache(c.Config.DNSCache)
+	if err != nil {
+		return configuration, err
 	}
+	configuration.HTTPConfig.DNSCache = dnsCache
 	dnsclient, err := netx.NewDNSClientWithOverrides(
 		configuration.HTTPConfig, c.Config.ResolverURL,
 		c.Config.DNSHTTPHost, c.Config.DNSTLSServerName,
@@ -80,21 +65,51 @@ func (c Configurer) NewConfiguration() (Configuration, error) {
 	configuration.DNSClient = dnsclient
 	configuration.HTTPConfig.BaseResolver = dnsclient
 	// configure TLS
-	configuration.HTTPConfig.TLSConfig = &tls.Config{ // #nosec G402 - we need to use a large TLS versions range for measuring
-		NextProtos: []string{"h2", "http/1.1"},
-	}
-	if c.Config.TLSServerName != "" {
-		configuration.HTTPConfig.TLSConfig.ServerName = c.Config.TLSServerName
-	}
-	err = netxlite.ConfigureTLSVersion(
-		configuration.HTTPConfig.TLSConfig, c.Config.TLSVersion,
-	)
+	configuration.HTTPConfig.TLSConfig, err = newTLSConfig(&c.Config)
 	if err != nil {
 		return configuration, err
 	}
-	configuration.HTTPConfig.TLSConfig.InsecureSkipVerify = c.Config.NoTLSVerify
-	configuration.HTTPConfig.TLSConfig.RootCAs = c.Config.CertPool
 	// configure proxy
 	configuration.HTTPConfig.ProxyURL = c.ProxyURL
 	return configuration, nil
 }
+
+// newDNSCache parses the DNSCache config option, which has the
+// "DOMAIN IP..." format, and returns the corresponding DNS cache.
+func newDNSCache(value string) (map[string][]string, error) {
+	if value == "" {
+		return nil, nil
+	}
+	entry := strings.Split(value, " ")
+	if len(entry) < 2 {
+		return nil, errors.New("invalid DNSCache string")
+	}
+	domainregex := regexp.MustCompile(`^([a-z0-9]+(-[a-z0-9]+)*\.)+[a-z]{2,}$`)
+	if !domainregex.MatchString(entry[0]) {
+		return nil, errors.New("invalid domain in DNSCache")
+	}
+	var addresses []string
+	for i := 1; i < len(entry); i++ {
+		if net.ParseIP(entry[i]) == nil {
+			return nil, errors.New("invalid IP in DNSCache")
+		}
+		addresses = append(addresses, entry[i])
+	}
+	return map[string][]string{entry[0]: addresses}, nil
+}
+
+// newTLSConfig returns the TLS config to use for measuring.
+func newTLSConfig(config *Config) (*tls.Config, error) {
+	tlsConfig := &tls.Config{ // #nosec G402 - we need to use a large TLS versions range for measuring
+		NextProtos: []string{"h2", "http/1.1"},
+	}
+	if config.TLSServerName != "" {
+		tlsConfig.ServerName = config.TLSServerName
+	}
+	if err := netxlite.ConfigureTLSVersion(tlsConfig, config.TLSVersion); err != nil {
+		return nil, err
+	}
+	tlsConfig.InsecureSkipVerify = config.NoTLSVerify
+	tlsConfig.RootCAs = config.CertPool
+	return tlsConfig, nil
+}
@@ -49,6 +49,9 @@ func (g Getter) Get(ctx context.Context) (TestKeys, error) {
 	if g.Begin.IsZero() {
 		g.Begin = time.Now()
 	}
+	if g.Config.MeasurexLite {
+		return g.getMeasurexLite(ctx)
+	}
 	saver := new(tracex.Saver)
 	tk, err := g.get(ctx, saver)
 	// Make sure we have an operation in cases where we fail before
@@ -89,7 +92,8 @@ func (g Getter) ioutilTempDir(dir, pattern string) (string, error) {
 	return ioutil.TempDir(dir, pattern)
 }
 
-func (g Getter) get(ctx context.Context, saver *tracex.Saver) (TestKeys, error) {
+// newTestKeys creates the initial test keys for this run.
+func (g Getter) newTestKeys() TestKeys {
 	tk := TestKeys{
 		Agent:  "redirect",
 		Tunnel: g.Config.Tunnel,
@@ -100,31 +104,46 @@ func (g Getter) get(ctx context.Context, saver *tracex.Saver) (TestKeys, error)
 	if g.Config.NoFollowRedirects {
 		tk.Agent = "agent"
 	}
+	return tk
+}
+
+// maybeStartTunnel starts the configured tunnel, if any, and returns the
+// proxy URL to use or nil, along with a function to stop the tunnel.
+func (g Getter) maybeStartTunnel(ctx context.Context, tk *TestKeys) (*url.URL, func(), error) {
+	if g.Config.Tunnel == "" {
+		return nil, func() {}, nil
+	}
+	// Every new instance of the tunnel goes into a separate
+	// directory within the temporary directory. Calling
+	// Session.Close will delete such a directory.
+	tundir, err := g.ioutilTempDir(g.Session.TempDir(), "urlgetter-tunnel-")
+	if err != nil {
+		return nil, nil, err
+	}
+	tun, _, err := tunnel.Start(ctx, &tunnel.Config{
+		Name:      g.Config.Tunnel,
+		Session:   g.Session,
+		TorArgs:   g.Session.TorArgs(),
+		TorBinary: g.Session.TorBinary(),
+		TunnelDir: tundir,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	tk.BootstrapTime = tun.BootstrapTime().Seconds()
+	proxyURL := tun.SOCKS5ProxyURL()
+	tk.SOCKSProxy = proxyURL.String()
+	return proxyURL, tun.Stop, nil
+}
+
+func (g Getter) get(ctx context.Context, saver *tracex.Saver) (TestKeys, error) {
+	tk := g.newTestKeys()
 	// start tunnel
-	var proxyURL *url.URL
-	if g.Config.Tunnel != "" {
-		// Every new instance of the tunnel goes into a separate
-		// directory within the temporary directory. Calling
-		// Session.Close will delete such a directory.
-		tundir, err := g.ioutilTempDir(g.Session.TempDir(), "urlgetter-tunnel-")
-		if err != nil {
-			return tk, err
-		}
-		tun, _, err := tunnel.Start(ctx, &tunnel.Config{
-			Name:      g.Config.Tunnel,
-			Session:   g.Session,
-			TorArgs:   g.Session.TorArgs(),
-			TorBinary: g.Session.TorBinary(),
-			TunnelDir: tundir,
-		})
-		if err != nil {
-			return tk, err
-		}
-		tk.BootstrapTime = tun.BootstrapTime().Seconds()
-		proxyURL = tun.SOCKS5ProxyURL()
-		tk.SOCKSProxy = proxyURL.String()
-		defer tun.Stop()
+	proxyURL, stop, err := g.maybeStartTunnel(ctx, &tk)
+	if err != nil {
+		return tk, err
 	}
+	defer stop()
 	// create configuration
 	configurer := Configurer{
 		Config:   g.Config,
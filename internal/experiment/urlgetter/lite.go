@@ -0,0 +1,608 @@
+package urlgetter
+
+//
+// Implementation based on measurexlite
+//
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/ooni/probe-cli/v3/internal/bytecounter"
+	"github.com/ooni/probe-cli/v3/internal/measurexlite"
+	"github.com/ooni/probe-cli/v3/internal/model"
+	"github.com/ooni/probe-cli/v3/internal/netxlite"
+	"github.com/ooni/probe-cli/v3/internal/runtimex"
+)
+
+// liteMaxBodySnapshotSize is the maximum response body snapshot size, which
+// is the same default snapshot size used by the legacy implementation.
+const liteMaxBodySnapshotSize = 1 << 17
+
+// getMeasurexLite is like get but uses measurexlite rather than legacy/netx
+// and legacy/tracex. The resulting test keys use the same schema.
+func (g Getter) getMeasurexLite(ctx context.Context) (TestKeys, error) {
+	tk := g.newTestKeys()
+	lr := &liteRunner{
+		begin:    g.Begin,
+		config:   g.Config,
+		logger:   g.Session.Logger(),
+		mu:       sync.Mutex{},
+		netx:     &netxlite.Netx{Underlying: nil}, // use the host network
+		requests: nil,
+		traces:   nil,
+	}
+	err := g.runMeasurexLite(ctx, lr, &tk)
+	// Make sure we have an operation in cases where we fail before
+	// hitting our httptransport that does error wrapping.
+	if err != nil {
+		err = netxlite.NewTopLevelGenericErrWrapper(err)
+	}
+	tk.FailedOperation = liteNewFailedOperation(err)
+	tk.Failure = measurexlite.NewFailure(err)
+	lr.collect(&tk)
+	if len(tk.Requests) > 0 {
+		// OONI's convention is that the last request appears first
+		tk.HTTPResponseStatus = tk.Requests[0].Response.Code
+		tk.HTTPResponseBody = string(tk.Requests[0].Response.Body)
+		tk.HTTPResponseLocations = tk.Requests[0].Response.Locations
+	}
+	return tk, err
+}
+
+// runMeasurexLite starts the tunnel, if needed, and runs the measurement.
+func (g Getter) runMeasurexLite(ctx context.Context, lr *liteRunner, tk *TestKeys) error {
+	proxyURL, stop, err := g.maybeStartTunnel(ctx, tk)
+	if err != nil {
+		return err
+	}
+	defer stop()
+	lr.proxyURL = proxyURL
+	return lr.Run(ctx, g.Target)
+}
+
+// liteNewFailedOperation returns the failed operation for the given error.
+func liteNewFailedOperation(err error) *string {
+	if err == nil {
+		return nil
+	}
+	var (
+		errWrapper *netxlite.ErrWrapper
+		s          = netxlite.UnknownOperation
+	)
+	if errors.As(err, &errWrapper) && errWrapper.Operation != "" {
+		s = errWrapper.Operation
+	}
+	return &s
+}
+
+// liteRunner runs a measurement using measurexlite. Because each trace only
+// buffers a limited number of observations, we create a new trace for each
+// operation and each connection and we merge all the traces at the end.
+type liteRunner struct {
+	// begin is the time when the measurement begun.
+	begin time.Time
+
+	// config contains the configuration.
+	config Config
+
+	// logger is the logger to use.
+	logger model.Logger
+
+	// mu provides mutual exclusion.
+	mu sync.Mutex
+
+	// netx is the underlying network.
+	netx *netxlite.Netx
+
+	// proxyURL is the OPTIONAL proxy URL.
+	proxyURL *url.URL
+
+	// queries contains the DNS lookups saved by [*liteResolver].
+	queries []*model.ArchivalDNSLookupResult
+
+	// requests contains the HTTP requests in the order in which they completed.
+	requests []*model.ArchivalHTTPRequestResult
+
+	// traces contains all the traces we created.
+	traces []*measurexlite.Trace
+}
+
+// newTrace creates a new trace and registers it for collection.
+func (lr *liteRunner) newTrace() *measurexlite.Trace {
+	// Like the legacy implementation, we do not use transaction IDs
+	tx := measurexlite.NewTrace(0, lr.begin)
+	tx.Netx = lr.netx
+	defer lr.mu.Unlock()
+	lr.mu.Lock()
+	lr.traces = append(lr.traces, tx)
+	return tx
+}
+
+// saveRequest saves an HTTP request result.
+func (lr *liteRunner) saveRequest(ev *model.ArchivalHTTPRequestResult) {
+	defer lr.mu.Unlock()
+	lr.mu.Lock()
+	lr.requests = append(lr.requests, ev)
+}
+
+// saveQuery saves a DNS lookup result.
+func (lr *liteRunner) saveQuery(ev *model.ArchivalDNSLookupResult) {
+	defer lr.mu.Unlock()
+	lr.mu.Lock()
+	lr.queries = append(lr.queries, ev)
+}
+
+// collect merges the observations collected by all the traces into tk.
+func (lr *liteRunner) collect(tk *TestKeys) {
+	defer lr.mu.Unlock()
+	lr.mu.Lock()
+	for _, ev := range lr.queries {
+		tk.Queries = append(tk.Queries, *ev)
+	}
+	for _, tx := range lr.traces {
+		for _, ev := range tx.NetworkEvents() {
+			tk.NetworkEvents = append(tk.NetworkEvents, *ev)
+		}
+		for _, ev := range tx.TCPConnects() {
+			tk.TCPConnect = append(tk.TCPConnect, *ev)
+		}
+		tk.TCPInfo = append(tk.TCPInfo, tx.TCPInfoSamples()...)
+		for _, ev := range tx.TLSHandshakes() {
+			tk.TLSHandshakes = append(tk.TLSHandshakes, *ev)
+		}
+		for _, ev := range tx.QUICHandshakes() {
+			tk.TLSHandshakes = append(tk.TLSHandshakes, *ev)
+		}
+	}
+	// Like the legacy implementation, sort by completion time
+	sort.SliceStable(tk.Queries, func(i, j int) bool {
+		return tk.Queries[i].T < tk.Queries[j].T
+	})
+	sort.SliceStable(tk.NetworkEvents, func(i, j int) bool {
+		return tk.NetworkEvents[i].T < tk.NetworkEvents[j].T
+	})
+	sort.SliceStable(tk.TCPConnect, func(i, j int) bool {
+		return tk.TCPConnect[i].T < tk.TCPConnect[j].T
+	})
+	sort.SliceStable(tk.TCPInfo, func(i, j int) bool {
+		return tk.TCPInfo[i].T < tk.TCPInfo[j].T
+	})
+	sort.SliceStable(tk.TLSHandshakes, func(i, j int) bool {
+		return tk.TLSHandshakes[i].T < tk.TLSHandshakes[j].T
+	})
+	// OONI wants the last request to appear first
+	for idx := len(lr.requests) - 1; idx >= 0; idx-- {
+		tk.Requests = append(tk.Requests, *lr.requests[idx])
+	}
+}
+
+// Run measures the given target.
+func (lr *liteRunner) Run(ctx context.Context, target string) error {
+	// create configuration
+	dnsCache, err := newDNSCache(lr.config.DNSCache)
+	if err != nil {
+		return err
+	}
+	reso, err := lr.newResolver(dnsCache)
+	if err != nil {
+		return err
+	}
+	defer reso.CloseIdleConnections()
+	tlsConfig, err := newTLSConfig(&lr.config)
+	if err != nil {
+		return err
+	}
+	// run the measurement
+	targetURL, err := url.Parse(target)
+	if err != nil {
+		return fmt.Errorf("urlgetter: invalid target URL: %w", err)
+	}
+	switch targetURL.Scheme {
+	case "http", "https":
+		return lr.httpGet(ctx, reso, tlsConfig, target)
+	case "dnslookup":
+		return lr.dnsLookup(ctx, reso, targetURL.Hostname())
+	case "tlshandshake":
+		return lr.tlsHandshake(ctx, reso, tlsConfig, targetURL.Host)
+	case "tcpconnect":
+		return lr.tcpConnect(ctx, reso, targetURL.Host)
+	default:
+		return errors.New("unknown targetURL scheme")
+	}
+}
+
+func (lr *liteRunner) httpGet(ctx context.Context, reso model.Resolver, tlsConfig *tls.Config, URL string) error {
+	// Implementation note: empty Method implies using the GET method
+	req, err := http.NewRequest(lr.config.Method, URL, nil)
+	runtimex.PanicOnError(err, "http.NewRequest failed")
+	req = req.WithContext(ctx)
+	req.Header.Set("Accept", model.HTTPHeaderAccept)
+	req.Header.Set("Accept-Language", model.HTTPHeaderAcceptLanguage)
+	req.Header.Set("User-Agent", MaybeUserAgent(lr.config.UserAgent))
+	if lr.config.HTTPHost != "" {
+		req.Host = lr.config.HTTPHost
+	}
+	// Implementation note: the following cookiejar accepts all cookies
+	// from all domains. As such, would not be safe for usage where cookies
+	// matter, but it's totally fine for performing measurements.
+	jar, err := cookiejar.New(nil)
+	runtimex.PanicOnError(err, "cookiejar.New failed")
+	httpClient := &http.Client{
+		Jar:       jar,
+		Transport: lr.newHTTPTransport(reso, tlsConfig, lr.config.HTTP3Enabled),
+	}
+	if lr.config.NoFollowRedirects {
+		httpClient.CheckRedirect = func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		}
+	}
+	defer httpClient.CloseIdleConnections()
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if _, err = netxlite.CopyContext(ctx, io.Discard, resp.Body); err != nil {
+		return err
+	}
+	// Implementation note: we shall check for this error once we have read the
+	// whole body. Even though we discard the body, we want to know whether we
+	// see any error when reading the body before inspecting the HTTP status code.
+	if resp.StatusCode >= 400 && lr.config.FailOnHTTPError {
+		return ErrHTTPRequestFailed
+	}
+	return nil
+}
+
+func (lr *liteRunner) dnsLookup(ctx context.Context, reso model.Resolver, hostname string) error {
+	ctx = netxlite.ContextWithTrace(ctx, lr.newTrace())
+	_, err := reso.LookupHost(ctx, hostname)
+	return err
+}
+
+func (lr *liteRunner) tlsHandshake(ctx context.Context, reso model.Resolver, tlsConfig *tls.Config, address string) error {
+	ctx = netxlite.ContextWithTrace(ctx, lr.newTrace())
+	tlsDialer := lr.newTLSDialer(reso, tlsConfig)
+	conn, err := tlsDialer.DialTLSContext(ctx, "tcp", address)
+	if conn != nil {
+		_ = conn.Close()
+	}
+	return err
+}
+
+func (lr *liteRunner) tcpConnect(ctx context.Context, reso model.Resolver, address string) error {
+	ctx = netxlite.ContextWithTrace(ctx, lr.newTrace())
+	dialer := lr.newDialer(reso)
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if conn != nil {
+		_ = conn.Close()
+	}
+	return err
+}
+
+// newBootstrapResolver creates the resolver used to resolve the domain
+// names of DNS-over-TCP, DNS-over-TLS, and DNS-over-HTTPS servers.
+func (lr *liteRunner) newBootstrapResolver(dnsCache map[string][]string) model.Resolver {
+	return lr.wrapResolver(dnsCache, netxlite.NewUnwrappedStdlibResolver())
+}
+
+// wrapResolver wraps the given resolver like the legacy implementation does.
+func (lr *liteRunner) wrapResolver(dnsCache map[string][]string, reso model.Resolver) model.Resolver {
+	reso = netxlite.WrapResolver(lr.logger, reso)
+	reso = netxlite.MaybeWrapWithCachingResolver(true, reso)
+	reso = netxlite.MaybeWrapWithStaticDNSCache(dnsCache, reso)
+	reso = netxlite.MaybeWrapWithBogonResolver(lr.config.RejectDNSBogons, reso)
+	return &liteResolver{reso, lr}
+}
+
+// newResolver creates the resolver described by the ResolverURL config option.
+func (lr *liteRunner) newResolver(dnsCache map[string][]string) (model.Resolver, error) {
+	URL := lr.config.ResolverURL
+	switch URL {
+	case "doh://google":
+		URL = "https://dns.google/dns-query"
+	case "doh://cloudflare":
+		URL = "https://cloudflare-dns.com/dns-query"
+	case "":
+		URL = "system:///"
+	}
+	resolverURL, err := url.Parse(URL)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig := &tls.Config{ServerName: lr.config.DNSTLSServerName}
+	if err := netxlite.ConfigureTLSVersion(tlsConfig, lr.config.DNSTLSVersion); err != nil {
+		return nil, err
+	}
+	bootstrap := lr.newBootstrapResolver(dnsCache)
+	var txp model.DNSTransport
+	switch resolverURL.Scheme {
+	case "system":
+		return bootstrap, nil
+	case "https":
+		tlsConfig.NextProtos = []string{"h2", "http/1.1"}
+		httpClient := &http.Client{Transport: lr.newHTTPTransport(bootstrap, tlsConfig, false)}
+		txp = netxlite.NewUnwrappedDNSOverHTTPSTransportWithHostOverride(
+			httpClient, URL, lr.config.DNSHTTPHost)
+	case "odoh":
+		tlsConfig.NextProtos = []string{"h2", "http/1.1"}
+		targetConfig := tlsConfig.Clone()
+		targetConfig.ServerName = ""
+		resolverURL.Scheme = "https"
+		odohTxp := netxlite.NewUnwrappedObliviousDNSOverHTTPSTransportWithHostOverride(
+			&http.Client{Transport: lr.newHTTPTransport(bootstrap, tlsConfig, false)},
+			resolverURL.String(), lr.config.DNSHTTPHost)
+		odohTxp.ConfigClient = &http.Client{Transport: lr.newHTTPTransport(bootstrap, targetConfig, false)}
+		txp = odohTxp
+	case "udp":
+		endpoint, err := makeValidEndpoint(resolverURL)
+		if err != nil {
+			return nil, err
+		}
+		txp = netxlite.NewUnwrappedDNSOverUDPTransport(lr.newDialer(bootstrap), endpoint)
+	case "dot":
+		tlsConfig.NextProtos = []string{"dot"}
+		endpoint, err := makeValidEndpoint(resolverURL)
+		if err != nil {
+			return nil, err
+		}
+		txp = netxlite.NewUnwrappedDNSOverTLSTransport(
+			lr.newTLSDialer(bootstrap, tlsConfig).DialTLSContext, endpoint)
+	case "quic":
+		tlsConfig.NextProtos = []string{"doq"}
+		endpoint, err := makeValidEndpoint(resolverURL)
+		if err != nil {
+			return nil, err
+		}
+		txp = netxlite.NewUnwrappedDNSOverQUICTransportWithTLSConfig(
+			lr.newQUICDialer(bootstrap), endpoint, tlsConfig)
+	case "tcp":
+		endpoint, err := makeValidEndpoint(resolverURL)
+		if err != nil {
+			return nil, err
+		}
+		txp = netxlite.NewUnwrappedDNSOverTCPTransport(lr.newDialer(bootstrap).DialContext, endpoint)
+	default:
+		return nil, errors.New("unsupported resolver scheme")
+	}
+	return lr.wrapResolver(dnsCache, netxlite.NewUnwrappedSerialResolver(txp)), nil
+}
+
+// makeValidEndpoint makes a valid endpoint for DoT, DoQ, TCP, and UDP
+// resolver URLs, which may or may not contain a port number.
+func makeValidEndpoint(URL *url.URL) (string, error) {
+	// Implementation note: when we're using a quic, dot, tcp, or udp
+	// resolver, the URL.Host is actually the endpoint
+	if _, _, err := net.SplitHostPort(URL.Host); err == nil {
+		return URL.Host, nil
+	}
+	// Here we need to add the default port
+	host := URL.Host
+	if URL.Scheme == "dot" || URL.Scheme == "quic" {
+		host += ":853"
+	} else {
+		host += ":53"
+	}
+	if _, _, err := net.SplitHostPort(host); err != nil {
+		return "", err
+	}
+	return host, nil
+}
+
+// newDialer creates a dialer using the given resolver. The dialer saves
+// connect events into the trace bound to the context and each connection
+// saves its I/O events into its own trace.
+func (lr *liteRunner) newDialer(reso model.Resolver) model.Dialer {
+	d := lr.netx.NewDialerWithResolver(lr.logger, reso, &liteConnWrapper{lr})
+	d = lr.netx.MaybeWrapWithProxyDialer(d, lr.proxyURL)
+	return bytecounter.MaybeWrapWithContextAwareDialer(true, d)
+}
+
+// newTLSDialer creates a TLS dialer using the given resolver.
+func (lr *liteRunner) newTLSDialer(reso model.Resolver, tlsConfig *tls.Config) model.TLSDialer {
+	thx := lr.netx.NewTLSHandshakerStdlib(lr.logger)
+	tlsConfig = netxlite.ClonedTLSConfigOrNewEmptyConfig(tlsConfig)
+	return netxlite.NewTLSDialerWithConfig(lr.newDialer(reso), thx, tlsConfig)
+}
+
+// newQUICDialer creates a QUIC dialer using the given resolver.
+func (lr *liteRunner) newQUICDialer(reso model.Resolver) model.QUICDialer {
+	ql := &liteUDPListener{lr.netx.NewUDPListener(), lr}
+	return lr.netx.NewQUICDialerWithResolver(ql, lr.logger, reso)
+}
+
+// newHTTPTransport creates an HTTP transport using the given resolver
+// that saves each round trip into a new trace.
+func (lr *liteRunner) newHTTPTransport(
+	reso model.Resolver, tlsConfig *tls.Config, http3 bool) model.HTTPTransport {
+	var txp model.HTTPTransport
+	switch http3 {
+	case true:
+		txp = netxlite.NewHTTP3Transport(lr.logger, lr.newQUICDialer(reso), tlsConfig)
+	default:
+		dialer := lr.newDialer(reso)
+		txp = netxlite.NewHTTPTransport(lr.logger, dialer, lr.newTLSDialer(reso, tlsConfig))
+	}
+	return &liteHTTPTransport{txp, lr}
+}
+
+// liteResolver is a [model.Resolver] saving the result of each LookupHost. Like
+// the legacy implementation, we save lookups at the outermost layer, so that we
+// also save the results served by the DNS cache and the bogon errors, and we save
+// separate A and AAAA entries for each lookup.
+type liteResolver struct {
+	model.Resolver
+	lr *liteRunner
+}
+
+// LookupHost implements model.Resolver.
+func (r *liteResolver) LookupHost(ctx context.Context, hostname string) ([]string, error) {
+	// The underlying resolvers would save the DNS round trips into the trace
+	// bound to the context, thus duplicating the lookup we save here, so we
+	// run them using a trace that we are not going to collect.
+	tx := measurexlite.NewTrace(0, r.lr.begin)
+	started := tx.TimeSince(tx.ZeroTime())
+	addrs, err := r.Resolver.LookupHost(netxlite.ContextWithTrace(ctx, tx), hostname)
+	finished := tx.TimeSince(tx.ZeroTime())
+
+	// Like the legacy implementation, we split the lookup into an A and an AAAA
+	// entry and we skip the entries without answers unless the lookup failed.
+	for _, qtype := range []uint16{dns.TypeA, dns.TypeAAAA} {
+		qaddrs := liteFilterAddrsByQueryType(addrs, qtype)
+		if len(qaddrs) <= 0 && err == nil {
+			continue
+		}
+		query := (&netxlite.DNSEncoderMiekg{}).Encode(hostname, qtype, false)
+		ev := measurexlite.NewArchivalDNSLookupResultFromRoundTrip(
+			tx.Index(), started, r.Resolver, query, nil, qaddrs, err, finished)
+		// Because we split the lookup, we must use "system" rather than "getaddrinfo"
+		// like the legacy implementation does. Also, we did not send this query.
+		ev.Engine = liteResolverNetworkAdaptNames(ev.Engine)
+		ev.RawQuery = nil
+		r.lr.saveQuery(ev)
+	}
+	return addrs, err
+}
+
+// liteResolverNetworkAdaptNames maps the getaddrinfo and golang_net_resolver
+// resolver names to "system", which is what the legacy implementation uses
+// when splitting getaddrinfo lookups into A and AAAA lookups.
+//
+// See https://github.com/ooni/spec/pull/257 for more information.
+func liteResolverNetworkAdaptNames(input string) string {
+	switch input {
+	case netxlite.StdlibResolverGetaddrinfo, netxlite.StdlibResolverGolangNetResolver:
+		return netxlite.StdlibResolverSystem
+	default:
+		return input
+	}
+}
+
+// liteFilterAddrsByQueryType returns the addresses matching the given query type.
+func liteFilterAddrsByQueryType(addrs []string, qtype uint16) (out []string) {
+	for _, addr := range addrs {
+		isIPv6, err := netxlite.IsIPv6(addr)
+		if err != nil {
+			continue
+		}
+		if isIPv6 == (qtype == dns.TypeAAAA) {
+			out = append(out, addr)
+		}
+	}
+	return
+}
+
+// liteConnWrapper is a [model.DialerWrapper] saving I/O events.
+type liteConnWrapper struct {
+	lr *liteRunner
+}
+
+// WrapDialer implements model.DialerWrapper.
+func (w *liteConnWrapper) WrapDialer(d model.Dialer) model.Dialer {
+	return &liteConnDialer{d, w.lr}
+}
+
+// liteConnDialer is the dialer returned by [*liteConnWrapper].
+type liteConnDialer struct {
+	model.Dialer
+	lr *liteRunner
+}
+
+// DialContext implements model.Dialer.
+func (d *liteConnDialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	conn, err := d.Dialer.DialContext(ctx, network, address)
+	if err != nil {
+		return nil, err
+	}
+	return d.lr.newTrace().MaybeWrapNetConn(conn), nil
+}
+
+// liteUDPListener is a [model.UDPListener] saving I/O events.
+type liteUDPListener struct {
+	model.UDPListener
+	lr *liteRunner
+}
+
+// Listen implements model.UDPListener.
+func (ql *liteUDPListener) Listen(addr *net.UDPAddr) (model.UDPLikeConn, error) {
+	pconn, err := ql.UDPListener.Listen(addr)
+	if err != nil {
+		return nil, err
+	}
+	return ql.lr.newTrace().MaybeWrapUDPLikeConn(pconn), nil
+}
+
+// liteHTTPTransport is an [model.HTTPTransport] saving each round trip.
+type liteHTTPTransport struct {
+	model.HTTPTransport
+	lr *liteRunner
+}
+
+// RoundTrip implements model.HTTPTransport.
+func (txp *liteHTTPTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	tx := txp.lr.newTrace()
+	req = req.WithContext(netxlite.ContextWithTrace(req.Context(), tx))
+	started := tx.TimeSince(tx.ZeroTime())
+
+	resp, err := txp.HTTPTransport.RoundTrip(req)
+	if err != nil {
+		txp.saveRequest(tx, started, req, nil, nil, err)
+		return nil, err
+	}
+
+	r := io.LimitReader(resp.Body, liteMaxBodySnapshotSize)
+	body, err := netxlite.ReadAllContext(req.Context(), r)
+	if err != nil {
+		txp.saveRequest(tx, started, req, resp, nil, err)
+		return nil, err
+	}
+	txp.saveRequest(tx, started, req, resp, body, nil)
+
+	resp.Body = &liteReadableAgainBody{ // allow for reading again the whole body
+		Reader: io.MultiReader(bytes.NewReader(body), resp.Body),
+		Closer: resp.Body,
+	}
+	return resp, nil
+}
+
+// saveRequest saves the result of an HTTP round trip.
+func (txp *liteHTTPTransport) saveRequest(tx *measurexlite.Trace, started time.Duration,
+	req *http.Request, resp *http.Response, body []byte, err error) {
+	ev := measurexlite.NewArchivalHTTPRequestResult(
+		tx.Index(),
+		started,
+		"", // like the legacy implementation, do not include the network
+		"", // like the legacy implementation, do not include the address
+		"", // like the legacy implementation, do not include the ALPN
+		txp.Network(),
+		req,
+		resp,
+		liteMaxBodySnapshotSize,
+		body,
+		err,
+		tx.TimeSince(tx.ZeroTime()),
+	)
+	if len(ev.Response.Locations) <= 0 {
+		ev.Response.Locations = nil // like the legacy implementation
+	}
+	txp.lr.saveRequest(ev)
+}
+
+// liteReadableAgainBody allows to read again the body snapshot.
+type liteReadableAgainBody struct {
+	io.Reader
+	io.Closer
+}
@@ -13,7 +13,6 @@ import (
 	"crypto/x509"
 	"time"
 
-	"github.com/ooni/probe-cli/v3/internal/legacy/tracex"
 	"github.com/ooni/probe-cli/v3/internal/model"
 )
 
@@ -36,6 +35,7 @@ type Config struct {
 	FailOnHTTPError   bool   `ooni:"Fail HTTP request if status code is 400 or above"`
 	HTTP3Enabled      bool   `ooni:"use http3 instead of http/1.1 or http2"`
 	HTTPHost          string `ooni:"Force using specific HTTP Host header"`
+	MeasurexLite      bool   `ooni:"Use the measurexlite based implementation"`
 	Method            string `ooni:"Force HTTP method different than GET"`
 	NoFollowRedirects bool   `ooni:"Disable following redirects"`
 	NoTLSVerify       bool   `ooni:"Disable TLS verification"`
@@ -50,18 +50,24 @@ type Config struct {
 // TestKeys contains the experiment's result.
 type TestKeys struct {
 	// The following fields are part of the typical JSON emitted by OONI.
-	Agent           string                   `json:"agent"`
-	BootstrapTime   float64                  `json:"bootstrap_time,omitempty"`
-	DNSCache        []string                 `json:"dns_cache,omitempty"`
-	FailedOperation *string                  `json:"failed_operation"`
-	Failure         *string                  `json:"failure"`
-	NetworkEvents   []tracex.NetworkEvent    `json:"network_events"`
-	Queries         []tracex.DNSQueryEntry   `json:"queries"`
-	Requests        []tracex.RequestEntry    `json:"requests"`
-	SOCKSProxy      string                   `json:"socksproxy,omitempty"`
-	TCPConnect      []tracex.TCPConnectEntry `json:"tcp_connect"`
-	TLSHandshakes   []tracex.TLSHandshake    `json:"tls_handshakes"`
-	Tunnel          string                   `json:"tunnel,omitempty"`
+	Agent           string                                   `json:"agent"`
+	BootstrapTime   float64                                  `json:"bootstrap_time,omitempty"`
+	DNSCache        []string                                 `json:"dns_cache,omitempty"`
+	FailedOperation *string                                  `json:"failed_operation"`
+	Failure         *string                                  `json:"failure"`
+	NetworkEvents   []model.ArchivalNetworkEvent             `json:"network_events"`
+	Queries         []model.ArchivalDNSLookupResult          `json:"queries"`
+	Requests        []model.ArchivalHTTPRequestResult        `json:"requests"`
+	SOCKSProxy      string                                   `json:"socksproxy,omitempty"`
+	TCPConnect      []model.ArchivalTCPConnectResult         `json:"tcp_connect"`
+	TLSHandshakes   []model.ArchivalTLSOrQUICHandshakeResult `json:"tls_handshakes"`
+	Tunnel          string                                   `json:"tunnel,omitempty"`
+
+	// TCPInfo contains the kernel's TCP_INFO samples for each TCP connection
+	// (see [model.ArchivalTCPInfo]). Only the measurexlite based implementation
+	// (see Config.MeasurexLite) collects them and only on Linux, so this field
+	// is omitted from the JSON in all the other cases.
+	TCPInfo []*model.ArchivalTCPInfo `json:"tcp_info,omitempty"`
 
 	// The following fields are not serialised but are useful to simplify
 	// analysing the measurements in telegram, whatsapp, etc.
@@ -73,12 +79,12 @@ type TestKeys struct {
 // RegisterExtensions registers the extensions used by the urlgetter
 // experiment into the provided measurement.
 func RegisterExtensions(m *model.Measurement) {
-	tracex.ExtHTTP.AddTo(m)
-	tracex.ExtDNS.AddTo(m)
-	tracex.ExtNetevents.AddTo(m)
-	tracex.ExtTCPConnect.AddTo(m)
-	tracex.ExtTLSHandshake.AddTo(m)
-	tracex.ExtTunnel.AddTo(m)
+	model.ArchivalExtHTTP.AddTo(m)
+	model.ArchivalExtDNS.AddTo(m)
+	model.ArchivalExtNetevents.AddTo(m)
+	model.ArchivalExtTCPConnect.AddTo(m)
+	model.ArchivalExtTLSHandshake.AddTo(m)
+	model.ArchivalExtTunnel.AddTo(m)
 }
 
 // Measurer performs the measurement.
@@ -4,7 +4,19 @@ package webconnectivitylte
 // Config
 //
 
+import "time"
+
 // Config contains webconnectivity experiment configuration.
 type Config struct {
+	// DNSOverUDPResolver is the OPTIONAL address of the DNS-over-UDP resolver to use.
 	DNSOverUDPResolver string
+
+	// DNSOverUDPDelayedResponsesWindow is the OPTIONAL number of milliseconds during which
+	// we collect delayed DNS-over-UDP responses. When zero, we use a default window. When
+	// negative, we do not collect delayed responses.
+	DNSOverUDPDelayedResponsesWindow int64
+}
+
+func (c *Config) dnsOverUDPDelayedResponsesWindow() time.Duration {
+	return time.Duration(c.DNSOverUDPDelayedResponsesWindow) * time.Millisecond
 }
@@ -81,6 +81,11 @@ type DNSResolvers struct {
 	// UDPAddress is the OPTIONAL address of the UDP resolver to use. If this
 	// field is not set we use a default one (e.g., `8.8.8.8:53`).
 	UDPAddress string
+
+	// UDPDelayedResponsesWindow is the OPTIONAL window during which we collect delayed
+	// DNS-over-UDP responses. When zero, we use a default window (e.g., 500 milliseconds).
+	// When negative, we do not collect delayed responses.
+	UDPDelayedResponsesWindow time.Duration
 }
 
 // Start starts this task in a background goroutine.
@@ -260,7 +265,8 @@ func (t *DNSResolvers) lookupHostUDP(parentCtx context.Context, udpAddress strin
 	// runs the lookup
 	netx := &netxlite.Netx{}
 	dialer := netx.NewDialerWithoutResolver(t.Logger)
-	reso := trace.NewParallelUDPResolver(t.Logger, dialer, udpAddress)
+	reso := trace.NewParallelUDPResolverWithOptions(t.Logger, dialer, udpAddress,
+		netxlite.DNSOverUDPTransportOptionDelayedResponsesWindow(t.udpDelayedResponsesWindow()))
 	addrs, err := reso.LookupHost(lookupCtx, t.Domain)
 
 	// saves the results making sure we split Do53 queries from other queries
@@ -282,11 +288,22 @@ func (t *DNSResolvers) lookupHostUDP(parentCtx context.Context, udpAddress strin
 // Waits for late DNS replies.
 func (t *DNSResolvers) waitForLateReplies(parentCtx context.Context, trace *measurexlite.Trace) {
 	defer t.WaitGroup.Done()
-	const lateTimeout = 500 * time.Millisecond
+	lateTimeout := max(t.udpDelayedResponsesWindow(), 0)
 	events := trace.DelayedDNSResponseWithTimeout(parentCtx, lateTimeout)
+	if len(events) > 0 {
+		t.Logger.Warnf("[#%d] received %d duplicate DNS responses", trace.Index(), len(events))
+	}
 	t.TestKeys.AppendDNSLateReplies(events...)
 }
 
+// Returns the window during which we collect delayed DNS-over-UDP responses.
+func (t *DNSResolvers) udpDelayedResponsesWindow() time.Duration {
+	if t.UDPDelayedResponsesWindow != 0 {
+		return t.UDPDelayedResponsesWindow
+	}
+	return 500 * time.Millisecond
+}
+
 // Divides queries generated by Do53 in Do53-proper queries and other queries.
 func (t *DNSResolvers) do53SplitQueries(
 	input []*model.ArchivalDNSLookupResult) (do53, other []*model.ArchivalDNSLookupResult) {
@@ -393,25 +410,26 @@ func (t *DNSResolvers) startCleartextFlows(
 	for index, addr := range addresses {
 		MaybeDelayCleartextFlows(index) // allow specific callers to space flows apart
 		task := &CleartextFlow{
-			Address:                 net.JoinHostPort(addr.Addr, port),
-			Classic:                 addr.Flags&DNSAddrFlagSystemResolver != 0,
-			Depth:                   t.Depth,
-			DNSCache:                t.DNSCache,
-			DNSOverHTTPSURLProvider: t.DNSOverHTTPSURLProvider,
-			IDGenerator:             t.IDGenerator,
-			Logger:                  t.Logger,
-			NumRedirects:            t.NumRedirects,
-			TestKeys:                t.TestKeys,
-			ZeroTime:                t.ZeroTime,
-			WaitGroup:               t.WaitGroup,
-			CookieJar:               t.CookieJar,
-			FollowRedirects:         t.URL.Scheme == "http",
-			HostHeader:              t.URL.Host,
-			PrioSelector:            ps,
-			Referer:                 t.Referer,
-			UDPAddress:              t.UDPAddress,
-			URLPath:                 t.URL.Path,
-			URLRawQuery:             t.URL.RawQuery,
+			Address:                   net.JoinHostPort(addr.Addr, port),
+			Classic:                   addr.Flags&DNSAddrFlagSystemResolver != 0,
+			Depth:                     t.Depth,
+			DNSCache:                  t.DNSCache,
+			DNSOverHTTPSURLProvider:   t.DNSOverHTTPSURLProvider,
+			IDGenerator:               t.IDGenerator,
+			Logger:                    t.Logger,
+			NumRedirects:              t.NumRedirects,
+			TestKeys:                  t.TestKeys,
+			ZeroTime:                  t.ZeroTime,
+			WaitGroup:                 t.WaitGroup,
+			CookieJar:                 t.CookieJar,
+			FollowRedirects:           t.URL.Scheme == "http",
+			HostHeader:                t.URL.Host,
+			PrioSelector:              ps,
+			Referer:                   t.Referer,
+			UDPAddress:                t.UDPAddress,
+			UDPDelayedResponsesWindow: t.UDPDelayedResponsesWindow,
+			URLPath:                   t.URL.Path,
+			URLRawQuery:               t.URL.RawQuery,
 		}
 		task.Start(ctx)
 	}
@@ -444,27 +462,28 @@ func (t *DNSResolvers) startSecureFlows(
 	for index, addr := range addresses {
 		MaybeDelaySecureFlows(index) // allow specific callers to space flows apart
 		task := &SecureFlow{
-			Address:                 net.JoinHostPort(addr.Addr, port),
-			Classic:                 addr.Flags&DNSAddrFlagSystemResolver != 0,
-			Depth:                   t.Depth,
-			DNSCache:                t.DNSCache,
-			DNSOverHTTPSURLProvider: t.DNSOverHTTPSURLProvider,
-			IDGenerator:             t.IDGenerator,
-			Logger:                  t.Logger,
-			NumRedirects:            t.NumRedirects,
-			TestKeys:                t.TestKeys,
-			ZeroTime:                t.ZeroTime,
-			WaitGroup:               t.WaitGroup,
-			ALPN:                    []string{"h2", "http/1.1"},
-			CookieJar:               t.CookieJar,
-			FollowRedirects:         t.URL.Scheme == "https",
-			SNI:                     t.URL.Hostname(),
-			HostHeader:              t.URL.Host,
-			PrioSelector:            ps,
-			Referer:                 t.Referer,
-			UDPAddress:              t.UDPAddress,
-			URLPath:                 t.URL.Path,
-			URLRawQuery:             t.URL.RawQuery,
+			Address:                   net.JoinHostPort(addr.Addr, port),
+			Classic:                   addr.Flags&DNSAddrFlagSystemResolver != 0,
+			Depth:                     t.Depth,
+			DNSCache:                  t.DNSCache,
+			DNSOverHTTPSURLProvider:   t.DNSOverHTTPSURLProvider,
+			IDGenerator:               t.IDGenerator,
+			Logger:                    t.Logger,
+			NumRedirects:              t.NumRedirects,
+			TestKeys:                  t.TestKeys,
+			ZeroTime:                  t.ZeroTime,
+			WaitGroup:                 t.WaitGroup,
+			ALPN:                      []string{"h2", "http/1.1"},
+			CookieJar:                 t.CookieJar,
+			FollowRedirects:           t.URL.Scheme == "https",
+			SNI:                       t.URL.Hostname(),
+			HostHeader:                t.URL.Host,
+			PrioSelector:              ps,
+			Referer:                   t.Referer,
+			UDPAddress:                t.UDPAddress,
+			UDPDelayedResponsesWindow: t.UDPDelayedResponsesWindow,
+			URLPath:                   t.URL.Path,
+			URLRawQuery:               t.URL.RawQuery,
 		}
 		task.Start(ctx)
 	}
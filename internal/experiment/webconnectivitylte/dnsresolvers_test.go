@@ -0,0 +1,32 @@
+package webconnectivitylte
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDNSResolversUDPDelayedResponsesWindow(t *testing.T) {
+	t.Run("by default", func(t *testing.T) {
+		config := &Config{}
+		resos := &DNSResolvers{UDPDelayedResponsesWindow: config.dnsOverUDPDelayedResponsesWindow()}
+		if resos.udpDelayedResponsesWindow() != 500*time.Millisecond {
+			t.Fatal("invalid default window")
+		}
+	})
+
+	t.Run("with a positive value", func(t *testing.T) {
+		config := &Config{DNSOverUDPDelayedResponsesWindow: 1000}
+		resos := &DNSResolvers{UDPDelayedResponsesWindow: config.dnsOverUDPDelayedResponsesWindow()}
+		if resos.udpDelayedResponsesWindow() != time.Second {
+			t.Fatal("invalid window")
+		}
+	})
+
+	t.Run("with a negative value", func(t *testing.T) {
+		config := &Config{DNSOverUDPDelayedResponsesWindow: -1}
+		resos := &DNSResolvers{UDPDelayedResponsesWindow: config.dnsOverUDPDelayedResponsesWindow()}
+		if resos.udpDelayedResponsesWindow() >= 0 {
+			t.Fatal("expected a negative window")
+		}
+	})
+}
@@ -46,7 +46,7 @@ func (m *Measurer) ExperimentName() string {
 
 // ExperimentVersion implements model.ExperimentMeasurer.
 func (m *Measurer) ExperimentVersion() string {
-	return "0.5.28"
+	return "0.5.31"
 }
 
 // Run implements model.ExperimentMeasurer.
@@ -114,22 +114,23 @@ func (m *Measurer) Run(ctx context.Context, args *model.ExperimentArgs) error {
 
 	// start background tasks
 	resos := &DNSResolvers{
-		DNSCache:                NewDNSCache(),
-		DNSOverHTTPSURLProvider: m.DNSOverHTTPSURLProvider,
-		Depth:                   0,
-		Domain:                  URL.Hostname(),
-		IDGenerator:             NewIDGenerator(),
-		Logger:                  sess.Logger(),
-		NumRedirects:            NewNumRedirects(10),
-		TestKeys:                tk,
-		URL:                     URL,
-		ZeroTime:                measurement.MeasurementStartTimeSaved,
-		WaitGroup:               wg,
-		CookieJar:               jar,
-		Referer:                 "",
-		Session:                 sess,
-		TestHelpers:             testhelpers,
-		UDPAddress:              m.Config.DNSOverUDPResolver,
+		DNSCache:                  NewDNSCache(),
+		DNSOverHTTPSURLProvider:   m.DNSOverHTTPSURLProvider,
+		Depth:                     0,
+		Domain:                    URL.Hostname(),
+		IDGenerator:               NewIDGenerator(),
+		Logger:                    sess.Logger(),
+		NumRedirects:              NewNumRedirects(10),
+		TestKeys:                  tk,
+		URL:                       URL,
+		ZeroTime:                  measurement.MeasurementStartTimeSaved,
+		WaitGroup:                 wg,
+		CookieJar:                 jar,
+		Referer:                   "",
+		Session:                   sess,
+		TestHelpers:               testhelpers,
+		UDPAddress:                m.Config.DNSOverUDPResolver,
+		UDPDelayedResponsesWindow: m.Config.dnsOverUDPDelayedResponsesWindow(),
 	}
 	resos.Start(ctx)
 
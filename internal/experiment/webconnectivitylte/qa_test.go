@@ -22,3 +22,26 @@ func TestQA(t *testing.T) {
 		})
 	}
 }
+
+func TestQADNSDuplicateResponsesCount(t *testing.T) {
+	for _, tc := range webconnectivityqa.AllTestCases() {
+		if tc.Name != "dnsHijackingToProxyWithHTTPURL" {
+			continue
+		}
+		measurer := NewExperimentMeasurer(&Config{})
+		meas, err := webconnectivityqa.MeasureTestCase(measurer, tc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tk := meas.TestKeys.(*TestKeys)
+		// the DPI spoofs the response, so we expect to see the legitimate one late
+		if len(tk.DNSDuplicateResponses) <= 0 {
+			t.Fatal("expected to see duplicate responses")
+		}
+		if tk.DNSDuplicateResponsesCount != int64(len(tk.DNSDuplicateResponses)) {
+			t.Fatal("unexpected DNSDuplicateResponsesCount", tk.DNSDuplicateResponsesCount)
+		}
+		return
+	}
+	t.Fatal("did not find the test case")
+}
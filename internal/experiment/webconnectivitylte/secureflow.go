@@ -91,6 +91,11 @@ type SecureFlow struct {
 	// field is not set we use a default one (e.g., `8.8.8.8:53`).
 	UDPAddress string
 
+	// UDPDelayedResponsesWindow is the OPTIONAL window during which we collect delayed
+	// DNS-over-UDP responses. When zero, we use a default window (e.g., 500 milliseconds).
+	// When negative, we do not collect delayed responses.
+	UDPDelayedResponsesWindow time.Duration
+
 	// URLPath is the OPTIONAL URL path.
 	URLPath string
 
@@ -144,6 +149,10 @@ func (t *SecureFlow) Run(parentCtx context.Context, index int64) error {
 		// Additionally, we must register this defer here because we want to include
 		// the "connect" event in case connect has failed.
 		t.TestKeys.AppendNetworkEvents(trace.NetworkEvents()...)
+
+		// Likewise, we must collect TCP_INFO samples here to include the
+		// sample we collect when closing the TCP conn.
+		t.TestKeys.AppendTCPInfo(trace.TCPInfoSamples()...)
 	}()
 	if err != nil {
 		ol.Stop(err)
@@ -388,22 +397,23 @@ func (t *SecureFlow) maybeFollowRedirects(ctx context.Context, resp *http.Respon
 		}
 		t.Logger.Infof("redirect to: %s", location.String())
 		resolvers := &DNSResolvers{
-			CookieJar:               t.CookieJar,
-			Depth:                   t.Depth + 1,
-			DNSOverHTTPSURLProvider: t.DNSOverHTTPSURLProvider,
-			DNSCache:                t.DNSCache,
-			Domain:                  location.Hostname(),
-			IDGenerator:             t.IDGenerator,
-			Logger:                  t.Logger,
-			NumRedirects:            t.NumRedirects,
-			TestKeys:                t.TestKeys,
-			URL:                     location,
-			ZeroTime:                t.ZeroTime,
-			WaitGroup:               t.WaitGroup,
-			Referer:                 resp.Request.URL.String(),
-			Session:                 nil, // no need to issue another control request
-			TestHelpers:             nil, // ditto
-			UDPAddress:              t.UDPAddress,
+			CookieJar:                 t.CookieJar,
+			Depth:                     t.Depth + 1,
+			DNSOverHTTPSURLProvider:   t.DNSOverHTTPSURLProvider,
+			DNSCache:                  t.DNSCache,
+			Domain:                    location.Hostname(),
+			IDGenerator:               t.IDGenerator,
+			Logger:                    t.Logger,
+			NumRedirects:              t.NumRedirects,
+			TestKeys:                  t.TestKeys,
+			URL:                       location,
+			ZeroTime:                  t.ZeroTime,
+			WaitGroup:                 t.WaitGroup,
+			Referer:                   resp.Request.URL.String(),
+			Session:                   nil, // no need to issue another control request
+			TestHelpers:               nil, // ditto
+			UDPAddress:                t.UDPAddress,
+			UDPDelayedResponsesWindow: t.UDPDelayedResponsesWindow,
 		}
 		resolvers.Start(ctx)
 	}
@@ -49,6 +49,9 @@ type TestKeys struct {
 	// a resolver (which may raise eyebrows if they're different).
 	DNSDuplicateResponses []*model.ArchivalDNSLookupResult `json:"x_dns_duplicate_responses"`
 
+	// DNSDuplicateResponsesCount is the number of entries in DNSDuplicateResponses.
+	DNSDuplicateResponsesCount int64 `json:"x_dns_duplicate_responses_count"`
+
 	// Queries contains DNS queries.
 	Queries []*model.ArchivalDNSLookupResult `json:"queries"`
 
@@ -58,6 +61,10 @@ type TestKeys struct {
 	// TCPConnect contains TCP connect results.
 	TCPConnect []*model.ArchivalTCPConnectResult `json:"tcp_connect"`
 
+	// TCPInfo contains TCP_INFO samples collected after connect, after the TLS
+	// handshake, and before closing each TCP conn. Only collected on Linux.
+	TCPInfo []*model.ArchivalTCPInfo `json:"x_tcp_info"`
+
 	// TLSHandshakes contains TLS handshakes results.
 	TLSHandshakes []*model.ArchivalTLSOrQUICHandshakeResult `json:"tls_handshakes"`
 
@@ -206,6 +213,7 @@ func (tk *TestKeys) AppendNetworkEvents(v ...*model.ArchivalNetworkEvent) {
 func (tk *TestKeys) AppendDNSLateReplies(v ...*model.ArchivalDNSLookupResult) {
 	tk.mu.Lock()
 	tk.DNSDuplicateResponses = append(tk.DNSDuplicateResponses, v...)
+	tk.DNSDuplicateResponsesCount += int64(len(v))
 	tk.mu.Unlock()
 }
 
@@ -232,6 +240,13 @@ func (tk *TestKeys) AppendTCPConnectResults(v ...*model.ArchivalTCPConnectResult
 	tk.mu.Unlock()
 }
 
+// AppendTCPInfo appends to TCPInfo.
+func (tk *TestKeys) AppendTCPInfo(v ...*model.ArchivalTCPInfo) {
+	tk.mu.Lock()
+	tk.TCPInfo = append(tk.TCPInfo, v...)
+	tk.mu.Unlock()
+}
+
 // AppendTLSHandshakes appends to TLSHandshakes.
 func (tk *TestKeys) AppendTLSHandshakes(v ...*model.ArchivalTLSOrQUICHandshakeResult) {
 	tk.mu.Lock()
@@ -336,31 +351,33 @@ func NewTestKeys() *TestKeys {
 			NetworkEvents: []*model.ArchivalNetworkEvent{},
 			Queries:       []*model.ArchivalDNSLookupResult{},
 		},
-		DNSDuplicateResponses: []*model.ArchivalDNSLookupResult{},
-		Queries:               []*model.ArchivalDNSLookupResult{},
-		Requests:              []*model.ArchivalHTTPRequestResult{},
-		TCPConnect:            []*model.ArchivalTCPConnectResult{},
-		TLSHandshakes:         []*model.ArchivalTLSOrQUICHandshakeResult{},
-		Control:               nil,
-		ConnPriorityLog:       []*ConnPriorityLogEntry{},
-		ControlFailure:        nil,
-		DNSFlags:              0,
-		DNSExperimentFailure:  nil,
-		DNSConsistency:        optional.None[string](),
-		HTTPExperimentFailure: optional.None[string](),
-		BlockingFlags:         0,
-		NullNullFlags:         0,
-		BodyProportion:        0,
-		BodyLengthMatch:       optional.None[bool](),
-		HeadersMatch:          optional.None[bool](),
-		StatusCodeMatch:       optional.None[bool](),
-		TitleMatch:            optional.None[bool](),
-		Blocking:              nil,
-		Accessible:            optional.None[bool](),
-		ControlRequest:        nil,
-		fundamentalFailure:    nil,
-		mu:                    &sync.Mutex{},
-		testHelper:            nil,
+		DNSDuplicateResponses:      []*model.ArchivalDNSLookupResult{},
+		DNSDuplicateResponsesCount: 0,
+		Queries:                    []*model.ArchivalDNSLookupResult{},
+		Requests:                   []*model.ArchivalHTTPRequestResult{},
+		TCPConnect:                 []*model.ArchivalTCPConnectResult{},
+		TCPInfo:                    []*model.ArchivalTCPInfo{},
+		TLSHandshakes:              []*model.ArchivalTLSOrQUICHandshakeResult{},
+		Control:                    nil,
+		ConnPriorityLog:            []*ConnPriorityLogEntry{},
+		ControlFailure:             nil,
+		DNSFlags:                   0,
+		DNSExperimentFailure:       nil,
+		DNSConsistency:             optional.None[string](),
+		HTTPExperimentFailure:      optional.None[string](),
+		BlockingFlags:              0,
+		NullNullFlags:              0,
+		BodyProportion:             0,
+		BodyLengthMatch:            optional.None[bool](),
+		HeadersMatch:               optional.None[bool](),
+		StatusCodeMatch:            optional.None[bool](),
+		TitleMatch:                 optional.None[bool](),
+		Blocking:                   nil,
+		Accessible:                 optional.None[bool](),
+		ControlRequest:             nil,
+		fundamentalFailure:         nil,
+		mu:                         &sync.Mutex{},
+		testHelper:                 nil,
 	}
 }
 
@@ -2,5 +2,7 @@
 //
 // This package is now frozen. Please, use measurexlite for new code. See
 // https://github.com/ooni/probe-cli/blob/master/docs/design/dd-003-step-by-step.md
-// for details about this.
+// for details about this. Code still depending on the archival data format
+// produced by this package should use measurexshim, which reimplements the
+// easy API on top of measurexlite, such that we can delete this package.
 package measurex
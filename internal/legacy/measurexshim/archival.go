@@ -0,0 +1,421 @@
+package measurexshim
+
+//
+// Archival
+//
+// This file defines the legacy measurex archival data format and
+// the code to convert measurexlite observations to such a format.
+//
+
+import (
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ooni/probe-cli/v3/internal/model"
+	"github.com/ooni/probe-cli/v3/internal/netxlite"
+)
+
+//
+// BinaryData
+//
+
+// ArchivalBinaryData is the archival format for binary data.
+type ArchivalBinaryData struct {
+	Data   []byte `json:"data"`
+	Format string `json:"format"`
+}
+
+// NewArchivalBinaryData builds a new ArchivalBinaryData
+// from an array of bytes. If the array is nil, we return nil.
+func NewArchivalBinaryData(data []byte) (out *ArchivalBinaryData) {
+	if len(data) > 0 {
+		out = &ArchivalBinaryData{
+			Data:   data,
+			Format: "base64",
+		}
+	}
+	return
+}
+
+//
+// NetworkEvent
+//
+
+// ArchivalNetworkEvent is the OONI data format representation
+// of a network event according to df-008-netevents.
+type ArchivalNetworkEvent struct {
+	// JSON names compatible with df-008-netevents
+	RemoteAddr string  `json:"address"`
+	Failure    *string `json:"failure"`
+	Count      int     `json:"num_bytes,omitempty"`
+	Operation  string  `json:"operation"`
+	Network    string  `json:"proto"`
+	Finished   float64 `json:"t"`
+	Started    float64 `json:"started"`
+
+	// Names that are not part of the spec.
+	Oddity Oddity `json:"oddity"`
+}
+
+// NewArchivalNetworkEvent converts a network event to the legacy archival format.
+func NewArchivalNetworkEvent(in *model.ArchivalNetworkEvent) *ArchivalNetworkEvent {
+	return &ArchivalNetworkEvent{
+		RemoteAddr: in.Address,
+		Failure:    in.Failure,
+		Count:      int(in.NumBytes),
+		Operation:  in.Operation,
+		Network:    in.Proto,
+		Finished:   in.T,
+		Started:    in.T0,
+		Oddity:     "",
+	}
+}
+
+// NewArchivalNetworkEventList converts a list of network events to the legacy
+// archival format. Like measurex, we only keep I/O events, thus discarding, e.g.,
+// the "connect" and "tls_handshake_done" events emitted by measurexlite.
+func NewArchivalNetworkEventList(in []*model.ArchivalNetworkEvent) (out []*ArchivalNetworkEvent) {
+	for _, ev := range in {
+		switch ev.Operation {
+		case netxlite.ReadOperation, netxlite.WriteOperation,
+			netxlite.ReadFromOperation, netxlite.WriteToOperation:
+			out = append(out, NewArchivalNetworkEvent(ev))
+		default:
+			// nothing
+		}
+	}
+	return
+}
+
+//
+// HTTPRoundTrip
+//
+
+// HTTPRequest is the HTTP request.
+type HTTPRequest struct {
+	// Names consistent with df-001-http.md
+	Method  string          `json:"method"`
+	URL     string          `json:"url"`
+	Headers ArchivalHeaders `json:"headers"`
+}
+
+// HTTPResponse is the HTTP response.
+type HTTPResponse struct {
+	// Names consistent with df-001-http.md
+	Code            int64               `json:"code"`
+	Headers         ArchivalHeaders     `json:"headers"`
+	Body            *ArchivalBinaryData `json:"body"`
+	BodyIsTruncated bool                `json:"body_is_truncated"`
+
+	// Fields not part of the spec
+	BodyLength int64 `json:"x_body_length"`
+	BodyIsUTF8 bool  `json:"x_body_is_utf8"`
+}
+
+// ArchivalHTTPRoundTripEvent is the archival format of an
+// HTTP response according to df-001-http.md.
+type ArchivalHTTPRoundTripEvent struct {
+	// JSON names following the df-001-httpt data format.
+	Failure  *string       `json:"failure"`
+	Request  *HTTPRequest  `json:"request"`
+	Response *HTTPResponse `json:"response"`
+	Finished float64       `json:"t"`
+	Started  float64       `json:"started"`
+
+	// Names not in the specification
+	Oddity Oddity `json:"oddity"`
+}
+
+// ArchivalHeaders is a list of HTTP headers.
+type ArchivalHeaders map[string]string
+
+// Get searches for the first header with the named key
+// and returns it. If not found, returns an empty string.
+func (headers ArchivalHeaders) Get(key string) string {
+	return headers[strings.ToLower(key)]
+}
+
+// NewArchivalHeaders builds new ArchivalHeaders from http.Header.
+func NewArchivalHeaders(in http.Header) (out ArchivalHeaders) {
+	out = make(ArchivalHeaders)
+	for k, vv := range in {
+		for _, v := range vv {
+			// Like measurex, we only keep the first header.
+			out[strings.ToLower(k)] = v
+			break
+		}
+	}
+	return
+}
+
+// newArchivalHeadersFromMap builds new ArchivalHeaders from the
+// headers map used by the measurexlite archival format.
+func newArchivalHeadersFromMap(in map[string]model.ArchivalScrubbedMaybeBinaryString) (out ArchivalHeaders) {
+	out = make(ArchivalHeaders)
+	for k, v := range in {
+		out[strings.ToLower(k)] = string(v)
+	}
+	return
+}
+
+// NewArchivalHTTPRoundTripEvent converts an HTTP round trip to the legacy archival format.
+func NewArchivalHTTPRoundTripEvent(in *model.ArchivalHTTPRequestResult) *ArchivalHTTPRoundTripEvent {
+	body := []byte(in.Response.Body)
+	out := &ArchivalHTTPRoundTripEvent{
+		Failure: in.Failure,
+		Request: &HTTPRequest{
+			Method:  in.Request.Method,
+			URL:     in.Request.URL,
+			Headers: newArchivalHeadersFromMap(in.Request.Headers),
+		},
+		Response: &HTTPResponse{
+			Code:            in.Response.Code,
+			Headers:         newArchivalHeadersFromMap(in.Response.Headers),
+			Body:            nil, // see below
+			BodyIsTruncated: false,
+			BodyLength:      0,
+			BodyIsUTF8:      false,
+		},
+		Finished: in.T,
+		Started:  in.T0,
+		Oddity:   "",
+	}
+	// Like measurex, we only fill these fields on success
+	if in.Failure == nil {
+		out.Response.Body = NewArchivalBinaryData(body)
+		out.Response.BodyIsTruncated = in.Response.BodyIsTruncated
+		out.Response.BodyLength = int64(len(body))
+		out.Response.BodyIsUTF8 = utf8.Valid(body)
+		out.Oddity = newHTTPStatusOddity(in.Response.Code)
+	}
+	return out
+}
+
+// NewArchivalHTTPRoundTripEventList converts a list of HTTP
+// round trips to the legacy archival format.
+func NewArchivalHTTPRoundTripEventList(in []*model.ArchivalHTTPRequestResult) (out []*ArchivalHTTPRoundTripEvent) {
+	for _, ev := range in {
+		out = append(out, NewArchivalHTTPRoundTripEvent(ev))
+	}
+	return
+}
+
+//
+// QUICTLSHandshakeEvent
+//
+
+// ArchivalQUICTLSHandshakeEvent is the archival data format for a
+// QUIC or TLS handshake event according to df-006-tlshandshake.
+type ArchivalQUICTLSHandshakeEvent struct {
+	// JSON names compatible with df-006-tlshandshake
+	CipherSuite     string                `json:"cipher_suite"`
+	Failure         *string               `json:"failure"`
+	NegotiatedProto string                `json:"negotiated_proto"`
+	TLSVersion      string                `json:"tls_version"`
+	PeerCerts       []*ArchivalBinaryData `json:"peer_certificates"`
+	Finished        float64               `json:"t"`
+
+	// JSON names that are consistent with the
+	// spirit of the spec but are not in it
+	RemoteAddr string   `json:"address"`
+	SNI        string   `json:"server_name"` // used in prod
+	ALPN       []string `json:"alpn"`
+	SkipVerify bool     `json:"no_tls_verify"` // used in prod
+	Oddity     Oddity   `json:"oddity"`
+	Network    string   `json:"proto"`
+	Started    float64  `json:"started"`
+}
+
+// NewArchivalTLSCerts builds a new []*ArchivalBinaryData from a list of
+// certificates. Like measurex, we return nil when there are no certificates.
+func NewArchivalTLSCerts(in []model.ArchivalBinaryData) (out []*ArchivalBinaryData) {
+	for _, cert := range in {
+		out = append(out, &ArchivalBinaryData{
+			Data:   cert,
+			Format: "base64",
+		})
+	}
+	return
+}
+
+// NewArchivalQUICTLSHandshakeEvent converts a TLS or QUIC handshake to the
+// legacy archival format. The alpn argument contains the ALPN we offered,
+// which is not part of the measurexlite archival format, and the oddity
+// function allows to compute TLS or QUIC specific oddities.
+func NewArchivalQUICTLSHandshakeEvent(in *model.ArchivalTLSOrQUICHandshakeResult,
+	alpn []string, oddity func(failure *string) Oddity) *ArchivalQUICTLSHandshakeEvent {
+	return &ArchivalQUICTLSHandshakeEvent{
+		CipherSuite:     in.CipherSuite,
+		Failure:         in.Failure,
+		NegotiatedProto: in.NegotiatedProtocol,
+		TLSVersion:      in.TLSVersion,
+		PeerCerts:       NewArchivalTLSCerts(in.PeerCertificates),
+		Finished:        in.T,
+		RemoteAddr:      in.Address,
+		SNI:             in.ServerName,
+		ALPN:            alpn,
+		SkipVerify:      in.NoTLSVerify,
+		Oddity:          oddity(in.Failure),
+		Network:         in.Network,
+		Started:         in.T0,
+	}
+}
+
+//
+// DNSLookup
+//
+
+// ArchivalDNSLookupAnswer is the archival format of a
+// DNS lookup answer according to df-002-dnst.
+type ArchivalDNSLookupAnswer struct {
+	// JSON names compatible with df-002-dnst's spec
+	Type string `json:"answer_type"`
+	IPv4 string `json:"ipv4,omitempty"`
+	IPv6 string `json:"ivp6,omitempty"` // the typo is part of the legacy data format
+
+	// Names not part of the spec.
+	ALPN string `json:"alpn,omitempty"`
+}
+
+// ArchivalDNSLookupEvent is the archival data format
+// of a DNS lookup according to df-002-dnst.
+type ArchivalDNSLookupEvent struct {
+	// fields inside df-002-dnst
+	Answers   []ArchivalDNSLookupAnswer `json:"answers"`
+	Network   string                    `json:"engine"`
+	Failure   *string                   `json:"failure"`
+	Domain    string                    `json:"hostname"`
+	QueryType string                    `json:"query_type"`
+	Address   string                    `json:"resolver_address"`
+	Finished  float64                   `json:"t"`
+
+	// Names not part of the spec.
+	Started float64 `json:"started"`
+	Oddity  Oddity  `json:"oddity"`
+}
+
+// newArchivalDNSLookupEvent converts a DNS lookup to the legacy archival
+// format keeping only the answers with the given query type.
+func newArchivalDNSLookupEvent(in *model.ArchivalDNSLookupResult, qtype string) *ArchivalDNSLookupEvent {
+	out := &ArchivalDNSLookupEvent{
+		Answers:   nil, // see below
+		Network:   resolverNetworkAdaptNames(in.Engine),
+		Failure:   in.Failure,
+		Domain:    in.Hostname,
+		QueryType: qtype,
+		Address:   in.ResolverAddress,
+		Finished:  in.T,
+		Started:   in.T0,
+		Oddity:    "", // see below
+	}
+	var addrs []string
+	for _, answer := range in.Answers {
+		if answer.AnswerType != qtype {
+			continue
+		}
+		switch qtype {
+		case "A":
+			out.Answers = append(out.Answers, ArchivalDNSLookupAnswer{Type: "A", IPv4: answer.IPv4})
+			addrs = append(addrs, answer.IPv4)
+		case "AAAA":
+			out.Answers = append(out.Answers, ArchivalDNSLookupAnswer{Type: "AAAA", IPv6: answer.IPv6})
+			addrs = append(addrs, answer.IPv6)
+		}
+	}
+	out.Oddity = newDNSLookupHostOddity(in.Failure, addrs)
+	return out
+}
+
+// NewArchivalDNSLookupEventList converts a list of DNS lookups to the legacy archival
+// format. Like measurex, we split each ANY lookup, which is how measurexlite represents
+// getaddrinfo lookups, into an A and an AAAA lookup sharing the same failure and timing.
+func NewArchivalDNSLookupEventList(in []*model.ArchivalDNSLookupResult) (out []*ArchivalDNSLookupEvent) {
+	for _, ev := range in {
+		switch ev.QueryType {
+		case "ANY":
+			out = append(out, newArchivalDNSLookupEvent(ev, "A"))
+			out = append(out, newArchivalDNSLookupEvent(ev, "AAAA"))
+		case "A", "AAAA":
+			out = append(out, newArchivalDNSLookupEvent(ev, ev.QueryType))
+		default:
+			// nothing
+		}
+	}
+	return
+}
+
+// resolverNetworkAdaptNames maps the getaddrinfo and golang_net_resolver
+// resolver names to "system", which is what measurex used when
+// splitting getaddrinfo lookups into A and AAAA lookups.
+func resolverNetworkAdaptNames(input string) string {
+	switch input {
+	case netxlite.StdlibResolverGetaddrinfo, netxlite.StdlibResolverGolangNetResolver:
+		return netxlite.StdlibResolverSystem
+	default:
+		return input
+	}
+}
+
+//
+// TCPConnect
+//
+
+// ArchivalTCPConnect is the archival form of TCP connect
+// events in compliance with df-005-tcpconnect.
+type ArchivalTCPConnect struct {
+	// Names part of the spec.
+	IP       string                    `json:"ip"`
+	Port     int64                     `json:"port"`
+	Finished float64                   `json:"t"`
+	Status   *ArchivalTCPConnectStatus `json:"status"`
+
+	// Names not part of the spec.
+	Started float64 `json:"started"`
+	Oddity  Oddity  `json:"oddity"`
+}
+
+// ArchivalTCPConnectStatus contains the status of a TCP connect.
+type ArchivalTCPConnectStatus struct {
+	Blocked bool    `json:"blocked"`
+	Failure *string `json:"failure"`
+	Success bool    `json:"success"`
+}
+
+// NewArchivalTCPConnect converts a TCP connect to the legacy archival format.
+func NewArchivalTCPConnect(in *model.ArchivalTCPConnectResult) *ArchivalTCPConnect {
+	return &ArchivalTCPConnect{
+		IP:       in.IP,
+		Port:     int64(in.Port),
+		Finished: in.T,
+		Status: &ArchivalTCPConnectStatus{
+			Blocked: in.Status.Failure != nil,
+			Failure: in.Status.Failure,
+			Success: in.Status.Failure == nil,
+		},
+		Started: in.T0,
+		Oddity:  newTCPConnectOddity(in.Status.Failure),
+	}
+}
+
+// NewArchivalTCPConnectList converts a list of TCP connects to the legacy archival format.
+func NewArchivalTCPConnectList(in []*model.ArchivalTCPConnectResult) (out []*ArchivalTCPConnect) {
+	for _, ev := range in {
+		out = append(out, NewArchivalTCPConnect(ev))
+	}
+	return
+}
+
+//
+// Measurement
+//
+
+// ArchivalMeasurement is the archival representation of a measurement.
+type ArchivalMeasurement struct {
+	NetworkEvents  []*ArchivalNetworkEvent          `json:"network_events,omitempty"`
+	Queries        []*ArchivalDNSLookupEvent        `json:"queries,omitempty"`
+	TCPConnect     []*ArchivalTCPConnect            `json:"tcp_connect,omitempty"`
+	TLSHandshakes  []*ArchivalQUICTLSHandshakeEvent `json:"tls_handshakes,omitempty"`
+	QUICHandshakes []*ArchivalQUICTLSHandshakeEvent `json:"quic_handshakes,omitempty"`
+	Requests       []*ArchivalHTTPRoundTripEvent    `json:"requests,omitempty"`
+}
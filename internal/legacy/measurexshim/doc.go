@@ -0,0 +1,19 @@
+// Package measurexshim allows code written for [measurex] to run
+// on top of [measurexlite] while producing the same output.
+//
+// The [measurex] package is frozen and we want to delete it. Yet, some
+// experiments (e.g., tor) still produce data using its archival format, which
+// we cannot change without also changing the data format. This package
+// provides a drop-in replacement for the subset of the [measurex] API used
+// by such experiments. We collect [*Observations] using a [*measurexlite.Trace]
+// and we convert them to the legacy archival format. You can also convert
+// the [*Observations] to [minipipeline] observations for analysis.
+//
+// The golden files inside the testdata directory document the expected
+// archival output. We generated them using [measurex] and we use them to
+// make sure this package keeps producing the same output.
+//
+// [measurex]: https://pkg.go.dev/github.com/ooni/probe-cli/v3/internal/legacy/measurex
+// [measurexlite]: https://pkg.go.dev/github.com/ooni/probe-cli/v3/internal/measurexlite
+// [minipipeline]: https://pkg.go.dev/github.com/ooni/probe-cli/v3/internal/minipipeline
+package measurexshim
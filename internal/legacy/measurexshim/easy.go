@@ -0,0 +1,453 @@
+package measurexshim
+
+//
+// Easy
+//
+// API for reducing boilerplate for simple measurements.
+//
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/ooni/probe-cli/v3/internal/logx"
+	"github.com/ooni/probe-cli/v3/internal/measurexlite"
+	"github.com/ooni/probe-cli/v3/internal/model"
+	"github.com/ooni/probe-cli/v3/internal/netxlite"
+	"github.com/ooni/probe-cli/v3/internal/ptx"
+)
+
+// EasyHTTPRoundTripGET performs a GET with the given URL
+// and default headers. This function will perform just
+// a single HTTP round trip (i.e., no redirections).
+//
+// Arguments:
+//
+// - ctx is the context for deadline/timeout/cancellation;
+//
+// - timeout is the timeout for the whole operation;
+//
+// - URL is the URL to GET;
+//
+// Returns:
+//
+// - meas is a JSON serializable OONI measurement (this
+// field will never be a nil pointer);
+//
+// - failure is either nil or a pointer to a OONI failure.
+//
+// Note:
+//
+// - we reproduce a measurex QUIRK where the DNS lookups, the TCP connects
+// and the network events are saved twice because the resolver and the
+// dialer were wrapped twice, and TLS handshakes are not saved at all.
+func (mx *Measurer) EasyHTTPRoundTripGET(ctx context.Context, timeout time.Duration,
+	URL string) (meas *ArchivalMeasurement, failure *string) {
+	obs, failure := mx.ObserveHTTPRoundTripGET(ctx, timeout, URL)
+	meas = obs.AsArchivalMeasurement()
+	meas.NetworkEvents = easyDuplicateEach(meas.NetworkEvents)
+	meas.Queries = append(meas.Queries, meas.Queries...)
+	meas.TCPConnect = easyDuplicateEach(meas.TCPConnect)
+	meas.TLSHandshakes = nil
+	return meas, failure
+}
+
+// easyDuplicateEach returns a list where each entry of the input list appears twice in a row.
+func easyDuplicateEach[T any](in []T) (out []T) {
+	for _, entry := range in {
+		out = append(out, entry, entry)
+	}
+	return
+}
+
+// ObserveHTTPRoundTripGET is like EasyHTTPRoundTripGET but returns the
+// [*Observations], which you can also convert to [minipipeline] observations.
+//
+// [minipipeline]: https://pkg.go.dev/github.com/ooni/probe-cli/v3/internal/minipipeline
+func (mx *Measurer) ObserveHTTPRoundTripGET(ctx context.Context, timeout time.Duration,
+	URL string) (obs *Observations, failure *string) {
+	ctx, cancel := context.WithTimeout(ctx, timeout) // honour the timeout
+	defer cancel()
+	obs = &Observations{}
+	req, err := NewHTTPRequestWithContext(ctx, "GET", URL, nil)
+	if err != nil {
+		failure := err.Error()
+		return obs, &failure
+	}
+	trace := mx.newTrace()
+	dialer := netxlite.WrapDialer(mx.Logger, trace.NewStdlibResolver(mx.Logger),
+		&easyDialerWrapper{trace.NewDialerWithoutResolver(mx.Logger), trace})
+	tlsDialer := netxlite.NewTLSDialer(dialer, trace.NewTLSHandshakerStdlib(mx.Logger))
+	txp := netxlite.NewHTTPTransport(mx.Logger, dialer, tlsDialer)
+	defer txp.CloseIdleConnections()
+	started := trace.TimeSince(trace.ZeroTime())
+	resp, body, err := mx.easyHTTPRoundTrip(txp, req)
+	finished := trace.TimeSince(trace.ZeroTime())
+	obs.CollectTrace(trace)
+	obs.Requests = append(obs.Requests, measurexlite.NewArchivalHTTPRequestResult(
+		trace.Index(), started, "tcp", "", "", "tcp", req, resp,
+		mx.httpMaxBodySnapshotSize(), body, err, finished))
+	if err != nil {
+		failure := err.Error()
+		return obs, &failure
+	}
+	return obs, nil
+}
+
+// easyHTTPRoundTrip performs the round trip and reads a snapshot of the body.
+func (mx *Measurer) easyHTTPRoundTrip(
+	txp model.HTTPTransport, req *http.Request) (*http.Response, []byte, error) {
+	resp, err := txp.RoundTrip(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	r := io.LimitReader(resp.Body, mx.httpMaxBodySnapshotSize())
+	body, err := netxlite.ReadAllContext(req.Context(), r)
+	if err != nil {
+		return resp, nil, err
+	}
+	return resp, body, nil
+}
+
+// easyDialerWrapper wraps the connections created by a dialer
+// using a [*measurexlite.Trace] such that we observe network events.
+type easyDialerWrapper struct {
+	model.Dialer
+	trace *measurexlite.Trace
+}
+
+// DialContext implements model.Dialer.
+func (d *easyDialerWrapper) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	conn, err := d.Dialer.DialContext(ctx, network, address)
+	if err != nil {
+		return nil, err
+	}
+	return d.trace.MaybeWrapNetConn(conn), nil
+}
+
+// NewHTTPRequestHeaderForMeasuring returns an http.Header where
+// the headers are the ones we use for measuring.
+func NewHTTPRequestHeaderForMeasuring() http.Header {
+	h := http.Header{}
+	h.Set("Accept", model.HTTPHeaderAccept)
+	h.Set("Accept-Language", model.HTTPHeaderAcceptLanguage)
+	h.Set("User-Agent", model.HTTPHeaderUserAgent)
+	return h
+}
+
+// NewHTTPRequestWithContext is a convenience factory for creating
+// a new HTTP request with the typical headers we use when performing
+// measurements already set inside of req.Header.
+func NewHTTPRequestWithContext(ctx context.Context,
+	method, URL string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, URL, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header = NewHTTPRequestHeaderForMeasuring()
+	return req, nil
+}
+
+// EasyTLSConfig helps you to generate a *tls.Config.
+type EasyTLSConfig struct {
+	config *tls.Config
+}
+
+// NewEasyTLSConfig creates a new EasyTLSConfig instance.
+func NewEasyTLSConfig() *EasyTLSConfig {
+	return &EasyTLSConfig{
+		config: &tls.Config{ // #nosec G402 - we need to use a large TLS versions range for measuring
+			// Because here we use nil, this causes netxlite to use
+			// a cached copy of Mozilla's CA pool.
+			RootCAs: nil,
+		},
+	}
+}
+
+// NewEasyTLSConfigWithServerName creates a new EasyTLSConfig
+// with an already configured value for ServerName.
+func NewEasyTLSConfigWithServerName(serverName string) *EasyTLSConfig {
+	return NewEasyTLSConfig().ServerName(serverName)
+}
+
+// ServerName sets the SNI value.
+func (easy *EasyTLSConfig) ServerName(v string) *EasyTLSConfig {
+	easy.config.ServerName = v
+	return easy
+}
+
+// InsecureSkipVerify disables TLS verification.
+func (easy *EasyTLSConfig) InsecureSkipVerify(v bool) *EasyTLSConfig {
+	easy.config.InsecureSkipVerify = v
+	return easy
+}
+
+// RootCAs allows the set the CA pool.
+func (easy *EasyTLSConfig) RootCAs(v *x509.CertPool) *EasyTLSConfig {
+	easy.config.RootCAs = v
+	return easy
+}
+
+// asTLSConfig converts an *EasyTLSConfig to a *tls.Config.
+func (easy *EasyTLSConfig) asTLSConfig() *tls.Config {
+	if easy == nil || easy.config == nil {
+		return &tls.Config{} // #nosec G402 - we need to use a large TLS versions range for measuring
+	}
+	return easy.config
+}
+
+// EasyTLSConnectAndHandshake performs a TCP connect to a TCP endpoint
+// followed by a TLS handshake using the given config.
+//
+// Arguments:
+//
+// - ctx is the context for deadline/timeout/cancellation;
+//
+// - endpoint is the TCP endpoint to connect to (e.g.,
+// 8.8.8.8:443 where the address part of the endpoint MUST
+// be an IPv4 or IPv6 address and MUST NOT be a domain);
+//
+// - tlsConfig is the EasyTLSConfig to use (MUST NOT be nil).
+//
+// Returns:
+//
+// - meas is a JSON serializable OONI measurement (this
+// field will never be a nil pointer);
+//
+// - failure is either nil or a pointer to a OONI failure.
+//
+// Note:
+//
+// - we use the Measurer's TCPConnectTimeout and TLSHandshakeTimeout.
+func (mx *Measurer) EasyTLSConnectAndHandshake(ctx context.Context, endpoint string,
+	tlsConfig *EasyTLSConfig) (meas *ArchivalMeasurement, failure *string) {
+	obs, failure := mx.ObserveTLSConnectAndHandshake(ctx, endpoint, tlsConfig)
+	return obs.AsArchivalMeasurement(), failure
+}
+
+// ObserveTLSConnectAndHandshake is like EasyTLSConnectAndHandshake but returns the
+// [*Observations], which you can also convert to [minipipeline] observations.
+//
+// [minipipeline]: https://pkg.go.dev/github.com/ooni/probe-cli/v3/internal/minipipeline
+func (mx *Measurer) ObserveTLSConnectAndHandshake(ctx context.Context, endpoint string,
+	tlsConfig *EasyTLSConfig) (obs *Observations, failure *string) {
+	obs = &Observations{}
+	trace := mx.newTrace()
+	conn, err := mx.tcpConnect(ctx, trace, endpoint)
+	if err != nil {
+		obs.CollectTrace(trace)
+		failure := err.Error()
+		return obs, &failure
+	}
+	config := tlsConfig.asTLSConfig()
+	obs.saveNextProtos(trace.Index(), config.NextProtos)
+	tlsConn, err := mx.tlsHandshake(ctx, trace, conn, endpoint, config)
+	if err != nil {
+		conn.Close()
+		obs.CollectTrace(trace)
+		failure := err.Error()
+		return obs, &failure
+	}
+	_ = tlsConn.Close()
+	obs.CollectTrace(trace)
+	return obs, nil
+}
+
+// EasyTCPConnect performs a TCP connect to a TCP endpoint.
+//
+// Arguments:
+//
+// - ctx is the context for deadline/timeout/cancellation;
+//
+// - endpoint is the TCP endpoint to connect to (e.g.,
+// 8.8.8.8:443 where the address part of the endpoint MUST
+// be an IPv4 or IPv6 address and MUST NOT be a domain).
+//
+// Returns:
+//
+// - meas is a JSON serializable OONI measurement (this
+// field will never be a nil pointer);
+//
+// - failure is either nil or a pointer to a OONI failure.
+//
+// Note:
+//
+// - we use the Measurer's TCPConnectTimeout.
+func (mx *Measurer) EasyTCPConnect(ctx context.Context,
+	endpoint string) (meas *ArchivalMeasurement, failure *string) {
+	obs, failure := mx.ObserveTCPConnect(ctx, endpoint)
+	return obs.AsArchivalMeasurement(), failure
+}
+
+// ObserveTCPConnect is like EasyTCPConnect but returns the [*Observations],
+// which you can also convert to [minipipeline] observations.
+//
+// [minipipeline]: https://pkg.go.dev/github.com/ooni/probe-cli/v3/internal/minipipeline
+func (mx *Measurer) ObserveTCPConnect(ctx context.Context,
+	endpoint string) (obs *Observations, failure *string) {
+	obs = &Observations{}
+	trace := mx.newTrace()
+	conn, err := mx.tcpConnect(ctx, trace, endpoint)
+	if err != nil {
+		obs.CollectTrace(trace)
+		failure := err.Error()
+		return obs, &failure
+	}
+	_ = conn.Close()
+	obs.CollectTrace(trace)
+	return obs, nil
+}
+
+// easyOBFS4Params contains params for OBFS4.
+type easyOBFS4Params struct {
+	// Cert contains the MANDATORY certificate parameter.
+	Cert string
+
+	// DataDir is the MANDATORY directory where to store obfs4 data.
+	DataDir string
+
+	// Fingerprint is the MANDATORY bridge fingerprint.
+	Fingerprint string
+
+	// IATMode contains the MANDATORY iat-mode parameter.
+	IATMode string
+}
+
+// newEasyOBFS4Params constructs an EasyOBFS4Params structure
+// from the map[string][]string returned by the OONI API.
+//
+// This function will only fail when the rawParams contains
+// more than one entry for each input key.
+func newEasyOBFS4Params(dataDir string, rawParams map[string][]string) (*easyOBFS4Params, error) {
+	out := &easyOBFS4Params{DataDir: dataDir}
+	for key, values := range rawParams {
+		var field *string
+		switch key {
+		case "cert":
+			field = &out.Cert
+		case "fingerprint":
+			field = &out.Fingerprint
+		case "iat-mode":
+			field = &out.IATMode
+		default:
+			continue // not interested
+		}
+		if len(values) != 1 {
+			return nil, fmt.Errorf("obfs4: expected exactly one value for %s", key)
+		}
+		*field = values[0]
+	}
+	// Assume that the API knows what it's returning, so don't bother
+	// checking whether some fields are missing. If this happens, it
+	// will be the obfs4 library task to tell us about that.
+	return out, nil
+}
+
+// EasyOBFS4ConnectAndHandshake performs a TCP connect to a TCP endpoint
+// followed by an OBFS4 handshake. This function is designed to receive
+// in input the Tor bridges from the OONI API.
+//
+// Arguments:
+//
+// - ctx is the context for deadline/timeout/cancellation;
+//
+// - timeout is the timeout for the whole operation;
+//
+// - endpoint is the TCP endpoint to connect to (e.g.,
+// 8.8.8.8:443 where the address part of the endpoint MUST
+// be an IPv4 or IPv6 address and MUST NOT be a domain);
+//
+// - dataDir is the data directory to use for obfs4;
+//
+// - rawParams contains raw obfs4 params from the OONI API.
+//
+// Returns:
+//
+// - meas is a JSON serializable OONI measurement (this
+// field will never be a nil pointer);
+//
+// - failure is either nil or a pointer to a OONI failure.
+func (mx *Measurer) EasyOBFS4ConnectAndHandshake(ctx context.Context,
+	timeout time.Duration, endpoint string, dataDir string,
+	rawParams map[string][]string) (meas *ArchivalMeasurement, failure *string) {
+	obs, failure := mx.ObserveOBFS4ConnectAndHandshake(ctx, timeout, endpoint, dataDir, rawParams)
+	return obs.AsArchivalMeasurement(), failure
+}
+
+// ObserveOBFS4ConnectAndHandshake is like EasyOBFS4ConnectAndHandshake but returns
+// the [*Observations], which you can also convert to [minipipeline] observations.
+//
+// [minipipeline]: https://pkg.go.dev/github.com/ooni/probe-cli/v3/internal/minipipeline
+func (mx *Measurer) ObserveOBFS4ConnectAndHandshake(ctx context.Context,
+	timeout time.Duration, endpoint string, dataDir string,
+	rawParams map[string][]string) (obs *Observations, failure *string) {
+	ctx, cancel := context.WithTimeout(ctx, timeout) // honour the timeout
+	defer cancel()
+	obs = &Observations{}
+	params, err := newEasyOBFS4Params(dataDir, rawParams)
+	if err != nil {
+		failure := err.Error()
+		return obs, &failure
+	}
+	trace := mx.newTrace()
+	conn, err := mx.tcpConnect(ctx, trace, endpoint)
+	if err != nil {
+		obs.CollectTrace(trace)
+		failure := err.Error()
+		return obs, &failure
+	}
+	defer conn.Close()
+	dialer := netxlite.NewSingleUseDialer(conn)
+	obfs4 := ptx.OBFS4Dialer{
+		Address:          endpoint,
+		Cert:             params.Cert,
+		DataDir:          params.DataDir,
+		Fingerprint:      params.Fingerprint,
+		IATMode:          params.IATMode,
+		UnderlyingDialer: dialer,
+	}
+	o4conn, err := obfs4.DialContext(ctx)
+	if err != nil {
+		obs.CollectTrace(trace)
+		failure := err.Error()
+		return obs, &failure
+	}
+	_ = o4conn.Close()
+	obs.CollectTrace(trace)
+	return obs, nil
+}
+
+// tcpConnect connects to the given endpoint using the given trace.
+func (mx *Measurer) tcpConnect(
+	ctx context.Context, trace *measurexlite.Trace, address string) (net.Conn, error) {
+	ol := logx.NewOperationLogger(mx.Logger, "TCPConnect %s", address)
+	ctx, cancel := context.WithTimeout(ctx, mx.tcpConnectTimeout())
+	defer cancel()
+	dialer := trace.NewDialerWithoutResolver(mx.Logger)
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	ol.Stop(err)
+	if err != nil {
+		return nil, err
+	}
+	return trace.MaybeWrapNetConn(conn), nil
+}
+
+// tlsHandshake performs a TLS handshake using the given trace.
+func (mx *Measurer) tlsHandshake(ctx context.Context, trace *measurexlite.Trace,
+	conn net.Conn, address string, config *tls.Config) (model.TLSConn, error) {
+	ol := logx.NewOperationLogger(mx.Logger,
+		"TLSHandshake %s with sni=%s", address, config.ServerName)
+	ctx, cancel := context.WithTimeout(ctx, mx.tlsHandshakeTimeout())
+	defer cancel()
+	thx := trace.NewTLSHandshakerStdlib(mx.Logger)
+	tlsConn, err := thx.Handshake(ctx, conn, config)
+	ol.Stop(err)
+	return tlsConn, err
+}
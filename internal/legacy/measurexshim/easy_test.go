@@ -0,0 +1,246 @@
+package measurexshim
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/ooni/netem"
+	"github.com/ooni/probe-cli/v3/internal/geoipx"
+	"github.com/ooni/probe-cli/v3/internal/model"
+	"github.com/ooni/probe-cli/v3/internal/must"
+	"github.com/ooni/probe-cli/v3/internal/netemx"
+)
+
+// updateGolden allows to regenerate the golden files using measurex.
+var updateGolden = flag.Bool("update", false, "regenerate golden files using measurex")
+
+// goldenTestCase is a test case comparing the output with a golden file.
+type goldenTestCase struct {
+	// name is the name of the test case and of the golden file.
+	name string
+
+	// operation is one of "http_get", "tls", and "tcp".
+	operation string
+
+	// target is the URL or the endpoint to measure.
+	target string
+
+	// sni is the OPTIONAL SNI to use for "tls".
+	sni string
+
+	// skipVerify OPTIONALLY disables TLS verification for "tls".
+	skipVerify bool
+
+	// configure OPTIONALLY configures the scenario.
+	configure func(env *netemx.QAEnv)
+}
+
+// goldenTestCases contains the test cases for which we have golden files.
+var goldenTestCases = []goldenTestCase{{
+	name:      "http_get_with_ip_address",
+	operation: "http_get",
+	target:    "http://93.184.216.34/",
+}, {
+	name:      "http_get_with_domain",
+	operation: "http_get",
+	target:    "https://www.example.com/",
+}, {
+	name:      "http_get_with_nxdomain",
+	operation: "http_get",
+	target:    "https://www.nonexistent.example.com/",
+}, {
+	name:      "tls_success",
+	operation: "tls",
+	target:    "93.184.216.34:443",
+	sni:       "www.example.com",
+}, {
+	name:       "tls_with_skip_verify",
+	operation:  "tls",
+	target:     "93.184.216.34:443",
+	skipVerify: true,
+}, {
+	name:      "tls_with_invalid_hostname",
+	operation: "tls",
+	target:    "93.184.216.34:443",
+	sni:       "www.nonexistent.example.com",
+}, {
+	name:      "tls_with_reset",
+	operation: "tls",
+	target:    "93.184.216.34:443",
+	sni:       "www.example.com",
+	configure: func(env *netemx.QAEnv) {
+		env.DPIEngine().AddRule(&netem.DPIResetTrafficForTLSSNI{
+			Logger: model.DiscardLogger,
+			SNI:    "www.example.com",
+		})
+	},
+}, {
+	name:      "tcp_success",
+	operation: "tcp",
+	target:    "93.184.216.34:443",
+}, {
+	name:      "tcp_with_connection_refused",
+	operation: "tcp",
+	target:    "93.184.216.34:81",
+}}
+
+// goldenResult is the content of a golden file.
+type goldenResult struct {
+	Measurement any
+	Failure     *string
+}
+
+// goldenRun runs the test case inside a fresh scenario using the given function
+// and returns the normalized result, which we can compare with the golden file.
+func goldenRun(tc goldenTestCase, measure func() (any, *string)) []byte {
+	env := netemx.MustNewScenario(netemx.InternetScenario)
+	defer env.Close()
+	if tc.configure != nil {
+		tc.configure(env)
+	}
+	var result goldenResult
+	env.Do(func() {
+		result.Measurement, result.Failure = measure()
+	})
+	var generic map[string]any
+	must.UnmarshalJSON(must.MarshalJSON(result.Measurement), &generic)
+	result.Measurement = goldenNormalize(generic)
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		panic(err)
+	}
+	return append(data, '\n')
+}
+
+// goldenNormalize removes the fields that change across runs.
+func goldenNormalize(meas map[string]any) map[string]any {
+	for _, key := range []string{"network_events", "queries", "tcp_connect", "tls_handshakes", "requests"} {
+		entries, _ := meas[key].([]any)
+		for _, entry := range entries {
+			ev := entry.(map[string]any)
+			ev["started"] = 0.0
+			ev["t"] = 0.0
+			certs, _ := ev["peer_certificates"].([]any)
+			for _, cert := range certs {
+				cert.(map[string]any)["data"] = ""
+			}
+			if response, found := ev["response"].(map[string]any); found {
+				delete(response["headers"].(map[string]any), "date")
+			}
+		}
+	}
+	if events, found := meas["network_events"]; found {
+		meas["network_events"] = goldenNormalizeNetworkEvents(events)
+	}
+	return meas
+}
+
+// goldenNormalizeNetworkEvents merges consecutive I/O events that only differ
+// in the number of bytes, because how reads are split depends on timing.
+func goldenNormalizeNetworkEvents(input any) any {
+	entries, _ := input.([]any)
+	var out []any
+	for _, entry := range entries {
+		ev := entry.(map[string]any)
+		if len(out) > 0 {
+			prev := out[len(out)-1].(map[string]any)
+			if prev["operation"] == ev["operation"] && prev["address"] == ev["address"] &&
+				prev["proto"] == ev["proto"] && prev["failure"] == ev["failure"] {
+				continue
+			}
+		}
+		delete(ev, "num_bytes")
+		out = append(out, ev)
+	}
+	return out
+}
+
+// goldenPath returns the path of the golden file for the test case.
+func goldenPath(tc goldenTestCase) string {
+	return filepath.Join("testdata", tc.name+".json")
+}
+
+// goldenCompare compares the result with the golden file.
+func goldenCompare(t *testing.T, tc goldenTestCase, got []byte) {
+	expect := must.ReadFile(goldenPath(tc))
+	if diff := cmp.Diff(string(expect), string(got)); diff != "" {
+		t.Fatal(diff)
+	}
+}
+
+func TestGolden(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skip test in short mode")
+	}
+	for _, tc := range goldenTestCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := goldenRun(tc, func() (any, *string) {
+				mx := NewMeasurerWithDefaultSettings()
+				mx.Logger = model.DiscardLogger
+				ctx := context.Background()
+				switch tc.operation {
+				case "http_get":
+					return mx.EasyHTTPRoundTripGET(ctx, 10*time.Second, tc.target)
+				case "tls":
+					config := NewEasyTLSConfigWithServerName(tc.sni).InsecureSkipVerify(tc.skipVerify)
+					return mx.EasyTLSConnectAndHandshake(ctx, tc.target, config)
+				default:
+					return mx.EasyTCPConnect(ctx, tc.target)
+				}
+			})
+			goldenCompare(t, tc, got)
+		})
+	}
+}
+
+func TestObservations(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skip test in short mode")
+	}
+
+	t.Run("AsWebObservations", func(t *testing.T) {
+		env := netemx.MustNewScenario(netemx.InternetScenario)
+		defer env.Close()
+		env.Do(func() {
+			mx := NewMeasurerWithDefaultSettings()
+			mx.Logger = model.DiscardLogger
+			obs, failure := mx.ObserveHTTPRoundTripGET(
+				context.Background(), 10*time.Second, "https://www.example.com/")
+			if failure != nil {
+				t.Fatal(*failure)
+			}
+			container := obs.AsWebObservations(model.GeoIPASNLookupperFunc(geoipx.LookupASN))
+			if len(container.DNSLookupSuccesses) != 1 {
+				t.Fatal("expected one DNS lookup success")
+			}
+			if len(container.KnownTCPEndpoints) != 1 {
+				t.Fatal("expected one TCP endpoint")
+			}
+			for _, wobs := range container.KnownTCPEndpoints {
+				if wobs.IPAddress.Unwrap() != "93.184.216.34" {
+					t.Fatal("unexpected IP address", wobs.IPAddress.Unwrap())
+				}
+				if wobs.TLSHandshakeFailure.Unwrap() != "" {
+					t.Fatal("unexpected TLS handshake failure")
+				}
+			}
+		})
+	})
+
+	t.Run("the TLS handshake ALPN is preserved", func(t *testing.T) {
+		obs := &Observations{}
+		obs.saveNextProtos(7, []string{"h2", "http/1.1"})
+		obs.TLSHandshakes = append(obs.TLSHandshakes, &model.ArchivalTLSOrQUICHandshakeResult{
+			TransactionID: 7,
+		})
+		meas := obs.AsArchivalMeasurement()
+		if diff := cmp.Diff([]string{"h2", "http/1.1"}, meas.TLSHandshakes[0].ALPN); diff != "" {
+			t.Fatal(diff)
+		}
+	})
+}
@@ -0,0 +1,45 @@
+package measurexshim
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/ooni/probe-cli/v3/internal/legacy/measurex"
+	"github.com/ooni/probe-cli/v3/internal/model"
+)
+
+// TestLegacyGolden checks that measurex produces the output inside the golden
+// files, or regenerates them when running with `-update`. We will remove this
+// test along with measurex, while keeping the golden files.
+func TestLegacyGolden(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skip test in short mode")
+	}
+	for _, tc := range goldenTestCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := goldenRun(tc, func() (any, *string) {
+				mx := measurex.NewMeasurerWithDefaultSettings()
+				mx.Logger = model.DiscardLogger
+				ctx := context.Background()
+				switch tc.operation {
+				case "http_get":
+					return mx.EasyHTTPRoundTripGET(ctx, 10*time.Second, tc.target)
+				case "tls":
+					config := measurex.NewEasyTLSConfigWithServerName(tc.sni).InsecureSkipVerify(tc.skipVerify)
+					return mx.EasyTLSConnectAndHandshake(ctx, tc.target, config)
+				default:
+					return mx.EasyTCPConnect(ctx, tc.target)
+				}
+			})
+			if *updateGolden {
+				if err := os.WriteFile(goldenPath(tc), got, 0600); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			goldenCompare(t, tc, got)
+		})
+	}
+}
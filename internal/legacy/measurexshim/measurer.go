@@ -0,0 +1,96 @@
+package measurexshim
+
+//
+// Measurer
+//
+// Measurer settings and factories.
+//
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/ooni/probe-cli/v3/internal/measurexlite"
+	"github.com/ooni/probe-cli/v3/internal/model"
+)
+
+// Measurer performs measurements. If you don't use a factory
+// for creating this type, make sure you set all the MANDATORY fields.
+type Measurer struct {
+	// Begin is when we started measuring (this field is MANDATORY).
+	Begin time.Time
+
+	// HTTPMaxBodySnapshotSize is the OPTIONAL maximum size,
+	// in bytes, of the response body snapshot we save. If this field
+	// is zero or negative, we'll use a small default value.
+	HTTPMaxBodySnapshotSize int64
+
+	// Logger is the MANDATORY logger to use.
+	Logger model.Logger
+
+	// TCPConnectTimeout is the OPTIONAL timeout for performing
+	// a tcp connect. If not set, we use a default value.
+	TCPConnectTimeout time.Duration
+
+	// TLSHandshakeTimeout is the OPTIONAL timeout for performing
+	// a tls handshake. If not set, we use a default value.
+	TLSHandshakeTimeout time.Duration
+
+	// idGenerator generates unique transaction IDs.
+	idGenerator atomic.Int64
+}
+
+// NewMeasurerWithDefaultSettings creates a new Measurer
+// instance using the most default settings.
+func NewMeasurerWithDefaultSettings() *Measurer {
+	return &Measurer{
+		Begin:                   time.Now(),
+		HTTPMaxBodySnapshotSize: 0,
+		Logger:                  log.Log,
+		TCPConnectTimeout:       0,
+		TLSHandshakeTimeout:     0,
+		idGenerator:             atomic.Int64{},
+	}
+}
+
+// DefaultHTTPMaxBodySnapshotSize is the default size used when
+// saving HTTP body snapshots. We only save a small snapshot of the
+// body to keep measurements lean, since we're mostly interested
+// in TLS interference nowadays and much less in full bodies.
+const DefaultHTTPMaxBodySnapshotSize = 1 << 11
+
+// httpMaxBodySnapshotSize selects the maximum body snapshot size.
+func (mx *Measurer) httpMaxBodySnapshotSize() int64 {
+	if mx.HTTPMaxBodySnapshotSize > 0 {
+		return mx.HTTPMaxBodySnapshotSize
+	}
+	return DefaultHTTPMaxBodySnapshotSize
+}
+
+// DefaultTCPConnectTimeout is the default TCP connect timeout.
+const DefaultTCPConnectTimeout = 15 * time.Second
+
+// tcpConnectTimeout selects the TCP connect timeout.
+func (mx *Measurer) tcpConnectTimeout() time.Duration {
+	if mx.TCPConnectTimeout > 0 {
+		return mx.TCPConnectTimeout
+	}
+	return DefaultTCPConnectTimeout
+}
+
+// DefaultTLSHandshakeTimeout is the default TLS handshake timeout.
+const DefaultTLSHandshakeTimeout = 10 * time.Second
+
+// tlsHandshakeTimeout selects the TLS handshake timeout.
+func (mx *Measurer) tlsHandshakeTimeout() time.Duration {
+	if mx.TLSHandshakeTimeout > 0 {
+		return mx.TLSHandshakeTimeout
+	}
+	return DefaultTLSHandshakeTimeout
+}
+
+// newTrace creates a new [*measurexlite.Trace] with a unique transaction ID.
+func (mx *Measurer) newTrace() *measurexlite.Trace {
+	return measurexlite.NewTrace(mx.idGenerator.Add(1), mx.Begin)
+}
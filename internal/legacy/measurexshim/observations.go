@@ -0,0 +1,90 @@
+package measurexshim
+
+//
+// Observations
+//
+// This file defines the container for measurexlite observations.
+//
+
+import (
+	"github.com/ooni/probe-cli/v3/internal/measurexlite"
+	"github.com/ooni/probe-cli/v3/internal/minipipeline"
+	"github.com/ooni/probe-cli/v3/internal/model"
+)
+
+// Observations contains observations collected using [*measurexlite.Trace].
+//
+// The zero value is ready to use. This struct is not goroutine safe.
+type Observations struct {
+	// NetworkEvents contains network events.
+	NetworkEvents []*model.ArchivalNetworkEvent
+
+	// Queries contains DNS lookups.
+	Queries []*model.ArchivalDNSLookupResult
+
+	// TCPConnect contains TCP connect results.
+	TCPConnect []*model.ArchivalTCPConnectResult
+
+	// TLSHandshakes contains TLS handshake results.
+	TLSHandshakes []*model.ArchivalTLSOrQUICHandshakeResult
+
+	// QUICHandshakes contains QUIC handshake results.
+	QUICHandshakes []*model.ArchivalTLSOrQUICHandshakeResult
+
+	// Requests contains HTTP round trips.
+	Requests []*model.ArchivalHTTPRequestResult
+
+	// NextProtos maps the transaction ID of each TLS or QUIC handshake
+	// to the ALPN we offered, which is part of the legacy archival
+	// format but is not part of the measurexlite archival format.
+	NextProtos map[int64][]string
+}
+
+// CollectTrace drains the observations buffered inside the given trace.
+func (obs *Observations) CollectTrace(trace *measurexlite.Trace) {
+	obs.NetworkEvents = append(obs.NetworkEvents, trace.NetworkEvents()...)
+	obs.Queries = append(obs.Queries, trace.DNSLookupsFromRoundTrip()...)
+	obs.TCPConnect = append(obs.TCPConnect, trace.TCPConnects()...)
+	obs.TLSHandshakes = append(obs.TLSHandshakes, trace.TLSHandshakes()...)
+	obs.QUICHandshakes = append(obs.QUICHandshakes, trace.QUICHandshakes()...)
+}
+
+// saveNextProtos remembers the ALPN offered by the handshake with the given transaction ID.
+func (obs *Observations) saveNextProtos(index int64, nextProtos []string) {
+	if obs.NextProtos == nil {
+		obs.NextProtos = map[int64][]string{}
+	}
+	obs.NextProtos[index] = nextProtos
+}
+
+// AsArchivalMeasurement converts the observations to the legacy archival format.
+func (obs *Observations) AsArchivalMeasurement() *ArchivalMeasurement {
+	out := &ArchivalMeasurement{
+		NetworkEvents:  NewArchivalNetworkEventList(obs.NetworkEvents),
+		Queries:        NewArchivalDNSLookupEventList(obs.Queries),
+		TCPConnect:     NewArchivalTCPConnectList(obs.TCPConnect),
+		TLSHandshakes:  nil, // done below
+		QUICHandshakes: nil, // done below
+		Requests:       NewArchivalHTTPRoundTripEventList(obs.Requests),
+	}
+	for _, ev := range obs.TLSHandshakes {
+		out.TLSHandshakes = append(out.TLSHandshakes, NewArchivalQUICTLSHandshakeEvent(
+			ev, obs.NextProtos[ev.TransactionID], newTLSHandshakeOddity))
+	}
+	for _, ev := range obs.QUICHandshakes {
+		out.QUICHandshakes = append(out.QUICHandshakes, NewArchivalQUICTLSHandshakeEvent(
+			ev, obs.NextProtos[ev.TransactionID], newQUICHandshakeOddity))
+	}
+	return out
+}
+
+// AsWebObservations converts the observations to [minipipeline] web observations.
+func (obs *Observations) AsWebObservations(
+	lookupper model.GeoIPASNLookupper) *minipipeline.WebObservationsContainer {
+	container := minipipeline.NewWebObservationsContainer()
+	container.IngestDNSLookupEvents(lookupper, obs.Queries...)
+	container.IngestTCPConnectEvents(lookupper, obs.TCPConnect...)
+	container.IngestTLSHandshakeEvents(obs.TLSHandshakes...)
+	container.IngestHTTPRoundTripEvents(obs.Requests...)
+	return container
+}
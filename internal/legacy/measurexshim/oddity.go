@@ -0,0 +1,142 @@
+package measurexshim
+
+//
+// Oddity
+//
+// Here we define the oddity type.
+//
+
+import "github.com/ooni/probe-cli/v3/internal/netxlite"
+
+// Oddity is an unexpected result on the probe or
+// or test helper side during a measurement. We will
+// promote the oddity to anomaly if the probe and
+// the test helper see different results.
+type Oddity string
+
+// This enumeration lists all known oddities.
+var (
+	// tcp.connect
+	OddityTCPConnectTimeout         = Oddity("tcp.connect.timeout")
+	OddityTCPConnectRefused         = Oddity("tcp.connect.refused")
+	OddityTCPConnectHostUnreachable = Oddity("tcp.connect.host_unreachable")
+	OddityTCPConnectOher            = Oddity("tcp.connect.other")
+
+	// tls.handshake
+	OddityTLSHandshakeTimeout          = Oddity("tls.handshake.timeout")
+	OddityTLSHandshakeReset            = Oddity("tls.handshake.reset")
+	OddityTLSHandshakeOther            = Oddity("tls.handshake.other")
+	OddityTLSHandshakeUnexpectedEOF    = Oddity("tls.handshake.unexpected_eof")
+	OddityTLSHandshakeInvalidHostname  = Oddity("tls.handshake.invalid_hostname")
+	OddityTLSHandshakeUnknownAuthority = Oddity("tls.handshake.unknown_authority")
+
+	// quic.handshake
+	OddityQUICHandshakeTimeout         = Oddity("quic.handshake.timeout")
+	OddityQUICHandshakeHostUnreachable = Oddity("quic.handshake.host_unreachable")
+	OddityQUICHandshakeOther           = Oddity("quic.handshake.other")
+
+	// dns.lookup
+	OddityDNSLookupNXDOMAIN = Oddity("dns.lookup.nxdomain")
+	OddityDNSLookupTimeout  = Oddity("dns.lookup.timeout")
+	OddityDNSLookupRefused  = Oddity("dns.lookup.refused")
+	OddityDNSLookupBogon    = Oddity("dns.lookup.bogon")
+	OddityDNSLookupOther    = Oddity("dns.lookup.other")
+
+	// http.status
+	OddityStatus403   = Oddity("http.status.403")
+	OddityStatus404   = Oddity("http.status.404")
+	OddityStatus503   = Oddity("http.status.503")
+	OddityStatusOther = Oddity("http.status.other")
+)
+
+// newTCPConnectOddity computes the oddity of a TCP connect.
+func newTCPConnectOddity(failure *string) Oddity {
+	if failure == nil {
+		return ""
+	}
+	switch *failure {
+	case netxlite.FailureGenericTimeoutError:
+		return OddityTCPConnectTimeout
+	case netxlite.FailureConnectionRefused:
+		return OddityTCPConnectRefused
+	case netxlite.FailureHostUnreachable:
+		return OddityTCPConnectHostUnreachable
+	default:
+		return OddityTCPConnectOher
+	}
+}
+
+// newTLSHandshakeOddity computes the oddity of a TLS handshake.
+func newTLSHandshakeOddity(failure *string) Oddity {
+	if failure == nil {
+		return ""
+	}
+	switch *failure {
+	case netxlite.FailureGenericTimeoutError:
+		return OddityTLSHandshakeTimeout
+	case netxlite.FailureConnectionReset:
+		return OddityTLSHandshakeReset
+	case netxlite.FailureEOFError:
+		return OddityTLSHandshakeUnexpectedEOF
+	case netxlite.FailureSSLInvalidHostname:
+		return OddityTLSHandshakeInvalidHostname
+	case netxlite.FailureSSLUnknownAuthority:
+		return OddityTLSHandshakeUnknownAuthority
+	default:
+		return OddityTLSHandshakeOther
+	}
+}
+
+// newQUICHandshakeOddity computes the oddity of a QUIC handshake.
+func newQUICHandshakeOddity(failure *string) Oddity {
+	if failure == nil {
+		return ""
+	}
+	switch *failure {
+	case netxlite.FailureGenericTimeoutError:
+		return OddityQUICHandshakeTimeout
+	case netxlite.FailureHostUnreachable:
+		return OddityQUICHandshakeHostUnreachable
+	default:
+		return OddityQUICHandshakeOther
+	}
+}
+
+// newDNSLookupHostOddity computes the oddity of a DNS lookup
+// given the failure and the addresses of the query type.
+func newDNSLookupHostOddity(failure *string, addrs []string) Oddity {
+	if failure != nil {
+		switch *failure {
+		case netxlite.FailureGenericTimeoutError:
+			return OddityDNSLookupTimeout
+		case netxlite.FailureDNSNXDOMAINError:
+			return OddityDNSLookupNXDOMAIN
+		case netxlite.FailureDNSRefusedError:
+			return OddityDNSLookupRefused
+		default:
+			return OddityDNSLookupOther
+		}
+	}
+	for _, addr := range addrs {
+		if netxlite.IsBogon(addr) {
+			return OddityDNSLookupBogon
+		}
+	}
+	return ""
+}
+
+// newHTTPStatusOddity computes the oddity of an HTTP status code.
+func newHTTPStatusOddity(code int64) Oddity {
+	switch {
+	case code == 403:
+		return OddityStatus403
+	case code == 404:
+		return OddityStatus404
+	case code == 503:
+		return OddityStatus503
+	case code >= 400:
+		return OddityStatusOther
+	default:
+		return ""
+	}
+}
@@ -33,6 +33,15 @@ import (
 // - if the URL starts with `udp://`, then we create a client using
 // a resolver that uses the specified UDP endpoint.
 //
+// - if the URL starts with `quic://`, then we create a DNS-over-QUIC
+// client using the specified QUIC endpoint.
+//
+// - if the URL starts with `odoh://`, then we create an Oblivious DoH
+// client using the `https://` URL obtained by replacing the scheme, which
+// identifies the proxy, and its targethost and targetpath query parameters,
+// which identify the target (e.g., odoh://proxy.example/proxy?targethost=
+// odoh.cloudflare-dns.com&targetpath=/dns-query).
+//
 // We return error if the URL does not parse or the URL scheme does not
 // fall into one of the cases described above.
 //
@@ -74,6 +83,20 @@ func NewDNSClientWithOverrides(config Config, URL, hostOverride, SNIOverride,
 			httpClient, URL, hostOverride)
 		txp = config.Saver.WrapDNSTransport(txp) // safe when config.Saver == nil
 		return netxlite.NewUnwrappedSerialResolver(txp), nil
+	case "odoh":
+		// the host and SNI overrides only apply to the proxy, so we use a
+		// distinct client to fetch the target configs
+		config.TLSConfig.NextProtos = []string{"h2", "http/1.1"}
+		targetConfig := config
+		targetConfig.TLSConfig = config.TLSConfig.Clone()
+		targetConfig.TLSConfig.ServerName = ""
+		resolverURL.Scheme = "https"
+		odohTxp := netxlite.NewUnwrappedObliviousDNSOverHTTPSTransportWithHostOverride(
+			&http.Client{Transport: NewHTTPTransport(config)}, resolverURL.String(), hostOverride)
+		odohTxp.ConfigClient = &http.Client{Transport: NewHTTPTransport(targetConfig)}
+		var txp model.DNSTransport = odohTxp
+		txp = config.Saver.WrapDNSTransport(txp) // safe when config.Saver == nil
+		return netxlite.NewUnwrappedSerialResolver(txp), nil
 	case "udp":
 		dialer := NewDialer(config)
 		endpoint, err := makeValidEndpoint(resolverURL)
@@ -95,6 +118,17 @@ func NewDNSClientWithOverrides(config Config, URL, hostOverride, SNIOverride,
 			tlsDialer.DialTLSContext, endpoint)
 		txp = config.Saver.WrapDNSTransport(txp) // safe when config.Saver == nil
 		return netxlite.NewUnwrappedSerialResolver(txp), nil
+	case "quic":
+		config.TLSConfig.NextProtos = []string{"doq"}
+		quicDialer := NewQUICDialer(config)
+		endpoint, err := makeValidEndpoint(resolverURL)
+		if err != nil {
+			return nil, err
+		}
+		var txp model.DNSTransport = netxlite.NewUnwrappedDNSOverQUICTransportWithTLSConfig(
+			quicDialer, endpoint, config.TLSConfig)
+		txp = config.Saver.WrapDNSTransport(txp) // safe when config.Saver == nil
+		return netxlite.NewUnwrappedSerialResolver(txp), nil
 	case "tcp":
 		dialer := NewDialer(config)
 		endpoint, err := makeValidEndpoint(resolverURL)
@@ -110,11 +144,11 @@ func NewDNSClientWithOverrides(config Config, URL, hostOverride, SNIOverride,
 	}
 }
 
-// makeValidEndpoint makes a valid endpoint for DoT and Do53 given the
+// makeValidEndpoint makes a valid endpoint for DoT, DoQ, and Do53 given the
 // input URL representing such endpoint. Specifically, we are
 // concerned with the case where the port is missing. In such a
 // case, we ensure that we are using the default port 853 for DoT
-// and default port 53 for TCP and UDP.
+// and DoQ and default port 53 for TCP and UDP.
 func makeValidEndpoint(URL *url.URL) (string, error) {
 	// Implementation note: when we're using a quoted IPv6
 	// address, URL.Host contains the quotes but instead the
@@ -152,7 +186,7 @@ func makeValidEndpoint(URL *url.URL) (string, error) {
 	// For this reason we check again whether we can split it using
 	// net.SplitHostPort. If we cannot, we were in case four.
 	host := URL.Host
-	if URL.Scheme == "dot" {
+	if URL.Scheme == "dot" || URL.Scheme == "quic" {
 		host += ":853"
 	} else {
 		host += ":53"
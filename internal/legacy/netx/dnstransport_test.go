@@ -220,6 +220,37 @@ func TestNewDNSCLientDoTWithoutPort(t *testing.T) {
 	}
 }
 
+func TestNewDNSClientDoQ(t *testing.T) {
+	dnsclient, err := NewDNSClient(
+		Config{}, "quic://94.140.14.14")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, ok := dnsclient.(*netxlite.SerialResolver)
+	if !ok {
+		t.Fatal("not the resolver we expected")
+	}
+	txp, ok := r.Transport().(*netxlite.DNSOverQUICTransport)
+	if !ok {
+		t.Fatal("not the transport we expected")
+	}
+	if txp.Network() != "doq" {
+		t.Fatal("not the Network we expected")
+	}
+	if txp.Address() != "94.140.14.14:853" {
+		t.Fatal("expected default port to be added")
+	}
+	dnsclient.CloseIdleConnections()
+}
+
+func TestNewDNSClientBadDoQEndpoint(t *testing.T) {
+	_, err := NewDNSClient(
+		Config{}, "quic://bad:endpoint:853")
+	if err == nil || !strings.Contains(err.Error(), "too many colons in address") {
+		t.Fatal("expected error with bad endpoint")
+	}
+}
+
 func TestNewDNSCLientTCPWithoutPort(t *testing.T) {
 	c, err := NewDNSClientWithOverrides(
 		Config{}, "tcp://8.8.8.8", "", "8.8.8.8", "")
@@ -273,3 +304,32 @@ func TestNewDNSCLientWithInvalidTLSVersion(t *testing.T) {
 		t.Fatalf("not the error we expected: %+v", err)
 	}
 }
+
+func TestNewDNSClientODoH(t *testing.T) {
+	const URL = "odoh://odoh-proxy.example.org/proxy?targethost=odoh.cloudflare-dns.com&targetpath=/dns-query"
+	dnsclient, err := NewDNSClientWithOverrides(Config{}, URL, "proxy.host", "proxy.sni", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, ok := dnsclient.(*netxlite.SerialResolver)
+	if !ok {
+		t.Fatal("not the resolver we expected")
+	}
+	txp, ok := r.Transport().(*netxlite.ObliviousDNSOverHTTPSTransport)
+	if !ok {
+		t.Fatal("not the transport we expected")
+	}
+	if txp.Network() != "odoh" {
+		t.Fatal("not the Network we expected")
+	}
+	if txp.Address() != "https://odoh-proxy.example.org/proxy?targethost=odoh.cloudflare-dns.com&targetpath=/dns-query" {
+		t.Fatal("not the Address we expected", txp.Address())
+	}
+	if txp.HostOverride != "proxy.host" {
+		t.Fatal("not the HostOverride we expected")
+	}
+	if txp.ConfigClient == nil || txp.ConfigClient == txp.Client {
+		t.Fatal("expected a distinct config client")
+	}
+	dnsclient.CloseIdleConnections()
+}
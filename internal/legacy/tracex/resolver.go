@@ -97,6 +97,11 @@ func (r *ResolverSaver) LookupNS(ctx context.Context, domain string) ([]*net.NS,
 	return r.Resolver.LookupNS(ctx, domain)
 }
 
+func (r *ResolverSaver) LookupRaw(ctx context.Context, domain string, qtype uint16) ([]*model.DNSRecord, error) {
+	// TODO(bassosimone): we should probably implement this method
+	return r.Resolver.LookupRaw(ctx, domain, qtype)
+}
+
 // DNSTransportSaver is a DNS transport that saves events.
 type DNSTransportSaver struct {
 	// DNSTransport is the underlying DNS transport.
@@ -216,6 +216,24 @@ func TestResolverSaver(t *testing.T) {
 		}
 	})
 
+	t.Run("LookupRaw", func(t *testing.T) {
+		expected := errors.New("mocked")
+		saver := &Saver{}
+		child := &mocks.Resolver{
+			MockLookupRaw: func(ctx context.Context, domain string, qtype uint16) ([]*model.DNSRecord, error) {
+				return nil, expected
+			},
+		}
+		reso := saver.WrapResolver(child)
+		records, err := reso.LookupRaw(context.Background(), "dns.google", dns.TypeTXT)
+		if !errors.Is(err, expected) {
+			t.Fatal("unexpected err", err)
+		}
+		if len(records) != 0 {
+			t.Fatal("expected zero length array")
+		}
+	})
+
 	t.Run("CloseIdleConnections", func(t *testing.T) {
 		var called bool
 		saver := &Saver{}
@@ -39,6 +39,11 @@ type connTrace struct {
 
 var _ net.Conn = &connTrace{}
 
+// NetConn returns the underlying net.Conn.
+func (c *connTrace) NetConn() net.Conn {
+	return c.Conn
+}
+
 type remoteAddrProvider interface {
 	RemoteAddr() net.Addr
 }
@@ -10,6 +10,7 @@ import (
 	"math"
 	"net"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/ooni/probe-cli/v3/internal/bytecounter"
@@ -41,7 +42,19 @@ var _ model.Dialer = &dialerTrace{}
 func (d *dialerTrace) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
 	// Here we make sure that we're counting bytes sent and received.
 	dialer := bytecounter.WrapWithContextAwareDialer(d.d)
-	return dialer.DialContext(netxlite.ContextWithTrace(ctx, d.tx), network, address)
+	conn, err := dialer.DialContext(netxlite.ContextWithTrace(ctx, d.tx), network, address)
+	if err != nil {
+		return nil, err
+	}
+	// Here we make sure we sample TCP_INFO after connect and before close. We only
+	// wrap the conn when we can actually sample (i.e., for kernel TCP sockets on Linux).
+	switch network {
+	case "tcp", "tcp4", "tcp6":
+		if d.tx.maybeSampleTCPInfo(netxlite.ConnectOperation, conn) {
+			conn = &tcpInfoConn{Conn: conn, once: sync.Once{}, tx: d.tx}
+		}
+	}
+	return conn, nil
 }
 
 // CloseIdleConnections implements model.Dialer.CloseIdleConnections.
@@ -92,6 +92,13 @@ func (r *resolverTrace) LookupNS(ctx context.Context, domain string) ([]*net.NS,
 	return r.r.LookupNS(netxlite.ContextWithTrace(ctx, r.tx), domain)
 }
 
+// LookupRaw implements model.Resolver.LookupRaw
+func (r *resolverTrace) LookupRaw(ctx context.Context, domain string, qtype uint16) ([]*model.DNSRecord, error) {
+	defer r.emiteResolveDone()
+	r.emitResolveStart()
+	return r.r.LookupRaw(netxlite.ContextWithTrace(ctx, r.tx), domain, qtype)
+}
+
 // NewStdlibResolver returns a trace-ware system resolver
 func (tx *Trace) NewStdlibResolver(logger model.DebugLogger) model.Resolver {
 	// Here we make sure that we're counting bytes sent and received.
@@ -103,11 +110,45 @@ func (tx *Trace) NewParallelUDPResolver(logger model.DebugLogger, dialer model.D
 	return tx.wrapResolver(tx.Netx.NewParallelUDPResolver(logger, dialer, address))
 }
 
+// parallelUDPResolverWithOptionsFactory is the [*netxlite.Netx] method
+// allowing to configure the underlying DNS-over-UDP transport.
+type parallelUDPResolverWithOptionsFactory interface {
+	NewParallelUDPResolverWithOptions(logger model.DebugLogger, dialer model.Dialer,
+		address string, options ...netxlite.DNSOverUDPTransportOption) model.Resolver
+}
+
+// NewParallelUDPResolverWithOptions is like NewParallelUDPResolver but allows to configure
+// the underlying DNS-over-UDP transport. When the Netx field does not support configuring
+// the transport (e.g., because it is a mock), we ignore the options.
+func (tx *Trace) NewParallelUDPResolverWithOptions(logger model.DebugLogger, dialer model.Dialer,
+	address string, options ...netxlite.DNSOverUDPTransportOption) model.Resolver {
+	factory, ok := tx.Netx.(parallelUDPResolverWithOptionsFactory)
+	if !ok {
+		return tx.NewParallelUDPResolver(logger, dialer, address)
+	}
+	return tx.wrapResolver(factory.NewParallelUDPResolverWithOptions(logger, dialer, address, options...))
+}
+
 // NewParallelDNSOverHTTPSResolver returns a trace-aware parallel DoH resolver
 func (tx *Trace) NewParallelDNSOverHTTPSResolver(logger model.DebugLogger, URL string) model.Resolver {
 	return tx.wrapResolver(tx.Netx.NewParallelDNSOverHTTPSResolver(logger, URL))
 }
 
+// NewParallelDNSOverQUICResolver returns a trace-aware parallel DoQ resolver
+func (tx *Trace) NewParallelDNSOverQUICResolver(logger model.DebugLogger, address string) model.Resolver {
+	return tx.wrapResolver(tx.Netx.NewParallelDNSOverQUICResolver(logger, address))
+}
+
+// NewParallelDNSOverTLSResolver returns a trace-aware parallel DoT resolver
+func (tx *Trace) NewParallelDNSOverTLSResolver(logger model.DebugLogger, address string) model.Resolver {
+	return tx.wrapResolver(tx.Netx.NewParallelDNSOverTLSResolver(logger, address))
+}
+
+// NewParallelTCPResolver returns a trace-ware parallel TCP resolver
+func (tx *Trace) NewParallelTCPResolver(logger model.DebugLogger, dialer model.Dialer, address string) model.Resolver {
+	return tx.wrapResolver(tx.Netx.NewParallelTCPResolver(logger, dialer, address))
+}
+
 // OnDNSRoundTripForLookupHost implements model.Trace.OnDNSRoundTripForLookupHost
 func (tx *Trace) OnDNSRoundTripForLookupHost(started time.Time, reso model.Resolver, query model.DNSQuery,
 	response model.DNSResponse, addrs []string, err error, finished time.Time) {
@@ -147,18 +188,23 @@ type DNSNetworkAddresser interface {
 func NewArchivalDNSLookupResultFromRoundTrip(index int64, started time.Duration,
 	reso DNSNetworkAddresser, query model.DNSQuery, response model.DNSResponse,
 	addrs []string, err error, finished time.Duration, tags ...string) *model.ArchivalDNSLookupResult {
+	msg := maybeUnpackResponse(response)
 	return &model.ArchivalDNSLookupResult{
-		Answers:          newArchivalDNSAnswers(addrs, response),
+		AnswerSection:    newArchivalDNSAnswerSection(msg),
+		Answers:          addArchivalDNSAnswersTTLs(newArchivalDNSAnswersForQuery(query, addrs, response), msg),
+		EDNS0:            newArchivalDNSEDNS0(msg),
 		Engine:           reso.Network(),
 		Failure:          NewFailure(err),
 		GetaddrinfoError: netxlite.ErrorToGetaddrinfoRetvalOrZero(err),
 		Hostname:         query.Domain(),
 		QueryType:        dns.TypeToString[query.Type()],
+		RawQuery:         maybeRawQuery(reso, query),
 		RawResponse:      maybeRawResponse(response),
 		Rcode:            maybeResponseRcode(response),
 		ResolverHostname: nil,
 		ResolverPort:     nil,
 		ResolverAddress:  reso.Address(),
+		ResponseFlags:    newArchivalDNSResponseFlags(msg),
 		T0:               started.Seconds(),
 		T:                finished.Seconds(),
 		Tags:             copyAndNormalizeTags(tags),
@@ -166,6 +212,193 @@ func NewArchivalDNSLookupResultFromRoundTrip(index int64, started time.Duration,
 	}
 }
 
+// maybeRawQuery returns the raw query unless we're using getaddrinfo or
+// the Go resolver, which do not send the query we have encoded.
+func maybeRawQuery(reso DNSNetworkAddresser, query model.DNSQuery) []byte {
+	switch reso.Network() {
+	case netxlite.StdlibResolverGetaddrinfo,
+		netxlite.StdlibResolverGolangNetResolver,
+		netxlite.StdlibResolverSystem:
+		return nil
+	}
+	data, err := query.Bytes()
+	if err != nil {
+		return nil
+	}
+	return data
+}
+
+// maybeUnpackResponse parses the raw response (when available) or returns nil.
+func maybeUnpackResponse(resp model.DNSResponse) *dns.Msg {
+	data := maybeRawResponse(resp)
+	if len(data) <= 0 {
+		return nil
+	}
+	msg := &dns.Msg{}
+	if err := msg.Unpack(data); err != nil {
+		return nil
+	}
+	return msg
+}
+
+// newArchivalDNSAnswerSection returns the answer section of [msg] in presentation format.
+func newArchivalDNSAnswerSection(msg *dns.Msg) (out []string) {
+	if msg != nil {
+		for _, answer := range msg.Answer {
+			out = append(out, answer.String())
+		}
+	}
+	return
+}
+
+// newArchivalDNSResponseFlags returns the flags set in the header of [msg].
+func newArchivalDNSResponseFlags(msg *dns.Msg) (out []string) {
+	if msg == nil {
+		return
+	}
+	flags := []struct {
+		name  string
+		isSet bool
+	}{
+		{"qr", msg.Response},
+		{"aa", msg.Authoritative},
+		{"tc", msg.Truncated},
+		{"rd", msg.RecursionDesired},
+		{"ra", msg.RecursionAvailable},
+		{"z", msg.Zero},
+		{"ad", msg.AuthenticatedData},
+		{"cd", msg.CheckingDisabled},
+	}
+	for _, flag := range flags {
+		if flag.isSet {
+			out = append(out, flag.name)
+		}
+	}
+	return
+}
+
+// archivalDNSEDNS0OptionNames maps the EDNS(0) option codes we know about to their names.
+var archivalDNSEDNS0OptionNames = map[uint16]string{
+	dns.EDNS0NSID:         "NSID",
+	dns.EDNS0SUBNET:       "ECS",
+	dns.EDNS0EXPIRE:       "EXPIRE",
+	dns.EDNS0COOKIE:       "COOKIE",
+	dns.EDNS0TCPKEEPALIVE: "TCP_KEEPALIVE",
+	dns.EDNS0PADDING:      "PADDING",
+	dns.EDNS0EDE:          "EDE",
+}
+
+// newArchivalDNSEDNS0 returns the EDNS(0) OPT pseudo-record of [msg], if any.
+func newArchivalDNSEDNS0(msg *dns.Msg) *model.ArchivalDNSEDNS0 {
+	if msg == nil {
+		return nil
+	}
+	opt := msg.IsEdns0()
+	if opt == nil {
+		return nil
+	}
+	out := &model.ArchivalDNSEDNS0{
+		DNSSECOK: opt.Do(),
+		Options:  []model.ArchivalDNSEDNS0Option{},
+		UDPSize:  opt.UDPSize(),
+		Version:  opt.Version(),
+	}
+	rawOptions := rawEDNS0Options(opt)
+	for idx, option := range opt.Option {
+		entry := model.ArchivalDNSEDNS0Option{
+			Code:   option.Option(),
+			Data:   option.String(),
+			Length: 0,
+			Name:   archivalDNSEDNS0OptionNames[option.Option()],
+		}
+		if idx < len(rawOptions) {
+			entry.Length = int64(len(rawOptions[idx]))
+		}
+		// Omit the padding when it only contains zeroes as RFC 7830 Sect. 3
+		// says it SHOULD, so that we only archive anomalous padding.
+		if padding, ok := option.(*dns.EDNS0_PADDING); ok && isAllZeroes(padding.Padding) {
+			entry.Data = ""
+		}
+		out.Options = append(out.Options, entry)
+	}
+	return out
+}
+
+// rawEDNS0Options returns the raw data of each option inside [opt].
+func rawEDNS0Options(opt *dns.OPT) (out [][]byte) {
+	buffer := make([]byte, dns.Len(opt))
+	off, err := dns.PackRR(opt, buffer, 0, nil, false)
+	if err != nil {
+		return
+	}
+	// skip the root name (1), type (2), class (2), TTL (4), and rdlength (2)
+	const headerSize = 11
+	if off < headerSize {
+		return
+	}
+	rdata := buffer[headerSize:off]
+	for len(rdata) >= 4 {
+		length := int(rdata[2])<<8 | int(rdata[3])
+		if len(rdata) < 4+length {
+			return
+		}
+		out = append(out, rdata[4:4+length])
+		rdata = rdata[4+length:]
+	}
+	return
+}
+
+// isAllZeroes returns whether [data] only contains zero bytes.
+func isAllZeroes(data []byte) bool {
+	for _, b := range data {
+		if b != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// addArchivalDNSAnswersTTLs sets the TTL of the [answers] that do not already have
+// one by searching for the corresponding record inside the answer section of [msg].
+func addArchivalDNSAnswersTTLs(answers []model.ArchivalDNSAnswer, msg *dns.Msg) []model.ArchivalDNSAnswer {
+	if msg == nil {
+		return answers
+	}
+	for idx := range answers {
+		answer := &answers[idx]
+		if answer.TTL != nil {
+			continue
+		}
+		for _, rr := range msg.Answer {
+			if ttl, found := archivalDNSAnswerMatchesRR(answer, rr); found {
+				answer.TTL = &ttl
+				break
+			}
+		}
+	}
+	return answers
+}
+
+// archivalDNSAnswerMatchesRR returns the TTL of [rr] and true when [rr] is the record from which we
+// extracted the given [answer]. Otherwise, it returns zero and false.
+func archivalDNSAnswerMatchesRR(answer *model.ArchivalDNSAnswer, rr dns.RR) (uint32, bool) {
+	switch v := rr.(type) {
+	case *dns.A:
+		if answer.AnswerType == "A" && answer.IPv4 == v.A.String() {
+			return v.Hdr.Ttl, true
+		}
+	case *dns.AAAA:
+		if answer.AnswerType == "AAAA" && answer.IPv6 == v.AAAA.String() {
+			return v.Hdr.Ttl, true
+		}
+	case *dns.CNAME:
+		if answer.AnswerType == "CNAME" && answer.Hostname == v.Target {
+			return v.Hdr.Ttl, true
+		}
+	}
+	return 0, false
+}
+
 // maybeResponseRcode returns the response rcode (when available)
 func maybeResponseRcode(resp model.DNSResponse) (out int64) {
 	if resp != nil {
@@ -182,6 +415,17 @@ func maybeRawResponse(resp model.DNSResponse) (out []byte) {
 	return
 }
 
+// newArchivalDNSAnswersForQuery generates []model.ArchivalDNSAnswer for the given
+// [query]. Responses to queries other than A, AAAA, and ANY come from LookupRaw, hence
+// we include all the records in the answer section of the response.
+func newArchivalDNSAnswersForQuery(
+	query model.DNSQuery, addrs []string, resp model.DNSResponse) []model.ArchivalDNSAnswer {
+	if resp != nil && !isLookupHostQueryType(query.Type()) {
+		return newArchivalDNSAnswersFromRecords(resp)
+	}
+	return newArchivalDNSAnswers(addrs, resp)
+}
+
 // newArchivalDNSAnswers generates []model.ArchivalDNSAnswer from [addrs] and [resp].
 func newArchivalDNSAnswers(addrs []string, resp model.DNSResponse) (out []model.ArchivalDNSAnswer) {
 	// Design note: in principle we might want to extract everything from the
@@ -247,6 +491,51 @@ func newArchivalDNSAnswers(addrs []string, resp model.DNSResponse) (out []model.
 	return
 }
 
+// isLookupHostQueryType returns whether qtype is one of the query
+// types that netxlite uses for implementing LookupHost.
+func isLookupHostQueryType(qtype uint16) bool {
+	switch qtype {
+	case dns.TypeA, dns.TypeAAAA, dns.TypeANY:
+		return true
+	default:
+		return false
+	}
+}
+
+// newArchivalDNSAnswersFromRecords generates []model.ArchivalDNSAnswer
+// from all the records in the answer section of [resp].
+func newArchivalDNSAnswersFromRecords(resp model.DNSResponse) (out []model.ArchivalDNSAnswer) {
+	records, err := resp.DecodeRecords()
+	if err != nil {
+		return
+	}
+	for _, record := range records {
+		ttl := record.TTL
+		answer := model.ArchivalDNSAnswer{
+			ASN:        0,
+			ASOrgName:  "",
+			AnswerType: record.Type,
+			Data:       "",
+			Hostname:   "",
+			IPv4:       "",
+			IPv6:       "",
+			TTL:        &ttl,
+		}
+		switch record.Type {
+		case "A":
+			answer.IPv4 = record.Data
+		case "AAAA":
+			answer.IPv6 = record.Data
+		case "CNAME", "DNAME", "NS", "PTR":
+			answer.Hostname = record.Data
+		default:
+			answer.Data = record.Data
+		}
+		out = append(out, answer)
+	}
+	return
+}
+
 // DNSLookupsFromRoundTrip drains the network events buffered inside the DNSLookup channel
 func (tx *Trace) DNSLookupsFromRoundTrip() (out []*model.ArchivalDNSLookupResult) {
 	for {
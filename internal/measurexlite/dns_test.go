@@ -54,6 +54,14 @@ func TestNewResolver(t *testing.T) {
 					Host: "1.1.1.1",
 				}}, nil
 			},
+			MockLookupRaw: func(ctx context.Context, domain string, qtype uint16) ([]*model.DNSRecord, error) {
+				return []*model.DNSRecord{{
+					Name: "example.com.",
+					Type: "TXT",
+					TTL:  300,
+					Data: `"v=spf1 -all"`,
+				}}, nil
+			},
 			MockCloseIdleConnections: func() {
 				called = true
 			},
@@ -114,6 +122,23 @@ func TestNewResolver(t *testing.T) {
 			}
 		})
 
+		t.Run("LookupRaw is correctly forwarded", func(t *testing.T) {
+			want := []*model.DNSRecord{{
+				Name: "example.com.",
+				Type: "TXT",
+				TTL:  300,
+				Data: `"v=spf1 -all"`,
+			}}
+			ctx := context.Background()
+			got, err := resolver.LookupRaw(ctx, "example.com", dns.TypeTXT)
+			if err != nil {
+				t.Fatal("expected nil error")
+			}
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+
 		t.Run("CloseIdleConnections is correctly forwarded", func(t *testing.T) {
 			resolver.CloseIdleConnections()
 			if !called {
@@ -226,6 +251,84 @@ func TestNewResolver(t *testing.T) {
 		})
 	})
 
+	t.Run("LookupRaw saves into trace", func(t *testing.T) {
+		zeroTime := time.Now()
+		td := testingx.NewTimeDeterministic(zeroTime)
+		trace := NewTrace(0, zeroTime, "antani")
+		trace.timeNowFn = td.Now
+		records := []*model.DNSRecord{{
+			Name: "example.com.",
+			Type: "CNAME",
+			TTL:  60,
+			Data: "mail.example.com.",
+		}, {
+			Name: "mail.example.com.",
+			Type: "MX",
+			TTL:  300,
+			Data: "10 mx.example.com.",
+		}}
+		txp := &mocks.DNSTransport{
+			MockRoundTrip: func(ctx context.Context, query model.DNSQuery) (model.DNSResponse, error) {
+				response := &mocks.DNSResponse{
+					MockDecodeRecords: func() ([]*model.DNSRecord, error) {
+						return records, nil
+					},
+					MockRcode: func() int {
+						return 0
+					},
+					MockBytes: func() []byte {
+						return []byte{}
+					},
+				}
+				return response, nil
+			},
+			MockRequiresPadding: func() bool {
+				return true
+			},
+			MockNetwork: func() string {
+				return "mocked"
+			},
+			MockAddress: func() string {
+				return "dns.google"
+			},
+		}
+		r := netxlite.NewUnwrappedParallelResolver(txp)
+		resolver := trace.wrapResolver(r)
+		ctx := context.Background()
+		got, err := resolver.LookupRaw(ctx, "example.com", dns.TypeMX)
+		if err != nil {
+			t.Fatal("unexpected err", err)
+		}
+		if diff := cmp.Diff(records, got); diff != "" {
+			t.Fatal(diff)
+		}
+
+		events := trace.DNSLookupsFromRoundTrip()
+		if len(events) != 1 {
+			t.Fatal("unexpected DNS events length")
+		}
+		ev := events[0]
+		if ev.QueryType != "MX" {
+			t.Fatal("unexpected query type", ev.QueryType)
+		}
+		if ev.Hostname != "example.com" {
+			t.Fatal("unexpected hostname", ev.Hostname)
+		}
+		cnameTTL, mxTTL := uint32(60), uint32(300)
+		expectAnswers := []model.ArchivalDNSAnswer{{
+			AnswerType: "CNAME",
+			Hostname:   "mail.example.com.",
+			TTL:        &cnameTTL,
+		}, {
+			AnswerType: "MX",
+			Data:       "10 mx.example.com.",
+			TTL:        &mxTTL,
+		}}
+		if diff := cmp.Diff(expectAnswers, ev.Answers); diff != "" {
+			t.Fatal(diff)
+		}
+	})
+
 	t.Run("LookupHost discards events when buffers are full", func(t *testing.T) {
 		zeroTime := time.Now()
 		td := testingx.NewTimeDeterministic(zeroTime)
@@ -311,6 +414,45 @@ func TestNewWrappedResolvers(t *testing.T) {
 		}
 	})
 
+	t.Run("NewParallelDNSOverQUICResolver works as intended", func(t *testing.T) {
+		zeroTime := time.Now()
+		trace := NewTrace(0, zeroTime)
+		resolver := trace.NewParallelDNSOverQUICResolver(model.DiscardLogger, "94.140.14.14:853")
+		resolvert := resolver.(*resolverTrace)
+		if resolvert.tx != trace {
+			t.Fatal("invalid trace")
+		}
+		if resolver.Network() != "doq" {
+			t.Fatal("unexpected resolver network")
+		}
+	})
+
+	t.Run("NewParallelDNSOverTLSResolver works as intended", func(t *testing.T) {
+		zeroTime := time.Now()
+		trace := NewTrace(0, zeroTime)
+		resolver := trace.NewParallelDNSOverTLSResolver(model.DiscardLogger, "1.1.1.1:853")
+		resolvert := resolver.(*resolverTrace)
+		if resolvert.tx != trace {
+			t.Fatal("invalid trace")
+		}
+		if resolver.Network() != "dot" {
+			t.Fatal("unexpected resolver network")
+		}
+	})
+
+	t.Run("NewParallelTCPResolver works as intended", func(t *testing.T) {
+		zeroTime := time.Now()
+		trace := NewTrace(0, zeroTime)
+		resolver := trace.NewParallelTCPResolver(model.DiscardLogger, trace.NewDialerWithoutResolver(model.DiscardLogger), "1.1.1.1:53")
+		resolvert := resolver.(*resolverTrace)
+		if resolvert.tx != trace {
+			t.Fatal("invalid trace")
+		}
+		if resolver.Network() != "tcp" {
+			t.Fatal("unexpected resolver network")
+		}
+	})
+
 	t.Run("NewParallelUDPResolver works as intended", func(t *testing.T) {
 		zeroTime := time.Now()
 		trace := NewTrace(0, zeroTime)
@@ -325,6 +467,39 @@ func TestNewWrappedResolvers(t *testing.T) {
 		}
 	})
 
+	t.Run("NewParallelUDPResolverWithOptions works as intended", func(t *testing.T) {
+		zeroTime := time.Now()
+		trace := NewTrace(0, zeroTime)
+		dialer := netxlite.NewDialerWithStdlibResolver(model.DiscardLogger)
+		resolver := trace.NewParallelUDPResolverWithOptions(model.DiscardLogger, dialer, "1.1.1.1:53",
+			netxlite.DNSOverUDPTransportOptionDelayedResponsesWindow(time.Second))
+		resolvert := resolver.(*resolverTrace)
+		if resolvert.tx != trace {
+			t.Fatal("invalid trace")
+		}
+		if resolver.Network() != "udp" {
+			t.Fatal("unexpected resolver network")
+		}
+	})
+
+	t.Run("NewParallelUDPResolverWithOptions falls back when Netx does not support options", func(t *testing.T) {
+		zeroTime := time.Now()
+		trace := NewTrace(0, zeroTime)
+		var called bool
+		trace.Netx = &mocks.MeasuringNetwork{
+			MockNewParallelUDPResolver: func(logger model.DebugLogger, dialer model.Dialer, address string) model.Resolver {
+				called = true
+				return &mocks.Resolver{}
+			},
+		}
+		dialer := netxlite.NewDialerWithStdlibResolver(model.DiscardLogger)
+		_ = trace.NewParallelUDPResolverWithOptions(model.DiscardLogger, dialer, "1.1.1.1:53",
+			netxlite.DNSOverUDPTransportOptionDelayedResponsesWindow(time.Second))
+		if !called {
+			t.Fatal("expected to fall back to NewParallelUDPResolver")
+		}
+	})
+
 	t.Run("NewStdlibResolver works as intended", func(t *testing.T) {
 		zeroTime := time.Now()
 		trace := NewTrace(0, zeroTime)
@@ -398,6 +573,9 @@ func TestDelayedDNSResponseWithTimeout(t *testing.T) {
 			}
 			started := trace.TimeNow()
 			query := &mocks.DNSQuery{
+				MockBytes: func() ([]byte, error) {
+					return []byte{}, nil
+				},
 				MockType: func() uint16 {
 					return dns.TypeA
 				},
@@ -449,6 +627,9 @@ func TestDelayedDNSResponseWithTimeout(t *testing.T) {
 			}
 			started := trace.TimeNow()
 			query := &mocks.DNSQuery{
+				MockBytes: func() ([]byte, error) {
+					return []byte{}, nil
+				},
 				MockType: func() uint16 {
 					return dns.TypeA
 				},
@@ -498,6 +679,9 @@ func TestDelayedDNSResponseWithTimeout(t *testing.T) {
 			}
 			started := trace.TimeNow()
 			query := &mocks.DNSQuery{
+				MockBytes: func() ([]byte, error) {
+					return []byte{}, nil
+				},
 				MockType: func() uint16 {
 					return dns.TypeA
 				},
@@ -548,6 +732,9 @@ func TestDelayedDNSResponseWithTimeout(t *testing.T) {
 			}
 			started := trace.TimeNow()
 			query := &mocks.DNSQuery{
+				MockBytes: func() ([]byte, error) {
+					return []byte{}, nil
+				},
 				MockType: func() uint16 {
 					return dns.TypeA
 				},
@@ -732,3 +919,192 @@ func TestNewArchivalDNSAnswers(t *testing.T) {
 		})
 	}
 }
+
+func TestNewArchivalDNSLookupResultFromRoundTrip(t *testing.T) {
+	// newRoundTrip creates a query for www.example.com and a response containing
+	// a CNAME and an A record as well as the given EDNS(0) options.
+	newRoundTrip := func(t *testing.T, options ...dns.EDNS0) (model.DNSQuery, model.DNSResponse) {
+		query := (&netxlite.DNSEncoderMiekg{}).Encode("www.example.com", dns.TypeA, true)
+		rawQuery, err := query.Bytes()
+		if err != nil {
+			t.Fatal(err)
+		}
+		queryMsg := &dns.Msg{}
+		if err := queryMsg.Unpack(rawQuery); err != nil {
+			t.Fatal(err)
+		}
+		reply := &dns.Msg{}
+		reply.SetReply(queryMsg)
+		reply.RecursionAvailable = true
+		reply.Answer = append(reply.Answer, &dns.CNAME{
+			Hdr: dns.RR_Header{
+				Name:   "www.example.com.",
+				Rrtype: dns.TypeCNAME,
+				Class:  dns.ClassINET,
+				Ttl:    60,
+			},
+			Target: "example.com.",
+		}, &dns.A{
+			Hdr: dns.RR_Header{
+				Name:   "example.com.",
+				Rrtype: dns.TypeA,
+				Class:  dns.ClassINET,
+				Ttl:    300,
+			},
+			A: net.IPv4(93, 184, 216, 34),
+		})
+		if len(options) > 0 {
+			reply.SetEdns0(1232, true)
+			opt := reply.IsEdns0()
+			opt.Option = append(opt.Option, options...)
+		}
+		rawReply, err := reply.Pack()
+		if err != nil {
+			t.Fatal(err)
+		}
+		response, err := (&netxlite.DNSDecoderMiekg{}).DecodeResponse(rawReply, query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return query, response
+	}
+
+	t.Run("we record the raw messages, the TTLs, the flags, and the answer section", func(t *testing.T) {
+		query, response := newRoundTrip(t)
+		reso := &mocks.DNSTransport{
+			MockNetwork: func() string {
+				return "udp"
+			},
+			MockAddress: func() string {
+				return "8.8.8.8:53"
+			},
+		}
+		ev := NewArchivalDNSLookupResultFromRoundTrip(
+			0, 0, reso, query, response, []string{"93.184.216.34"}, nil, time.Second)
+
+		rawQuery, _ := query.Bytes()
+		if diff := cmp.Diff(rawQuery, ev.RawQuery); diff != "" {
+			t.Fatal(diff)
+		}
+		if diff := cmp.Diff(response.Bytes(), ev.RawResponse); diff != "" {
+			t.Fatal(diff)
+		}
+		if diff := cmp.Diff([]string{"qr", "rd", "ra"}, ev.ResponseFlags); diff != "" {
+			t.Fatal(diff)
+		}
+		expectSection := []string{
+			"www.example.com.\t60\tIN\tCNAME\texample.com.",
+			"example.com.\t300\tIN\tA\t93.184.216.34",
+		}
+		if diff := cmp.Diff(expectSection, ev.AnswerSection); diff != "" {
+			t.Fatal(diff)
+		}
+		if len(ev.Answers) != 2 {
+			t.Fatal("unexpected number of answers", len(ev.Answers))
+		}
+		if ev.Answers[0].AnswerType != "A" || ev.Answers[0].TTL == nil || *ev.Answers[0].TTL != 300 {
+			t.Fatal("unexpected first answer", ev.Answers[0])
+		}
+		if ev.Answers[1].AnswerType != "CNAME" || ev.Answers[1].TTL == nil || *ev.Answers[1].TTL != 60 {
+			t.Fatal("unexpected second answer", ev.Answers[1])
+		}
+		if ev.EDNS0 != nil {
+			t.Fatal("expected nil EDNS0", ev.EDNS0)
+		}
+	})
+
+	t.Run("we record the EDNS(0) options", func(t *testing.T) {
+		query, response := newRoundTrip(t,
+			&dns.EDNS0_SUBNET{
+				Code:          dns.EDNS0SUBNET,
+				Family:        1,
+				SourceNetmask: 24,
+				SourceScope:   0,
+				Address:       net.IPv4(130, 192, 91, 0),
+			},
+			&dns.EDNS0_COOKIE{
+				Code:   dns.EDNS0COOKIE,
+				Cookie: "0102030405060708",
+			},
+			&dns.EDNS0_PADDING{Padding: make([]byte, 16)},
+			&dns.EDNS0_PADDING{Padding: []byte{0xde, 0xad}},
+		)
+		reso := &mocks.DNSTransport{
+			MockNetwork: func() string {
+				return "doh"
+			},
+			MockAddress: func() string {
+				return "https://dns.google/dns-query"
+			},
+		}
+		ev := NewArchivalDNSLookupResultFromRoundTrip(
+			0, 0, reso, query, response, []string{"93.184.216.34"}, nil, time.Second)
+		expect := &model.ArchivalDNSEDNS0{
+			DNSSECOK: true,
+			Options: []model.ArchivalDNSEDNS0Option{{
+				Code:   dns.EDNS0SUBNET,
+				Data:   "130.192.91.0/24/0",
+				Length: 7,
+				Name:   "ECS",
+			}, {
+				Code:   dns.EDNS0COOKIE,
+				Data:   "0102030405060708",
+				Length: 8,
+				Name:   "COOKIE",
+			}, {
+				Code:   dns.EDNS0PADDING,
+				Data:   "",
+				Length: 16,
+				Name:   "PADDING",
+			}, {
+				Code:   dns.EDNS0PADDING,
+				Data:   "DEAD",
+				Length: 2,
+				Name:   "PADDING",
+			}},
+			UDPSize: 1232,
+			Version: 0,
+		}
+		if diff := cmp.Diff(expect, ev.EDNS0); diff != "" {
+			t.Fatal(diff)
+		}
+	})
+
+	t.Run("we do not record the raw query when using getaddrinfo", func(t *testing.T) {
+		query, _ := newRoundTrip(t)
+		reso := &mocks.DNSTransport{
+			MockNetwork: func() string {
+				return netxlite.StdlibResolverGetaddrinfo
+			},
+			MockAddress: func() string {
+				return ""
+			},
+		}
+		ev := NewArchivalDNSLookupResultFromRoundTrip(
+			0, 0, reso, query, nil, nil, netxlite.ErrOODNSNoAnswer, time.Second)
+		if ev.RawQuery != nil {
+			t.Fatal("expected nil raw query")
+		}
+		if ev.RawResponse != nil || ev.AnswerSection != nil || ev.ResponseFlags != nil || ev.EDNS0 != nil {
+			t.Fatal("expected no response data", ev)
+		}
+	})
+
+	t.Run("we record the raw query when the round trip fails", func(t *testing.T) {
+		query, _ := newRoundTrip(t)
+		reso := &mocks.DNSTransport{
+			MockNetwork: func() string {
+				return "udp"
+			},
+			MockAddress: func() string {
+				return "8.8.8.8:53"
+			},
+		}
+		ev := NewArchivalDNSLookupResultFromRoundTrip(
+			0, 0, reso, query, nil, nil, netxlite.ErrOODNSNoAnswer, time.Second)
+		rawQuery, _ := query.Bytes()
+		if diff := cmp.Diff(rawQuery, ev.RawQuery); diff != "" {
+			t.Fatal(diff)
+		}
+	})
+}
@@ -0,0 +1,342 @@
+package measurexlite
+
+//
+// Converting observations to hierarchical spans
+//
+
+import (
+	"encoding/binary"
+	"sort"
+	"time"
+
+	"github.com/ooni/probe-cli/v3/internal/model"
+)
+
+// SpanObservations contains the observations to convert to spans. We use the same
+// JSON tags used by the test keys of most experiments, so you can also unmarshal
+// the test keys of a measurement (e.g., Web Connectivity) into this struct.
+type SpanObservations struct {
+	// NetworkEvents contains I/O events.
+	NetworkEvents []*model.ArchivalNetworkEvent `json:"network_events"`
+
+	// Queries contains the DNS queries results.
+	Queries []*model.ArchivalDNSLookupResult `json:"queries"`
+
+	// Requests contains HTTP request results.
+	Requests []*model.ArchivalHTTPRequestResult `json:"requests"`
+
+	// TCPConnect contains the TCP connect results.
+	TCPConnect []*model.ArchivalTCPConnectResult `json:"tcp_connect"`
+
+	// TLSHandshakes contains the TLS handshakes results.
+	TLSHandshakes []*model.ArchivalTLSOrQUICHandshakeResult `json:"tls_handshakes"`
+
+	// QUICHandshakes contains the QUIC handshakes results.
+	QUICHandshakes []*model.ArchivalTLSOrQUICHandshakeResult `json:"quic_handshakes"`
+}
+
+// NewSpanObservationsFromTrace drains the observations buffered inside the given
+// [*Trace]. Because the [*Trace] does not collect HTTP round trips, you need to
+// append them to the Requests field yourself, if you have any.
+func NewSpanObservationsFromTrace(tx *Trace) *SpanObservations {
+	return &SpanObservations{
+		NetworkEvents:  tx.NetworkEvents(),
+		Queries:        tx.DNSLookupsFromRoundTrip(),
+		Requests:       []*model.ArchivalHTTPRequestResult{},
+		TCPConnect:     tx.TCPConnects(),
+		TLSHandshakes:  tx.TLSHandshakes(),
+		QUICHandshakes: tx.QUICHandshakes(),
+	}
+}
+
+// Span is an operation within a [*Spans] tree.
+type Span struct {
+	// Attributes contains the span attributes (e.g., the hostname for DNS lookups).
+	Attributes map[string]any
+
+	// Children contains the child spans sorted by start time.
+	Children []*Span
+
+	// End is the time when the span ended relative to the zero time.
+	End time.Duration
+
+	// Events contains the events that occurred during the span sorted by time.
+	Events []*SpanEvent
+
+	// Failure is the OPTIONAL failure of the operation.
+	Failure *string
+
+	// ID is the span ID. The spans of transactions use the transaction ID as
+	// their ID, while the other spans use IDs larger than [SpanIDBase].
+	ID uint64
+
+	// Name is the span name (e.g., "dns_lookup").
+	Name string
+
+	// ParentID is the ID of the parent span or zero for the root span.
+	ParentID uint64
+
+	// Start is the time when the span started relative to the zero time.
+	Start time.Duration
+
+	// TransactionID is the transaction ID or zero for the root span.
+	TransactionID int64
+}
+
+// SpanEvent is an event occurring during a [*Span] (e.g., a network read).
+type SpanEvent struct {
+	// Attributes contains the event attributes.
+	Attributes map[string]any
+
+	// Name is the event name (e.g., "read").
+	Name string
+
+	// Time is the time when the event occurred relative to the zero time.
+	Time time.Duration
+}
+
+// SpanIDBase is the smallest ID of spans that are not transactions. We assume the
+// transaction IDs are smaller than this value, which is reasonable because we
+// allocate transaction IDs sequentially starting from one.
+const SpanIDBase = 1 << 32
+
+// Spans contains the hierarchical spans built from observations. The root span represents
+// the whole measurement. Its children represent transactions. In turn, the children of
+// each transaction represent operations (DNS lookups, TCP connects, TLS and QUIC handshakes,
+// and HTTP round trips). We attach network events to the enclosing transaction. Observations
+// without a transaction ID become direct children of the root span.
+type Spans struct {
+	// Root is the root span.
+	Root *Span
+
+	// TraceID is the trace ID, which we derive from the zero time.
+	TraceID [16]byte
+
+	// ZeroTime is the time when we started measuring.
+	ZeroTime time.Time
+}
+
+// NewSpans creates a [*Spans] tree from the given observations, where zeroTime is
+// the time when we started measuring (e.g., [*Trace.ZeroTime]).
+func NewSpans(zeroTime time.Time, observations ...*SpanObservations) *Spans {
+	sb := &spansBuilder{
+		nextID: SpanIDBase,
+		root:   nil,
+		txs:    map[int64]*Span{},
+	}
+	sb.root = sb.newSpan(0, "measurement", 0, 0, nil, nil)
+	for _, obs := range observations {
+		sb.addObservations(obs)
+	}
+	sb.finish()
+	spans := &Spans{
+		Root:     sb.root,
+		TraceID:  [16]byte{},
+		ZeroTime: zeroTime,
+	}
+	binary.BigEndian.PutUint64(spans.TraceID[8:], uint64(zeroTime.UnixNano()))
+	return spans
+}
+
+// spansBuilder builds a [*Spans] tree.
+type spansBuilder struct {
+	// nextID is the next ID for spans that are not transactions.
+	nextID uint64
+
+	// root is the root span.
+	root *Span
+
+	// txs maps a transaction ID to its span.
+	txs map[int64]*Span
+}
+
+// spanDuration converts archival seconds to a [time.Duration].
+func spanDuration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// newSpan creates a new span that does not represent a transaction.
+func (sb *spansBuilder) newSpan(txid int64, name string, t0, t float64,
+	failure *string, attributes map[string]any) *Span {
+	span := &Span{
+		Attributes:    attributes,
+		Children:      []*Span{},
+		End:           spanDuration(t),
+		Events:        []*SpanEvent{},
+		Failure:       failure,
+		ID:            sb.nextID,
+		Name:          name,
+		ParentID:      0,
+		Start:         spanDuration(t0),
+		TransactionID: txid,
+	}
+	if span.Attributes == nil {
+		span.Attributes = map[string]any{}
+	}
+	sb.nextID++
+	return span
+}
+
+// parent returns the parent span for the given transaction ID.
+func (sb *spansBuilder) parent(txid int64) *Span {
+	if txid <= 0 {
+		return sb.root
+	}
+	span, found := sb.txs[txid]
+	if !found {
+		span = &Span{
+			Attributes:    map[string]any{"transaction_id": txid},
+			Children:      []*Span{},
+			End:           0,
+			Events:        []*SpanEvent{},
+			Failure:       nil,
+			ID:            uint64(txid),
+			Name:          "transaction",
+			ParentID:      sb.root.ID,
+			Start:         0,
+			TransactionID: txid,
+		}
+		sb.txs[txid] = span
+		sb.root.Children = append(sb.root.Children, span)
+	}
+	return span
+}
+
+// addSpan adds the given span to the tree.
+func (sb *spansBuilder) addSpan(span *Span, tags []string) {
+	if len(tags) > 0 {
+		span.Attributes["tags"] = copyAndNormalizeTags(tags)
+	}
+	parent := sb.parent(span.TransactionID)
+	span.ParentID = parent.ID
+	parent.Children = append(parent.Children, span)
+}
+
+// addObservations adds the given observations to the tree.
+func (sb *spansBuilder) addObservations(obs *SpanObservations) {
+	for _, ev := range obs.Queries {
+		sb.addSpan(sb.newSpan(ev.TransactionID, "dns_lookup", ev.T0, ev.T, ev.Failure, map[string]any{
+			"answers":          spanDNSAnswers(ev.Answers),
+			"engine":           ev.Engine,
+			"hostname":         ev.Hostname,
+			"query_type":       ev.QueryType,
+			"resolver_address": ev.ResolverAddress,
+		}), ev.Tags)
+	}
+	for _, ev := range obs.TCPConnect {
+		sb.addSpan(sb.newSpan(ev.TransactionID, "tcp_connect", ev.T0, ev.T, ev.Status.Failure, map[string]any{
+			"ip":   ev.IP,
+			"port": int64(ev.Port),
+		}), ev.Tags)
+	}
+	for _, ev := range obs.TLSHandshakes {
+		sb.addSpan(sb.newHandshakeSpan("tls_handshake", ev), ev.Tags)
+	}
+	for _, ev := range obs.QUICHandshakes {
+		sb.addSpan(sb.newHandshakeSpan("quic_handshake", ev), ev.Tags)
+	}
+	for _, ev := range obs.Requests {
+		sb.addSpan(sb.newSpan(ev.TransactionID, "http_round_trip", ev.T0, ev.T, ev.Failure, map[string]any{
+			"address":              ev.Address,
+			"alpn":                 ev.ALPN,
+			"method":               ev.Request.Method,
+			"network":              ev.Network,
+			"response_status_code": ev.Response.Code,
+			"url":                  ev.Request.URL,
+		}), ev.Tags)
+	}
+	for _, ev := range obs.NetworkEvents {
+		sb.addEvent(ev)
+	}
+}
+
+// newHandshakeSpan creates a span for a TLS or QUIC handshake.
+func (sb *spansBuilder) newHandshakeSpan(name string, ev *model.ArchivalTLSOrQUICHandshakeResult) *Span {
+	return sb.newSpan(ev.TransactionID, name, ev.T0, ev.T, ev.Failure, map[string]any{
+		"address":             ev.Address,
+		"cipher_suite":        ev.CipherSuite,
+		"negotiated_protocol": ev.NegotiatedProtocol,
+		"network":             ev.Network,
+		"server_name":         ev.ServerName,
+		"tls_version":         ev.TLSVersion,
+	})
+}
+
+// spanDNSAnswers returns the addresses and CNAMEs contained by the given answers.
+func spanDNSAnswers(answers []model.ArchivalDNSAnswer) (out []string) {
+	out = []string{}
+	for _, answer := range answers {
+		switch {
+		case answer.IPv4 != "":
+			out = append(out, answer.IPv4)
+		case answer.IPv6 != "":
+			out = append(out, answer.IPv6)
+		case answer.Hostname != "":
+			out = append(out, answer.Hostname)
+		}
+	}
+	return
+}
+
+// addEvent adds a network event to the enclosing transaction.
+func (sb *spansBuilder) addEvent(ev *model.ArchivalNetworkEvent) {
+	attributes := map[string]any{}
+	if ev.Address != "" {
+		attributes["address"] = ev.Address
+	}
+	if ev.Failure != nil {
+		attributes["failure"] = *ev.Failure
+	}
+	if ev.NumBytes > 0 {
+		attributes["num_bytes"] = ev.NumBytes
+	}
+	if ev.Proto != "" {
+		attributes["proto"] = ev.Proto
+	}
+	if len(ev.Tags) > 0 {
+		attributes["tags"] = copyAndNormalizeTags(ev.Tags)
+	}
+	parent := sb.parent(ev.TransactionID)
+	parent.Events = append(parent.Events, &SpanEvent{
+		Attributes: attributes,
+		Name:       ev.Operation,
+		Time:       spanDuration(ev.T),
+	})
+}
+
+// finish sorts the spans and computes the bounds of the transactions and of the root.
+func (sb *spansBuilder) finish() {
+	for _, span := range sb.root.Children {
+		if span.ID < SpanIDBase {
+			spanSortAndComputeBounds(span)
+		}
+	}
+	spanSortAndComputeBounds(sb.root)
+}
+
+// spanSortAndComputeBounds sorts the children and the events of a span and sets
+// its start and end to include all its children and events.
+func spanSortAndComputeBounds(span *Span) {
+	sort.SliceStable(span.Children, func(i, j int) bool {
+		return span.Children[i].Start < span.Children[j].Start
+	})
+	sort.SliceStable(span.Events, func(i, j int) bool {
+		return span.Events[i].Time < span.Events[j].Time
+	})
+	first := true
+	extend := func(start, end time.Duration) {
+		if first || start < span.Start {
+			span.Start = start
+		}
+		if first || end > span.End {
+			span.End = end
+		}
+		first = false
+	}
+	for _, child := range span.Children {
+		extend(child.Start, child.End)
+	}
+	for _, ev := range span.Events {
+		extend(ev.Time, ev.Time)
+	}
+}
@@ -0,0 +1,321 @@
+package measurexlite
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/ooni/probe-cli/v3/internal/model"
+)
+
+// newSpanObservationsForTesting returns observations describing two transactions and
+// a DNS lookup without transaction ID, which should be attached to the root.
+func newSpanObservationsForTesting() *SpanObservations {
+	failure := "connection_reset"
+	return &SpanObservations{
+		NetworkEvents: []*model.ArchivalNetworkEvent{{
+			Address:       "93.184.216.34:443",
+			NumBytes:      517,
+			Operation:     "write",
+			Proto:         "tcp",
+			T0:            0.3,
+			T:             0.35,
+			TransactionID: 2,
+		}, {
+			Address:       "93.184.216.34:443",
+			Failure:       &failure,
+			Operation:     "read",
+			Proto:         "tcp",
+			T0:            0.35,
+			T:             0.5,
+			TransactionID: 2,
+		}},
+		Queries: []*model.ArchivalDNSLookupResult{{
+			Answers: []model.ArchivalDNSAnswer{{
+				AnswerType: "A",
+				IPv4:       "93.184.216.34",
+			}, {
+				AnswerType: "CNAME",
+				Hostname:   "www.example.com",
+			}},
+			Engine:          "udp",
+			Hostname:        "example.com",
+			QueryType:       "A",
+			ResolverAddress: "8.8.8.8:53",
+			T0:              0.01,
+			T:               0.1,
+			Tags:            []string{"depth=0"},
+			TransactionID:   1,
+		}, {
+			Engine:    "getaddrinfo",
+			Hostname:  "example.com",
+			QueryType: "ANY",
+			T0:        0.02,
+			T:         0.09,
+		}},
+		Requests: []*model.ArchivalHTTPRequestResult{{
+			Network: "tcp",
+			Address: "93.184.216.34:443",
+			ALPN:    "h2",
+			Request: model.ArchivalHTTPRequest{
+				Method: "GET",
+				URL:    "https://example.com/",
+			},
+			Response: model.ArchivalHTTPResponse{
+				Code: 200,
+			},
+			T0:            0.4,
+			T:             0.6,
+			TransactionID: 2,
+		}},
+		TCPConnect: []*model.ArchivalTCPConnectResult{{
+			IP:   "93.184.216.34",
+			Port: 443,
+			Status: model.ArchivalTCPConnectStatus{
+				Success: true,
+			},
+			T0:            0.1,
+			T:             0.2,
+			TransactionID: 2,
+		}},
+		TLSHandshakes: []*model.ArchivalTLSOrQUICHandshakeResult{{
+			Network:            "tcp",
+			Address:            "93.184.216.34:443",
+			CipherSuite:        "TLS_AES_128_GCM_SHA256",
+			NegotiatedProtocol: "h2",
+			ServerName:         "example.com",
+			T0:                 0.2,
+			T:                  0.3,
+			TLSVersion:         "TLSv1.3",
+			TransactionID:      2,
+		}},
+		QUICHandshakes: []*model.ArchivalTLSOrQUICHandshakeResult{},
+	}
+}
+
+func TestNewSpans(t *testing.T) {
+	zeroTime := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	spans := NewSpans(zeroTime, newSpanObservationsForTesting())
+
+	t.Run("the trace ID depends on the zero time", func(t *testing.T) {
+		expect := [16]byte{0, 0, 0, 0, 0, 0, 0, 0, 0x17, 0xb8, 0x9b, 0xa6, 0xf7, 0x1f, 0x40, 0x00}
+		if spans.TraceID != expect {
+			t.Fatalf("unexpected trace ID: %x", spans.TraceID)
+		}
+	})
+
+	t.Run("the root span includes all the other spans", func(t *testing.T) {
+		root := spans.Root
+		if root.Name != "measurement" || root.ID != SpanIDBase || root.ParentID != 0 {
+			t.Fatal("unexpected root", root.Name, root.ID, root.ParentID)
+		}
+		if root.Start != 10*time.Millisecond || root.End != 600*time.Millisecond {
+			t.Fatal("unexpected root bounds", root.Start, root.End)
+		}
+		var names []string
+		for _, child := range root.Children {
+			names = append(names, child.Name)
+		}
+		if diff := cmp.Diff([]string{"transaction", "dns_lookup", "transaction"}, names); diff != "" {
+			t.Fatal(diff)
+		}
+	})
+
+	t.Run("transaction spans use the transaction ID as their ID", func(t *testing.T) {
+		tx := spans.Root.Children[2]
+		if tx.ID != 2 || tx.TransactionID != 2 || tx.ParentID != SpanIDBase {
+			t.Fatal("unexpected transaction", tx.ID, tx.TransactionID, tx.ParentID)
+		}
+		if tx.Start != 100*time.Millisecond || tx.End != 600*time.Millisecond {
+			t.Fatal("unexpected transaction bounds", tx.Start, tx.End)
+		}
+		var names []string
+		for _, child := range tx.Children {
+			names = append(names, child.Name)
+			if child.ParentID != 2 || child.ID <= SpanIDBase {
+				t.Fatal("unexpected child IDs", child.ID, child.ParentID)
+			}
+		}
+		expectNames := []string{"tcp_connect", "tls_handshake", "http_round_trip"}
+		if diff := cmp.Diff(expectNames, names); diff != "" {
+			t.Fatal(diff)
+		}
+		if len(tx.Events) != 2 || tx.Events[0].Name != "write" || tx.Events[1].Name != "read" {
+			t.Fatal("unexpected events")
+		}
+		if tx.Events[1].Attributes["failure"] != "connection_reset" {
+			t.Fatal("unexpected failure", tx.Events[1].Attributes)
+		}
+	})
+
+	t.Run("operation spans contain the expected attributes", func(t *testing.T) {
+		dns := spans.Root.Children[0].Children[0]
+		expect := map[string]any{
+			"answers":          []string{"93.184.216.34", "www.example.com"},
+			"engine":           "udp",
+			"hostname":         "example.com",
+			"query_type":       "A",
+			"resolver_address": "8.8.8.8:53",
+			"tags":             []string{"depth=0"},
+		}
+		if diff := cmp.Diff(expect, dns.Attributes); diff != "" {
+			t.Fatal(diff)
+		}
+		if dns.Start != 10*time.Millisecond || dns.End != 100*time.Millisecond {
+			t.Fatal("unexpected bounds", dns.Start, dns.End)
+		}
+	})
+
+	t.Run("we correctly handle empty observations", func(t *testing.T) {
+		spans := NewSpans(zeroTime)
+		if len(spans.Root.Children) != 0 || spans.Root.Start != 0 || spans.Root.End != 0 {
+			t.Fatal("unexpected root")
+		}
+	})
+}
+
+func TestNewSpanObservationsFromTrace(t *testing.T) {
+	trace := NewTrace(0, time.Now())
+	trace.networkEvent <- &model.ArchivalNetworkEvent{Operation: "read"}
+	trace.dnsLookup <- &model.ArchivalDNSLookupResult{Hostname: "example.com"}
+	trace.tcpConnect <- &model.ArchivalTCPConnectResult{IP: "93.184.216.34"}
+	trace.tlsHandshake <- &model.ArchivalTLSOrQUICHandshakeResult{Network: "tcp"}
+	trace.quicHandshake <- &model.ArchivalTLSOrQUICHandshakeResult{Network: "udp"}
+	obs := NewSpanObservationsFromTrace(trace)
+	if len(obs.NetworkEvents) != 1 || len(obs.Queries) != 1 || len(obs.TCPConnect) != 1 ||
+		len(obs.TLSHandshakes) != 1 || len(obs.QUICHandshakes) != 1 || len(obs.Requests) != 0 {
+		t.Fatal("unexpected observations")
+	}
+	if len(trace.NetworkEvents()) != 0 {
+		t.Fatal("expected the trace to be drained")
+	}
+}
+
+func TestSpansWriteOTLPJSON(t *testing.T) {
+	zeroTime := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	spans := NewSpans(zeroTime, newSpanObservationsForTesting())
+	buffer := &bytes.Buffer{}
+	if err := spans.WriteOTLPJSON(buffer); err != nil {
+		t.Fatal(err)
+	}
+	var request otlpExportTraceServiceRequest
+	if err := json.Unmarshal(buffer.Bytes(), &request); err != nil {
+		t.Fatal(err)
+	}
+	ospans := request.ResourceSpans[0].ScopeSpans[0].Spans
+	if len(ospans) != 8 {
+		t.Fatal("unexpected number of spans", len(ospans))
+	}
+
+	t.Run("the root span", func(t *testing.T) {
+		root := ospans[0]
+		if root.TraceID != "000000000000000017b89ba6f71f4000" {
+			t.Fatal("unexpected trace ID", root.TraceID)
+		}
+		if root.SpanID != "0000000100000000" || root.ParentSpanID != "" {
+			t.Fatal("unexpected span IDs", root.SpanID, root.ParentSpanID)
+		}
+		if root.StartTimeUnixNano != "1709287200010000000" || root.EndTimeUnixNano != "1709287200600000000" {
+			t.Fatal("unexpected times", root.StartTimeUnixNano, root.EndTimeUnixNano)
+		}
+		if root.Kind != otlpSpanKindInternal {
+			t.Fatal("unexpected kind", root.Kind)
+		}
+	})
+
+	t.Run("the HTTP round trip span", func(t *testing.T) {
+		http := ospans[7]
+		if http.Name != "http_round_trip" || http.ParentSpanID != "0000000000000002" {
+			t.Fatal("unexpected span", http.Name, http.ParentSpanID)
+		}
+		if http.Kind != otlpSpanKindClient || http.Status.Code != otlpStatusCodeOK {
+			t.Fatal("unexpected kind or status", http.Kind, http.Status.Code)
+		}
+		var found bool
+		for _, attr := range http.Attributes {
+			if attr.Key == "response_status_code" {
+				found = attr.Value.IntValue != nil && *attr.Value.IntValue == "200"
+			}
+		}
+		if !found {
+			t.Fatal("did not find the expected status code")
+		}
+	})
+
+	t.Run("the transaction span includes the network events", func(t *testing.T) {
+		tx := ospans[4]
+		if tx.SpanID != "0000000000000002" || len(tx.Events) != 2 {
+			t.Fatal("unexpected span", tx.SpanID, len(tx.Events))
+		}
+		if tx.Events[1].Name != "read" || tx.Events[1].TimeUnixNano != "1709287200500000000" {
+			t.Fatal("unexpected event", tx.Events[1].Name, tx.Events[1].TimeUnixNano)
+		}
+	})
+
+	t.Run("failed operations have an error status", func(t *testing.T) {
+		failure := "generic_timeout_error"
+		spans := NewSpans(zeroTime, &SpanObservations{
+			TCPConnect: []*model.ArchivalTCPConnectResult{{
+				Status:        model.ArchivalTCPConnectStatus{Failure: &failure},
+				TransactionID: 1,
+			}},
+		})
+		buffer := &bytes.Buffer{}
+		if err := spans.WriteOTLPJSON(buffer); err != nil {
+			t.Fatal(err)
+		}
+		var request otlpExportTraceServiceRequest
+		if err := json.Unmarshal(buffer.Bytes(), &request); err != nil {
+			t.Fatal(err)
+		}
+		status := request.ResourceSpans[0].ScopeSpans[0].Spans[2].Status
+		if status.Code != otlpStatusCodeError || status.Message != failure {
+			t.Fatal("unexpected status", status.Code, status.Message)
+		}
+	})
+}
+
+func TestSpansWriteChromeTraceEvents(t *testing.T) {
+	zeroTime := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	spans := NewSpans(zeroTime, newSpanObservationsForTesting())
+	buffer := &bytes.Buffer{}
+	if err := spans.WriteChromeTraceEvents(buffer); err != nil {
+		t.Fatal(err)
+	}
+	var trace chromeTrace
+	if err := json.Unmarshal(buffer.Bytes(), &trace); err != nil {
+		t.Fatal(err)
+	}
+	type summary struct {
+		Name  string
+		Phase string
+		TID   int64
+		TS    float64
+	}
+	var got []summary
+	for _, ev := range trace.TraceEvents {
+		got = append(got, summary{ev.Name, ev.Phase, ev.TID, ev.Timestamp})
+	}
+	expect := []summary{
+		{"measurement", "X", 0, 10000},
+		{"thread_name", "M", 1, 0},
+		{"transaction", "X", 1, 10000},
+		{"dns_lookup", "X", 1, 10000},
+		{"dns_lookup", "X", 0, 20000},
+		{"thread_name", "M", 2, 0},
+		{"transaction", "X", 2, 100000},
+		{"write", "i", 2, 350000},
+		{"read", "i", 2, 500000},
+		{"tcp_connect", "X", 2, 100000},
+		{"tls_handshake", "X", 2, 200000},
+		{"http_round_trip", "X", 2, 400000},
+	}
+	if diff := cmp.Diff(expect, got); diff != "" {
+		t.Fatal(diff)
+	}
+	if *trace.TraceEvents[3].Duration != 90000 {
+		t.Fatal("unexpected duration", *trace.TraceEvents[3].Duration)
+	}
+}
@@ -0,0 +1,269 @@
+package measurexlite
+
+//
+// Exporting spans using the OTLP/JSON and Chrome trace event formats
+//
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// WriteOTLPJSON writes the spans into the given [io.Writer] using the OTLP/JSON encoding
+// of an ExportTraceServiceRequest, which you can import in trace viewers such as Jaeger.
+//
+// See https://opentelemetry.io/docs/specs/otlp/#json-protobuf-encoding.
+func (s *Spans) WriteOTLPJSON(w io.Writer) error {
+	request := &otlpExportTraceServiceRequest{
+		ResourceSpans: []*otlpResourceSpans{{
+			Resource: &otlpResource{
+				Attributes: otlpAttributes(map[string]any{"service.name": "ooniprobe"}),
+			},
+			ScopeSpans: []*otlpScopeSpans{{
+				Scope: &otlpScope{Name: "measurexlite"},
+				Spans: s.otlpSpans(s.Root, []*otlpSpan{}),
+			}},
+		}},
+	}
+	return json.NewEncoder(w).Encode(request)
+}
+
+type otlpExportTraceServiceRequest struct {
+	ResourceSpans []*otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   *otlpResource     `json:"resource"`
+	ScopeSpans []*otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []*otlpKeyValue `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope *otlpScope  `json:"scope"`
+	Spans []*otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceID           string          `json:"traceId"`
+	SpanID            string          `json:"spanId"`
+	ParentSpanID      string          `json:"parentSpanId,omitempty"`
+	Name              string          `json:"name"`
+	Kind              int64           `json:"kind"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+	Attributes        []*otlpKeyValue `json:"attributes"`
+	Events            []*otlpEvent    `json:"events"`
+	Status            *otlpStatus     `json:"status"`
+}
+
+// These constants are defined by the OTLP protobuf spec.
+const (
+	otlpSpanKindInternal = 1
+	otlpSpanKindClient   = 3
+	otlpStatusCodeOK     = 1
+	otlpStatusCodeError  = 2
+)
+
+type otlpEvent struct {
+	TimeUnixNano string          `json:"timeUnixNano"`
+	Name         string          `json:"name"`
+	Attributes   []*otlpKeyValue `json:"attributes"`
+}
+
+type otlpStatus struct {
+	Code    int64  `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+type otlpKeyValue struct {
+	Key   string        `json:"key"`
+	Value *otlpAnyValue `json:"value"`
+}
+
+type otlpAnyValue struct {
+	StringValue *string         `json:"stringValue,omitempty"`
+	BoolValue   *bool           `json:"boolValue,omitempty"`
+	IntValue    *string         `json:"intValue,omitempty"` // int64 values are strings in OTLP/JSON
+	DoubleValue *float64        `json:"doubleValue,omitempty"`
+	ArrayValue  *otlpArrayValue `json:"arrayValue,omitempty"`
+}
+
+type otlpArrayValue struct {
+	Values []*otlpAnyValue `json:"values"`
+}
+
+// otlpSpans appends the given span and its descendants to the given list.
+func (s *Spans) otlpSpans(span *Span, out []*otlpSpan) []*otlpSpan {
+	ospan := &otlpSpan{
+		TraceID:           hex.EncodeToString(s.TraceID[:]),
+		SpanID:            otlpSpanID(span.ID),
+		ParentSpanID:      "",
+		Name:              span.Name,
+		Kind:              otlpSpanKindInternal,
+		StartTimeUnixNano: s.otlpTime(span.Start),
+		EndTimeUnixNano:   s.otlpTime(span.End),
+		Attributes:        otlpAttributes(span.Attributes),
+		Events:            []*otlpEvent{},
+		Status:            &otlpStatus{Code: otlpStatusCodeOK},
+	}
+	if span.ParentID != 0 {
+		ospan.ParentSpanID = otlpSpanID(span.ParentID)
+	}
+	if span.ID >= SpanIDBase && span.ParentID != 0 {
+		ospan.Kind = otlpSpanKindClient // i.e., an operation
+	}
+	if span.Failure != nil {
+		ospan.Status = &otlpStatus{Code: otlpStatusCodeError, Message: *span.Failure}
+	}
+	for _, ev := range span.Events {
+		ospan.Events = append(ospan.Events, &otlpEvent{
+			TimeUnixNano: s.otlpTime(ev.Time),
+			Name:         ev.Name,
+			Attributes:   otlpAttributes(ev.Attributes),
+		})
+	}
+	out = append(out, ospan)
+	for _, child := range span.Children {
+		out = s.otlpSpans(child, out)
+	}
+	return out
+}
+
+// otlpSpanID formats a span ID as OTLP/JSON expects.
+func otlpSpanID(id uint64) string {
+	return fmt.Sprintf("%016x", id)
+}
+
+// otlpTime converts a time relative to the zero time to OTLP/JSON.
+func (s *Spans) otlpTime(t time.Duration) string {
+	return strconv.FormatInt(s.ZeroTime.Add(t).UnixNano(), 10)
+}
+
+// otlpAttributes converts attributes to OTLP/JSON sorting them by key.
+func otlpAttributes(attributes map[string]any) (out []*otlpKeyValue) {
+	out = []*otlpKeyValue{}
+	for key, value := range attributes {
+		out = append(out, &otlpKeyValue{Key: key, Value: otlpValue(value)})
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].Key < out[j].Key
+	})
+	return
+}
+
+// otlpValue converts an attribute value to OTLP/JSON.
+func otlpValue(value any) *otlpAnyValue {
+	switch v := value.(type) {
+	case string:
+		return &otlpAnyValue{StringValue: &v}
+	case bool:
+		return &otlpAnyValue{BoolValue: &v}
+	case int64:
+		s := strconv.FormatInt(v, 10)
+		return &otlpAnyValue{IntValue: &s}
+	case float64:
+		return &otlpAnyValue{DoubleValue: &v}
+	case []string:
+		array := &otlpArrayValue{Values: []*otlpAnyValue{}}
+		for _, entry := range v {
+			array.Values = append(array.Values, otlpValue(entry))
+		}
+		return &otlpAnyValue{ArrayValue: array}
+	default:
+		s := fmt.Sprintf("%v", v)
+		return &otlpAnyValue{StringValue: &s}
+	}
+}
+
+// WriteChromeTraceEvents writes the spans into the given [io.Writer] using the Chrome
+// trace event format, which you can load into chrome://tracing or https://ui.perfetto.dev.
+// We show each transaction as a distinct thread and the timestamps are relative to the
+// zero time. Network events become instant events within their transaction's thread.
+//
+// See https://docs.google.com/document/d/1CvAClvFfyA5R-PhYUmn5OOQtYMH4h6I0nSsKchNAySU.
+func (s *Spans) WriteChromeTraceEvents(w io.Writer) error {
+	trace := &chromeTrace{
+		DisplayTimeUnit: "ms",
+		TraceEvents:     s.chromeEvents(s.Root, []*chromeTraceEvent{}),
+	}
+	return json.NewEncoder(w).Encode(trace)
+}
+
+type chromeTrace struct {
+	DisplayTimeUnit string              `json:"displayTimeUnit"`
+	TraceEvents     []*chromeTraceEvent `json:"traceEvents"`
+}
+
+type chromeTraceEvent struct {
+	Args      map[string]any `json:"args,omitempty"`
+	Category  string         `json:"cat,omitempty"`
+	Duration  *float64       `json:"dur,omitempty"`
+	Name      string         `json:"name"`
+	Phase     string         `json:"ph"`
+	PID       int64          `json:"pid"`
+	Scope     string         `json:"s,omitempty"`
+	TID       int64          `json:"tid"`
+	Timestamp float64        `json:"ts"`
+}
+
+// chromeMicroseconds converts a [time.Duration] to microseconds.
+func chromeMicroseconds(t time.Duration) float64 {
+	return float64(t) / float64(time.Microsecond)
+}
+
+// chromeEvents appends the events for the given span and its descendants to the given list.
+func (s *Spans) chromeEvents(span *Span, out []*chromeTraceEvent) []*chromeTraceEvent {
+	tid := span.TransactionID
+	if span.ID < SpanIDBase {
+		out = append(out, &chromeTraceEvent{
+			Args:  map[string]any{"name": fmt.Sprintf("transaction #%d", tid)},
+			Name:  "thread_name",
+			Phase: "M",
+			TID:   tid,
+		})
+	}
+	args := map[string]any{}
+	for key, value := range span.Attributes {
+		args[key] = value
+	}
+	if span.Failure != nil {
+		args["failure"] = *span.Failure
+	}
+	duration := chromeMicroseconds(span.End - span.Start)
+	out = append(out, &chromeTraceEvent{
+		Args:      args,
+		Category:  "measurexlite",
+		Duration:  &duration,
+		Name:      span.Name,
+		Phase:     "X",
+		TID:       tid,
+		Timestamp: chromeMicroseconds(span.Start),
+	})
+	for _, ev := range span.Events {
+		out = append(out, &chromeTraceEvent{
+			Args:      ev.Attributes,
+			Category:  "network_event",
+			Name:      ev.Name,
+			Phase:     "i",
+			Scope:     "t",
+			TID:       tid,
+			Timestamp: chromeMicroseconds(ev.Time),
+		})
+	}
+	for _, child := range span.Children {
+		out = s.chromeEvents(child, out)
+	}
+	return out
+}
@@ -0,0 +1,76 @@
+package measurexlite
+
+//
+// TCP_INFO sampling
+//
+
+import (
+	"errors"
+	"net"
+	"sync"
+
+	"github.com/ooni/probe-cli/v3/internal/model"
+	"github.com/ooni/probe-cli/v3/internal/netxlite"
+)
+
+// errTCPInfoNotSupported indicates that we cannot sample TCP_INFO for a conn
+// because we're not on Linux or because the conn is not a kernel socket (e.g.,
+// when using netem, whose TCP conns live inside a userspace TCP/IP stack).
+var errTCPInfoNotSupported = errors.New("measurexlite: TCP_INFO not supported")
+
+// maybeSampleTCPInfo samples the TCP_INFO of the given conn and saves the result
+// inside the trace. The operation argument indicates when we're sampling (e.g.,
+// "connect"). This method returns false when sampling is not possible.
+func (tx *Trace) maybeSampleTCPInfo(operation string, conn net.Conn) bool {
+	if conn == nil {
+		return false
+	}
+	sample, err := tcpInfoSample(netxlite.UnwrapNetConn(conn))
+	if err != nil {
+		return false
+	}
+	sample.Address = safeRemoteAddrString(conn)
+	sample.Operation = operation
+	sample.T = tx.TimeSince(tx.ZeroTime()).Seconds()
+	sample.Tags = copyAndNormalizeTags(tx.tags)
+	sample.TransactionID = tx.Index()
+	select {
+	case tx.tcpInfo <- sample:
+	default: // buffer is full
+	}
+	return true
+}
+
+// TCPInfoSamples drains the TCP_INFO samples buffered inside the TCPInfo channel. We
+// sample TCP_INFO after connect, after the TLS handshake and before closing the conn
+// returned by the dialer. We only collect samples on Linux.
+func (tx *Trace) TCPInfoSamples() (out []*model.ArchivalTCPInfo) {
+	for {
+		select {
+		case ev := <-tx.tcpInfo:
+			out = append(out, ev)
+		default:
+			return // done
+		}
+	}
+}
+
+// tcpInfoConn is a TCP conn that samples TCP_INFO before closing.
+type tcpInfoConn struct {
+	net.Conn
+	once sync.Once
+	tx   *Trace
+}
+
+// Close implements net.Conn.
+func (c *tcpInfoConn) Close() error {
+	c.once.Do(func() {
+		c.tx.maybeSampleTCPInfo("close", c.Conn)
+	})
+	return c.Conn.Close()
+}
+
+// NetConn returns the underlying net.Conn.
+func (c *tcpInfoConn) NetConn() net.Conn {
+	return c.Conn
+}
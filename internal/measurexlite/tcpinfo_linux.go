@@ -0,0 +1,51 @@
+//go:build linux
+
+package measurexlite
+
+import (
+	"net"
+	"syscall"
+
+	"github.com/ooni/probe-cli/v3/internal/model"
+	"golang.org/x/sys/unix"
+)
+
+// tcpInfoSample samples the TCP_INFO of the given unwrapped conn.
+func tcpInfoSample(conn net.Conn) (*model.ArchivalTCPInfo, error) {
+	sconn, good := conn.(syscall.Conn)
+	if !good {
+		return nil, errTCPInfoNotSupported
+	}
+	rawConn, err := sconn.SyscallConn()
+	if err != nil {
+		return nil, err
+	}
+	var (
+		info   *unix.TCPInfo
+		errOpt error
+	)
+	err = rawConn.Control(func(fd uintptr) {
+		info, errOpt = unix.GetsockoptTCPInfo(int(fd), unix.IPPROTO_TCP, unix.TCP_INFO)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if errOpt != nil {
+		return nil, errOpt
+	}
+	sample := &model.ArchivalTCPInfo{
+		BytesAcked:    info.Bytes_acked,
+		BytesReceived: info.Bytes_received,
+		BytesRetrans:  info.Bytes_retrans,
+		DeliveryRate:  info.Delivery_rate,
+		Lost:          info.Lost,
+		MinRTT:        info.Min_rtt,
+		RTT:           info.Rtt,
+		RTTVar:        info.Rttvar,
+		Retrans:       info.Retrans,
+		SndCwnd:       info.Snd_cwnd,
+		SndMSS:        info.Snd_mss,
+		TotalRetrans:  info.Total_retrans,
+	}
+	return sample, nil
+}
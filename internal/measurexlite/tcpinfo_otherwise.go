@@ -0,0 +1,14 @@
+//go:build !linux
+
+package measurexlite
+
+import (
+	"net"
+
+	"github.com/ooni/probe-cli/v3/internal/model"
+)
+
+// tcpInfoSample returns an error because we only support TCP_INFO on Linux.
+func tcpInfoSample(conn net.Conn) (*model.ArchivalTCPInfo, error) {
+	return nil, errTCPInfoNotSupported
+}
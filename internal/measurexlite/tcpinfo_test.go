@@ -0,0 +1,110 @@
+package measurexlite
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/ooni/probe-cli/v3/internal/mocks"
+	"github.com/ooni/probe-cli/v3/internal/model"
+	"github.com/ooni/probe-cli/v3/internal/netxlite"
+	"github.com/ooni/probe-cli/v3/internal/runtimex"
+)
+
+func TestTCPInfo(t *testing.T) {
+	t.Run("we do not sample TCP_INFO for non-kernel conns", func(t *testing.T) {
+		trace := NewTrace(0, time.Now())
+		conn := &mocks.Conn{
+			MockRemoteAddr: func() net.Addr {
+				return &mocks.Addr{
+					MockString: func() string {
+						return "1.1.1.1:443"
+					},
+				}
+			},
+		}
+		trace.maybeSampleTCPInfo(netxlite.ConnectOperation, conn)
+		trace.maybeSampleTCPInfo(netxlite.ConnectOperation, nil)
+		if samples := trace.TCPInfoSamples(); len(samples) != 0 {
+			t.Fatal("expected no samples")
+		}
+	})
+
+	t.Run("we sample TCP_INFO at connect, TLS handshake, and close", func(t *testing.T) {
+		if runtime.GOOS != "linux" {
+			t.Skip("TCP_INFO is only supported on Linux")
+		}
+
+		listener := runtimex.Try1(net.Listen("tcp", "127.0.0.1:0"))
+		defer listener.Close()
+		go func() {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}()
+
+		trace := NewTrace(7, time.Now(), "antani")
+		dialer := trace.NewDialerWithoutResolver(model.DiscardLogger)
+		conn, err := dialer.DialContext(context.Background(), "tcp", listener.Addr().String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		thx := trace.NewTLSHandshakerStdlib(model.DiscardLogger)
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		_, _ = thx.Handshake(ctx, conn, &tls.Config{ServerName: "example.com"})
+		conn.Close()
+		conn.Close() // we should only sample once
+
+		samples := trace.TCPInfoSamples()
+		expectOps := []string{netxlite.ConnectOperation, "tls_handshake_done", "close"}
+		if len(samples) != len(expectOps) {
+			t.Fatal("unexpected number of samples", len(samples))
+		}
+		for idx, sample := range samples {
+			if sample.Operation != expectOps[idx] {
+				t.Fatal("unexpected operation", sample.Operation)
+			}
+			if sample.Address != listener.Addr().String() {
+				t.Fatal("unexpected address", sample.Address)
+			}
+			if sample.TransactionID != 7 {
+				t.Fatal("unexpected transaction ID", sample.TransactionID)
+			}
+			if len(sample.Tags) != 1 || sample.Tags[0] != "antani" {
+				t.Fatal("unexpected tags", sample.Tags)
+			}
+			if sample.SndMSS <= 0 {
+				t.Fatal("expected positive MSS")
+			}
+		}
+	})
+
+	t.Run("we do not wrap UDP conns and non-kernel TCP conns", func(t *testing.T) {
+		trace := NewTrace(0, time.Now())
+		trace.Netx = &mocks.MeasuringNetwork{
+			MockNewDialerWithoutResolver: func(dl model.DebugLogger, w ...model.DialerWrapper) model.Dialer {
+				return &mocks.Dialer{
+					MockDialContext: func(ctx context.Context, network, address string) (net.Conn, error) {
+						return &mocks.Conn{}, nil
+					},
+				}
+			},
+		}
+		dialer := trace.NewDialerWithoutResolver(model.DiscardLogger)
+		for _, network := range []string{"udp", "tcp"} {
+			conn, err := dialer.DialContext(context.Background(), network, "1.1.1.1:443")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, good := conn.(*tcpInfoConn); good {
+				t.Fatal("should not have wrapped the conn for", network)
+			}
+		}
+	})
+}
@@ -36,7 +36,9 @@ var _ model.TLSHandshaker = &tlsHandshakerTrace{}
 // Handshake implements model.TLSHandshaker.Handshake.
 func (thx *tlsHandshakerTrace) Handshake(
 	ctx context.Context, conn net.Conn, tlsConfig *tls.Config) (model.TLSConn, error) {
-	return thx.thx.Handshake(netxlite.ContextWithTrace(ctx, thx.tx), conn, tlsConfig)
+	tlsConn, err := thx.thx.Handshake(netxlite.ContextWithTrace(ctx, thx.tx), conn, tlsConfig)
+	thx.tx.maybeSampleTCPInfo("tls_handshake_done", conn)
+	return tlsConn, err
 }
 
 // OnTLSHandshakeStart implements model.Trace.OnTLSHandshakeStart.
@@ -54,6 +54,9 @@ type Trace struct {
 	// tcpConnect is MANDATORY and buffers TCP connect observations.
 	tcpConnect chan *model.ArchivalTCPConnectResult
 
+	// tcpInfo is MANDATORY and buffers TCP_INFO samples.
+	tcpInfo chan *model.ArchivalTCPInfo
+
 	// tlsHandshake is MANDATORY and buffers TLS handshake observations.
 	tlsHandshake chan *model.ArchivalTLSOrQUICHandshakeResult
 
@@ -85,6 +88,9 @@ const DelayedDNSResponseBufferSize = 8
 // TCPConnectBufferSize is the [*Trace] buffer size for TCP connect events.
 const TCPConnectBufferSize = 8
 
+// TCPInfoBufferSize is the [*Trace] buffer size for TCP_INFO samples.
+const TCPInfoBufferSize = 16
+
 // TLSHandshakeBufferSize is the [*Trace] buffer size for TLS handshake events.
 const TLSHandshakeBufferSize = 8
 
@@ -127,6 +133,10 @@ func NewTrace(index int64, zeroTime time.Time, tags ...string) *Trace {
 			chan *model.ArchivalTCPConnectResult,
 			TCPConnectBufferSize,
 		),
+		tcpInfo: make(
+			chan *model.ArchivalTCPInfo,
+			TCPInfoBufferSize,
+		),
 		tlsHandshake: make(
 			chan *model.ArchivalTLSOrQUICHandshakeResult,
 			TLSHandshakeBufferSize,
@@ -119,6 +119,25 @@ func TestNewTrace(t *testing.T) {
 			}
 		})
 
+		t.Run("tcpInfo has the expected buffer size", func(t *testing.T) {
+			ff := &testingx.FakeFiller{}
+			var idx int
+		Loop:
+			for {
+				ev := &model.ArchivalTCPInfo{}
+				ff.Fill(ev)
+				select {
+				case trace.tcpInfo <- ev:
+					idx++
+				default:
+					break Loop
+				}
+			}
+			if idx != TCPInfoBufferSize {
+				t.Fatal("invalid tcpInfo channel buffer size")
+			}
+		})
+
 		t.Run("tlsHandshake has the expected buffer size", func(t *testing.T) {
 			ff := &testingx.FakeFiller{}
 			var idx int
@@ -218,6 +237,20 @@ func TestTrace(t *testing.T) {
 		}
 	})
 
+	t.Run("NewParallelDNSOverQUICResolver works as intended", func(t *testing.T) {
+		tx := NewTrace(0, time.Now())
+		resolver := tx.NewParallelDNSOverQUICResolver(model.DiscardLogger, "94.140.14.14:853")
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		addrs, err := resolver.LookupHost(ctx, "example.com")
+		if err == nil || err.Error() != netxlite.FailureInterrupted {
+			t.Fatal("unexpected err", err)
+		}
+		if len(addrs) != 0 {
+			t.Fatal("expected array of size 0")
+		}
+	})
+
 	t.Run("NewDialerWithoutResolver works as intended", func(t *testing.T) {
 		tx := NewTrace(0, time.Now())
 		dialer := tx.NewDialerWithoutResolver(model.DiscardLogger)
@@ -16,6 +16,7 @@ func NormalizeDNSLookupResults(values []*model.ArchivalDNSLookupResult) {
 		}
 		entry.T0 = 0
 		entry.T = 0
+		entry.RawQuery = nil
 		entry.RawResponse = nil
 	}
 }
@@ -32,11 +32,13 @@ func TestNormalizeDNSLookupResults(t *testing.T) {
 		inputGen: func() []*model.ArchivalDNSLookupResult {
 			return []*model.ArchivalDNSLookupResult{{
 				Engine:      "udp",
+				RawQuery:    []byte("0xabad1dea"),
 				RawResponse: []byte("0xdeadbeef"),
 				T0:          0.11,
 				T:           0.4,
 			}, {
 				Engine:      "doh",
+				RawQuery:    []byte("0xabad1dea"),
 				RawResponse: []byte("0xdeadbeef"),
 				T0:          0.5,
 				T:           0.66,
@@ -8,7 +8,7 @@ import (
 
 // Database allows mocking a database
 type Database struct {
-	MockCreateNetwork        func(loc model.LocationProvider) (*model.DatabaseNetwork, error)
+	MockCreateNetwork        func(loc model.LocationProvider, info model.DatabaseNetworkInfo) (*model.DatabaseNetwork, error)
 	MockCreateOrUpdateURL    func(urlStr string, categoryCode string, countryCode string) (int64, error)
 	MockCreateResult         func(homePath string, testGroupName string, networkID int64) (*model.DatabaseResult, error)
 	MockUpdateUploadedStatus func(result *model.DatabaseResult) error
@@ -24,13 +24,16 @@ type Database struct {
 	MockListResults        func() ([]model.DatabaseResultNetwork, []model.DatabaseResultNetwork, error)
 	MockListMeasurements   func(resultID int64) ([]model.DatabaseMeasurementURLNetwork, error)
 	MockGetMeasurementJSON func(msmtID int64) (map[string]interface{}, error)
+	MockListPendingUploads func() ([]model.DatabaseMeasurement, error)
+	MockGetResult          func(resultID int64) (*model.DatabaseResult, error)
 }
 
 var _ model.WritableDatabase = &Database{}
 
 // CreateNetwork calls MockCreateNetwork
-func (d *Database) CreateNetwork(loc model.LocationProvider) (*model.DatabaseNetwork, error) {
-	return d.MockCreateNetwork(loc)
+func (d *Database) CreateNetwork(
+	loc model.LocationProvider, info model.DatabaseNetworkInfo) (*model.DatabaseNetwork, error) {
+	return d.MockCreateNetwork(loc, info)
 }
 
 // CreateOrUpdateURL calls MockCreateOrUpdateURL
@@ -105,3 +108,13 @@ func (d *Database) ListMeasurements(resultID int64) ([]model.DatabaseMeasurement
 func (d *Database) GetMeasurementJSON(msmtID int64) (map[string]interface{}, error) {
 	return d.MockGetMeasurementJSON(msmtID)
 }
+
+// ListPendingUploads calls MockListPendingUploads
+func (d *Database) ListPendingUploads() ([]model.DatabaseMeasurement, error) {
+	return d.MockListPendingUploads()
+}
+
+// GetResult calls MockGetResult
+func (d *Database) GetResult(resultID int64) (*model.DatabaseResult, error) {
+	return d.MockGetResult(resultID)
+}
@@ -12,12 +12,12 @@ func TestDatabase(t *testing.T) {
 	t.Run("CreateNetwork", func(t *testing.T) {
 		expected := errors.New("mocked")
 		db := &Database{
-			MockCreateNetwork: func(loc model.LocationProvider) (*model.DatabaseNetwork, error) {
+			MockCreateNetwork: func(loc model.LocationProvider, info model.DatabaseNetworkInfo) (*model.DatabaseNetwork, error) {
 				return nil, expected
 			},
 		}
 		sess := &LocationProvider{}
-		network, err := db.CreateNetwork(sess)
+		network, err := db.CreateNetwork(sess, model.DatabaseNetworkInfo{})
 		if network != nil {
 			t.Fatal("expected nil network")
 		}
@@ -243,4 +243,36 @@ func TestDatabase(t *testing.T) {
 			t.Fatal("not the error we expected")
 		}
 	})
+
+	t.Run("ListPendingUploads", func(t *testing.T) {
+		expected := errors.New("mocked")
+		db := &Database{
+			MockListPendingUploads: func() ([]model.DatabaseMeasurement, error) {
+				return nil, expected
+			},
+		}
+		msmts, err := db.ListPendingUploads()
+		if msmts != nil {
+			t.Fatal("expected nil measurements")
+		}
+		if !errors.Is(err, expected) {
+			t.Fatal("not the error we expected")
+		}
+	})
+
+	t.Run("GetResult", func(t *testing.T) {
+		expected := errors.New("mocked")
+		db := &Database{
+			MockGetResult: func(resultID int64) (*model.DatabaseResult, error) {
+				return nil, expected
+			},
+		}
+		result, err := db.GetResult(0)
+		if result != nil {
+			t.Fatal("expected nil result")
+		}
+		if !errors.Is(err, expected) {
+			t.Fatal("not the error we expected")
+		}
+	})
 }
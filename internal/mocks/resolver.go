@@ -15,6 +15,7 @@ type Resolver struct {
 	MockCloseIdleConnections func()
 	MockLookupHTTPS          func(ctx context.Context, domain string) (*model.HTTPSSvc, error)
 	MockLookupNS             func(ctx context.Context, domain string) ([]*net.NS, error)
+	MockLookupRaw            func(ctx context.Context, domain string, qtype uint16) ([]*model.DNSRecord, error)
 }
 
 // LookupHost calls MockLookupHost.
@@ -46,3 +47,8 @@ func (r *Resolver) LookupHTTPS(ctx context.Context, domain string) (*model.HTTPS
 func (r *Resolver) LookupNS(ctx context.Context, domain string) ([]*net.NS, error) {
 	return r.MockLookupNS(ctx, domain)
 }
+
+// LookupRaw calls MockLookupRaw.
+func (r *Resolver) LookupRaw(ctx context.Context, domain string, qtype uint16) ([]*model.DNSRecord, error) {
+	return r.MockLookupRaw(ctx, domain, qtype)
+}
@@ -179,28 +179,49 @@ func (value *ArchivalScrubbedMaybeBinaryString) UnmarshalJSON(rawData []byte) er
 //
 // See https://github.com/ooni/spec/blob/master/data-formats/df-002-dnst.md.
 type ArchivalDNSLookupResult struct {
+	AnswerSection    []string            `json:"answer_section,omitempty"`
 	Answers          []ArchivalDNSAnswer `json:"answers"`
+	EDNS0            *ArchivalDNSEDNS0   `json:"edns0,omitempty"`
 	Engine           string              `json:"engine"`
 	Failure          *string             `json:"failure"`
 	GetaddrinfoError int64               `json:"getaddrinfo_error,omitempty"`
 	Hostname         string              `json:"hostname"`
 	QueryType        string              `json:"query_type"`
+	RawQuery         []byte              `json:"raw_query,omitempty"`
 	RawResponse      []byte              `json:"raw_response,omitempty"`
 	Rcode            int64               `json:"rcode,omitempty"`
 	ResolverHostname *string             `json:"resolver_hostname"`
 	ResolverPort     *string             `json:"resolver_port"`
 	ResolverAddress  string              `json:"resolver_address"`
+	ResponseFlags    []string            `json:"response_flags,omitempty"`
 	T0               float64             `json:"t0,omitempty"`
 	T                float64             `json:"t"`
 	Tags             []string            `json:"tags"`
 	TransactionID    int64               `json:"transaction_id,omitempty"`
 }
 
+// ArchivalDNSEDNS0 contains the EDNS(0) OPT pseudo-record of a DNS response.
+type ArchivalDNSEDNS0 struct {
+	DNSSECOK bool                     `json:"dnssec_ok"`
+	Options  []ArchivalDNSEDNS0Option `json:"options"`
+	UDPSize  uint16                   `json:"udp_size"`
+	Version  uint8                    `json:"version"`
+}
+
+// ArchivalDNSEDNS0Option is an EDNS(0) option (e.g., ECS, cookie, padding).
+type ArchivalDNSEDNS0Option struct {
+	Code   uint16 `json:"code"`
+	Data   string `json:"data,omitempty"`
+	Length int64  `json:"length"`
+	Name   string `json:"name,omitempty"`
+}
+
 // ArchivalDNSAnswer is a DNS answer.
 type ArchivalDNSAnswer struct {
 	ASN        int64   `json:"asn,omitempty"`
 	ASOrgName  string  `json:"as_org_name,omitempty"`
 	AnswerType string  `json:"answer_type"`
+	Data       string  `json:"data,omitempty"`
 	Hostname   string  `json:"hostname,omitempty"`
 	IPv4       string  `json:"ipv4,omitempty"`
 	IPv6       string  `json:"ipv6,omitempty"`
@@ -231,6 +252,29 @@ type ArchivalTCPConnectStatus struct {
 	Success bool    `json:"success"`
 }
 
+// ArchivalTCPInfo is a sample of the kernel's TCP_INFO for a TCP connection, which
+// we only collect on Linux. The Operation field indicates when we took the sample
+// (e.g., "connect", "tls_handshake_done", "close"). RTT values are in microseconds.
+type ArchivalTCPInfo struct {
+	Address       string   `json:"address"`
+	BytesAcked    uint64   `json:"bytes_acked"`
+	BytesReceived uint64   `json:"bytes_received"`
+	BytesRetrans  uint64   `json:"bytes_retrans"`
+	DeliveryRate  uint64   `json:"delivery_rate"`
+	Lost          uint32   `json:"lost"`
+	MinRTT        uint32   `json:"min_rtt"`
+	Operation     string   `json:"operation"`
+	RTT           uint32   `json:"rtt"`
+	RTTVar        uint32   `json:"rttvar"`
+	Retrans       uint32   `json:"retrans"`
+	SndCwnd       uint32   `json:"snd_cwnd"`
+	SndMSS        uint32   `json:"snd_mss"`
+	T             float64  `json:"t"`
+	Tags          []string `json:"tags"`
+	TotalRetrans  uint32   `json:"total_retrans"`
+	TransactionID int64    `json:"transaction_id,omitempty"`
+}
+
 //
 // TLS or QUIC handshake
 //
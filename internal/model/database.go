@@ -17,8 +17,10 @@ type WritableDatabase interface {
 	//
 	// - loc: loc is the location provider used to instantiate the network
 	//
+	// - info: info contains the network label and type
+	//
 	// Returns either a database network instance or an error
-	CreateNetwork(loc LocationProvider) (*DatabaseNetwork, error)
+	CreateNetwork(loc LocationProvider, info DatabaseNetworkInfo) (*DatabaseNetwork, error)
 
 	// CreateOrUpdateURL will create a new URL entry to the urls table if it doesn't
 	// exists, otherwise it will update the category code of the one already in
@@ -175,6 +177,23 @@ type ReadableDatabase interface {
 	//
 	// Returns the measurement JSON or an error
 	GetMeasurementJSON(msmtID int64) (map[string]interface{}, error)
+
+	// ListPendingUploads returns the measurements that are done but have
+	// not been uploaded yet, sorted by report ID and start time
+	//
+	// Arguments:
+	//
+	// Returns the measurements pending upload or an error
+	ListPendingUploads() ([]DatabaseMeasurement, error)
+
+	// GetResult returns the result with the given ID
+	//
+	// Arguments:
+	//
+	// - resultID is the id of the result to return
+	//
+	// Returns either the database result or an error
+	GetResult(resultID int64) (*DatabaseResult, error)
 }
 
 // ResultNetwork is used to represent the structure made from the JOIN
@@ -203,12 +222,29 @@ type DatabaseMeasurementURLNetwork struct {
 
 // DatabaseNetwork represents a network tested by the user
 type DatabaseNetwork struct {
-	ID          int64  `db:"network_id,omitempty"`
-	NetworkName string `db:"network_name"`
-	NetworkType string `db:"network_type"`
-	IP          string `db:"ip"`
-	ASN         uint   `db:"asn"`
-	CountryCode string `db:"network_country_code"`
+	ID           int64  `db:"network_id,omitempty"`
+	NetworkName  string `db:"network_name"`
+	NetworkType  string `db:"network_type"`
+	NetworkLabel string `db:"network_label"`
+	IP           string `db:"ip"`
+	ASN          uint   `db:"asn"`
+	CountryCode  string `db:"network_country_code"`
+}
+
+// DatabaseNetworkTypeDefault is the network type we use when we
+// don't know the network type. On desktop we historically considered
+// the network to always be wifi, so we keep using this value.
+const DatabaseNetworkTypeDefault = "wifi"
+
+// DatabaseNetworkInfo contains information about a network that
+// the LocationProvider does not know about.
+type DatabaseNetworkInfo struct {
+	// Label is the OPTIONAL label of the network.
+	Label string
+
+	// Type is the OPTIONAL network type (e.g., wifi, mobile, wired). When
+	// empty, we use the DatabaseNetworkTypeDefault network type.
+	Type string
 }
 
 // DatabaseURL represents URLs from the testing lists
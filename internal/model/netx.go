@@ -42,6 +42,11 @@ type DNSResponse interface {
 
 	// DecodeCNAME returns the first CNAME entry in this response.
 	DecodeCNAME() (string, error)
+
+	// DecodeRecords returns all the records in the answer section of this
+	// response. This function fails if the answer section does not contain
+	// at least one record matching the original query type.
+	DecodeRecords() ([]*DNSRecord, error)
 }
 
 // The DNSDecoder decodes DNS responses.
@@ -186,6 +191,22 @@ type HTTPSSvc struct {
 	IPv6 []string
 }
 
+// DNSRecord is a generic DNS resource record.
+type DNSRecord struct {
+	// Name is the owner name of the record (e.g., "www.example.com.").
+	Name string
+
+	// Type is the record type (e.g., "TXT").
+	Type string
+
+	// TTL is the record time to live in seconds.
+	TTL uint32
+
+	// Data is the record data in presentation format (e.g., for
+	// an MX record, "10 mail.example.com.").
+	Data string
+}
+
 // MeasuringNetwork defines the constructors required for implementing OONI experiments. All
 // these constructors MUST guarantee proper error wrapping to map Go errors to OONI errors
 // as documented by the [netxlite] package. The [*netxlite.Netx] type is currently the default
@@ -202,6 +223,22 @@ type MeasuringNetwork interface {
 	// NewParallelDNSOverHTTPSResolver creates a new DNS-over-HTTPS resolver with error wrapping.
 	NewParallelDNSOverHTTPSResolver(logger DebugLogger, URL string) Resolver
 
+	// NewParallelDNSOverQUICResolver creates a new DNS-over-QUIC resolver with error wrapping.
+	//
+	// The address argument is the QUIC endpoint address (e.g., 94.140.14.14:853, dns.adguard.com:853).
+	NewParallelDNSOverQUICResolver(logger DebugLogger, address string) Resolver
+
+	// NewParallelDNSOverTLSResolver creates a new DNS-over-TLS resolver with error wrapping.
+	//
+	// The address argument is the TLS endpoint address (e.g., 1.1.1.1:853, dns.google:853).
+	NewParallelDNSOverTLSResolver(logger DebugLogger, address string) Resolver
+
+	// NewParallelTCPResolver creates a new Resolver using DNS-over-TCP
+	// that performs parallel A/AAAA lookups during LookupHost.
+	//
+	// The address argument is the TCP endpoint address (e.g., 1.1.1.1:53, [::1]:53).
+	NewParallelTCPResolver(logger DebugLogger, dialer Dialer, address string) Resolver
+
 	// NewParallelUDPResolver creates a new Resolver using DNS-over-UDP
 	// that performs parallel A/AAAA lookups during LookupHost.
 	//
@@ -288,7 +325,11 @@ type Resolver interface {
 	//
 	// - doh: is a custom DNS-over-HTTPS resolver;
 	//
-	// - doh3: is a custom DNS-over-HTTP3 resolver.
+	// - doh3: is a custom DNS-over-HTTP3 resolver;
+	//
+	// - doq: is a custom DNS-over-QUIC resolver;
+	//
+	// - odoh: is a custom Oblivious DNS-over-HTTPS resolver.
 	//
 	// See https://github.com/ooni/probe/issues/2029#issuecomment-1140805266
 	// for an explanation of why it would not be proper to call "netgo" the
@@ -307,6 +348,11 @@ type Resolver interface {
 
 	// LookupNS issues a NS query for a domain.
 	LookupNS(ctx context.Context, domain string) ([]*net.NS, error)
+
+	// LookupRaw issues a query with the given type (e.g., dns.TypeTXT) for
+	// a domain and returns all the records in the answer section, which may
+	// include records of other types (e.g., the CNAME chain).
+	LookupRaw(ctx context.Context, domain string, qtype uint16) ([]*DNSRecord, error)
 }
 
 // TLSConn is the type of connection that oohttp expects from
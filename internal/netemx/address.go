@@ -89,3 +89,9 @@ const AddressOpenDNS220 = "208.67.220.220"
 
 // AddressNextDNSIo is a dns.nextdns.io address.
 const AddressNextDNSIo = "38.175.119.129"
+
+// AddressODoHCloudflareDNSCom is the IP address for odoh.cloudflare-dns.com, an ODoH target.
+const AddressODoHCloudflareDNSCom = "104.16.249.249"
+
+// AddressODoHProxy is the IP address of the ODoH proxy used by our QA environment.
+const AddressODoHProxy = "45.76.113.31"
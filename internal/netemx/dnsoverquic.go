@@ -0,0 +1,101 @@
+package netemx
+
+import (
+	"io"
+	"net"
+	"sync"
+
+	"github.com/ooni/netem"
+	"github.com/ooni/probe-cli/v3/internal/runtimex"
+	"github.com/ooni/probe-cli/v3/internal/testingx"
+)
+
+// DNSOverQUICServerFactory implements [NetStackServerFactory] for DNS-over-QUIC servers.
+//
+// When this factory constructs a [NetStackServer], it will use:
+//
+// 1. the [NetStackServerFactoryEnv.OtherResolversConfig] as DNS configuration;
+//
+// 2. the stack IP address and the OPTIONAL ServerNames as TLS server names.
+//
+// The server listens on UDP port 853 as mandated by RFC 9250.
+//
+// Use this factory along with [QAEnvOptionNetStack] to create DNS-over-QUIC servers.
+type DNSOverQUICServerFactory struct {
+	// ServerNames contains OPTIONAL extra server names we should configure.
+	ServerNames []string
+}
+
+var _ NetStackServerFactory = &DNSOverQUICServerFactory{}
+
+// MustNewServer implements NetStackServerFactory.
+func (f *DNSOverQUICServerFactory) MustNewServer(env NetStackServerFactoryEnv, stack *netem.UNetStack) NetStackServer {
+	return &dnsOverQUICResolver{
+		closers:     []io.Closer{},
+		config:      env.OtherResolversConfig(),
+		mu:          sync.Mutex{},
+		serverNames: f.ServerNames,
+		unet:        stack,
+	}
+}
+
+type dnsOverQUICResolver struct {
+	closers     []io.Closer
+	config      *netem.DNSConfig
+	mu          sync.Mutex
+	serverNames []string
+	unet        *netem.UNetStack
+}
+
+// Close implements NetStackServer.
+func (srv *dnsOverQUICResolver) Close() error {
+	// make the method locked as requested by the documentation
+	defer srv.mu.Unlock()
+	srv.mu.Lock()
+
+	// close each of the closers
+	for _, closer := range srv.closers {
+		_ = closer.Close()
+	}
+
+	// be idempotent
+	srv.closers = []io.Closer{}
+	return nil
+}
+
+// MustStart implements NetStackServer.
+func (srv *dnsOverQUICResolver) MustStart() {
+	// make the method locked as requested by the documentation
+	defer srv.mu.Unlock()
+	srv.mu.Lock()
+
+	// create the listening address
+	ipAddr := net.ParseIP(srv.unet.IPAddress())
+	runtimex.Assert(ipAddr != nil, "expected valid IP address")
+
+	// create TLS config for the IP address and the server names
+	tlsConfig := srv.unet.MustNewServerTLSConfig(srv.unet.IPAddress(), srv.serverNames...)
+
+	// create the DNS-over-QUIC server
+	listener := testingx.MustNewDNSOverQUICListener(
+		&net.UDPAddr{IP: ipAddr, Port: 853},
+		&dnsOverQUICUnderlyingListener{srv.unet},
+		tlsConfig,
+		testingx.NewDNSRoundTripperWithDNSConfig(srv.config),
+	)
+
+	// track this closable
+	srv.closers = append(srv.closers, listener)
+}
+
+// dnsOverQUICUnderlyingListener adapts [*netem.UNetStack] to be a [testingx.DNSOverUDPUnderlyingListener].
+type dnsOverQUICUnderlyingListener struct {
+	unet *netem.UNetStack
+}
+
+var _ testingx.DNSOverUDPUnderlyingListener = &dnsOverQUICUnderlyingListener{}
+
+// ListenUDP implements testingx.DNSOverUDPUnderlyingListener.
+func (ul *dnsOverQUICUnderlyingListener) ListenUDP(network string, addr *net.UDPAddr) (net.PacketConn, error) {
+	return ul.unet.ListenUDP(network, addr)
+}
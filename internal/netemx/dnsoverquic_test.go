@@ -0,0 +1,35 @@
+package netemx
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/apex/log"
+	"github.com/google/go-cmp/cmp"
+	"github.com/ooni/probe-cli/v3/internal/netxlite"
+)
+
+func TestDNSOverQUICServerFactory(t *testing.T) {
+	env := MustNewQAEnv(
+		QAEnvOptionNetStack(AddressDNSGoogle8844, &DNSOverQUICServerFactory{
+			ServerNames: []string{"dns.google"},
+		}),
+	)
+	defer env.Close()
+
+	env.AddRecordToAllResolvers("www.example.com", "", AddressWwwExampleCom)
+
+	env.Do(func() {
+		netx := &netxlite.Netx{}
+		reso := netx.NewParallelDNSOverQUICResolver(
+			log.Log, net.JoinHostPort(AddressDNSGoogle8844, "853"))
+		addrs, err := reso.LookupHost(context.Background(), "www.example.com")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff([]string{AddressWwwExampleCom}, addrs); diff != "" {
+			t.Fatal(diff)
+		}
+	})
+}
@@ -0,0 +1,39 @@
+package netemx
+
+import (
+	"net/http"
+
+	"github.com/apex/log"
+	"github.com/ooni/netem"
+	"github.com/ooni/probe-cli/v3/internal/netxlite"
+	"github.com/ooni/probe-cli/v3/internal/testingx"
+)
+
+// ObliviousDoHTargetHandlerFactory is a [HTTPHandlerFactory] for [testingx.ObliviousDoHTargetHandler].
+//
+// Each handler uses a freshly generated key pair and resolves queries using
+// the [NetStackServerFactoryEnv.OtherResolversConfig] DNS configuration.
+type ObliviousDoHTargetHandlerFactory struct{}
+
+var _ HTTPHandlerFactory = &ObliviousDoHTargetHandlerFactory{}
+
+// NewHandler implements HTTPHandlerFactory.
+func (f *ObliviousDoHTargetHandlerFactory) NewHandler(env NetStackServerFactoryEnv, stack *netem.UNetStack) http.Handler {
+	return testingx.NewObliviousDoHTargetHandler(
+		testingx.NewDNSRoundTripperWithDNSConfig(env.OtherResolversConfig()))
+}
+
+// ObliviousDoHProxyHandlerFactory is a [HTTPHandlerFactory] for [testingx.ObliviousDoHProxyHandler].
+//
+// Each handler uses the server's network stack to reach the targets.
+type ObliviousDoHProxyHandlerFactory struct{}
+
+var _ HTTPHandlerFactory = &ObliviousDoHProxyHandlerFactory{}
+
+// NewHandler implements HTTPHandlerFactory.
+func (f *ObliviousDoHProxyHandlerFactory) NewHandler(env NetStackServerFactoryEnv, stack *netem.UNetStack) http.Handler {
+	netx := &netxlite.Netx{Underlying: &netxlite.NetemUnderlyingNetworkAdapter{UNet: stack}}
+	return &testingx.ObliviousDoHProxyHandler{
+		Transport: netx.NewHTTPTransportStdlib(log.Log),
+	}
+}
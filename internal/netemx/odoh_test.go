@@ -0,0 +1,49 @@
+package netemx
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/apex/log"
+	"github.com/google/go-cmp/cmp"
+	"github.com/ooni/probe-cli/v3/internal/netxlite"
+)
+
+func TestObliviousDoHHandlerFactories(t *testing.T) {
+	env := MustNewQAEnv(
+		QAEnvOptionNetStack(AddressODoHProxy, &HTTPSecureServerFactory{
+			Factory:          &ObliviousDoHProxyHandlerFactory{},
+			Ports:            []int{443},
+			ServerNameMain:   "odoh-proxy.example.org",
+			ServerNameExtras: []string{},
+		}),
+		QAEnvOptionNetStack(AddressODoHCloudflareDNSCom, &HTTPSecureServerFactory{
+			Factory:          &ObliviousDoHTargetHandlerFactory{},
+			Ports:            []int{443},
+			ServerNameMain:   "odoh.cloudflare-dns.com",
+			ServerNameExtras: []string{},
+		}),
+	)
+	defer env.Close()
+
+	env.AddRecordToAllResolvers("odoh-proxy.example.org", "", AddressODoHProxy)
+	env.AddRecordToAllResolvers("odoh.cloudflare-dns.com", "", AddressODoHCloudflareDNSCom)
+	env.AddRecordToAllResolvers("www.example.com", "", AddressWwwExampleCom)
+
+	env.Do(func() {
+		netx := &netxlite.Netx{}
+		clnt := &http.Client{Transport: netx.NewHTTPTransportStdlib(log.Log)}
+		const URL = "https://odoh-proxy.example.org/proxy?targethost=odoh.cloudflare-dns.com&targetpath=/dns-query"
+		txp := netxlite.NewObliviousDNSOverHTTPSTransport(clnt, URL)
+		reso := netxlite.WrapResolver(log.Log, netxlite.NewUnwrappedParallelResolver(txp))
+		defer reso.CloseIdleConnections()
+		addrs, err := reso.LookupHost(context.Background(), "www.example.com")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff([]string{AddressWwwExampleCom}, addrs); diff != "" {
+			t.Fatal(diff)
+		}
+	})
+}
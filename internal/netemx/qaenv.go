@@ -52,7 +52,8 @@ func QAEnvOptionClientAddress(ipAddr string) QAEnvOption {
 }
 
 // QAEnvOptionClientNICWrapper sets the NIC wrapper for the client. The most common use case
-// for this functionality is capturing packets using [netem.NewPCAPDumper].
+// for this functionality is capturing packets using [netem.NewPCAPDumper] or, if you
+// prefer the PCAPNG format, using [netxlite.NewPCAPNGWriter].
 func QAEnvOptionClientNICWrapper(wrapper netem.LinkNICWrapper) QAEnvOption {
 	return func(config *qaEnvConfig) {
 		config.clientNICWrapper = wrapper
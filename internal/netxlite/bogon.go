@@ -65,6 +65,11 @@ func (r *bogonResolver) LookupNS(ctx context.Context, hostname string) ([]*net.N
 	return nil, ErrNoDNSTransport
 }
 
+// LookupRaw implements Resolver.LookupRaw
+func (r *bogonResolver) LookupRaw(ctx context.Context, hostname string, qtype uint16) ([]*model.DNSRecord, error) {
+	return nil, ErrNoDNSTransport
+}
+
 // Network implements Resolver.Network
 func (r *bogonResolver) Network() string {
 	return r.Resolver.Network()
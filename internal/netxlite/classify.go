@@ -9,6 +9,7 @@ import (
 	"crypto/x509"
 	"errors"
 	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/ooni/probe-cli/v3/internal/scrubber"
@@ -120,36 +121,179 @@ func classifyWithStringSuffix(err error) string {
 	return "" // not found
 }
 
-// TLS alert protocol as defined in RFC8446. We need these definitions
-// to figure out which error occurred during a QUIC handshake.
+// TLS alert protocol as defined in RFC8446 and in the IANA TLS Alerts
+// registry. We need these definitions to figure out which alert the peer
+// sent us during a TLS or QUIC handshake.
 const (
-	// Sender was unable to negotiate an acceptable set of security parameters given the options available.
-	quicTLSAlertHandshakeFailure = 40
-
-	// Certificate was corrupt, contained signatures that did not verify correctly, etc.
-	quicTLSAlertBadCertificate = 42
-
-	// Certificate was of an unsupported type.
-	quicTLSAlertUnsupportedCertificate = 43
-
-	// Certificate was revoked by its signer.
-	quicTLSAlertCertificateRevoked = 44
-
-	// Certificate has expired or is not currently valid.
-	quicTLSAlertCertificateExpired = 45
+	tlsAlertCloseNotify                  = 0
+	tlsAlertUnexpectedMessage            = 10
+	tlsAlertBadRecordMAC                 = 20
+	tlsAlertDecryptionFailed             = 21
+	tlsAlertRecordOverflow               = 22
+	tlsAlertDecompressionFailure         = 30
+	tlsAlertHandshakeFailure             = 40
+	tlsAlertBadCertificate               = 42
+	tlsAlertUnsupportedCertificate       = 43
+	tlsAlertCertificateRevoked           = 44
+	tlsAlertCertificateExpired           = 45
+	tlsAlertCertificateUnknown           = 46
+	tlsAlertIllegalParameter             = 47
+	tlsAlertUnknownCA                    = 48
+	tlsAlertAccessDenied                 = 49
+	tlsAlertDecodeError                  = 50
+	tlsAlertDecryptError                 = 51
+	tlsAlertExportRestriction            = 60
+	tlsAlertProtocolVersion              = 70
+	tlsAlertInsufficientSecurity         = 71
+	tlsAlertInternalError                = 80
+	tlsAlertInappropriateFallback        = 86
+	tlsAlertUserCanceled                 = 90
+	tlsAlertNoRenegotiation              = 100
+	tlsAlertMissingExtension             = 109
+	tlsAlertUnsupportedExtension         = 110
+	tlsAlertCertificateUnobtainable      = 111
+	tlsAlertUnrecognizedName             = 112
+	tlsAlertBadCertificateStatusResponse = 113
+	tlsAlertBadCertificateHashValue      = 114
+	tlsAlertUnknownPSKIdentity           = 115
+	tlsAlertCertificateRequired          = 116
+	tlsAlertNoApplicationProtocol        = 120
+	tlsAlertECHRequired                  = 121
+)
 
-	// Some unspecified issue arose in processing the certificate, rendering it unacceptable.
-	quicTLSAlertCertificateUnknown = 46
+// tlsAlertFailures maps TLS alerts to OONI failure strings.
+//
+// Certificate related alerts map to FailureSSLInvalidCertificate. The decrypt_error
+// and handshake_failure alerts map to FailureSSLFailedHandshake because both alerts
+// are caused by a failed or corrupted parameter negotiation during the handshake. We
+// map the other alerts to distinct failure strings, so that it's possible to tell
+// apart, e.g., a middlebox sending access_denied from a server rejecting our version.
+var tlsAlertFailures = map[uint8]string{
+	tlsAlertCloseNotify:                  FailureSSLAlertCloseNotify,
+	tlsAlertUnexpectedMessage:            FailureSSLAlertUnexpectedMessage,
+	tlsAlertBadRecordMAC:                 FailureSSLAlertBadRecordMAC,
+	tlsAlertDecryptionFailed:             FailureSSLAlertDecryptionFailed,
+	tlsAlertRecordOverflow:               FailureSSLAlertRecordOverflow,
+	tlsAlertDecompressionFailure:         FailureSSLAlertDecompressionFailure,
+	tlsAlertHandshakeFailure:             FailureSSLFailedHandshake,
+	tlsAlertBadCertificate:               FailureSSLInvalidCertificate,
+	tlsAlertUnsupportedCertificate:       FailureSSLInvalidCertificate,
+	tlsAlertCertificateRevoked:           FailureSSLInvalidCertificate,
+	tlsAlertCertificateExpired:           FailureSSLInvalidCertificate,
+	tlsAlertCertificateUnknown:           FailureSSLInvalidCertificate,
+	tlsAlertIllegalParameter:             FailureSSLAlertIllegalParameter,
+	tlsAlertUnknownCA:                    FailureSSLUnknownAuthority,
+	tlsAlertAccessDenied:                 FailureSSLAlertAccessDenied,
+	tlsAlertDecodeError:                  FailureSSLAlertDecodeError,
+	tlsAlertDecryptError:                 FailureSSLFailedHandshake,
+	tlsAlertExportRestriction:            FailureSSLAlertExportRestriction,
+	tlsAlertProtocolVersion:              FailureSSLAlertProtocolVersion,
+	tlsAlertInsufficientSecurity:         FailureSSLAlertInsufficientSecurity,
+	tlsAlertInternalError:                FailureSSLAlertInternalError,
+	tlsAlertInappropriateFallback:        FailureSSLAlertInappropriateFallback,
+	tlsAlertUserCanceled:                 FailureSSLAlertUserCanceled,
+	tlsAlertNoRenegotiation:              FailureSSLAlertNoRenegotiation,
+	tlsAlertMissingExtension:             FailureSSLAlertMissingExtension,
+	tlsAlertUnsupportedExtension:         FailureSSLAlertUnsupportedExtension,
+	tlsAlertCertificateUnobtainable:      FailureSSLAlertCertificateUnobtainable,
+	tlsAlertUnrecognizedName:             FailureSSLInvalidHostname,
+	tlsAlertBadCertificateStatusResponse: FailureSSLAlertBadCertificateStatusResponse,
+	tlsAlertBadCertificateHashValue:      FailureSSLAlertBadCertificateHashValue,
+	tlsAlertUnknownPSKIdentity:           FailureSSLAlertUnknownPSKIdentity,
+	tlsAlertCertificateRequired:          FailureSSLAlertCertificateRequired,
+	tlsAlertNoApplicationProtocol:        FailureSSLAlertNoApplicationProtocol,
+	tlsAlertECHRequired:                  FailureSSLAlertECHRequired,
+}
 
-	// Certificate was not accepted because the CA certificate could not be located or could not be matched with a known trust anchor.
-	quicTLSAlertUnknownCA = 48
+// tlsAlertText maps the string representation of TLS alerts used by the Go
+// standard library (and by refraction-networking/utls) to TLS alerts.
+var tlsAlertText = map[string]uint8{
+	"close notify":                    tlsAlertCloseNotify,
+	"unexpected message":              tlsAlertUnexpectedMessage,
+	"bad record MAC":                  tlsAlertBadRecordMAC,
+	"decryption failed":               tlsAlertDecryptionFailed,
+	"record overflow":                 tlsAlertRecordOverflow,
+	"decompression failure":           tlsAlertDecompressionFailure,
+	"handshake failure":               tlsAlertHandshakeFailure,
+	"bad certificate":                 tlsAlertBadCertificate,
+	"unsupported certificate":         tlsAlertUnsupportedCertificate,
+	"revoked certificate":             tlsAlertCertificateRevoked,
+	"expired certificate":             tlsAlertCertificateExpired,
+	"unknown certificate":             tlsAlertCertificateUnknown,
+	"illegal parameter":               tlsAlertIllegalParameter,
+	"unknown certificate authority":   tlsAlertUnknownCA,
+	"access denied":                   tlsAlertAccessDenied,
+	"error decoding message":          tlsAlertDecodeError,
+	"error decrypting message":        tlsAlertDecryptError,
+	"export restriction":              tlsAlertExportRestriction,
+	"protocol version not supported":  tlsAlertProtocolVersion,
+	"insufficient security level":     tlsAlertInsufficientSecurity,
+	"internal error":                  tlsAlertInternalError,
+	"inappropriate fallback":          tlsAlertInappropriateFallback,
+	"user canceled":                   tlsAlertUserCanceled,
+	"no renegotiation":                tlsAlertNoRenegotiation,
+	"missing extension":               tlsAlertMissingExtension,
+	"unsupported extension":           tlsAlertUnsupportedExtension,
+	"certificate unobtainable":        tlsAlertCertificateUnobtainable,
+	"unrecognized name":               tlsAlertUnrecognizedName,
+	"bad certificate status response": tlsAlertBadCertificateStatusResponse,
+	"bad certificate hash value":      tlsAlertBadCertificateHashValue,
+	"unknown PSK identity":            tlsAlertUnknownPSKIdentity,
+	"certificate required":            tlsAlertCertificateRequired,
+	"no application protocol":         tlsAlertNoApplicationProtocol,
+	"encrypted client hello required": tlsAlertECHRequired,
+}
 
-	// Handshake (not record layer) cryptographic operation failed.
-	quicTLSAlertDecryptError = 51
+// classifyTLSAlertString maps an error string ending with a TLS alert we received
+// from the peer (e.g., "remote error: tls: handshake failure" or, when using
+// yawning/utls, "tls: alert(112)") to the corresponding OONI failure string. This
+// function returns an empty string when the input does not end with an alert or when
+// the alert is one that we sent to the peer (i.e., a "local error").
+func classifyTLSAlertString(s string) string {
+	const tlsPrefix = "tls: "
+	idx := strings.LastIndex(s, tlsPrefix)
+	if idx < 0 {
+		return ""
+	}
+	if strings.HasSuffix(s[:idx], "local error: ") {
+		return ""
+	}
+	text := s[idx+len(tlsPrefix):]
+	if alert, found := tlsAlertText[text]; found {
+		return tlsAlertFailures[alert]
+	}
+	// Both the standard library and utls format unknown alerts as "alert(%d)"
+	if !strings.HasPrefix(text, "alert(") || !strings.HasSuffix(text, ")") {
+		return ""
+	}
+	value, err := strconv.ParseUint(text[len("alert("):len(text)-1], 10, 8)
+	if err != nil {
+		return ""
+	}
+	return tlsAlertFailures[uint8(value)]
+}
 
-	// Sent by servers when no server exists identified by the name provided by the client via the "server_name" extension.
-	quicTLSUnrecognizedName = 112
-)
+// quicTransportErrorFailures maps QUIC CONNECTION_CLOSE transport error codes
+// as defined in RFC9000 to OONI failure strings.
+var quicTransportErrorFailures = map[quic.TransportErrorCode]string{
+	quic.NoError:                   FailureQUICNoError,
+	quic.InternalError:             FailureQUICInternalError,
+	quic.ConnectionRefused:         FailureConnectionRefused,
+	quic.FlowControlError:          FailureQUICFlowControlError,
+	quic.StreamLimitError:          FailureQUICStreamLimitError,
+	quic.StreamStateError:          FailureQUICStreamStateError,
+	quic.FinalSizeError:            FailureQUICFinalSizeError,
+	quic.FrameEncodingError:        FailureQUICFrameEncodingError,
+	quic.TransportParameterError:   FailureQUICTransportParameterError,
+	quic.ConnectionIDLimitError:    FailureQUICConnectionIDLimitError,
+	quic.ProtocolViolation:         FailureQUICProtocolViolation,
+	quic.InvalidToken:              FailureQUICInvalidToken,
+	quic.ApplicationErrorErrorCode: FailureQUICApplicationError,
+	quic.CryptoBufferExceeded:      FailureQUICCryptoBufferExceeded,
+	quic.KeyUpdateError:            FailureQUICKeyUpdateError,
+	quic.AEADLimitReached:          FailureQUICAEADLimitReached,
+	quic.NoViablePathError:         FailureQUICNoViablePath,
+}
 
 // ClassifyQUICHandshakeError maps errors during a QUIC
 // handshake to OONI failure strings.
@@ -173,6 +317,7 @@ func ClassifyQUICHandshakeError(err error) string {
 		handshakeTimeout   *quic.HandshakeTimeoutError
 		idleTimeout        *quic.IdleTimeoutError
 		transportError     *quic.TransportError
+		applicationError   *quic.ApplicationError
 	)
 
 	if errors.As(err, &versionNegotiation) {
@@ -188,27 +333,6 @@ func ClassifyQUICHandshakeError(err error) string {
 		return FailureGenericTimeoutError
 	}
 	if errors.As(err, &transportError) {
-		if transportError.ErrorCode == quic.ConnectionRefused {
-			return FailureConnectionRefused
-		}
-		// the TLS Alert constants are taken from RFC8446
-		errCode := uint8(transportError.ErrorCode)
-		if quicIsCertificateError(errCode) {
-			return FailureSSLInvalidCertificate
-		}
-		// TLSAlertDecryptError and TLSAlertHandshakeFailure are summarized to a
-		// FailureSSLHandshake error because both alerts are caused by a failed or
-		// corrupted parameter negotiation during the TLS handshake.
-		if errCode == quicTLSAlertDecryptError || errCode == quicTLSAlertHandshakeFailure {
-			return FailureSSLFailedHandshake
-		}
-		if errCode == quicTLSAlertUnknownCA {
-			return FailureSSLUnknownAuthority
-		}
-		if errCode == quicTLSUnrecognizedName {
-			return FailureSSLInvalidHostname
-		}
-
 		// quic.TransportError wraps OONI errors using the error
 		// code quic.InternalError. So, if the error code is
 		// an internal error, search for a OONI error and, if
@@ -218,31 +342,21 @@ func ClassifyQUICHandshakeError(err error) string {
 				return s
 			}
 		}
+		// CRYPTO_ERROR codes carry the TLS alert in the lower byte.
+		if transportError.ErrorCode.IsCryptoError() {
+			if s := tlsAlertFailures[uint8(transportError.ErrorCode)]; s != "" {
+				return s
+			}
+		}
+		if s := quicTransportErrorFailures[transportError.ErrorCode]; s != "" {
+			return s
+		}
 	}
-	return ClassifyGenericError(err)
-}
-
-// quicIsCertificateError tells us whether a specific TLS alert error
-// we received is actually an error depending on the certificate.
-//
-// The set of checks we implement here is a set of heuristics based
-// on our understanding of the TLS spec and may need tweaks.
-func quicIsCertificateError(alert uint8) bool {
-	// List out each case separately so we know we test them
-	switch alert {
-	case quicTLSAlertBadCertificate:
-		return true
-	case quicTLSAlertUnsupportedCertificate:
-		return true
-	case quicTLSAlertCertificateExpired:
-		return true
-	case quicTLSAlertCertificateRevoked:
-		return true
-	case quicTLSAlertCertificateUnknown:
-		return true
-	default:
-		return false
+	if errors.As(err, &applicationError) {
+		// This is a CONNECTION_CLOSE using an application specific error code.
+		return FailureQUICApplicationError
 	}
+	return ClassifyGenericError(err)
 }
 
 // ErrDNSBogon indicates that we found a bogon address. Code that
@@ -322,9 +436,38 @@ func ClassifyResolverError(err error) string {
 	if errors.Is(err, ErrAndroidDNSCacheNoData) {
 		return FailureAndroidDNSCacheNoData
 	}
+	var statusErr *ErrDNSOverHTTPSStatus
+	if errors.As(err, &statusErr) {
+		return classifyDNSOverHTTPSStatus(statusErr.StatusCode)
+	}
 	return ClassifyGenericError(err)
 }
 
+// classifyDNSOverHTTPSStatus maps a non-200 HTTP status code returned by
+// a DoH server to the corresponding OONI failure string.
+func classifyDNSOverHTTPSStatus(statusCode int) string {
+	switch {
+	case statusCode == 400:
+		return FailureDNSHTTPBadRequest
+	case statusCode == 403:
+		return FailureDNSHTTPForbidden
+	case statusCode == 404:
+		return FailureDNSHTTPNotFound
+	case statusCode == 413:
+		return FailureDNSHTTPPayloadTooLarge
+	case statusCode == 415:
+		return FailureDNSHTTPUnsupportedMediaType
+	case statusCode == 429:
+		return FailureDNSHTTPTooManyRequests
+	case statusCode == 451:
+		return FailureDNSHTTPUnavailableForLegalReasons
+	case statusCode >= 500 && statusCode <= 599:
+		return FailureDNSHTTPServerError
+	default:
+		return FailureDNSHTTPUnexpectedStatus
+	}
+}
+
 // ClassifyTLSHandshakeError maps an error occurred during the TLS
 // handshake to an OONI failure string.
 //
@@ -358,11 +501,10 @@ func ClassifyTLSHandshakeError(err error) string {
 		return FailureSSLInvalidCertificate
 	}
 
-	if strings.HasSuffix(err.Error(), "tls: unrecognized name") {
-		return FailureSSLInvalidHostname
-	}
-	if strings.HasSuffix(err.Error(), "tls: alert(112)") { // yawning/utls
-		return FailureSSLInvalidHostname
+	// Handle the case where we've received an alert from the peer. Note that this
+	// includes, e.g., "tls: unrecognized name" and yawning/utls's "tls: alert(112)".
+	if failure := classifyTLSAlertString(err.Error()); failure != "" {
+		return failure
 	}
 
 	return ClassifyGenericError(err)
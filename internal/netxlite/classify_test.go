@@ -2,9 +2,12 @@ package netxlite
 
 import (
 	"context"
+	"crypto/tls"
 	"crypto/x509"
 	"errors"
+	"fmt"
 	"io"
+	"net"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
@@ -173,68 +176,101 @@ func TestClassifyQUICHandshakeError(t *testing.T) {
 	})
 
 	t.Run("for bad certificate", func(t *testing.T) {
-		var err quic.TransportErrorCode = quicTLSAlertBadCertificate
+		err := quic.TransportErrorCode(0x100 + tlsAlertBadCertificate)
 		if ClassifyQUICHandshakeError(&quic.TransportError{ErrorCode: err}) != FailureSSLInvalidCertificate {
 			t.Fatal("unexpected results")
 		}
 	})
 
 	t.Run("for unsupported certificate", func(t *testing.T) {
-		var err quic.TransportErrorCode = quicTLSAlertUnsupportedCertificate
+		err := quic.TransportErrorCode(0x100 + tlsAlertUnsupportedCertificate)
 		if ClassifyQUICHandshakeError(&quic.TransportError{ErrorCode: err}) != FailureSSLInvalidCertificate {
 			t.Fatal("unexpected results")
 		}
 	})
 
 	t.Run("for certificate expired", func(t *testing.T) {
-		var err quic.TransportErrorCode = quicTLSAlertCertificateExpired
+		err := quic.TransportErrorCode(0x100 + tlsAlertCertificateExpired)
 		if ClassifyQUICHandshakeError(&quic.TransportError{ErrorCode: err}) != FailureSSLInvalidCertificate {
 			t.Fatal("unexpected results")
 		}
 	})
 
 	t.Run("for certificate revoked", func(t *testing.T) {
-		var err quic.TransportErrorCode = quicTLSAlertCertificateRevoked
+		err := quic.TransportErrorCode(0x100 + tlsAlertCertificateRevoked)
 		if ClassifyQUICHandshakeError(&quic.TransportError{ErrorCode: err}) != FailureSSLInvalidCertificate {
 			t.Fatal("unexpected results")
 		}
 	})
 
 	t.Run("for certificate unknown", func(t *testing.T) {
-		var err quic.TransportErrorCode = quicTLSAlertCertificateUnknown
+		err := quic.TransportErrorCode(0x100 + tlsAlertCertificateUnknown)
 		if ClassifyQUICHandshakeError(&quic.TransportError{ErrorCode: err}) != FailureSSLInvalidCertificate {
 			t.Fatal("unexpected results")
 		}
 	})
 
 	t.Run("for decrypt error", func(t *testing.T) {
-		var err quic.TransportErrorCode = quicTLSAlertDecryptError
+		err := quic.TransportErrorCode(0x100 + tlsAlertDecryptError)
 		if ClassifyQUICHandshakeError(&quic.TransportError{ErrorCode: err}) != FailureSSLFailedHandshake {
 			t.Fatal("unexpected results")
 		}
 	})
 
 	t.Run("for handshake failure", func(t *testing.T) {
-		var err quic.TransportErrorCode = quicTLSAlertHandshakeFailure
+		err := quic.TransportErrorCode(0x100 + tlsAlertHandshakeFailure)
 		if ClassifyQUICHandshakeError(&quic.TransportError{ErrorCode: err}) != FailureSSLFailedHandshake {
 			t.Fatal("unexpected results")
 		}
 	})
 
 	t.Run("for unknown CA", func(t *testing.T) {
-		var err quic.TransportErrorCode = quicTLSAlertUnknownCA
+		err := quic.TransportErrorCode(0x100 + tlsAlertUnknownCA)
 		if ClassifyQUICHandshakeError(&quic.TransportError{ErrorCode: err}) != FailureSSLUnknownAuthority {
 			t.Fatal("unexpected results")
 		}
 	})
 
 	t.Run("for unrecognized hostname", func(t *testing.T) {
-		var err quic.TransportErrorCode = quicTLSUnrecognizedName
+		err := quic.TransportErrorCode(0x100 + tlsAlertUnrecognizedName)
 		if ClassifyQUICHandshakeError(&quic.TransportError{ErrorCode: err}) != FailureSSLInvalidHostname {
 			t.Fatal("unexpected results")
 		}
 	})
 
+	t.Run("for other TLS alerts", func(t *testing.T) {
+		for alert, expect := range tlsAlertFailures {
+			err := &quic.TransportError{ErrorCode: quic.TransportErrorCode(0x100 + uint16(alert)), Remote: true}
+			if got := ClassifyQUICHandshakeError(err); got != expect {
+				t.Fatal("for alert", alert, "expected", expect, "got", got)
+			}
+		}
+	})
+
+	t.Run("for transport error codes", func(t *testing.T) {
+		for code, expect := range quicTransportErrorFailures {
+			err := &quic.TransportError{ErrorCode: code, Remote: true}
+			if got := ClassifyQUICHandshakeError(err); got != expect {
+				t.Fatal("for code", code, "expected", expect, "got", got)
+			}
+		}
+	})
+
+	t.Run("for a transport error code that is not an alert", func(t *testing.T) {
+		// make sure we don't confuse PROTOCOL_VIOLATION (0x0a) with unexpected_message (10)
+		err := &quic.TransportError{ErrorCode: quic.ProtocolViolation}
+		if ClassifyQUICHandshakeError(err) != FailureQUICProtocolViolation {
+			t.Fatal("unexpected results")
+		}
+	})
+
+	t.Run("for an application error", func(t *testing.T) {
+		err := &quic.ApplicationError{ErrorCode: 0x0101, Remote: true}
+		if ClassifyQUICHandshakeError(err) != FailureQUICApplicationError {
+			t.Fatal("unexpected results")
+		}
+	})
+
 	t.Run("for a TransportError wrapping an OONI error", func(t *testing.T) {
 		err := &quic.TransportError{
 			ErrorCode:    quic.InternalError,
@@ -293,6 +329,29 @@ func TestClassifyResolverError(t *testing.T) {
 		}
 	})
 
+	t.Run("for ErrDNSOverHTTPSStatus", func(t *testing.T) {
+		expectations := map[int]string{
+			400: FailureDNSHTTPBadRequest,
+			403: FailureDNSHTTPForbidden,
+			404: FailureDNSHTTPNotFound,
+			413: FailureDNSHTTPPayloadTooLarge,
+			415: FailureDNSHTTPUnsupportedMediaType,
+			429: FailureDNSHTTPTooManyRequests,
+			451: FailureDNSHTTPUnavailableForLegalReasons,
+			500: FailureDNSHTTPServerError,
+			502: FailureDNSHTTPServerError,
+			599: FailureDNSHTTPServerError,
+			302: FailureDNSHTTPUnexpectedStatus,
+			418: FailureDNSHTTPUnexpectedStatus,
+		}
+		for statusCode, expect := range expectations {
+			err := fmt.Errorf("wrapped: %w", &ErrDNSOverHTTPSStatus{Err: errDoHServerError, StatusCode: statusCode})
+			if got := ClassifyResolverError(err); got != expect {
+				t.Fatal("for", statusCode, "expected", expect, "got", got)
+			}
+		}
+	})
+
 	t.Run("for another kind of error", func(t *testing.T) {
 		if ClassifyResolverError(io.EOF) != FailureEOFError {
 			t.Fatal("unexpected result")
@@ -346,6 +405,48 @@ func TestClassifyTLSHandshakeError(t *testing.T) {
 		}
 	})
 
+	t.Run("for alerts received from the peer", func(t *testing.T) {
+		for text, alert := range tlsAlertText {
+			expect := tlsAlertFailures[alert]
+			err := fmt.Errorf("remote error: tls: %s", text)
+			if got := ClassifyTLSHandshakeError(err); got != expect {
+				t.Fatal("for", text, "expected", expect, "got", got)
+			}
+			err = fmt.Errorf("remote error: tls: alert(%d)", alert)
+			if got := ClassifyTLSHandshakeError(err); got != expect {
+				t.Fatal("for", alert, "expected", expect, "got", got)
+			}
+		}
+	})
+
+	t.Run("for the error returned by crypto/tls when receiving an alert", func(t *testing.T) {
+		err := &net.OpError{Op: "remote error", Err: tls.AlertError(tlsAlertProtocolVersion)}
+		if ClassifyTLSHandshakeError(err) != FailureSSLAlertProtocolVersion {
+			t.Fatal("unexpected result")
+		}
+	})
+
+	t.Run("for alerts we sent to the peer", func(t *testing.T) {
+		err := errors.New("local error: tls: unexpected message")
+		if got := ClassifyTLSHandshakeError(err); got != "unknown_failure: local error: tls: unexpected message" {
+			t.Fatal("unexpected result", got)
+		}
+	})
+
+	t.Run("for strings that are not alerts", func(t *testing.T) {
+		inputs := []string{
+			"tls: first record does not look like a TLS handshake",
+			"remote error: tls: alert(antani)",
+			"remote error: tls: alert(1024)",
+			"remote error: tls: alert(41)",
+		}
+		for _, input := range inputs {
+			if got := ClassifyTLSHandshakeError(errors.New(input)); got != "unknown_failure: "+input {
+				t.Fatal("for", input, "got", got)
+			}
+		}
+	})
+
 	t.Run("for another kind of error", func(t *testing.T) {
 		if ClassifyTLSHandshakeError(io.EOF) != FailureEOFError {
 			t.Fatal("unexpected result")
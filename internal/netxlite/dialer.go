@@ -369,6 +369,27 @@ func (c *dialerErrWrapperConn) Close() error {
 	return nil
 }
 
+// NetConn returns the wrapped [net.Conn].
+func (c *dialerErrWrapperConn) NetConn() net.Conn {
+	return c.Conn
+}
+
+// UnwrapNetConn returns the innermost [net.Conn] by repeatedly calling the NetConn
+// method, which is implemented by TLS conns and by most of our conn wrappers. You
+// typically need this function to access the socket (e.g., to call getsockopt).
+func UnwrapNetConn(conn net.Conn) net.Conn {
+	type connUnwrapper interface {
+		NetConn() net.Conn
+	}
+	for {
+		unwrapper, good := conn.(connUnwrapper)
+		if !good {
+			return conn
+		}
+		conn = unwrapper.NetConn()
+	}
+}
+
 // ErrNoDialer is the type of error returned by "null" dialers
 // when you attempt to dial with them.
 var ErrNoDialer = errors.New("no configured dialer")
@@ -833,6 +833,23 @@ func TestDialerErrWrapperConn(t *testing.T) {
 	})
 }
 
+func TestUnwrapNetConn(t *testing.T) {
+	t.Run("with a conn that cannot be unwrapped", func(t *testing.T) {
+		conn := &mocks.Conn{}
+		if UnwrapNetConn(conn) != conn {
+			t.Fatal("unexpected conn")
+		}
+	})
+
+	t.Run("with a conn wrapped multiple times", func(t *testing.T) {
+		conn := &mocks.Conn{}
+		wrapped := &dialerErrWrapperConn{Conn: &dialerErrWrapperConn{Conn: conn}}
+		if UnwrapNetConn(wrapped) != conn {
+			t.Fatal("unexpected conn")
+		}
+	})
+}
+
 func TestNewNullDialer(t *testing.T) {
 	dialer := NewNullDialer()
 	conn, err := dialer.DialContext(context.Background(), "", "")
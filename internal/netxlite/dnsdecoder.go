@@ -7,6 +7,7 @@ package netxlite
 import (
 	"errors"
 	"net"
+	"strings"
 
 	"github.com/miekg/dns"
 	"github.com/ooni/probe-cli/v3/internal/model"
@@ -185,5 +186,32 @@ func (r *dnsResponse) DecodeCNAME() (string, error) {
 	return "", dnsDecoderWrapError(ErrOODNSNoAnswer)
 }
 
+// DecodeRecords implements model.DNSResponse.DecodeRecords.
+func (r *dnsResponse) DecodeRecords() ([]*model.DNSRecord, error) {
+	if err := r.rcodeToError(); err != nil {
+		return nil, err // error already wrapped
+	}
+	var (
+		out   = []*model.DNSRecord{}
+		found bool
+	)
+	for _, answer := range r.msg.Answer {
+		header := answer.Header()
+		found = found || header.Rrtype == r.query.Type()
+		out = append(out, &model.DNSRecord{
+			Name: header.Name,
+			Type: dns.TypeToString[header.Rrtype],
+			TTL:  header.Ttl,
+			// The header string is a prefix of the record string, so removing
+			// it leaves us with the record data in presentation format.
+			Data: strings.TrimPrefix(answer.String(), header.String()),
+		})
+	}
+	if !found {
+		return nil, dnsDecoderWrapError(ErrOODNSNoAnswer)
+	}
+	return out, nil
+}
+
 var _ model.DNSDecoder = &DNSDecoderMiekg{}
 var _ model.DNSResponse = &dnsResponse{}
@@ -9,6 +9,7 @@ import (
 	"github.com/google/go-cmp/cmp"
 	"github.com/miekg/dns"
 	"github.com/ooni/probe-cli/v3/internal/mocks"
+	"github.com/ooni/probe-cli/v3/internal/model"
 	"github.com/ooni/probe-cli/v3/internal/runtimex"
 )
 
@@ -607,6 +608,103 @@ func TestDNSDecoderMiekg(t *testing.T) {
 				}
 			})
 		})
+
+		t.Run("dnsResponse.DecodeRecords", func(t *testing.T) {
+			t.Run("with failure", func(t *testing.T) {
+				// Ensure that we're not trying to decode if rcode != 0
+				d := &DNSDecoderMiekg{}
+				queryID := dns.Id()
+				rawQuery := dnsGenQuery(dns.TypeTXT, queryID)
+				rawResponse := dnsGenReplyWithError(rawQuery, dns.RcodeRefused)
+				query := &mocks.DNSQuery{
+					MockID: func() uint16 {
+						return queryID
+					},
+				}
+				resp, err := d.DecodeResponse(rawResponse, query)
+				if err != nil {
+					t.Fatal(err)
+				}
+				records, err := resp.DecodeRecords()
+				if !errors.Is(err, ErrOODNSRefused) {
+					t.Fatal("unexpected err", err)
+				}
+				if !dnsDecoderErrorIsWrapped(err) {
+					t.Fatal("unwrapped error", err)
+				}
+				if len(records) > 0 {
+					t.Fatal("expected empty records result")
+				}
+			})
+
+			t.Run("with only a CNAME answer", func(t *testing.T) {
+				d := &DNSDecoderMiekg{}
+				queryID := dns.Id()
+				rawQuery := dnsGenQuery(dns.TypeTXT, queryID)
+				cname := &dnsCNAMEAnswer{CNAME: "dns.google."}
+				rawResponse := dnsGenTXTReplySuccess(rawQuery, cname)
+				query := &mocks.DNSQuery{
+					MockID: func() uint16 {
+						return queryID
+					},
+					MockType: func() uint16 {
+						return dns.TypeTXT
+					},
+				}
+				resp, err := d.DecodeResponse(rawResponse, query)
+				if err != nil {
+					t.Fatal(err)
+				}
+				records, err := resp.DecodeRecords()
+				if !errors.Is(err, ErrOODNSNoAnswer) {
+					t.Fatal("unexpected err", err)
+				}
+				if !dnsDecoderErrorIsWrapped(err) {
+					t.Fatal("unwrapped error", err)
+				}
+				if len(records) > 0 {
+					t.Fatal("expected empty records result")
+				}
+			})
+
+			t.Run("with full answer", func(t *testing.T) {
+				d := &DNSDecoderMiekg{}
+				queryID := dns.Id()
+				rawQuery := dnsGenQuery(dns.TypeTXT, queryID)
+				cname := &dnsCNAMEAnswer{CNAME: "dns.google."}
+				rawResponse := dnsGenTXTReplySuccess(rawQuery, cname, "v=spf1 -all")
+				query := &mocks.DNSQuery{
+					MockID: func() uint16 {
+						return queryID
+					},
+					MockType: func() uint16 {
+						return dns.TypeTXT
+					},
+				}
+				resp, err := d.DecodeResponse(rawResponse, query)
+				if err != nil {
+					t.Fatal(err)
+				}
+				records, err := resp.DecodeRecords()
+				if err != nil {
+					t.Fatal(err)
+				}
+				expect := []*model.DNSRecord{{
+					Name: "x.org.",
+					Type: "CNAME",
+					TTL:  300,
+					Data: "dns.google.",
+				}, {
+					Name: "dns.google.",
+					Type: "TXT",
+					TTL:  300,
+					Data: `"v=spf1 -all"`,
+				}}
+				if diff := cmp.Diff(expect, records); diff != "" {
+					t.Fatal(diff)
+				}
+			})
+		})
 	})
 }
 
@@ -782,3 +880,45 @@ func dnsGenNSReplySuccess(rawQuery []byte, names ...string) []byte {
 	runtimex.PanicOnError(err, "reply.Pack failed")
 	return data
 }
+
+// dnsGenTXTReplySuccess generates a successful TXT reply containing the
+// given optional CNAME followed by the given texts.
+func dnsGenTXTReplySuccess(rawQuery []byte, cname *dnsCNAMEAnswer, texts ...string) []byte {
+	query := new(dns.Msg)
+	err := query.Unpack(rawQuery)
+	runtimex.PanicOnError(err, "query.Unpack failed")
+	runtimex.Assert(len(query.Question) == 1, "more than one question")
+	question := query.Question[0]
+	runtimex.Assert(question.Qtype == dns.TypeTXT, "expected TXT query")
+	reply := new(dns.Msg)
+	reply.Compress = true
+	reply.MsgHdr.RecursionAvailable = true
+	reply.SetReply(query)
+	name := question.Name
+	if cname != nil {
+		reply.Answer = append(reply.Answer, &dns.CNAME{
+			Hdr: dns.RR_Header{
+				Name:   name,
+				Rrtype: dns.TypeCNAME,
+				Class:  dns.ClassINET,
+				Ttl:    300,
+			},
+			Target: cname.CNAME,
+		})
+		name = cname.CNAME
+	}
+	for _, text := range texts {
+		reply.Answer = append(reply.Answer, &dns.TXT{
+			Hdr: dns.RR_Header{
+				Name:   name,
+				Rrtype: dns.TypeTXT,
+				Class:  dns.ClassINET,
+				Ttl:    300,
+			},
+			Txt: []string{text},
+		})
+	}
+	data, err := reply.Pack()
+	runtimex.PanicOnError(err, "reply.Pack failed")
+	return data
+}
@@ -159,6 +159,10 @@ func (r *dnsOverGetaddrinfoResponse) DecodeNS() ([]*net.NS, error) {
 	return nil, ErrNoDNSTransport
 }
 
+func (r *dnsOverGetaddrinfoResponse) DecodeRecords() ([]*model.DNSRecord, error) {
+	return nil, ErrNoDNSTransport
+}
+
 func (r *dnsOverGetaddrinfoResponse) DecodeCNAME() (string, error) {
 	if r.cname == "" {
 		return "", ErrOODNSNoAnswer
@@ -89,9 +89,7 @@ func (t *DNSOverHTTPSTransport) RoundTrip(
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode != 200 {
-		// TODO(bassosimone): we should map the status code to a
-		// proper Error in the DNS context.
-		return nil, errors.New("doh: server returned error")
+		return nil, &ErrDNSOverHTTPSStatus{Err: errDoHServerError, StatusCode: resp.StatusCode}
 	}
 	if resp.Header.Get("content-type") != "application/dns-message" {
 		return nil, errors.New("doh: invalid content-type")
@@ -105,6 +103,30 @@ func (t *DNSOverHTTPSTransport) RoundTrip(
 	return t.Decoder.DecodeResponse(rawResponse, query)
 }
 
+// errDoHServerError indicates that the server returned a non-200 status code.
+var errDoHServerError = errors.New("doh: server returned error")
+
+// ErrDNSOverHTTPSStatus is the error returned when a DoH server (or an ODoH
+// proxy or target) responds with a non-200 HTTP status code. ClassifyResolverError
+// maps the status code to a distinct OONI failure string.
+type ErrDNSOverHTTPSStatus struct {
+	// Err is the underlying error.
+	Err error
+
+	// StatusCode is the HTTP status code.
+	StatusCode int
+}
+
+// Error implements error.
+func (e *ErrDNSOverHTTPSStatus) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap allows to use errors.Is and errors.As with the underlying error.
+func (e *ErrDNSOverHTTPSStatus) Unwrap() error {
+	return e.Err
+}
+
 // RequiresPadding returns true for DoH according to RFC8467.
 func (t *DNSOverHTTPSTransport) RequiresPadding() bool {
 	return true
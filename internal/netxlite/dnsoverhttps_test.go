@@ -128,6 +128,10 @@ func TestDNSOverHTTPSTransport(t *testing.T) {
 			if err == nil || err.Error() != "doh: server returned error" {
 				t.Fatal("unexpected err", err)
 			}
+			var statusErr *ErrDNSOverHTTPSStatus
+			if !errors.As(err, &statusErr) || statusErr.StatusCode != 500 {
+				t.Fatal("expected an ErrDNSOverHTTPSStatus with status code 500")
+			}
 			if resp != nil {
 				t.Fatal("expected no response here")
 			}
@@ -0,0 +1,255 @@
+package netxlite
+
+//
+// Oblivious DNS-over-HTTPS transport (RFC 9230)
+//
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/ooni/probe-cli/v3/internal/model"
+	"github.com/ooni/probe-cli/v3/internal/odoh"
+)
+
+// ObliviousDNSOverHTTPSTransport is an Oblivious DNS-over-HTTPS DNSTransport.
+//
+// We send encrypted queries to the proxy using the URL, which MUST contain the
+// targethost and targetpath query parameters (e.g., https://proxy.example/dns-query?
+// targethost=target.example&targetpath=/dns-query). Before sending the first query,
+// we fetch the target's configs from https://targethost/.well-known/odohconfigs.
+//
+// We cache the target's config and fetch it again when the target says that we
+// are using an invalid key ID (i.e., when the proxy relays a 401 status code).
+type ObliviousDNSOverHTTPSTransport struct {
+	// Client is the MANDATORY http client used to talk to the proxy.
+	Client model.HTTPClient
+
+	// ConfigClient is the OPTIONAL http client used to fetch the target's
+	// configs. If nil, we use the Client to fetch the configs.
+	ConfigClient model.HTTPClient
+
+	// Decoder is the MANDATORY DNSDecoder.
+	Decoder model.DNSDecoder
+
+	// URL is the MANDATORY URL of the ODoH proxy including the
+	// targethost and targetpath query parameters.
+	URL string
+
+	// HostOverride is OPTIONAL and allows to override the
+	// Host header sent in every request to the proxy.
+	HostOverride string
+
+	// config is the cached target config.
+	config *odoh.Config
+
+	// mu provides mutual exclusion for config.
+	mu sync.Mutex
+}
+
+// NewUnwrappedObliviousDNSOverHTTPSTransport creates a new ObliviousDNSOverHTTPSTransport
+// instance that has not been wrapped yet.
+//
+// Arguments:
+//
+// - client is a model.HTTPClient type;
+//
+// - URL is the ODoH proxy URL (e.g., https://proxy.example/dns-query?
+// targethost=target.example&targetpath=/dns-query).
+func NewUnwrappedObliviousDNSOverHTTPSTransport(
+	client model.HTTPClient, URL string) *ObliviousDNSOverHTTPSTransport {
+	return NewUnwrappedObliviousDNSOverHTTPSTransportWithHostOverride(client, URL, "")
+}
+
+// NewObliviousDNSOverHTTPSTransport is like NewUnwrappedObliviousDNSOverHTTPSTransport
+// but returns an already wrapped DNSTransport.
+func NewObliviousDNSOverHTTPSTransport(client model.HTTPClient, URL string) model.DNSTransport {
+	return wrapDNSTransport(NewUnwrappedObliviousDNSOverHTTPSTransport(client, URL))
+}
+
+// NewUnwrappedObliviousDNSOverHTTPSTransportWithHostOverride creates a new
+// ObliviousDNSOverHTTPSTransport with the given Host header override for the
+// proxy. This instance has not been wrapped yet.
+func NewUnwrappedObliviousDNSOverHTTPSTransportWithHostOverride(
+	client model.HTTPClient, URL, hostOverride string) *ObliviousDNSOverHTTPSTransport {
+	return &ObliviousDNSOverHTTPSTransport{
+		Client:       client,
+		ConfigClient: nil,
+		Decoder:      &DNSDecoderMiekg{},
+		URL:          URL,
+		HostOverride: hostOverride,
+		config:       nil,
+		mu:           sync.Mutex{},
+	}
+}
+
+var (
+	// errODoHMissingTarget indicates that the URL lacks the targethost or targetpath.
+	errODoHMissingTarget = errors.New("odoh: missing targethost or targetpath")
+
+	// errODoHServerError indicates that the server returned a non-200 status code.
+	errODoHServerError = errors.New("odoh: server returned error")
+
+	// errODoHInvalidContentType indicates that the server returned an invalid content-type.
+	errODoHInvalidContentType = errors.New("odoh: invalid content-type")
+
+	// errODoHInvalidKey indicates that the target does not know the key we're using.
+	errODoHInvalidKey = errors.New("odoh: target rejected our key")
+)
+
+// RoundTrip sends a query and receives a reply.
+func (t *ObliviousDNSOverHTTPSTransport) RoundTrip(
+	ctx context.Context, query model.DNSQuery) (model.DNSResponse, error) {
+	rawQuery, err := query.Bytes()
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, 45*time.Second)
+	defer cancel()
+	config, err := t.getConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	encQuery, qctx, err := config.EncryptQuery(rand.Reader, rawQuery)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest("POST", t.URL, bytes.NewReader(encQuery))
+	if err != nil {
+		return nil, err
+	}
+	req.Host = t.HostOverride
+	req.Header.Set("user-agent", model.HTTPHeaderUserAgent)
+	req.Header.Set("content-type", odoh.ContentType)
+	req.Header.Set("accept", odoh.ContentType)
+	encResponse, err := t.do(ctx, t.Client, req, odoh.ContentType)
+	if err != nil {
+		if errors.Is(err, errODoHInvalidKey) {
+			t.resetConfig()
+		}
+		return nil, err
+	}
+	rawResponse, err := qctx.DecryptResponse(encResponse)
+	if err != nil {
+		return nil, err
+	}
+	return t.Decoder.DecodeResponse(rawResponse, query)
+}
+
+// getConfig returns the cached config or fetches the configs from the target.
+func (t *ObliviousDNSOverHTTPSTransport) getConfig(ctx context.Context) (*odoh.Config, error) {
+	defer t.mu.Unlock()
+	t.mu.Lock()
+	if t.config != nil {
+		return t.config, nil
+	}
+	configsURL, err := t.configsURL()
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest("GET", configsURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("user-agent", model.HTTPHeaderUserAgent)
+	rawConfigs, err := t.do(ctx, t.configClient(), req, "")
+	if err != nil {
+		return nil, err
+	}
+	configs, err := odoh.ParseConfigs(rawConfigs)
+	if err != nil {
+		return nil, err
+	}
+	t.config = configs[0] // use the first supported config
+	return t.config, nil
+}
+
+// resetConfig clears the cached config.
+func (t *ObliviousDNSOverHTTPSTransport) resetConfig() {
+	defer t.mu.Unlock()
+	t.mu.Lock()
+	t.config = nil
+}
+
+// configsURL returns the URL from which to fetch the target's configs.
+func (t *ObliviousDNSOverHTTPSTransport) configsURL() (string, error) {
+	URL, err := url.Parse(t.URL)
+	if err != nil {
+		return "", err
+	}
+	values := URL.Query()
+	if values.Get("targethost") == "" || values.Get("targetpath") == "" {
+		return "", errODoHMissingTarget
+	}
+	configsURL := &url.URL{
+		Scheme: "https",
+		Host:   values.Get("targethost"),
+		Path:   odoh.ConfigsPath,
+	}
+	return configsURL.String(), nil
+}
+
+// configClient returns the client to use for fetching the configs.
+func (t *ObliviousDNSOverHTTPSTransport) configClient() model.HTTPClient {
+	if t.ConfigClient != nil {
+		return t.ConfigClient
+	}
+	return t.Client
+}
+
+// do sends the request using the given client and returns the response body. When
+// the contentType is not empty, we also make sure the response uses such a content-type.
+func (t *ObliviousDNSOverHTTPSTransport) do(ctx context.Context,
+	client model.HTTPClient, req *http.Request, contentType string) ([]byte, error) {
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == 401:
+		// RFC 9230 Sect. 4.3 says the target returns 401 when it cannot decrypt
+		// the query because we're using an expired or unknown key.
+		return nil, errODoHInvalidKey
+	case resp.StatusCode != 200:
+		return nil, &ErrDNSOverHTTPSStatus{Err: errODoHServerError, StatusCode: resp.StatusCode}
+	}
+	if contentType != "" && resp.Header.Get("content-type") != contentType {
+		return nil, errODoHInvalidContentType
+	}
+	const maxresponsesize = 1 << 20
+	limitReader := io.LimitReader(resp.Body, maxresponsesize)
+	return ReadAllContext(ctx, limitReader)
+}
+
+// RequiresPadding returns true for ODoH according to RFC 9230 Sect. 6.
+func (t *ObliviousDNSOverHTTPSTransport) RequiresPadding() bool {
+	return true
+}
+
+// Network returns the transport network, i.e., "odoh".
+func (t *ObliviousDNSOverHTTPSTransport) Network() string {
+	return "odoh"
+}
+
+// Address returns the URL we're using for the ODoH proxy.
+func (t *ObliviousDNSOverHTTPSTransport) Address() string {
+	return t.URL
+}
+
+// CloseIdleConnections closes idle connections, if any.
+func (t *ObliviousDNSOverHTTPSTransport) CloseIdleConnections() {
+	t.Client.CloseIdleConnections()
+	if t.ConfigClient != nil {
+		t.ConfigClient.CloseIdleConnections()
+	}
+}
+
+var _ model.DNSTransport = &ObliviousDNSOverHTTPSTransport{}
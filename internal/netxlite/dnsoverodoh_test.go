@@ -0,0 +1,359 @@
+package netxlite
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/miekg/dns"
+	"github.com/ooni/netem"
+	"github.com/ooni/probe-cli/v3/internal/mocks"
+	"github.com/ooni/probe-cli/v3/internal/model"
+	"github.com/ooni/probe-cli/v3/internal/odoh"
+	"github.com/ooni/probe-cli/v3/internal/runtimex"
+	"github.com/ooni/probe-cli/v3/internal/testingx"
+)
+
+func TestNewObliviousDNSOverHTTPSTransport(t *testing.T) {
+	const URL = "https://proxy.example/dns-query?targethost=target.example&targetpath=/dns-query"
+	clnt := &mocks.HTTPClient{}
+	txp := NewObliviousDNSOverHTTPSTransport(clnt, URL)
+	ew := txp.(*dnsTransportErrWrapper)
+	odohTxp := ew.DNSTransport.(*ObliviousDNSOverHTTPSTransport)
+	if odohTxp.Client != clnt {
+		t.Fatal("invalid client")
+	}
+	if odohTxp.ConfigClient != nil {
+		t.Fatal("invalid config client")
+	}
+	if odohTxp.URL != URL {
+		t.Fatal("invalid URL")
+	}
+	if odohTxp.HostOverride != "" {
+		t.Fatal("invalid host override")
+	}
+}
+
+func TestObliviousDNSOverHTTPSTransport(t *testing.T) {
+	const proxyURL = "https://proxy.example/dns-query?targethost=target.example&targetpath=/dns-query"
+
+	// newMockedQuery returns a mocked query for example.com.
+	newMockedQuery := func() model.DNSQuery {
+		return &mocks.DNSQuery{
+			MockBytes: func() ([]byte, error) {
+				query := &dns.Msg{}
+				query.SetQuestion("example.com.", dns.TypeA)
+				return query.Pack()
+			},
+		}
+	}
+
+	// newResponse returns an HTTP response with the given status code, content-type and body.
+	newResponse := func(status int, ctype string, body []byte) *http.Response {
+		return &http.Response{
+			StatusCode: status,
+			Header:     http.Header{"Content-Type": []string{ctype}},
+			Body:       io.NopCloser(bytes.NewReader(body)),
+		}
+	}
+
+	t.Run("RoundTrip", func(t *testing.T) {
+		kp := runtimex.Try1(odoh.NewKeyPair())
+		rawConfigs := odoh.MarshalConfigs(kp.Config)
+
+		t.Run("query serialization failure", func(t *testing.T) {
+			txp := NewUnwrappedObliviousDNSOverHTTPSTransport(&mocks.HTTPClient{}, proxyURL)
+			expected := errors.New("mocked error")
+			query := &mocks.DNSQuery{
+				MockBytes: func() ([]byte, error) {
+					return nil, expected
+				},
+			}
+			resp, err := txp.RoundTrip(context.Background(), query)
+			if !errors.Is(err, expected) {
+				t.Fatal("unexpected err", err)
+			}
+			if resp != nil {
+				t.Fatal("expected no response here")
+			}
+		})
+
+		t.Run("invalid URL", func(t *testing.T) {
+			txp := NewUnwrappedObliviousDNSOverHTTPSTransport(&mocks.HTTPClient{}, "\t")
+			resp, err := txp.RoundTrip(context.Background(), newMockedQuery())
+			if err == nil || !strings.HasSuffix(err.Error(), "invalid control character in URL") {
+				t.Fatal("unexpected err", err)
+			}
+			if resp != nil {
+				t.Fatal("expected no response here")
+			}
+		})
+
+		t.Run("missing targethost or targetpath", func(t *testing.T) {
+			txp := NewUnwrappedObliviousDNSOverHTTPSTransport(
+				&mocks.HTTPClient{}, "https://proxy.example/dns-query?targethost=target.example")
+			resp, err := txp.RoundTrip(context.Background(), newMockedQuery())
+			if !errors.Is(err, errODoHMissingTarget) {
+				t.Fatal("unexpected err", err)
+			}
+			if resp != nil {
+				t.Fatal("expected no response here")
+			}
+		})
+
+		t.Run("fetching configs fails", func(t *testing.T) {
+			expected := errors.New("mocked error")
+			var configsURL string
+			txp := NewUnwrappedObliviousDNSOverHTTPSTransport(&mocks.HTTPClient{
+				MockDo: func(req *http.Request) (*http.Response, error) {
+					configsURL = req.URL.String()
+					return nil, expected
+				},
+			}, proxyURL)
+			resp, err := txp.RoundTrip(context.Background(), newMockedQuery())
+			if !errors.Is(err, expected) {
+				t.Fatal("unexpected err", err)
+			}
+			if resp != nil {
+				t.Fatal("expected no response here")
+			}
+			if configsURL != "https://target.example/.well-known/odohconfigs" {
+				t.Fatal("unexpected configs URL", configsURL)
+			}
+		})
+
+		t.Run("fetching configs returns 500", func(t *testing.T) {
+			txp := NewUnwrappedObliviousDNSOverHTTPSTransport(&mocks.HTTPClient{
+				MockDo: func(req *http.Request) (*http.Response, error) {
+					return newResponse(500, "", nil), nil
+				},
+			}, proxyURL)
+			resp, err := txp.RoundTrip(context.Background(), newMockedQuery())
+			if !errors.Is(err, errODoHServerError) {
+				t.Fatal("unexpected err", err)
+			}
+			if resp != nil {
+				t.Fatal("expected no response here")
+			}
+		})
+
+		t.Run("parsing configs fails", func(t *testing.T) {
+			txp := NewUnwrappedObliviousDNSOverHTTPSTransport(&mocks.HTTPClient{
+				MockDo: func(req *http.Request) (*http.Response, error) {
+					return newResponse(200, "", []byte{0x22}), nil
+				},
+			}, proxyURL)
+			resp, err := txp.RoundTrip(context.Background(), newMockedQuery())
+			if !errors.Is(err, odoh.ErrInvalidMessage) {
+				t.Fatal("unexpected err", err)
+			}
+			if resp != nil {
+				t.Fatal("expected no response here")
+			}
+		})
+
+		t.Run("uses ConfigClient when set", func(t *testing.T) {
+			expected := errors.New("mocked error")
+			txp := NewUnwrappedObliviousDNSOverHTTPSTransport(&mocks.HTTPClient{
+				MockDo: func(req *http.Request) (*http.Response, error) {
+					return nil, expected
+				},
+			}, proxyURL)
+			var called bool
+			txp.ConfigClient = &mocks.HTTPClient{
+				MockDo: func(req *http.Request) (*http.Response, error) {
+					called = true
+					return newResponse(200, "", rawConfigs), nil
+				},
+			}
+			resp, err := txp.RoundTrip(context.Background(), newMockedQuery())
+			if !errors.Is(err, expected) {
+				t.Fatal("unexpected err", err)
+			}
+			if resp != nil {
+				t.Fatal("expected no response here")
+			}
+			if !called {
+				t.Fatal("did not use the config client")
+			}
+		})
+
+		t.Run("sets the expected headers when querying the proxy", func(t *testing.T) {
+			expected := errors.New("mocked error")
+			var correct bool
+			txp := NewUnwrappedObliviousDNSOverHTTPSTransportWithHostOverride(&mocks.HTTPClient{
+				MockDo: func(req *http.Request) (*http.Response, error) {
+					if req.Method == "GET" {
+						return newResponse(200, "", rawConfigs), nil
+					}
+					correct = (req.URL.String() == proxyURL &&
+						req.Host == "proxy.host" &&
+						req.Header.Get("User-Agent") == model.HTTPHeaderUserAgent &&
+						req.Header.Get("Content-Type") == odoh.ContentType &&
+						req.Header.Get("Accept") == odoh.ContentType)
+					return nil, expected
+				},
+			}, proxyURL, "proxy.host")
+			resp, err := txp.RoundTrip(context.Background(), newMockedQuery())
+			if !errors.Is(err, expected) {
+				t.Fatal("unexpected err", err)
+			}
+			if resp != nil {
+				t.Fatal("expected no response here")
+			}
+			if !correct {
+				t.Fatal("did not see the expected request")
+			}
+		})
+
+		t.Run("the target rejects our key", func(t *testing.T) {
+			var configFetches int
+			txp := NewUnwrappedObliviousDNSOverHTTPSTransport(&mocks.HTTPClient{
+				MockDo: func(req *http.Request) (*http.Response, error) {
+					if req.Method == "GET" {
+						configFetches++
+						return newResponse(200, "", rawConfigs), nil
+					}
+					return newResponse(401, "", nil), nil
+				},
+			}, proxyURL)
+			for idx := 0; idx < 2; idx++ {
+				resp, err := txp.RoundTrip(context.Background(), newMockedQuery())
+				if !errors.Is(err, errODoHInvalidKey) {
+					t.Fatal("unexpected err", err)
+				}
+				if resp != nil {
+					t.Fatal("expected no response here")
+				}
+			}
+			if configFetches != 2 {
+				t.Fatal("expected to fetch the configs again", configFetches)
+			}
+		})
+
+		t.Run("invalid content-type", func(t *testing.T) {
+			txp := NewUnwrappedObliviousDNSOverHTTPSTransport(&mocks.HTTPClient{
+				MockDo: func(req *http.Request) (*http.Response, error) {
+					if req.Method == "GET" {
+						return newResponse(200, "", rawConfigs), nil
+					}
+					return newResponse(200, "application/dns-message", nil), nil
+				},
+			}, proxyURL)
+			resp, err := txp.RoundTrip(context.Background(), newMockedQuery())
+			if !errors.Is(err, errODoHInvalidContentType) {
+				t.Fatal("unexpected err", err)
+			}
+			if resp != nil {
+				t.Fatal("expected no response here")
+			}
+		})
+
+		t.Run("decrypting the response fails", func(t *testing.T) {
+			txp := NewUnwrappedObliviousDNSOverHTTPSTransport(&mocks.HTTPClient{
+				MockDo: func(req *http.Request) (*http.Response, error) {
+					if req.Method == "GET" {
+						return newResponse(200, "", rawConfigs), nil
+					}
+					return newResponse(200, odoh.ContentType, []byte{0x22}), nil
+				},
+			}, proxyURL)
+			resp, err := txp.RoundTrip(context.Background(), newMockedQuery())
+			if !errors.Is(err, odoh.ErrInvalidMessage) {
+				t.Fatal("unexpected err", err)
+			}
+			if resp != nil {
+				t.Fatal("expected no response here")
+			}
+		})
+
+		t.Run("success with a local proxy and target", func(t *testing.T) {
+			config := netem.NewDNSConfig()
+			config.AddRecord("example.com", "", "93.184.216.34")
+			var configFetches atomic.Int64
+			handler := testingx.NewObliviousDoHTargetHandler(testingx.NewDNSRoundTripperWithDNSConfig(config))
+			target := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == "GET" {
+					configFetches.Add(1)
+				}
+				handler.ServeHTTP(w, r)
+			}))
+			defer target.Close()
+			proxy := httptest.NewServer(&testingx.ObliviousDoHProxyHandler{
+				Transport: target.Client().Transport,
+			})
+			defer proxy.Close()
+
+			URL := runtimex.Try1(url.Parse(proxy.URL))
+			URL.Path = "/dns-query"
+			URL.RawQuery = url.Values{
+				"targethost": {runtimex.Try1(url.Parse(target.URL)).Host},
+				"targetpath": {"/dns-query"},
+			}.Encode()
+			clnt := &http.Client{Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+			}}
+			txp := NewUnwrappedObliviousDNSOverHTTPSTransport(clnt, URL.String())
+			defer txp.CloseIdleConnections()
+
+			for idx := 0; idx < 2; idx++ {
+				query := (&DNSEncoderMiekg{}).Encode("example.com", dns.TypeA, true)
+				resp, err := txp.RoundTrip(context.Background(), query)
+				if err != nil {
+					t.Fatal(err)
+				}
+				addrs, err := resp.DecodeLookupHost()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(addrs) != 1 || addrs[0] != "93.184.216.34" {
+					t.Fatal("unexpected addrs", addrs)
+				}
+			}
+			if configFetches.Load() != 1 {
+				t.Fatal("expected to fetch the configs once", configFetches.Load())
+			}
+		})
+	})
+
+	t.Run("other functions behave correctly", func(t *testing.T) {
+		txp := NewUnwrappedObliviousDNSOverHTTPSTransport(http.DefaultClient, proxyURL)
+		if txp.Network() != "odoh" {
+			t.Fatal("invalid network")
+		}
+		if txp.RequiresPadding() != true {
+			t.Fatal("should require padding")
+		}
+		if txp.Address() != proxyURL {
+			t.Fatal("invalid address")
+		}
+	})
+
+	t.Run("CloseIdleConnections", func(t *testing.T) {
+		var called, configCalled bool
+		txp := &ObliviousDNSOverHTTPSTransport{
+			Client: &mocks.HTTPClient{
+				MockCloseIdleConnections: func() {
+					called = true
+				},
+			},
+			ConfigClient: &mocks.HTTPClient{
+				MockCloseIdleConnections: func() {
+					configCalled = true
+				},
+			},
+		}
+		txp.CloseIdleConnections()
+		if !called || !configCalled {
+			t.Fatal("not called")
+		}
+	})
+}
@@ -0,0 +1,168 @@
+package netxlite
+
+//
+// DNS-over-QUIC transport (RFC 9250)
+//
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"math"
+	"net"
+	"time"
+
+	"github.com/ooni/probe-cli/v3/internal/model"
+	"github.com/quic-go/quic-go"
+)
+
+// These are the application error codes defined by RFC 9250 Sect. 4.3.
+const (
+	// dnsOverQUICNoError indicates that we're closing without errors.
+	dnsOverQUICNoError = quic.ApplicationErrorCode(0x0)
+
+	// dnsOverQUICRequestCancelled indicates that we've cancelled the query.
+	dnsOverQUICRequestCancelled = quic.ApplicationErrorCode(0x3)
+)
+
+// DNSOverQUICTransport is a DNS-over-QUIC DNSTransport.
+//
+// Note: this implementation always creates a new QUIC connection for each query. This
+// strategy is less efficient but is consistent with what [DNSOverTCPTransport] does.
+type DNSOverQUICTransport struct {
+	dialer    model.QUICDialer
+	decoder   model.DNSDecoder
+	address   string
+	tlsConfig *tls.Config
+}
+
+// NewUnwrappedDNSOverQUICTransport creates a new DNSOverQUICTransport
+// that has not been wrapped yet.
+//
+// Arguments:
+//
+// - dialer is the [model.QUICDialer] to use;
+//
+// - address is the endpoint address (e.g., 94.140.14.14:853).
+func NewUnwrappedDNSOverQUICTransport(dialer model.QUICDialer, address string) *DNSOverQUICTransport {
+	return NewUnwrappedDNSOverQUICTransportWithTLSConfig(dialer, address, &tls.Config{})
+}
+
+// NewUnwrappedDNSOverQUICTransportWithTLSConfig is like NewUnwrappedDNSOverQUICTransport
+// but allows you to specify the TLS config to use. When the ServerName is empty, we use
+// the hostname in the address. When NextProtos is empty, we use "doq".
+func NewUnwrappedDNSOverQUICTransportWithTLSConfig(
+	dialer model.QUICDialer, address string, tlsConfig *tls.Config) *DNSOverQUICTransport {
+	return &DNSOverQUICTransport{
+		dialer:    dialer,
+		decoder:   &DNSDecoderMiekg{},
+		address:   address,
+		tlsConfig: tlsConfig,
+	}
+}
+
+// RoundTrip sends a query and receives a reply.
+func (t *DNSOverQUICTransport) RoundTrip(
+	ctx context.Context, query model.DNSQuery) (model.DNSResponse, error) {
+	rawQuery, err := query.Bytes()
+	if err != nil {
+		return nil, err
+	}
+	if len(rawQuery) > math.MaxUint16 {
+		return nil, errQueryTooLarge
+	}
+	// RFC 9250 Sect. 4.2.1 says the message ID MUST be zero. We operate on a copy
+	// because the query memoizes its bytes and other code may want to read them.
+	rawQuery = append([]byte{}, rawQuery...)
+	rawQuery[0], rawQuery[1] = 0, 0
+	qconn, err := t.dialer.DialContext(ctx, t.address, t.newTLSConfig(), &quic.Config{})
+	if err != nil {
+		return nil, err
+	}
+	// make sure we interrupt the I/O operations if the context is done
+	stop := context.AfterFunc(ctx, func() {
+		_ = qconn.CloseWithError(dnsOverQUICRequestCancelled, "")
+	})
+	defer stop()
+	defer qconn.CloseWithError(dnsOverQUICNoError, "")
+	rawResponse, err := t.exchange(ctx, qconn, rawQuery)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, err
+	}
+	// restore the original ID, otherwise the decoder rejects the response
+	if len(rawResponse) >= 2 {
+		rawResponse[0], rawResponse[1] = byte(query.ID()>>8), byte(query.ID())
+	}
+	return t.decoder.DecodeResponse(rawResponse, query)
+}
+
+// exchange sends the raw query and reads the raw response using a new stream.
+func (t *DNSOverQUICTransport) exchange(
+	ctx context.Context, qconn quic.EarlyConnection, rawQuery []byte) ([]byte, error) {
+	stream, err := qconn.OpenStreamSync(ctx)
+	if err != nil {
+		return nil, err
+	}
+	const iotimeout = 10 * time.Second
+	_ = stream.SetDeadline(time.Now().Add(iotimeout))
+	// Write request and close our side of the stream (RFC 9250 Sect. 4.2)
+	buf := []byte{byte(len(rawQuery) >> 8)}
+	buf = append(buf, byte(len(rawQuery)))
+	buf = append(buf, rawQuery...)
+	if _, err := stream.Write(buf); err != nil {
+		return nil, err
+	}
+	if err := stream.Close(); err != nil {
+		return nil, err
+	}
+	// Read response
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(stream, header); err != nil {
+		return nil, err
+	}
+	length := int(header[0])<<8 | int(header[1])
+	rawResponse := make([]byte, length)
+	if _, err := io.ReadFull(stream, rawResponse); err != nil {
+		return nil, err
+	}
+	return rawResponse, nil
+}
+
+// newTLSConfig returns the TLS config to use for dialing.
+func (t *DNSOverQUICTransport) newTLSConfig() *tls.Config {
+	config := t.tlsConfig.Clone()
+	if config.ServerName == "" {
+		if host, _, err := net.SplitHostPort(t.address); err == nil {
+			config.ServerName = host
+		}
+	}
+	if len(config.NextProtos) <= 0 {
+		config.NextProtos = []string{"doq"}
+	}
+	return config
+}
+
+// RequiresPadding returns true for DoQ according to RFC9250.
+func (t *DNSOverQUICTransport) RequiresPadding() bool {
+	return true
+}
+
+// Network returns the transport network, i.e., "doq".
+func (t *DNSOverQUICTransport) Network() string {
+	return "doq"
+}
+
+// Address returns the upstream server endpoint (e.g., "94.140.14.14:853").
+func (t *DNSOverQUICTransport) Address() string {
+	return t.address
+}
+
+// CloseIdleConnections closes idle connections, if any.
+func (t *DNSOverQUICTransport) CloseIdleConnections() {
+	t.dialer.CloseIdleConnections()
+}
+
+var _ model.DNSTransport = &DNSOverQUICTransport{}
@@ -0,0 +1,241 @@
+package netxlite
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"math"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/google/go-cmp/cmp"
+	"github.com/miekg/dns"
+	"github.com/ooni/netem"
+	"github.com/ooni/probe-cli/v3/internal/mocks"
+	"github.com/ooni/probe-cli/v3/internal/model"
+	"github.com/ooni/probe-cli/v3/internal/runtimex"
+	"github.com/ooni/probe-cli/v3/internal/testingx"
+	"github.com/quic-go/quic-go"
+)
+
+func TestDNSOverQUICTransport(t *testing.T) {
+	t.Run("RoundTrip", func(t *testing.T) {
+		t.Run("cannot encode query", func(t *testing.T) {
+			expected := errors.New("mocked error")
+			txp := NewUnwrappedDNSOverQUICTransport(&mocks.QUICDialer{}, "9.9.9.9:853")
+			query := &mocks.DNSQuery{
+				MockBytes: func() ([]byte, error) {
+					return nil, expected
+				},
+			}
+			resp, err := txp.RoundTrip(context.Background(), query)
+			if !errors.Is(err, expected) {
+				t.Fatal("unexpected err", err)
+			}
+			if resp != nil {
+				t.Fatal("expected nil response here")
+			}
+		})
+
+		t.Run("query too large", func(t *testing.T) {
+			txp := NewUnwrappedDNSOverQUICTransport(&mocks.QUICDialer{}, "9.9.9.9:853")
+			query := &mocks.DNSQuery{
+				MockBytes: func() ([]byte, error) {
+					return make([]byte, math.MaxUint16+1), nil
+				},
+			}
+			resp, err := txp.RoundTrip(context.Background(), query)
+			if !errors.Is(err, errQueryTooLarge) {
+				t.Fatal("unexpected err", err)
+			}
+			if resp != nil {
+				t.Fatal("expected nil response here")
+			}
+		})
+
+		t.Run("dial failure", func(t *testing.T) {
+			mocked := errors.New("mocked error")
+			var config *tls.Config
+			dialer := &mocks.QUICDialer{
+				MockDialContext: func(ctx context.Context, address string,
+					tlsConfig *tls.Config, quicConfig *quic.Config) (quic.EarlyConnection, error) {
+					config = tlsConfig
+					return nil, mocked
+				},
+			}
+			txp := NewUnwrappedDNSOverQUICTransport(dialer, "9.9.9.9:853")
+			query := &mocks.DNSQuery{
+				MockBytes: func() ([]byte, error) {
+					return make([]byte, 128), nil
+				},
+			}
+			resp, err := txp.RoundTrip(context.Background(), query)
+			if !errors.Is(err, mocked) {
+				t.Fatal("unexpected err", err)
+			}
+			if resp != nil {
+				t.Fatal("expected nil response here")
+			}
+			if config.ServerName != "9.9.9.9" {
+				t.Fatal("unexpected ServerName", config.ServerName)
+			}
+			if diff := cmp.Diff([]string{"doq"}, config.NextProtos); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+
+		t.Run("cannot open a stream", func(t *testing.T) {
+			mocked := errors.New("mocked error")
+			var closed bool
+			dialer := &mocks.QUICDialer{
+				MockDialContext: func(ctx context.Context, address string,
+					tlsConfig *tls.Config, quicConfig *quic.Config) (quic.EarlyConnection, error) {
+					qconn := &mocks.QUICEarlyConnection{
+						MockOpenStreamSync: func(ctx context.Context) (quic.Stream, error) {
+							return nil, mocked
+						},
+						MockCloseWithError: func(code quic.ApplicationErrorCode, reason string) error {
+							closed = true
+							return nil
+						},
+					}
+					return qconn, nil
+				},
+			}
+			txp := NewUnwrappedDNSOverQUICTransport(dialer, "9.9.9.9:853")
+			query := &mocks.DNSQuery{
+				MockBytes: func() ([]byte, error) {
+					return make([]byte, 128), nil
+				},
+			}
+			resp, err := txp.RoundTrip(context.Background(), query)
+			if !errors.Is(err, mocked) {
+				t.Fatal("unexpected err", err)
+			}
+			if resp != nil {
+				t.Fatal("expected nil response here")
+			}
+			if !closed {
+				t.Fatal("did not close the connection")
+			}
+		})
+
+		t.Run("with a local DoQ server", func(t *testing.T) {
+			ca := netem.MustNewCA()
+			dnsConfig := netem.NewDNSConfig()
+			runtimex.Try0(dnsConfig.AddRecord("www.example.com", "", "93.184.216.34"))
+
+			newTransport := func(rtx testingx.DNSRoundTripper) (*DNSOverQUICTransport, func()) {
+				listener := testingx.MustNewDNSOverQUICListener(
+					&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 0},
+					&testingx.DNSOverUDPListenerStdlib{},
+					ca.MustNewServerTLSConfig("dns.quad9.net"),
+					rtx,
+				)
+				netx := &Netx{}
+				dialer := netx.NewQUICDialerWithoutResolver(netx.NewUDPListener(), log.Log)
+				txp := NewUnwrappedDNSOverQUICTransportWithTLSConfig(
+					dialer, listener.LocalAddr().String(), &tls.Config{
+						RootCAs:    ca.DefaultCertPool(),
+						ServerName: "dns.quad9.net",
+					})
+				return txp, func() {
+					txp.CloseIdleConnections()
+					listener.Close()
+				}
+			}
+
+			t.Run("on success", func(t *testing.T) {
+				queryIDs := make(chan uint16, 1)
+				txp, cleanup := newTransport(testingx.DNSRoundTripperFunc(
+					func(ctx context.Context, rawReq []byte) ([]byte, error) {
+						queryIDs <- uint16(rawReq[0])<<8 | uint16(rawReq[1])
+						return testingx.NewDNSRoundTripperWithDNSConfig(dnsConfig).RoundTrip(ctx, rawReq)
+					}))
+				defer cleanup()
+				encoder := &DNSEncoderMiekg{}
+				query := encoder.Encode("www.example.com", dns.TypeA, txp.RequiresPadding())
+				resp, err := txp.RoundTrip(context.Background(), query)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if <-queryIDs != 0 {
+					t.Fatal("the query ID should be zero on the wire")
+				}
+				rawQuery, _ := query.Bytes()
+				if id := uint16(rawQuery[0])<<8 | uint16(rawQuery[1]); id != query.ID() {
+					t.Fatal("the transport should not modify the query bytes")
+				}
+				addrs, err := resp.DecodeLookupHost()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if diff := cmp.Diff([]string{"93.184.216.34"}, addrs); diff != "" {
+					t.Fatal(diff)
+				}
+			})
+
+			t.Run("when the context expires", func(t *testing.T) {
+				txp, cleanup := newTransport(testingx.DNSRoundTripperFunc(
+					func(ctx context.Context, rawReq []byte) ([]byte, error) {
+						<-ctx.Done() // never respond
+						return nil, ctx.Err()
+					}))
+				defer cleanup()
+				encoder := &DNSEncoderMiekg{}
+				query := encoder.Encode("www.example.com", dns.TypeA, txp.RequiresPadding())
+				ctx, cancel := context.WithTimeout(context.Background(), 250*time.Millisecond)
+				defer cancel()
+				resp, err := txp.RoundTrip(ctx, query)
+				if !errors.Is(err, context.DeadlineExceeded) {
+					t.Fatal("unexpected err", err)
+				}
+				if resp != nil {
+					t.Fatal("expected nil response here")
+				}
+			})
+		})
+	})
+
+	t.Run("other functions behave correctly", func(t *testing.T) {
+		const address = "9.9.9.9:853"
+		var called bool
+		dialer := &mocks.QUICDialer{
+			MockCloseIdleConnections: func() {
+				called = true
+			},
+		}
+		txp := NewUnwrappedDNSOverQUICTransport(dialer, address)
+		if !txp.RequiresPadding() {
+			t.Fatal("invalid RequiresPadding")
+		}
+		if txp.Network() != "doq" {
+			t.Fatal("invalid Network")
+		}
+		if txp.Address() != address {
+			t.Fatal("invalid Address")
+		}
+		txp.CloseIdleConnections()
+		if !called {
+			t.Fatal("did not call CloseIdleConnections")
+		}
+	})
+}
+
+func TestNewParallelDNSOverQUICResolver(t *testing.T) {
+	netx := &Netx{}
+	resolver := netx.NewParallelDNSOverQUICResolver(log.Log, "9.9.9.9:853")
+	idnaReso := resolver.(*resolverIDNA)
+	logger := idnaReso.Resolver.(*resolverLogger)
+	shortCircuit := logger.Resolver.(*ResolverShortCircuitIPAddr)
+	errWrapper := shortCircuit.Resolver.(*resolverErrWrapper)
+	para := errWrapper.Resolver.(*ParallelResolver)
+	txp := para.Transport().(*dnsTransportErrWrapper)
+	dnsTxp := txp.DNSTransport.(*DNSOverQUICTransport)
+	if dnsTxp.Address() != "9.9.9.9:853" {
+		t.Fatal("invalid address")
+	}
+	var _ model.DNSTransport = dnsTxp
+}
@@ -35,11 +35,19 @@ import (
 // make this code suitable to implement parasitic traceroute.
 //
 // This transport by default listens for additional responses after the first
-// one and makes them available using the context-configured trace.
+// one and makes them available using the context-configured trace. Injectors
+// such as the Great Firewall race the legitimate response, so receiving several,
+// different responses for the same query is a strong censorship signal.
 type DNSOverUDPTransport struct {
 	// Decoder is the MANDATORY DNSDecoder to use.
 	Decoder model.DNSDecoder
 
+	// DelayedResponsesWindow is the OPTIONAL grace window during which we keep the
+	// socket open to collect delayed responses after receiving the first one. When
+	// zero, we collect until the round trip's five seconds I/O deadline expires. When
+	// negative, we close the socket as soon as we have received the first response.
+	DelayedResponsesWindow time.Duration
+
 	// Dialer is the MANDATORY dialer used to create the conn.
 	Dialer model.Dialer
 
@@ -59,19 +67,38 @@ type DNSOverUDPTransport struct {
 //
 // - dialer is any type that implements the Dialer interface;
 //
-// - address is the endpoint address (e.g., 8.8.8.8:53).
+// - address is the endpoint address (e.g., 8.8.8.8:53);
+//
+// - options contains OPTIONAL settings for the transport.
 //
 // If the address contains a domain name rather than an IP address
 // (e.g., dns.google:53), we will end up using the first of the
 // IP addresses returned by the underlying DNS lookup performed using
 // the dialer. This usage pattern is NOT RECOMMENDED because we'll
 // have less control over which IP address is being used.
-func NewUnwrappedDNSOverUDPTransport(dialer model.Dialer, address string) *DNSOverUDPTransport {
-	return &DNSOverUDPTransport{
-		Decoder:       &DNSDecoderMiekg{},
-		Dialer:        dialer,
-		Endpoint:      address,
-		lateResponses: nil, // not interested by default
+func NewUnwrappedDNSOverUDPTransport(
+	dialer model.Dialer, address string, options ...DNSOverUDPTransportOption) *DNSOverUDPTransport {
+	txp := &DNSOverUDPTransport{
+		Decoder:                &DNSDecoderMiekg{},
+		DelayedResponsesWindow: 0, // until the I/O deadline
+		Dialer:                 dialer,
+		Endpoint:               address,
+		lateResponses:          nil, // not interested by default
+	}
+	for _, option := range options {
+		option(txp)
+	}
+	return txp
+}
+
+// DNSOverUDPTransportOption is an option for [NewUnwrappedDNSOverUDPTransport].
+type DNSOverUDPTransportOption func(txp *DNSOverUDPTransport)
+
+// DNSOverUDPTransportOptionDelayedResponsesWindow configures the grace window during
+// which we collect delayed responses. See [DNSOverUDPTransport] for more details.
+func DNSOverUDPTransportOptionDelayedResponsesWindow(window time.Duration) DNSOverUDPTransportOption {
+	return func(txp *DNSOverUDPTransport) {
+		txp.DelayedResponsesWindow = window
 	}
 }
 
@@ -107,6 +134,13 @@ func (t *DNSOverUDPTransport) RoundTrip(
 		_ = conn.Close() // we still own the conn
 		return nil, err
 	}
+	switch window := t.DelayedResponsesWindow; {
+	case window < 0:
+		_ = conn.Close() // we still own the conn
+		return resp, nil
+	case window > 0:
+		_ = conn.SetDeadline(time.Now().Add(window))
+	}
 	// start a goroutine to listen for any delayed DNS response and
 	// TRANSFER the conn's OWNERSHIP to such a goroutine.
 	go t.ownConnAndSendRecvLoop(ctx, conn, query, myaddr, joinedch)
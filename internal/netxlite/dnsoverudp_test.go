@@ -314,6 +314,62 @@ func TestDNSOverUDPTransport(t *testing.T) {
 			<-txp.lateResponses
 		})
 
+		t.Run("with a negative DelayedResponsesWindow", func(t *testing.T) {
+			udpAddr := &net.UDPAddr{
+				IP:   net.IPv4(127, 0, 0, 1),
+				Port: 0,
+			}
+			listener := testingx.MustNewDNSSimulateGWFListener(
+				udpAddr, &testingx.DNSOverUDPListenerStdlib{}, dnsConfigBogus,
+				dnsConfigGood, testingx.DNSNumBogusResponses(1))
+			defer listener.Close()
+			netx := &Netx{}
+			dialer := netx.NewDialerWithoutResolver(model.DiscardLogger)
+			txp := NewUnwrappedDNSOverUDPTransport(dialer, listener.LocalAddr().String(),
+				DNSOverUDPTransportOptionDelayedResponsesWindow(-1))
+			txp.lateResponses = make(chan any, 1) // with buffer to avoid deadlocks
+			encoder := &DNSEncoderMiekg{}
+			query := encoder.Encode("dns.google.", dns.TypeA, false)
+			rch, err := txp.RoundTrip(context.Background(), query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := rch.DecodeLookupHost(); err != nil {
+				t.Fatal(err)
+			}
+			select {
+			case <-txp.lateResponses:
+				t.Fatal("did not expect to see a delayed response")
+			case <-time.After(250 * time.Millisecond):
+			}
+		})
+
+		t.Run("with a positive DelayedResponsesWindow", func(t *testing.T) {
+			udpAddr := &net.UDPAddr{
+				IP:   net.IPv4(127, 0, 0, 1),
+				Port: 0,
+			}
+			listener := testingx.MustNewDNSSimulateGWFListener(
+				udpAddr, &testingx.DNSOverUDPListenerStdlib{}, dnsConfigBogus,
+				dnsConfigGood, testingx.DNSNumBogusResponses(1))
+			defer listener.Close()
+			netx := &Netx{}
+			dialer := netx.NewDialerWithoutResolver(model.DiscardLogger)
+			txp := NewUnwrappedDNSOverUDPTransport(dialer, listener.LocalAddr().String())
+			txp.DelayedResponsesWindow = 2 * time.Second
+			txp.lateResponses = make(chan any, 1) // with buffer to avoid deadlocks
+			encoder := &DNSEncoderMiekg{}
+			query := encoder.Encode("dns.google.", dns.TypeA, false)
+			rch, err := txp.RoundTrip(context.Background(), query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := rch.DecodeLookupHost(); err != nil {
+				t.Fatal(err)
+			}
+			<-txp.lateResponses
+		})
+
 		t.Run("uses a context-injected custom trace (success case)", func(t *testing.T) {
 			var (
 				delayedDNSResponseCalled bool
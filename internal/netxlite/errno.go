@@ -1,5 +1,5 @@
 // Code generated by go generate; DO NOT EDIT.
-// Generated: 2024-05-13 18:43:23.514043 +0200 CEST m=+0.480330667
+// Generated: 2026-10-18 22:12:15.321643664 +0000 UTC m=+0.356551330
 
 package netxlite
 
@@ -9,103 +9,203 @@ package netxlite
 // https://github.com/ooni/spec/blob/master/data-formats/df-007-errors.md.
 // Please, refer to that document for more information.
 const (
-	FailureAddressFamilyNotSupported       = "address_family_not_supported"
-	FailureAddressInUse                    = "address_in_use"
-	FailureAddressNotAvailable             = "address_not_available"
-	FailureAlreadyConnected                = "already_connected"
-	FailureAndroidDNSCacheNoData           = "android_dns_cache_no_data"
-	FailureBadAddress                      = "bad_address"
-	FailureBadFileDescriptor               = "bad_file_descriptor"
-	FailureConnectionAborted               = "connection_aborted"
-	FailureConnectionAlreadyClosed         = "connection_already_closed"
-	FailureConnectionAlreadyInProgress     = "connection_already_in_progress"
-	FailureConnectionRefused               = "connection_refused"
-	FailureConnectionReset                 = "connection_reset"
-	FailureDNSBogonError                   = "dns_bogon_error"
-	FailureDNSNXDOMAINError                = "dns_nxdomain_error"
-	FailureDNSNoAnswer                     = "dns_no_answer"
-	FailureDNSNonRecoverableFailure        = "dns_non_recoverable_failure"
-	FailureDNSRefusedError                 = "dns_refused_error"
-	FailureDNSReplyWithWrongQueryID        = "dns_reply_with_wrong_query_id"
-	FailureDNSServerMisbehaving            = "dns_server_misbehaving"
-	FailureDNSServfailError                = "dns_servfail_error"
-	FailureDNSTemporaryFailure             = "dns_temporary_failure"
-	FailureDestinationAddressRequired      = "destination_address_required"
-	FailureEOFError                        = "eof_error"
-	FailureGenericTimeoutError             = "generic_timeout_error"
-	FailureHTTPInvalidRedirectLocationHost = "http_invalid_redirect_location_host"
-	FailureHostUnreachable                 = "host_unreachable"
-	FailureInterrupted                     = "interrupted"
-	FailureInvalidArgument                 = "invalid_argument"
-	FailureJSONParseError                  = "json_parse_error"
-	FailureMessageSize                     = "message_size"
-	FailureNetworkDown                     = "network_down"
-	FailureNetworkReset                    = "network_reset"
-	FailureNetworkUnreachable              = "network_unreachable"
-	FailureNoBufferSpace                   = "no_buffer_space"
-	FailureNoProtocolOption                = "no_protocol_option"
-	FailureNotASocket                      = "not_a_socket"
-	FailureNotConnected                    = "not_connected"
-	FailureOperationWouldBlock             = "operation_would_block"
-	FailurePermissionDenied                = "permission_denied"
-	FailureProtocolNotSupported            = "protocol_not_supported"
-	FailureQUICIncompatibleVersion         = "quic_incompatible_version"
-	FailureSSLFailedHandshake              = "ssl_failed_handshake"
-	FailureSSLInvalidCertificate           = "ssl_invalid_certificate"
-	FailureSSLInvalidHostname              = "ssl_invalid_hostname"
-	FailureSSLUnknownAuthority             = "ssl_unknown_authority"
-	FailureTimedOut                        = "timed_out"
-	FailureWrongProtocolType               = "wrong_protocol_type"
+	FailureAddressFamilyNotSupported            = "address_family_not_supported"
+	FailureAddressInUse                         = "address_in_use"
+	FailureAddressNotAvailable                  = "address_not_available"
+	FailureAlreadyConnected                     = "already_connected"
+	FailureAndroidDNSCacheNoData                = "android_dns_cache_no_data"
+	FailureBadAddress                           = "bad_address"
+	FailureBadFileDescriptor                    = "bad_file_descriptor"
+	FailureConnectionAborted                    = "connection_aborted"
+	FailureConnectionAlreadyClosed              = "connection_already_closed"
+	FailureConnectionAlreadyInProgress          = "connection_already_in_progress"
+	FailureConnectionRefused                    = "connection_refused"
+	FailureConnectionReset                      = "connection_reset"
+	FailureDNSBogonError                        = "dns_bogon_error"
+	FailureDNSHTTPBadRequest                    = "dns_http_bad_request"
+	FailureDNSHTTPForbidden                     = "dns_http_forbidden"
+	FailureDNSHTTPNotFound                      = "dns_http_not_found"
+	FailureDNSHTTPPayloadTooLarge               = "dns_http_payload_too_large"
+	FailureDNSHTTPServerError                   = "dns_http_server_error"
+	FailureDNSHTTPTooManyRequests               = "dns_http_too_many_requests"
+	FailureDNSHTTPUnavailableForLegalReasons    = "dns_http_unavailable_for_legal_reasons"
+	FailureDNSHTTPUnexpectedStatus              = "dns_http_unexpected_status"
+	FailureDNSHTTPUnsupportedMediaType          = "dns_http_unsupported_media_type"
+	FailureDNSNXDOMAINError                     = "dns_nxdomain_error"
+	FailureDNSNoAnswer                          = "dns_no_answer"
+	FailureDNSNonRecoverableFailure             = "dns_non_recoverable_failure"
+	FailureDNSRefusedError                      = "dns_refused_error"
+	FailureDNSReplyWithWrongQueryID             = "dns_reply_with_wrong_query_id"
+	FailureDNSServerMisbehaving                 = "dns_server_misbehaving"
+	FailureDNSServfailError                     = "dns_servfail_error"
+	FailureDNSTemporaryFailure                  = "dns_temporary_failure"
+	FailureDestinationAddressRequired           = "destination_address_required"
+	FailureEOFError                             = "eof_error"
+	FailureGenericTimeoutError                  = "generic_timeout_error"
+	FailureHTTPInvalidRedirectLocationHost      = "http_invalid_redirect_location_host"
+	FailureHostUnreachable                      = "host_unreachable"
+	FailureInterrupted                          = "interrupted"
+	FailureInvalidArgument                      = "invalid_argument"
+	FailureJSONParseError                       = "json_parse_error"
+	FailureMessageSize                          = "message_size"
+	FailureNetworkDown                          = "network_down"
+	FailureNetworkReset                         = "network_reset"
+	FailureNetworkUnreachable                   = "network_unreachable"
+	FailureNoBufferSpace                        = "no_buffer_space"
+	FailureNoProtocolOption                     = "no_protocol_option"
+	FailureNotASocket                           = "not_a_socket"
+	FailureNotConnected                         = "not_connected"
+	FailureOperationWouldBlock                  = "operation_would_block"
+	FailurePermissionDenied                     = "permission_denied"
+	FailureProtocolNotSupported                 = "protocol_not_supported"
+	FailureQUICAEADLimitReached                 = "quic_aead_limit_reached"
+	FailureQUICApplicationError                 = "quic_application_error"
+	FailureQUICConnectionIDLimitError           = "quic_connection_id_limit_error"
+	FailureQUICCryptoBufferExceeded             = "quic_crypto_buffer_exceeded"
+	FailureQUICFinalSizeError                   = "quic_final_size_error"
+	FailureQUICFlowControlError                 = "quic_flow_control_error"
+	FailureQUICFrameEncodingError               = "quic_frame_encoding_error"
+	FailureQUICIncompatibleVersion              = "quic_incompatible_version"
+	FailureQUICInternalError                    = "quic_internal_error"
+	FailureQUICInvalidToken                     = "quic_invalid_token"
+	FailureQUICKeyUpdateError                   = "quic_key_update_error"
+	FailureQUICNoError                          = "quic_no_error"
+	FailureQUICNoViablePath                     = "quic_no_viable_path"
+	FailureQUICProtocolViolation                = "quic_protocol_violation"
+	FailureQUICStreamLimitError                 = "quic_stream_limit_error"
+	FailureQUICStreamStateError                 = "quic_stream_state_error"
+	FailureQUICTransportParameterError          = "quic_transport_parameter_error"
+	FailureSSLAlertAccessDenied                 = "ssl_alert_access_denied"
+	FailureSSLAlertBadCertificateHashValue      = "ssl_alert_bad_certificate_hash_value"
+	FailureSSLAlertBadCertificateStatusResponse = "ssl_alert_bad_certificate_status_response"
+	FailureSSLAlertBadRecordMAC                 = "ssl_alert_bad_record_mac"
+	FailureSSLAlertCertificateRequired          = "ssl_alert_certificate_required"
+	FailureSSLAlertCertificateUnobtainable      = "ssl_alert_certificate_unobtainable"
+	FailureSSLAlertCloseNotify                  = "ssl_alert_close_notify"
+	FailureSSLAlertDecodeError                  = "ssl_alert_decode_error"
+	FailureSSLAlertDecompressionFailure         = "ssl_alert_decompression_failure"
+	FailureSSLAlertDecryptionFailed             = "ssl_alert_decryption_failed"
+	FailureSSLAlertECHRequired                  = "ssl_alert_ech_required"
+	FailureSSLAlertExportRestriction            = "ssl_alert_export_restriction"
+	FailureSSLAlertIllegalParameter             = "ssl_alert_illegal_parameter"
+	FailureSSLAlertInappropriateFallback        = "ssl_alert_inappropriate_fallback"
+	FailureSSLAlertInsufficientSecurity         = "ssl_alert_insufficient_security"
+	FailureSSLAlertInternalError                = "ssl_alert_internal_error"
+	FailureSSLAlertMissingExtension             = "ssl_alert_missing_extension"
+	FailureSSLAlertNoApplicationProtocol        = "ssl_alert_no_application_protocol"
+	FailureSSLAlertNoRenegotiation              = "ssl_alert_no_renegotiation"
+	FailureSSLAlertProtocolVersion              = "ssl_alert_protocol_version"
+	FailureSSLAlertRecordOverflow               = "ssl_alert_record_overflow"
+	FailureSSLAlertUnexpectedMessage            = "ssl_alert_unexpected_message"
+	FailureSSLAlertUnknownPSKIdentity           = "ssl_alert_unknown_psk_identity"
+	FailureSSLAlertUnsupportedExtension         = "ssl_alert_unsupported_extension"
+	FailureSSLAlertUserCanceled                 = "ssl_alert_user_canceled"
+	FailureSSLFailedHandshake                   = "ssl_failed_handshake"
+	FailureSSLInvalidCertificate                = "ssl_invalid_certificate"
+	FailureSSLInvalidHostname                   = "ssl_invalid_hostname"
+	FailureSSLUnknownAuthority                  = "ssl_unknown_authority"
+	FailureTimedOut                             = "timed_out"
+	FailureWrongProtocolType                    = "wrong_protocol_type"
 )
 
 // failureMap lists all failures so we can match them
 // when they are wrapped by quic.TransportError.
 var failuresMap = map[string]string{
-	"address_family_not_supported":        "address_family_not_supported",
-	"address_in_use":                      "address_in_use",
-	"address_not_available":               "address_not_available",
-	"already_connected":                   "already_connected",
-	"android_dns_cache_no_data":           "android_dns_cache_no_data",
-	"bad_address":                         "bad_address",
-	"bad_file_descriptor":                 "bad_file_descriptor",
-	"connection_aborted":                  "connection_aborted",
-	"connection_already_closed":           "connection_already_closed",
-	"connection_already_in_progress":      "connection_already_in_progress",
-	"connection_refused":                  "connection_refused",
-	"connection_reset":                    "connection_reset",
-	"destination_address_required":        "destination_address_required",
-	"dns_bogon_error":                     "dns_bogon_error",
-	"dns_no_answer":                       "dns_no_answer",
-	"dns_non_recoverable_failure":         "dns_non_recoverable_failure",
-	"dns_nxdomain_error":                  "dns_nxdomain_error",
-	"dns_refused_error":                   "dns_refused_error",
-	"dns_reply_with_wrong_query_id":       "dns_reply_with_wrong_query_id",
-	"dns_server_misbehaving":              "dns_server_misbehaving",
-	"dns_servfail_error":                  "dns_servfail_error",
-	"dns_temporary_failure":               "dns_temporary_failure",
-	"eof_error":                           "eof_error",
-	"generic_timeout_error":               "generic_timeout_error",
-	"host_unreachable":                    "host_unreachable",
-	"http_invalid_redirect_location_host": "http_invalid_redirect_location_host",
-	"interrupted":                         "interrupted",
-	"invalid_argument":                    "invalid_argument",
-	"json_parse_error":                    "json_parse_error",
-	"message_size":                        "message_size",
-	"network_down":                        "network_down",
-	"network_reset":                       "network_reset",
-	"network_unreachable":                 "network_unreachable",
-	"no_buffer_space":                     "no_buffer_space",
-	"no_protocol_option":                  "no_protocol_option",
-	"not_a_socket":                        "not_a_socket",
-	"not_connected":                       "not_connected",
-	"operation_would_block":               "operation_would_block",
-	"permission_denied":                   "permission_denied",
-	"protocol_not_supported":              "protocol_not_supported",
-	"quic_incompatible_version":           "quic_incompatible_version",
-	"ssl_failed_handshake":                "ssl_failed_handshake",
-	"ssl_invalid_certificate":             "ssl_invalid_certificate",
-	"ssl_invalid_hostname":                "ssl_invalid_hostname",
-	"ssl_unknown_authority":               "ssl_unknown_authority",
-	"timed_out":                           "timed_out",
-	"wrong_protocol_type":                 "wrong_protocol_type",
+	"address_family_not_supported":              "address_family_not_supported",
+	"address_in_use":                            "address_in_use",
+	"address_not_available":                     "address_not_available",
+	"already_connected":                         "already_connected",
+	"android_dns_cache_no_data":                 "android_dns_cache_no_data",
+	"bad_address":                               "bad_address",
+	"bad_file_descriptor":                       "bad_file_descriptor",
+	"connection_aborted":                        "connection_aborted",
+	"connection_already_closed":                 "connection_already_closed",
+	"connection_already_in_progress":            "connection_already_in_progress",
+	"connection_refused":                        "connection_refused",
+	"connection_reset":                          "connection_reset",
+	"destination_address_required":              "destination_address_required",
+	"dns_bogon_error":                           "dns_bogon_error",
+	"dns_http_bad_request":                      "dns_http_bad_request",
+	"dns_http_forbidden":                        "dns_http_forbidden",
+	"dns_http_not_found":                        "dns_http_not_found",
+	"dns_http_payload_too_large":                "dns_http_payload_too_large",
+	"dns_http_server_error":                     "dns_http_server_error",
+	"dns_http_too_many_requests":                "dns_http_too_many_requests",
+	"dns_http_unavailable_for_legal_reasons":    "dns_http_unavailable_for_legal_reasons",
+	"dns_http_unexpected_status":                "dns_http_unexpected_status",
+	"dns_http_unsupported_media_type":           "dns_http_unsupported_media_type",
+	"dns_no_answer":                             "dns_no_answer",
+	"dns_non_recoverable_failure":               "dns_non_recoverable_failure",
+	"dns_nxdomain_error":                        "dns_nxdomain_error",
+	"dns_refused_error":                         "dns_refused_error",
+	"dns_reply_with_wrong_query_id":             "dns_reply_with_wrong_query_id",
+	"dns_server_misbehaving":                    "dns_server_misbehaving",
+	"dns_servfail_error":                        "dns_servfail_error",
+	"dns_temporary_failure":                     "dns_temporary_failure",
+	"eof_error":                                 "eof_error",
+	"generic_timeout_error":                     "generic_timeout_error",
+	"host_unreachable":                          "host_unreachable",
+	"http_invalid_redirect_location_host":       "http_invalid_redirect_location_host",
+	"interrupted":                               "interrupted",
+	"invalid_argument":                          "invalid_argument",
+	"json_parse_error":                          "json_parse_error",
+	"message_size":                              "message_size",
+	"network_down":                              "network_down",
+	"network_reset":                             "network_reset",
+	"network_unreachable":                       "network_unreachable",
+	"no_buffer_space":                           "no_buffer_space",
+	"no_protocol_option":                        "no_protocol_option",
+	"not_a_socket":                              "not_a_socket",
+	"not_connected":                             "not_connected",
+	"operation_would_block":                     "operation_would_block",
+	"permission_denied":                         "permission_denied",
+	"protocol_not_supported":                    "protocol_not_supported",
+	"quic_aead_limit_reached":                   "quic_aead_limit_reached",
+	"quic_application_error":                    "quic_application_error",
+	"quic_connection_id_limit_error":            "quic_connection_id_limit_error",
+	"quic_crypto_buffer_exceeded":               "quic_crypto_buffer_exceeded",
+	"quic_final_size_error":                     "quic_final_size_error",
+	"quic_flow_control_error":                   "quic_flow_control_error",
+	"quic_frame_encoding_error":                 "quic_frame_encoding_error",
+	"quic_incompatible_version":                 "quic_incompatible_version",
+	"quic_internal_error":                       "quic_internal_error",
+	"quic_invalid_token":                        "quic_invalid_token",
+	"quic_key_update_error":                     "quic_key_update_error",
+	"quic_no_error":                             "quic_no_error",
+	"quic_no_viable_path":                       "quic_no_viable_path",
+	"quic_protocol_violation":                   "quic_protocol_violation",
+	"quic_stream_limit_error":                   "quic_stream_limit_error",
+	"quic_stream_state_error":                   "quic_stream_state_error",
+	"quic_transport_parameter_error":            "quic_transport_parameter_error",
+	"ssl_alert_access_denied":                   "ssl_alert_access_denied",
+	"ssl_alert_bad_certificate_hash_value":      "ssl_alert_bad_certificate_hash_value",
+	"ssl_alert_bad_certificate_status_response": "ssl_alert_bad_certificate_status_response",
+	"ssl_alert_bad_record_mac":                  "ssl_alert_bad_record_mac",
+	"ssl_alert_certificate_required":            "ssl_alert_certificate_required",
+	"ssl_alert_certificate_unobtainable":        "ssl_alert_certificate_unobtainable",
+	"ssl_alert_close_notify":                    "ssl_alert_close_notify",
+	"ssl_alert_decode_error":                    "ssl_alert_decode_error",
+	"ssl_alert_decompression_failure":           "ssl_alert_decompression_failure",
+	"ssl_alert_decryption_failed":               "ssl_alert_decryption_failed",
+	"ssl_alert_ech_required":                    "ssl_alert_ech_required",
+	"ssl_alert_export_restriction":              "ssl_alert_export_restriction",
+	"ssl_alert_illegal_parameter":               "ssl_alert_illegal_parameter",
+	"ssl_alert_inappropriate_fallback":          "ssl_alert_inappropriate_fallback",
+	"ssl_alert_insufficient_security":           "ssl_alert_insufficient_security",
+	"ssl_alert_internal_error":                  "ssl_alert_internal_error",
+	"ssl_alert_missing_extension":               "ssl_alert_missing_extension",
+	"ssl_alert_no_application_protocol":         "ssl_alert_no_application_protocol",
+	"ssl_alert_no_renegotiation":                "ssl_alert_no_renegotiation",
+	"ssl_alert_protocol_version":                "ssl_alert_protocol_version",
+	"ssl_alert_record_overflow":                 "ssl_alert_record_overflow",
+	"ssl_alert_unexpected_message":              "ssl_alert_unexpected_message",
+	"ssl_alert_unknown_psk_identity":            "ssl_alert_unknown_psk_identity",
+	"ssl_alert_unsupported_extension":           "ssl_alert_unsupported_extension",
+	"ssl_alert_user_canceled":                   "ssl_alert_user_canceled",
+	"ssl_failed_handshake":                      "ssl_failed_handshake",
+	"ssl_invalid_certificate":                   "ssl_invalid_certificate",
+	"ssl_invalid_hostname":                      "ssl_invalid_hostname",
+	"ssl_unknown_authority":                     "ssl_unknown_authority",
+	"timed_out":                                 "timed_out",
+	"wrong_protocol_type":                       "wrong_protocol_type",
 }
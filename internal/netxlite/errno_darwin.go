@@ -1,5 +1,5 @@
 // Code generated by go generate; DO NOT EDIT.
-// Generated: 2024-05-13 18:43:23.034437 +0200 CEST m=+0.000713334
+// Generated: 2026-10-18 22:12:14.965774294 +0000 UTC m=+0.000681944
 
 package netxlite
 
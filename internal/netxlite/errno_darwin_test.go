@@ -1,5 +1,5 @@
 // Code generated by go generate; DO NOT EDIT.
-// Generated: 2024-05-13 18:43:23.30085 +0200 CEST m=+0.267132417
+// Generated: 2026-10-18 22:12:15.002384668 +0000 UTC m=+0.037292335
 
 package netxlite
 
@@ -1,5 +1,5 @@
 // Code generated by go generate; DO NOT EDIT.
-// Generated: 2024-05-13 18:43:23.327874 +0200 CEST m=+0.294157167
+// Generated: 2026-10-18 22:12:15.038032127 +0000 UTC m=+0.072939799
 
 package netxlite
 
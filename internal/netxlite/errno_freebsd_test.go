@@ -1,5 +1,5 @@
 // Code generated by go generate; DO NOT EDIT.
-// Generated: 2024-05-13 18:43:23.354839 +0200 CEST m=+0.321123042
+// Generated: 2026-10-18 22:12:15.075547788 +0000 UTC m=+0.110455440
 
 package netxlite
 
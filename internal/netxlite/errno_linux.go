@@ -1,5 +1,5 @@
 // Code generated by go generate; DO NOT EDIT.
-// Generated: 2024-05-13 18:43:23.42483 +0200 CEST m=+0.391115126
+// Generated: 2026-10-18 22:12:15.185445296 +0000 UTC m=+0.220352949
 
 package netxlite
 
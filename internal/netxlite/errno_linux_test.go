@@ -1,5 +1,5 @@
 // Code generated by go generate; DO NOT EDIT.
-// Generated: 2024-05-13 18:43:23.448805 +0200 CEST m=+0.415090876
+// Generated: 2026-10-18 22:12:15.221488858 +0000 UTC m=+0.256396502
 
 package netxlite
 
@@ -1,5 +1,5 @@
 // Code generated by go generate; DO NOT EDIT.
-// Generated: 2024-05-13 18:43:23.377383 +0200 CEST m=+0.343667459
+// Generated: 2026-10-18 22:12:15.110536788 +0000 UTC m=+0.145444447
 
 package netxlite
 
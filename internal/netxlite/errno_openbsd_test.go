@@ -1,5 +1,5 @@
 // Code generated by go generate; DO NOT EDIT.
-// Generated: 2024-05-13 18:43:23.402976 +0200 CEST m=+0.369261209
+// Generated: 2026-10-18 22:12:15.147749511 +0000 UTC m=+0.182657181
 
 package netxlite
 
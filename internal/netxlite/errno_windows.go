@@ -1,5 +1,5 @@
 // Code generated by go generate; DO NOT EDIT.
-// Generated: 2024-05-13 18:43:23.47073 +0200 CEST m=+0.437016626
+// Generated: 2026-10-18 22:12:15.256580152 +0000 UTC m=+0.291487822
 
 package netxlite
 
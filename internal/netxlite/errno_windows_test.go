@@ -1,5 +1,5 @@
 // Code generated by go generate; DO NOT EDIT.
-// Generated: 2024-05-13 18:43:23.492462 +0200 CEST m=+0.458748876
+// Generated: 2026-10-18 22:12:15.287618083 +0000 UTC m=+0.322525754
 
 package netxlite
 
@@ -172,6 +172,64 @@ var Specs = []*ErrorSpec{
 	NewLibraryError("connection_already_closed"),
 	NewLibraryError("HTTP_invalid_redirect_location_host"),
 
+	// TLS alerts received from the peer (see RFC8446 and the IANA TLS Alerts
+	// registry) that are not already mapped to one of the above SSL errors.
+	NewLibraryError("SSL_alert_close_notify"),
+	NewLibraryError("SSL_alert_unexpected_message"),
+	NewLibraryError("SSL_alert_bad_record_MAC"),
+	NewLibraryError("SSL_alert_decryption_failed"),
+	NewLibraryError("SSL_alert_record_overflow"),
+	NewLibraryError("SSL_alert_decompression_failure"),
+	NewLibraryError("SSL_alert_illegal_parameter"),
+	NewLibraryError("SSL_alert_access_denied"),
+	NewLibraryError("SSL_alert_decode_error"),
+	NewLibraryError("SSL_alert_export_restriction"),
+	NewLibraryError("SSL_alert_protocol_version"),
+	NewLibraryError("SSL_alert_insufficient_security"),
+	NewLibraryError("SSL_alert_internal_error"),
+	NewLibraryError("SSL_alert_inappropriate_fallback"),
+	NewLibraryError("SSL_alert_user_canceled"),
+	NewLibraryError("SSL_alert_no_renegotiation"),
+	NewLibraryError("SSL_alert_missing_extension"),
+	NewLibraryError("SSL_alert_unsupported_extension"),
+	NewLibraryError("SSL_alert_certificate_unobtainable"),
+	NewLibraryError("SSL_alert_bad_certificate_status_response"),
+	NewLibraryError("SSL_alert_bad_certificate_hash_value"),
+	NewLibraryError("SSL_alert_unknown_PSK_identity"),
+	NewLibraryError("SSL_alert_certificate_required"),
+	NewLibraryError("SSL_alert_no_application_protocol"),
+	NewLibraryError("SSL_alert_ECH_required"),
+
+	// QUIC CONNECTION_CLOSE error codes (see RFC9000 Sect. 20) that are not
+	// already mapped to one of the above errors.
+	NewLibraryError("QUIC_no_error"),
+	NewLibraryError("QUIC_internal_error"),
+	NewLibraryError("QUIC_flow_control_error"),
+	NewLibraryError("QUIC_stream_limit_error"),
+	NewLibraryError("QUIC_stream_state_error"),
+	NewLibraryError("QUIC_final_size_error"),
+	NewLibraryError("QUIC_frame_encoding_error"),
+	NewLibraryError("QUIC_transport_parameter_error"),
+	NewLibraryError("QUIC_connection_ID_limit_error"),
+	NewLibraryError("QUIC_protocol_violation"),
+	NewLibraryError("QUIC_invalid_token"),
+	NewLibraryError("QUIC_application_error"),
+	NewLibraryError("QUIC_crypto_buffer_exceeded"),
+	NewLibraryError("QUIC_key_update_error"),
+	NewLibraryError("QUIC_AEAD_limit_reached"),
+	NewLibraryError("QUIC_no_viable_path"),
+
+	// Non-200 HTTP status codes returned by DoH servers.
+	NewLibraryError("DNS_HTTP_bad_request"),
+	NewLibraryError("DNS_HTTP_forbidden"),
+	NewLibraryError("DNS_HTTP_not_found"),
+	NewLibraryError("DNS_HTTP_payload_too_large"),
+	NewLibraryError("DNS_HTTP_unsupported_media_type"),
+	NewLibraryError("DNS_HTTP_too_many_requests"),
+	NewLibraryError("DNS_HTTP_unavailable_for_legal_reasons"),
+	NewLibraryError("DNS_HTTP_server_error"),
+	NewLibraryError("DNS_HTTP_unexpected_status"),
+
 	// QUIRKS: the following errors exist to clearly flag strange
 	// underlying behavior implemented by platforms.
 	NewLibraryError("Android_DNS_cache_no_data"),
@@ -5,9 +5,14 @@ package netxlite
 //
 
 import (
+	"bufio"
 	"context"
+	"crypto/tls"
+	"encoding/base64"
 	"errors"
+	"fmt"
 	"net"
+	"net/http"
 	"net/url"
 
 	"github.com/ooni/probe-cli/v3/internal/model"
@@ -16,8 +21,15 @@ import (
 
 // proxyDialer is a dialer using a proxy.
 type proxyDialer struct {
-	Dialer   model.Dialer
+	// Dialer is the MANDATORY dialer used to connect to the proxy.
+	Dialer model.Dialer
+
+	// ProxyURL is the MANDATORY proxy URL.
 	ProxyURL *url.URL
+
+	// TLSHandshaker is the OPTIONAL TLS handshaker used to talk to
+	// https:// proxies. When nil, we use the stdlib handshaker.
+	TLSHandshaker model.TLSHandshaker
 }
 
 // MaybeWrapWithProxyDialer returns the original dialer if the proxyURL is nil
@@ -34,6 +46,22 @@ func MaybeWrapWithProxyDialer(dialer model.Dialer, proxyURL *url.URL) model.Dial
 	}
 }
 
+// MaybeWrapWithProxyDialer is like the deprecated package-level [MaybeWrapWithProxyDialer]
+// function except that it uses netx to perform the TLS handshake with https:// proxies.
+//
+// We support socks5://, http://, and https:// proxy URLs. For http:// and https://
+// proxies we use the CONNECT method, hence the proxy only works for TCP.
+func (netx *Netx) MaybeWrapWithProxyDialer(dialer model.Dialer, proxyURL *url.URL) model.Dialer {
+	if proxyURL == nil {
+		return dialer
+	}
+	return &proxyDialer{
+		Dialer:        dialer,
+		ProxyURL:      proxyURL,
+		TLSHandshaker: netx.NewTLSHandshakerStdlib(model.DiscardLogger),
+	}
+}
+
 var _ model.Dialer = &proxyDialer{}
 
 // CloseIdleConnections implements Dialer.CloseIdleConnections.
@@ -44,15 +72,25 @@ func (d *proxyDialer) CloseIdleConnections() {
 // ErrProxyUnsupportedScheme indicates we don't support the proxy scheme.
 var ErrProxyUnsupportedScheme = errors.New("proxy: unsupported scheme")
 
+// ErrProxyUnsupportedNetwork indicates that an HTTP proxy cannot proxy the given network.
+var ErrProxyUnsupportedNetwork = errors.New("proxy: unsupported network")
+
+// ErrProxyCONNECTFailed indicates that an HTTP proxy refused our CONNECT request.
+var ErrProxyCONNECTFailed = errors.New("proxy: CONNECT failed")
+
 // DialContext implements Dialer.DialContext.
 func (d *proxyDialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
 	url := d.ProxyURL
-	if url.Scheme != "socks5" {
+	switch url.Scheme {
+	case "socks5":
+		// the code at proxy/socks5.go never fails; see https://git.io/JfJ4g
+		child, _ := proxy.SOCKS5(network, url.Host, nil, &proxyDialerWrapper{d.Dialer})
+		return d.dial(ctx, child, network, address)
+	case "http", "https":
+		return d.dialHTTP(ctx, network, address)
+	default:
 		return nil, ErrProxyUnsupportedScheme
 	}
-	// the code at proxy/socks5.go never fails; see https://git.io/JfJ4g
-	child, _ := proxy.SOCKS5(network, url.Host, nil, &proxyDialerWrapper{d.Dialer})
-	return d.dial(ctx, child, network, address)
 }
 
 func (d *proxyDialer) dial(
@@ -61,6 +99,124 @@ func (d *proxyDialer) dial(
 	return cd.DialContext(ctx, network, address)
 }
 
+// dialHTTP dials address using the HTTP CONNECT method.
+func (d *proxyDialer) dialHTTP(ctx context.Context, network, address string) (net.Conn, error) {
+	switch network {
+	case "tcp", "tcp4", "tcp6":
+	default:
+		return nil, ErrProxyUnsupportedNetwork
+	}
+	conn, err := d.Dialer.DialContext(ctx, "tcp", proxyHostWithPort(d.ProxyURL))
+	if err != nil {
+		return nil, err
+	}
+	if d.ProxyURL.Scheme == "https" {
+		tlsConn, err := d.tlsHandshaker().Handshake(ctx, conn, &tls.Config{
+			ServerName: d.ProxyURL.Hostname(),
+			NextProtos: []string{"http/1.1"},
+		})
+		if err != nil {
+			conn.Close()
+			return nil, err
+		}
+		conn = tlsConn
+	}
+	pconn, err := d.connect(ctx, conn, address)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return pconn, nil
+}
+
+// tlsHandshaker returns the TLS handshaker to use for https:// proxies.
+func (d *proxyDialer) tlsHandshaker() model.TLSHandshaker {
+	if d.TLSHandshaker != nil {
+		return d.TLSHandshaker
+	}
+	netx := &Netx{Underlying: nil} // nil means using the default stdlib network
+	return netx.NewTLSHandshakerStdlib(model.DiscardLogger)
+}
+
+// connect sends the CONNECT request and reads the response honoring the context.
+func (d *proxyDialer) connect(ctx context.Context, conn net.Conn, address string) (net.Conn, error) {
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: address},
+		Host:   address,
+		Header: http.Header{},
+	}
+	if user := d.ProxyURL.User; user != nil {
+		password, _ := user.Password()
+		credentials := base64.StdEncoding.EncodeToString([]byte(user.Username() + ":" + password))
+		req.Header.Set("Proxy-Authorization", "Basic "+credentials)
+	}
+
+	// make sure we unblock the I/O operations if the context is done
+	done, interrupted := make(chan any), make(chan bool, 1)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+			interrupted <- true
+		case <-done:
+			interrupted <- false
+		}
+	}()
+	pconn, err := d.roundTrip(conn, req)
+	close(done)
+	if <-interrupted {
+		return nil, ctx.Err()
+	}
+	return pconn, err
+}
+
+// roundTrip writes the CONNECT request and reads the response.
+func (d *proxyDialer) roundTrip(conn net.Conn, req *http.Request) (net.Conn, error) {
+	if err := req.Write(conn); err != nil {
+		return nil, err
+	}
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, req)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: %s", ErrProxyCONNECTFailed, resp.Status)
+	}
+	if reader.Buffered() > 0 {
+		// the proxy has already sent data belonging to the tunnel
+		return &proxyBufferedConn{Conn: conn, reader: reader}, nil
+	}
+	return conn, nil
+}
+
+// proxyHostWithPort returns the proxy endpoint adding the default
+// port for the proxy scheme when the URL does not include a port.
+func proxyHostWithPort(URL *url.URL) string {
+	if URL.Port() != "" {
+		return URL.Host
+	}
+	port := "80"
+	if URL.Scheme == "https" {
+		port = "443"
+	}
+	return net.JoinHostPort(URL.Hostname(), port)
+}
+
+// proxyBufferedConn is a net.Conn that first returns the data
+// buffered while reading the CONNECT response.
+type proxyBufferedConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+// Read implements net.Conn.
+func (c *proxyBufferedConn) Read(b []byte) (int, error) {
+	return c.reader.Read(b)
+}
+
 // proxyDialerWrapper is required because SOCKS5 expects a Dialer.Dial type but internally
 // it checks whether DialContext is available and prefers that. So, we need to use this
 // structure to cast our inner Dialer the way in which SOCKS5 likes it.
@@ -0,0 +1,458 @@
+package netxlite
+
+//
+// PCAPNG capture of measurement traffic
+//
+
+import (
+	"context"
+	"io"
+	"math/rand"
+	"net"
+	"runtime"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/google/gopacket/pcapgo"
+	"github.com/ooni/netem"
+	"github.com/ooni/probe-cli/v3/internal/model"
+)
+
+// PCAPNGWriter writes a PCAPNG capture of the measurement traffic. The zero value
+// of this struct is invalid; use [NewPCAPNGWriter] to construct.
+//
+// You can use a [*PCAPNGWriter] in two ways:
+//
+// 1. call [PCAPNGWriter.WrapUnderlyingNetwork] to wrap a [model.UnderlyingNetwork] (e.g., a
+// [*DefaultTProxy]) and capture synthesized packets for the TCP and UDP conns created through it;
+//
+// 2. register it as a [netem.LinkNICWrapper] (e.g., using netemx.QAEnvOptionClientNICWrapper)
+// to capture the real packets flowing through the link when running inside netem.
+//
+// In the first case, we only see what the application layer reads and writes, so we fake
+// the TCP three-way handshake, sequence numbers, ACKs, and the FIN sent by the local
+// endpoint when closing. Because of that, the capture does not contain failed connection
+// attempts, retransmissions, RST segments, and other events only the kernel sees. Yet, the
+// capture is good enough to inspect the TLS and QUIC handshakes using Wireshark.
+type PCAPNGWriter struct {
+	// mu provides mutual exclusion for w.
+	mu sync.Mutex
+
+	// w is the underlying pcapng writer.
+	w *pcapgo.NgWriter
+}
+
+// NewPCAPNGWriter creates a new [*PCAPNGWriter] writing into the given [io.Writer]. The
+// writer buffers its output, so you MUST call [PCAPNGWriter.Flush] when done.
+func NewPCAPNGWriter(w io.Writer) (*PCAPNGWriter, error) {
+	intf := pcapgo.NgInterface{
+		Name:                "netxlite",
+		OS:                  runtime.GOOS,
+		LinkType:            layers.LinkTypeRaw,
+		TimestampResolution: 9,
+		SnapLength:          0, // unlimited
+	}
+	options := pcapgo.NgWriterOptions{
+		SectionInfo: pcapgo.NgSectionInfo{
+			Hardware:    runtime.GOARCH,
+			OS:          runtime.GOOS,
+			Application: "netxlite",
+		},
+	}
+	ngw, err := pcapgo.NewNgWriterInterface(w, intf, options)
+	if err != nil {
+		return nil, err
+	}
+	return &PCAPNGWriter{w: ngw}, nil
+}
+
+// Flush flushes the buffered packets into the underlying [io.Writer].
+func (pw *PCAPNGWriter) Flush() error {
+	defer pw.mu.Unlock()
+	pw.mu.Lock()
+	return pw.w.Flush()
+}
+
+// writePacket writes the given raw IP packet into the capture.
+func (pw *PCAPNGWriter) writePacket(packet []byte) {
+	ci := gopacket.CaptureInfo{
+		Timestamp:      time.Now(),
+		CaptureLength:  len(packet),
+		Length:         len(packet),
+		InterfaceIndex: 0,
+	}
+	defer pw.mu.Unlock()
+	pw.mu.Lock()
+	_ = pw.w.WritePacket(ci, packet) // a broken capture should not break the measurement
+}
+
+// pcapngEndpoint is an endpoint of a synthesized packet.
+type pcapngEndpoint struct {
+	ip   net.IP
+	port uint16
+}
+
+// newPCAPNGEndpoint creates a [pcapngEndpoint] from a [net.Addr]. We parse the string
+// representation of the address because the concrete type of the address depends on
+// the [model.UnderlyingNetwork] we're wrapping (e.g., netem's addresses).
+func newPCAPNGEndpoint(addr net.Addr) pcapngEndpoint {
+	ep := pcapngEndpoint{ip: net.IPv4zero, port: 0}
+	if addr == nil {
+		return ep
+	}
+	host, port, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return ep
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		ep.ip = ip
+	}
+	if value, err := strconv.ParseUint(port, 10, 16); err == nil {
+		ep.port = uint16(value)
+	}
+	return ep
+}
+
+// synthesize serializes a packet containing the given transport layer and payload
+// and writes it into the capture. We use IPv4 when the destination is IPv4 and
+// otherwise we use IPv6, adjusting the source to the destination family, which
+// is needed, e.g., when a UDP socket bound to [::] sends to an IPv4 address.
+func (pw *PCAPNGWriter) synthesize(src, dst pcapngEndpoint,
+	transport gopacket.SerializableLayer, payload []byte) {
+	var network gopacket.NetworkLayer
+	switch {
+	case dst.ip.To4() != nil:
+		srcIP := src.ip.To4()
+		if srcIP == nil {
+			srcIP = net.IPv4zero.To4()
+		}
+		network = &layers.IPv4{
+			Version:  4,
+			TTL:      64,
+			Protocol: pcapngIPProtocol(transport),
+			SrcIP:    srcIP,
+			DstIP:    dst.ip.To4(),
+		}
+	default:
+		srcIP := src.ip
+		if srcIP.To4() != nil {
+			srcIP = net.IPv6unspecified
+		}
+		network = &layers.IPv6{
+			Version:    6,
+			HopLimit:   64,
+			NextHeader: pcapngIPProtocol(transport),
+			SrcIP:      srcIP,
+			DstIP:      dst.ip,
+		}
+	}
+	switch tl := transport.(type) {
+	case *layers.TCP:
+		_ = tl.SetNetworkLayerForChecksum(network)
+	case *layers.UDP:
+		_ = tl.SetNetworkLayerForChecksum(network)
+	}
+	buffer := gopacket.NewSerializeBuffer()
+	options := gopacket.SerializeOptions{FixLengths: true, ComputeChecksums: true}
+	err := gopacket.SerializeLayers(buffer, options,
+		network.(gopacket.SerializableLayer), transport, gopacket.Payload(payload))
+	if err != nil {
+		return // e.g., the payload is too large
+	}
+	pw.writePacket(buffer.Bytes())
+}
+
+// pcapngIPProtocol returns the IP protocol number for the given transport layer.
+func pcapngIPProtocol(transport gopacket.SerializableLayer) layers.IPProtocol {
+	if _, ok := transport.(*layers.UDP); ok {
+		return layers.IPProtocolUDP
+	}
+	return layers.IPProtocolTCP
+}
+
+// synthesizeUDP writes a synthesized UDP datagram into the capture.
+func (pw *PCAPNGWriter) synthesizeUDP(src, dst pcapngEndpoint, payload []byte) {
+	udp := &layers.UDP{
+		SrcPort: layers.UDPPort(src.port),
+		DstPort: layers.UDPPort(dst.port),
+	}
+	pw.synthesize(src, dst, udp, payload)
+}
+
+// WrapUnderlyingNetwork returns a [model.UnderlyingNetwork] that writes synthesized
+// packets for the TCP and UDP conns it creates into the [*PCAPNGWriter].
+func (pw *PCAPNGWriter) WrapUnderlyingNetwork(unet model.UnderlyingNetwork) model.UnderlyingNetwork {
+	return &pcapngUnderlyingNetwork{
+		UnderlyingNetwork: unet,
+		pw:                pw,
+	}
+}
+
+type pcapngUnderlyingNetwork struct {
+	model.UnderlyingNetwork
+	pw *PCAPNGWriter
+}
+
+// DialContext implements model.UnderlyingNetwork.
+func (unet *pcapngUnderlyingNetwork) DialContext(
+	ctx context.Context, network, address string) (net.Conn, error) {
+	conn, err := unet.UnderlyingNetwork.DialContext(ctx, network, address)
+	if err != nil {
+		return nil, err
+	}
+	switch network {
+	case "tcp", "tcp4", "tcp6":
+		return newPCAPNGTCPConn(conn, unet.pw, true), nil
+	case "udp", "udp4", "udp6":
+		return newPCAPNGUDPConn(conn, unet.pw), nil
+	default:
+		return conn, nil
+	}
+}
+
+// ListenTCP implements model.UnderlyingNetwork.
+func (unet *pcapngUnderlyingNetwork) ListenTCP(
+	network string, addr *net.TCPAddr) (net.Listener, error) {
+	listener, err := unet.UnderlyingNetwork.ListenTCP(network, addr)
+	if err != nil {
+		return nil, err
+	}
+	return &pcapngListener{Listener: listener, pw: unet.pw}, nil
+}
+
+// ListenUDP implements model.UnderlyingNetwork.
+func (unet *pcapngUnderlyingNetwork) ListenUDP(
+	network string, addr *net.UDPAddr) (model.UDPLikeConn, error) {
+	pconn, err := unet.UnderlyingNetwork.ListenUDP(network, addr)
+	if err != nil {
+		return nil, err
+	}
+	return &pcapngUDPLikeConn{UDPLikeConn: pconn, pw: unet.pw}, nil
+}
+
+type pcapngListener struct {
+	net.Listener
+	pw *PCAPNGWriter
+}
+
+// Accept implements net.Listener.
+func (l *pcapngListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return newPCAPNGTCPConn(conn, l.pw, false), nil
+}
+
+// pcapngMaxSegmentSize is the maximum payload size of a synthesized TCP segment.
+const pcapngMaxSegmentSize = 1 << 14
+
+// pcapngTCPConn is a TCP [net.Conn] that synthesizes TCP segments.
+type pcapngTCPConn struct {
+	net.Conn
+
+	// closeOnce provides "once" semantics for Close.
+	closeOnce sync.Once
+
+	// local is the local endpoint.
+	local pcapngEndpoint
+
+	// localSeq is the next local sequence number.
+	localSeq uint32
+
+	// mu provides mutual exclusion for localSeq and remoteSeq.
+	mu sync.Mutex
+
+	// pw is the writer to use.
+	pw *PCAPNGWriter
+
+	// remote is the remote endpoint.
+	remote pcapngEndpoint
+
+	// remoteSeq is the next remote sequence number.
+	remoteSeq uint32
+}
+
+// newPCAPNGTCPConn wraps a TCP conn and writes the three-way handshake into
+// the capture. The dialer argument indicates whether the local endpoint is the
+// one that initiated the connection, which is false for accepted conns.
+func newPCAPNGTCPConn(conn net.Conn, pw *PCAPNGWriter, dialer bool) *pcapngTCPConn {
+	c := &pcapngTCPConn{
+		Conn:      conn,
+		closeOnce: sync.Once{},
+		local:     newPCAPNGEndpoint(conn.LocalAddr()),
+		localSeq:  rand.Uint32(),
+		mu:        sync.Mutex{},
+		pw:        pw,
+		remote:    newPCAPNGEndpoint(conn.RemoteAddr()),
+		remoteSeq: rand.Uint32(),
+	}
+	if dialer {
+		c.synthesizeLocked(true, &layers.TCP{SYN: true}, nil)
+		c.synthesizeLocked(false, &layers.TCP{SYN: true, ACK: true}, nil)
+		c.synthesizeLocked(true, &layers.TCP{ACK: true}, nil)
+	} else {
+		c.synthesizeLocked(false, &layers.TCP{SYN: true}, nil)
+		c.synthesizeLocked(true, &layers.TCP{SYN: true, ACK: true}, nil)
+		c.synthesizeLocked(false, &layers.TCP{ACK: true}, nil)
+	}
+	return c
+}
+
+// synthesizeLocked writes a TCP segment into the capture and updates the sequence
+// numbers. The outgoing argument indicates whether the segment is from the local
+// endpoint. This method MUST be called while holding the mutex or during init.
+func (c *pcapngTCPConn) synthesizeLocked(outgoing bool, tcp *layers.TCP, payload []byte) {
+	src, dst, seq, ack := c.local, c.remote, &c.localSeq, c.remoteSeq
+	if !outgoing {
+		src, dst, seq, ack = c.remote, c.local, &c.remoteSeq, c.localSeq
+	}
+	tcp.SrcPort = layers.TCPPort(src.port)
+	tcp.DstPort = layers.TCPPort(dst.port)
+	tcp.Seq = *seq
+	if tcp.ACK {
+		tcp.Ack = ack
+	}
+	tcp.Window = 65535
+	c.pw.synthesize(src, dst, tcp, payload)
+	*seq += uint32(len(payload))
+	if tcp.SYN || tcp.FIN {
+		*seq += 1 // SYN and FIN consume a sequence number
+	}
+}
+
+// synthesizeData writes the given data into the capture as one or more segments.
+func (c *pcapngTCPConn) synthesizeData(outgoing bool, data []byte) {
+	defer c.mu.Unlock()
+	c.mu.Lock()
+	for len(data) > 0 {
+		count := len(data)
+		if count > pcapngMaxSegmentSize {
+			count = pcapngMaxSegmentSize
+		}
+		c.synthesizeLocked(outgoing, &layers.TCP{PSH: true, ACK: true}, data[:count])
+		data = data[count:]
+	}
+}
+
+// Read implements net.Conn.
+func (c *pcapngTCPConn) Read(b []byte) (int, error) {
+	count, err := c.Conn.Read(b)
+	c.synthesizeData(false, b[:count])
+	return count, err
+}
+
+// Write implements net.Conn.
+func (c *pcapngTCPConn) Write(b []byte) (int, error) {
+	count, err := c.Conn.Write(b)
+	c.synthesizeData(true, b[:count])
+	return count, err
+}
+
+// NetConn returns the underlying net.Conn.
+func (c *pcapngTCPConn) NetConn() net.Conn {
+	return c.Conn
+}
+
+// Close implements net.Conn.
+func (c *pcapngTCPConn) Close() error {
+	c.closeOnce.Do(func() {
+		defer c.mu.Unlock()
+		c.mu.Lock()
+		c.synthesizeLocked(true, &layers.TCP{FIN: true, ACK: true}, nil)
+	})
+	return c.Conn.Close()
+}
+
+// pcapngUDPConn is a connected UDP [net.Conn] that synthesizes UDP datagrams.
+type pcapngUDPConn struct {
+	net.Conn
+	local  pcapngEndpoint
+	pw     *PCAPNGWriter
+	remote pcapngEndpoint
+}
+
+func newPCAPNGUDPConn(conn net.Conn, pw *PCAPNGWriter) *pcapngUDPConn {
+	return &pcapngUDPConn{
+		Conn:   conn,
+		local:  newPCAPNGEndpoint(conn.LocalAddr()),
+		pw:     pw,
+		remote: newPCAPNGEndpoint(conn.RemoteAddr()),
+	}
+}
+
+// Read implements net.Conn.
+func (c *pcapngUDPConn) Read(b []byte) (int, error) {
+	count, err := c.Conn.Read(b)
+	if count > 0 {
+		c.pw.synthesizeUDP(c.remote, c.local, b[:count])
+	}
+	return count, err
+}
+
+// Write implements net.Conn.
+func (c *pcapngUDPConn) Write(b []byte) (int, error) {
+	count, err := c.Conn.Write(b)
+	if count > 0 {
+		c.pw.synthesizeUDP(c.local, c.remote, b[:count])
+	}
+	return count, err
+}
+
+// pcapngUDPLikeConn is a [model.UDPLikeConn] that synthesizes UDP datagrams.
+type pcapngUDPLikeConn struct {
+	model.UDPLikeConn
+	pw *PCAPNGWriter
+}
+
+// ReadFrom implements model.UDPLikeConn.
+func (c *pcapngUDPLikeConn) ReadFrom(b []byte) (int, net.Addr, error) {
+	count, addr, err := c.UDPLikeConn.ReadFrom(b)
+	if count > 0 {
+		local := newPCAPNGEndpoint(c.UDPLikeConn.LocalAddr())
+		c.pw.synthesizeUDP(newPCAPNGEndpoint(addr), local, b[:count])
+	}
+	return count, addr, err
+}
+
+// WriteTo implements model.UDPLikeConn.
+func (c *pcapngUDPLikeConn) WriteTo(b []byte, addr net.Addr) (int, error) {
+	count, err := c.UDPLikeConn.WriteTo(b, addr)
+	if count > 0 {
+		local := newPCAPNGEndpoint(c.UDPLikeConn.LocalAddr())
+		c.pw.synthesizeUDP(local, newPCAPNGEndpoint(addr), b[:count])
+	}
+	return count, err
+}
+
+var _ netem.LinkNICWrapper = &PCAPNGWriter{}
+
+// WrapNIC implements [netem.LinkNICWrapper].
+func (pw *PCAPNGWriter) WrapNIC(nic netem.NIC) netem.NIC {
+	return &pcapngNIC{NIC: nic, pw: pw}
+}
+
+// pcapngNIC is a [netem.NIC] that writes the real packets into the capture.
+type pcapngNIC struct {
+	netem.NIC
+	pw *PCAPNGWriter
+}
+
+// ReadFrameNonblocking implements netem.NIC.
+func (nic *pcapngNIC) ReadFrameNonblocking() (*netem.Frame, error) {
+	frame, err := nic.NIC.ReadFrameNonblocking()
+	if err != nil {
+		return nil, err
+	}
+	nic.pw.writePacket(frame.Payload)
+	return frame, nil
+}
+
+// WriteFrame implements netem.NIC.
+func (nic *pcapngNIC) WriteFrame(frame *netem.Frame) error {
+	nic.pw.writePacket(frame.Payload)
+	return nic.NIC.WriteFrame(frame)
+}
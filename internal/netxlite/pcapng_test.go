@@ -0,0 +1,296 @@
+package netxlite
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/apex/log"
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/google/gopacket/pcapgo"
+	"github.com/ooni/netem"
+	"github.com/ooni/probe-cli/v3/internal/mocks"
+	"github.com/ooni/probe-cli/v3/internal/runtimex"
+)
+
+// readPCAPNGPackets parses the packets written into a [*PCAPNGWriter].
+func readPCAPNGPackets(t *testing.T, pw *PCAPNGWriter, buffer *bytes.Buffer) []gopacket.Packet {
+	if err := pw.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	reader, err := pcapgo.NewNgReader(buffer, pcapgo.DefaultNgReaderOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reader.LinkType() != layers.LinkTypeRaw {
+		t.Fatal("unexpected link type", reader.LinkType())
+	}
+	var packets []gopacket.Packet
+	for {
+		data, _, err := reader.ReadPacketData()
+		if errors.Is(err, io.EOF) {
+			return packets
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		packets = append(packets, gopacket.NewPacket(data, layers.LinkTypeRaw, gopacket.Default))
+	}
+}
+
+func TestPCAPNGWriter(t *testing.T) {
+	// constants for the IP address we're using
+	const (
+		clientAddress = "130.192.91.211"
+		serverAddress = "93.184.216.34"
+	)
+
+	// newTopology creates a star topology where the client link uses the given config
+	newTopology := func(clientConfig *netem.LinkConfig) (*netem.StarTopology, *netem.UNetStack, *netem.UNetStack) {
+		topology := netem.MustNewStarTopology(log.Log)
+		serverStack := runtimex.Try1(topology.AddHost(serverAddress, "0.0.0.0", &netem.LinkConfig{}))
+		clientStack := runtimex.Try1(topology.AddHost(clientAddress, "0.0.0.0", clientConfig))
+		return topology, serverStack, clientStack
+	}
+
+	t.Run("WrapUnderlyingNetwork with TCP", func(t *testing.T) {
+		topology, serverStack, clientStack := newTopology(&netem.LinkConfig{})
+		defer topology.Close()
+
+		// create an echo server
+		serverEndpoint := &net.TCPAddr{IP: net.ParseIP(serverAddress), Port: 443}
+		listener := runtimex.Try1((&NetemUnderlyingNetworkAdapter{serverStack}).ListenTCP("tcp", serverEndpoint))
+		defer listener.Close()
+		go func() {
+			conn := runtimex.Try1(listener.Accept())
+			defer conn.Close()
+			_, _ = io.Copy(conn, conn)
+		}()
+
+		// exchange some data using the wrapped client network
+		buffer := &bytes.Buffer{}
+		pw := runtimex.Try1(NewPCAPNGWriter(buffer))
+		unet := pw.WrapUnderlyingNetwork(&NetemUnderlyingNetworkAdapter{clientStack})
+		conn, err := unet.DialContext(context.Background(), "tcp", serverEndpoint.String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := conn.Write([]byte("hello")); err != nil {
+			t.Fatal(err)
+		}
+		data := make([]byte, 5)
+		if _, err := io.ReadFull(conn, data); err != nil {
+			t.Fatal(err)
+		}
+		conn.Close()
+
+		// make sure we have synthesized the expected segments
+		packets := readPCAPNGPackets(t, pw, buffer)
+		type expectation struct {
+			srcPort layers.TCPPort
+			syn     bool
+			ack     bool
+			fin     bool
+			payload string
+		}
+		expect := []expectation{
+			{srcPort: 0, syn: true},
+			{srcPort: 443, syn: true, ack: true},
+			{srcPort: 0, ack: true},
+			{srcPort: 0, ack: true, payload: "hello"},
+			{srcPort: 443, ack: true, payload: "hello"},
+			{srcPort: 0, ack: true, fin: true},
+		}
+		if len(packets) != len(expect) {
+			t.Fatal("unexpected number of packets", len(packets))
+		}
+		var clientISN, serverISN uint32
+		for idx, packet := range packets {
+			ip := packet.Layer(layers.LayerTypeIPv4).(*layers.IPv4)
+			tcp := packet.Layer(layers.LayerTypeTCP).(*layers.TCP)
+			exp := expect[idx]
+			if exp.srcPort == 443 {
+				if ip.SrcIP.String() != serverAddress || tcp.SrcPort != 443 {
+					t.Fatal(idx, "unexpected source", ip.SrcIP, tcp.SrcPort)
+				}
+			} else if ip.SrcIP.String() != clientAddress || tcp.DstPort != 443 {
+				t.Fatal(idx, "unexpected source", ip.SrcIP, tcp.DstPort)
+			}
+			if tcp.SYN != exp.syn || tcp.ACK != exp.ack || tcp.FIN != exp.fin {
+				t.Fatal(idx, "unexpected flags", tcp.SYN, tcp.ACK, tcp.FIN)
+			}
+			if string(tcp.Payload) != exp.payload {
+				t.Fatal(idx, "unexpected payload", string(tcp.Payload))
+			}
+			switch idx {
+			case 0:
+				clientISN = tcp.Seq
+			case 1:
+				serverISN = tcp.Seq
+				if tcp.Ack != clientISN+1 {
+					t.Fatal("unexpected SYN-ACK ack", tcp.Ack)
+				}
+			case 3:
+				if tcp.Seq != clientISN+1 || tcp.Ack != serverISN+1 {
+					t.Fatal("unexpected client data seq/ack", tcp.Seq, tcp.Ack)
+				}
+			case 4:
+				if tcp.Seq != serverISN+1 || tcp.Ack != clientISN+6 {
+					t.Fatal("unexpected server data seq/ack", tcp.Seq, tcp.Ack)
+				}
+			case 5:
+				if tcp.Seq != clientISN+6 || tcp.Ack != serverISN+6 {
+					t.Fatal("unexpected FIN seq/ack", tcp.Seq, tcp.Ack)
+				}
+			}
+		}
+	})
+
+	t.Run("WrapUnderlyingNetwork with UDP", func(t *testing.T) {
+		topology, serverStack, clientStack := newTopology(&netem.LinkConfig{})
+		defer topology.Close()
+
+		// create an echo server
+		serverEndpoint := &net.UDPAddr{IP: net.ParseIP(serverAddress), Port: 443}
+		pconn := runtimex.Try1((&NetemUnderlyingNetworkAdapter{serverStack}).ListenUDP("udp", serverEndpoint))
+		defer pconn.Close()
+		go func() {
+			buffer := make([]byte, 1024)
+			for {
+				count, addr, err := pconn.ReadFrom(buffer)
+				if err != nil {
+					return
+				}
+				_, _ = pconn.WriteTo(buffer[:count], addr)
+			}
+		}()
+
+		// exchange some data using both a listening and a connected socket
+		buffer := &bytes.Buffer{}
+		pw := runtimex.Try1(NewPCAPNGWriter(buffer))
+		unet := pw.WrapUnderlyingNetwork(&NetemUnderlyingNetworkAdapter{clientStack})
+		clientPconn, err := unet.ListenUDP("udp", &net.UDPAddr{IP: net.ParseIP(clientAddress), Port: 54321})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer clientPconn.Close()
+		if _, err := clientPconn.WriteTo([]byte("ping"), serverEndpoint); err != nil {
+			t.Fatal(err)
+		}
+		data := make([]byte, 1024)
+		if _, _, err := clientPconn.ReadFrom(data); err != nil {
+			t.Fatal(err)
+		}
+		clientConn, err := unet.DialContext(context.Background(), "udp", serverEndpoint.String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer clientConn.Close()
+		if _, err := clientConn.Write([]byte("ping")); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := clientConn.Read(data); err != nil {
+			t.Fatal(err)
+		}
+
+		// make sure we have synthesized the expected datagrams
+		packets := readPCAPNGPackets(t, pw, buffer)
+		if len(packets) != 4 {
+			t.Fatal("unexpected number of packets", len(packets))
+		}
+		for idx, packet := range packets {
+			udp := packet.Layer(layers.LayerTypeUDP).(*layers.UDP)
+			if string(udp.Payload) != "ping" {
+				t.Fatal(idx, "unexpected payload", string(udp.Payload))
+			}
+			port := udp.DstPort
+			if idx%2 == 1 {
+				port = udp.SrcPort
+			}
+			if port != 443 {
+				t.Fatal(idx, "unexpected server port", port)
+			}
+		}
+	})
+
+	t.Run("WrapUnderlyingNetwork does not write anything on dial failure", func(t *testing.T) {
+		buffer := &bytes.Buffer{}
+		pw := runtimex.Try1(NewPCAPNGWriter(buffer))
+		expected := errors.New("mocked error")
+		unet := pw.WrapUnderlyingNetwork(&mocks.UnderlyingNetwork{
+			MockDialContext: func(ctx context.Context, network, address string) (net.Conn, error) {
+				return nil, expected
+			},
+		})
+		conn, err := unet.DialContext(context.Background(), "tcp", "1.1.1.1:443")
+		if !errors.Is(err, expected) {
+			t.Fatal("unexpected error", err)
+		}
+		if conn != nil {
+			t.Fatal("expected nil conn")
+		}
+		if packets := readPCAPNGPackets(t, pw, buffer); len(packets) != 0 {
+			t.Fatal("unexpected number of packets", len(packets))
+		}
+	})
+
+	t.Run("synthesized packets use the destination address family", func(t *testing.T) {
+		buffer := &bytes.Buffer{}
+		pw := runtimex.Try1(NewPCAPNGWriter(buffer))
+		udpAddr := &net.UDPAddr{IP: net.IPv4zero, Port: 54321}
+		pw.synthesizeUDP(newPCAPNGEndpoint(udpAddr), newPCAPNGEndpoint(&net.UDPAddr{
+			IP:   net.ParseIP("2001:db8::1"),
+			Port: 443,
+		}), []byte("ping"))
+		packets := readPCAPNGPackets(t, pw, buffer)
+		if len(packets) != 1 {
+			t.Fatal("unexpected number of packets", len(packets))
+		}
+		ip, good := packets[0].Layer(layers.LayerTypeIPv6).(*layers.IPv6)
+		if !good {
+			t.Fatal("expected an IPv6 packet")
+		}
+		if !ip.SrcIP.Equal(net.IPv6unspecified) || ip.DstIP.String() != "2001:db8::1" {
+			t.Fatal("unexpected addresses", ip.SrcIP, ip.DstIP)
+		}
+	})
+
+	t.Run("WrapNIC", func(t *testing.T) {
+		buffer := &bytes.Buffer{}
+		pw := runtimex.Try1(NewPCAPNGWriter(buffer))
+		topology, serverStack, clientStack := newTopology(&netem.LinkConfig{LeftNICWrapper: pw})
+		defer topology.Close()
+
+		// create a server that closes immediately
+		serverEndpoint := &net.TCPAddr{IP: net.ParseIP(serverAddress), Port: 443}
+		listener := runtimex.Try1((&NetemUnderlyingNetworkAdapter{serverStack}).ListenTCP("tcp", serverEndpoint))
+		defer listener.Close()
+		go func() {
+			conn := runtimex.Try1(listener.Accept())
+			conn.Close()
+		}()
+
+		// connect using the unwrapped client network
+		clientAdapter := &NetemUnderlyingNetworkAdapter{clientStack}
+		conn, err := clientAdapter.DialContext(context.Background(), "tcp", serverEndpoint.String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		conn.Close()
+		topology.Close() // make sure the NIC is not writing anymore
+
+		// make sure we have captured the real SYN
+		packets := readPCAPNGPackets(t, pw, buffer)
+		if len(packets) < 1 {
+			t.Fatal("expected to see some packets")
+		}
+		tcp, good := packets[0].Layer(layers.LayerTypeTCP).(*layers.TCP)
+		if !good || !tcp.SYN || tcp.ACK {
+			t.Fatal("expected the first packet to be a SYN")
+		}
+	})
+}
@@ -117,3 +117,8 @@ func (r *cacheResolver) LookupHTTPS(ctx context.Context, domain string) (*model.
 func (r *cacheResolver) LookupNS(ctx context.Context, domain string) ([]*net.NS, error) {
 	return nil, ErrNoDNSTransport
 }
+
+// LookupRaw implements model.Resolver.LookupRaw.
+func (r *cacheResolver) LookupRaw(ctx context.Context, domain string, qtype uint16) ([]*model.DNSRecord, error) {
+	return nil, ErrNoDNSTransport
+}
@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/miekg/dns"
 	"github.com/ooni/probe-cli/v3/internal/mocks"
 )
 
@@ -201,5 +202,16 @@ func TestCacheResolver(t *testing.T) {
 				t.Fatal("expected zero length slice")
 			}
 		})
+
+		t.Run("LookupRaw", func(t *testing.T) {
+			reso := &cacheResolver{}
+			records, err := reso.LookupRaw(context.Background(), "dns.google", dns.TypeTXT)
+			if !errors.Is(err, ErrNoDNSTransport) {
+				t.Fatal("unexpected err", err)
+			}
+			if len(records) != 0 {
+				t.Fatal("expected zero length slice")
+			}
+		})
 	})
 }
@@ -35,6 +35,28 @@ func (netx *Netx) NewParallelDNSOverHTTPSResolver(logger model.DebugLogger, URL
 	return WrapResolver(logger, NewUnwrappedParallelResolver(txp))
 }
 
+// NewParallelDNSOverQUICResolver implements [model.MeasuringNetwork].
+func (netx *Netx) NewParallelDNSOverQUICResolver(logger model.DebugLogger, address string) model.Resolver {
+	dialer := netx.NewQUICDialerWithResolver(netx.NewUDPListener(), logger, netx.NewStdlibResolver(logger))
+	txp := wrapDNSTransport(NewUnwrappedDNSOverQUICTransport(dialer, address))
+	return WrapResolver(logger, NewUnwrappedParallelResolver(txp))
+}
+
+// NewParallelDNSOverTLSResolver implements [model.MeasuringNetwork].
+func (netx *Netx) NewParallelDNSOverTLSResolver(logger model.DebugLogger, address string) model.Resolver {
+	dialer := netx.NewDialerWithResolver(logger, netx.NewStdlibResolver(logger))
+	tlsDialer := NewTLSDialer(dialer, netx.NewTLSHandshakerStdlib(logger))
+	txp := wrapDNSTransport(NewUnwrappedDNSOverTLSTransport(tlsDialer.DialTLSContext, address))
+	return WrapResolver(logger, NewUnwrappedParallelResolver(txp))
+}
+
+// NewParallelTCPResolver implements [model.MeasuringNetwork].
+func (netx *Netx) NewParallelTCPResolver(logger model.DebugLogger, dialer model.Dialer, address string) model.Resolver {
+	return WrapResolver(logger, NewUnwrappedParallelResolver(
+		wrapDNSTransport(NewUnwrappedDNSOverTCPTransport(dialer.DialContext, address)),
+	))
+}
+
 func (netx *Netx) newUnwrappedStdlibResolver() model.Resolver {
 	return &resolverSystem{
 		t: wrapDNSTransport(netx.newDNSOverGetaddrinfoTransport()),
@@ -71,8 +93,15 @@ func NewSerialUDPResolver(logger model.DebugLogger, dialer model.Dialer, address
 
 // NewParallelUDPResolver implements [model.MeasuringNetwork].
 func (netx *Netx) NewParallelUDPResolver(logger model.DebugLogger, dialer model.Dialer, address string) model.Resolver {
+	return netx.NewParallelUDPResolverWithOptions(logger, dialer, address)
+}
+
+// NewParallelUDPResolverWithOptions is like [Netx.NewParallelUDPResolver] but
+// allows to configure the underlying [*DNSOverUDPTransport].
+func (netx *Netx) NewParallelUDPResolverWithOptions(logger model.DebugLogger, dialer model.Dialer,
+	address string, options ...DNSOverUDPTransportOption) model.Resolver {
 	return WrapResolver(logger, NewUnwrappedParallelResolver(
-		wrapDNSTransport(NewUnwrappedDNSOverUDPTransport(dialer, address)),
+		wrapDNSTransport(NewUnwrappedDNSOverUDPTransport(dialer, address, options...)),
 	))
 }
 
@@ -150,6 +179,11 @@ func (r *resolverSystem) LookupNS(
 	return nil, ErrNoDNSTransport
 }
 
+func (r *resolverSystem) LookupRaw(
+	ctx context.Context, domain string, qtype uint16) ([]*model.DNSRecord, error) {
+	return nil, ErrNoDNSTransport
+}
+
 // resolverLogger is a resolver that emits events
 type resolverLogger struct {
 	Resolver model.Resolver
@@ -217,6 +251,21 @@ func (r *resolverLogger) LookupNS(
 	return ns, nil
 }
 
+func (r *resolverLogger) LookupRaw(
+	ctx context.Context, domain string, qtype uint16) ([]*model.DNSRecord, error) {
+	prefix := fmt.Sprintf("resolve[%s] %s with %s (%s)", dns.TypeToString[qtype], domain, r.Network(), r.Address())
+	r.Logger.Debugf("%s...", prefix)
+	start := time.Now()
+	records, err := r.Resolver.LookupRaw(ctx, domain, qtype)
+	elapsed := time.Since(start)
+	if err != nil {
+		r.Logger.Debugf("%s... %s in %s", prefix, err, elapsed)
+		return nil, err
+	}
+	r.Logger.Debugf("%s... %d records in %s", prefix, len(records), elapsed)
+	return records, nil
+}
+
 // resolverIDNA supports resolving Internationalized Domain Names.
 //
 // See RFC3492 for more information.
@@ -264,6 +313,15 @@ func (r *resolverIDNA) LookupNS(
 	return r.Resolver.LookupNS(ctx, host)
 }
 
+func (r *resolverIDNA) LookupRaw(
+	ctx context.Context, domain string, qtype uint16) ([]*model.DNSRecord, error) {
+	host, err := idnax.ToASCII(domain)
+	if err != nil {
+		return nil, err
+	}
+	return r.Resolver.LookupRaw(ctx, host, qtype)
+}
+
 // ResolverShortCircuitIPAddr recognizes when the input hostname is an
 // IP address and returns it immediately to the caller.
 type ResolverShortCircuitIPAddr struct {
@@ -316,6 +374,23 @@ func (r *ResolverShortCircuitIPAddr) LookupNS(
 	return r.Resolver.LookupNS(ctx, hostname)
 }
 
+// LookupRaw returns [ErrDNSIPAddress] when hostname is an IP address, except
+// for PTR queries, where we convert the IP address to its reverse domain.
+func (r *ResolverShortCircuitIPAddr) LookupRaw(
+	ctx context.Context, hostname string, qtype uint16) ([]*model.DNSRecord, error) {
+	if net.ParseIP(hostname) != nil {
+		if qtype != dns.TypePTR {
+			return nil, ErrDNSIPAddress
+		}
+		reverse, err := dns.ReverseAddr(hostname)
+		if err != nil {
+			return nil, err
+		}
+		hostname = reverse
+	}
+	return r.Resolver.LookupRaw(ctx, hostname, qtype)
+}
+
 // IsIPv6 returns true if the given candidate is a valid IP address
 // representation and such representation is IPv6.
 func IsIPv6(candidate string) (bool, error) {
@@ -368,6 +443,11 @@ func (r *NullResolver) LookupNS(
 	return nil, ErrNoResolver
 }
 
+func (r *NullResolver) LookupRaw(
+	ctx context.Context, domain string, qtype uint16) ([]*model.DNSRecord, error) {
+	return nil, ErrNoResolver
+}
+
 // resolverErrWrapper is a Resolver that knows about wrapping errors.
 type resolverErrWrapper struct {
 	Resolver model.Resolver
@@ -412,3 +492,12 @@ func (r *resolverErrWrapper) LookupNS(
 	}
 	return out, nil
 }
+
+func (r *resolverErrWrapper) LookupRaw(
+	ctx context.Context, domain string, qtype uint16) ([]*model.DNSRecord, error) {
+	out, err := r.Resolver.LookupRaw(ctx, domain, qtype)
+	if err != nil {
+		return nil, NewErrWrapper(ClassifyResolverError, ResolveOperation, err)
+	}
+	return out, nil
+}
@@ -74,6 +74,26 @@ func TestNewParallelUDPResolver(t *testing.T) {
 	}
 }
 
+func TestNewParallelUDPResolverWithOptions(t *testing.T) {
+	netx := &Netx{}
+	d := netx.NewDialerWithoutResolver(log.Log)
+	resolver := netx.NewParallelUDPResolverWithOptions(log.Log, d, "1.1.1.1:53",
+		DNSOverUDPTransportOptionDelayedResponsesWindow(-1))
+	idnaReso := resolver.(*resolverIDNA)
+	logger := idnaReso.Resolver.(*resolverLogger)
+	shortCircuit := logger.Resolver.(*ResolverShortCircuitIPAddr)
+	errWrapper := shortCircuit.Resolver.(*resolverErrWrapper)
+	para := errWrapper.Resolver.(*ParallelResolver)
+	txp := para.Transport().(*dnsTransportErrWrapper)
+	dnsTxp := txp.DNSTransport.(*DNSOverUDPTransport)
+	if dnsTxp.Address() != "1.1.1.1:53" {
+		t.Fatal("invalid address")
+	}
+	if dnsTxp.DelayedResponsesWindow != -1 {
+		t.Fatal("invalid delayed responses window")
+	}
+}
+
 func TestNewParallelDNSOverHTTPSResolver(t *testing.T) {
 	netx := &Netx{}
 	resolver := netx.NewParallelDNSOverHTTPSResolver(log.Log, "https://1.1.1.1/dns-query")
@@ -92,6 +112,48 @@ func TestNewParallelDNSOverHTTPSResolver(t *testing.T) {
 	}
 }
 
+func TestNewParallelDNSOverTLSResolver(t *testing.T) {
+	netx := &Netx{}
+	resolver := netx.NewParallelDNSOverTLSResolver(log.Log, "1.1.1.1:853")
+	idnaReso := resolver.(*resolverIDNA)
+	logger := idnaReso.Resolver.(*resolverLogger)
+	if logger.Logger != log.Log {
+		t.Fatal("invalid logger")
+	}
+	shortCircuit := logger.Resolver.(*ResolverShortCircuitIPAddr)
+	errWrapper := shortCircuit.Resolver.(*resolverErrWrapper)
+	para := errWrapper.Resolver.(*ParallelResolver)
+	txp := para.Transport().(*dnsTransportErrWrapper)
+	dnsTxp := txp.DNSTransport.(*DNSOverTCPTransport)
+	if dnsTxp.Address() != "1.1.1.1:853" {
+		t.Fatal("invalid address")
+	}
+	if dnsTxp.Network() != "dot" {
+		t.Fatal("invalid network")
+	}
+}
+
+func TestNewParallelTCPResolver(t *testing.T) {
+	netx := &Netx{}
+	resolver := netx.NewParallelTCPResolver(log.Log, netx.NewDialerWithoutResolver(log.Log), "1.1.1.1:53")
+	idnaReso := resolver.(*resolverIDNA)
+	logger := idnaReso.Resolver.(*resolverLogger)
+	if logger.Logger != log.Log {
+		t.Fatal("invalid logger")
+	}
+	shortCircuit := logger.Resolver.(*ResolverShortCircuitIPAddr)
+	errWrapper := shortCircuit.Resolver.(*resolverErrWrapper)
+	para := errWrapper.Resolver.(*ParallelResolver)
+	txp := para.Transport().(*dnsTransportErrWrapper)
+	dnsTxp := txp.DNSTransport.(*DNSOverTCPTransport)
+	if dnsTxp.Address() != "1.1.1.1:53" {
+		t.Fatal("invalid address")
+	}
+	if dnsTxp.Network() != "tcp" {
+		t.Fatal("invalid network")
+	}
+}
+
 func TestResolverSystem(t *testing.T) {
 	t.Run("Network", func(t *testing.T) {
 		expected := "antani"
@@ -209,6 +271,17 @@ func TestResolverSystem(t *testing.T) {
 		}
 	})
 
+	t.Run("LookupRaw", func(t *testing.T) {
+		r := &resolverSystem{}
+		records, err := r.LookupRaw(context.Background(), "x.org", dns.TypeTXT)
+		if !errors.Is(err, ErrNoDNSTransport) {
+			t.Fatal("not the error we expected")
+		}
+		if len(records) != 0 {
+			t.Fatal("expected no results")
+		}
+	})
+
 	t.Run("uses a context-injected custom trace (success case)", func(t *testing.T) {
 		var (
 			onLookupCalled     bool
@@ -566,6 +639,81 @@ func TestResolverLogger(t *testing.T) {
 			}
 		})
 	})
+
+	t.Run("LookupRaw", func(t *testing.T) {
+		t.Run("with success", func(t *testing.T) {
+			var count int
+			lo := &mocks.Logger{
+				MockDebugf: func(format string, v ...interface{}) {
+					count++
+				},
+			}
+			expected := []*model.DNSRecord{{
+				Name: "dns.google.",
+				Type: "TXT",
+				TTL:  300,
+				Data: `"v=spf1 -all"`,
+			}}
+			r := &resolverLogger{
+				Logger: lo,
+				Resolver: &mocks.Resolver{
+					MockLookupRaw: func(ctx context.Context, domain string, qtype uint16) ([]*model.DNSRecord, error) {
+						return expected, nil
+					},
+					MockNetwork: func() string {
+						return StdlibResolverGetaddrinfo
+					},
+					MockAddress: func() string {
+						return ""
+					},
+				},
+			}
+			records, err := r.LookupRaw(context.Background(), "dns.google", dns.TypeTXT)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(expected, records); diff != "" {
+				t.Fatal(diff)
+			}
+			if count != 2 {
+				t.Fatal("unexpected count")
+			}
+		})
+
+		t.Run("with failure", func(t *testing.T) {
+			var count int
+			lo := &mocks.Logger{
+				MockDebugf: func(format string, v ...interface{}) {
+					count++
+				},
+			}
+			expected := errors.New("mocked error")
+			r := &resolverLogger{
+				Logger: lo,
+				Resolver: &mocks.Resolver{
+					MockLookupRaw: func(ctx context.Context, domain string, qtype uint16) ([]*model.DNSRecord, error) {
+						return nil, expected
+					},
+					MockNetwork: func() string {
+						return StdlibResolverGetaddrinfo
+					},
+					MockAddress: func() string {
+						return ""
+					},
+				},
+			}
+			records, err := r.LookupRaw(context.Background(), "dns.google", dns.TypeTXT)
+			if !errors.Is(err, expected) {
+				t.Fatal("not the error we expected", err)
+			}
+			if records != nil {
+				t.Fatal("expected nil records here")
+			}
+			if count != 2 {
+				t.Fatal("unexpected count")
+			}
+		})
+	})
 }
 
 func TestResolverIDNA(t *testing.T) {
@@ -735,6 +883,52 @@ func TestResolverIDNA(t *testing.T) {
 			}
 		})
 	})
+
+	t.Run("LookupRaw", func(t *testing.T) {
+		t.Run("with valid IDNA in input", func(t *testing.T) {
+			expected := []*model.DNSRecord{{
+				Name: "xn--d1acpjx3f.xn--p1ai.",
+				Type: "MX",
+				TTL:  300,
+				Data: "10 mx.yandex.net.",
+			}}
+			r := &resolverIDNA{
+				Resolver: &mocks.Resolver{
+					MockLookupRaw: func(ctx context.Context, domain string, qtype uint16) ([]*model.DNSRecord, error) {
+						if domain != "xn--d1acpjx3f.xn--p1ai" {
+							return nil, errors.New("passed invalid domain")
+						}
+						return expected, nil
+					},
+				},
+			}
+			ctx := context.Background()
+			records, err := r.LookupRaw(ctx, "яндекс.рф", dns.TypeMX)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(expected, records); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+
+		t.Run("with invalid punycode", func(t *testing.T) {
+			r := &resolverIDNA{Resolver: &mocks.Resolver{
+				MockLookupRaw: func(ctx context.Context, domain string, qtype uint16) ([]*model.DNSRecord, error) {
+					return nil, errors.New("should not happen")
+				},
+			}}
+			// See https://www.farsightsecurity.com/blog/txt-record/punycode-20180711/
+			ctx := context.Background()
+			records, err := r.LookupRaw(ctx, "xn--0000h", dns.TypeMX)
+			if err == nil || !strings.HasPrefix(err.Error(), "idna: invalid label") {
+				t.Fatal("not the error we expected")
+			}
+			if records != nil {
+				t.Fatal("expected no response here")
+			}
+		})
+	})
 }
 
 func TestResolverShortCircuitIPAddr(t *testing.T) {
@@ -888,6 +1082,84 @@ func TestResolverShortCircuitIPAddr(t *testing.T) {
 		})
 	})
 
+	t.Run("LookupRaw", func(t *testing.T) {
+		t.Run("with IP addr and a query type other than PTR", func(t *testing.T) {
+			r := &ResolverShortCircuitIPAddr{
+				Resolver: &mocks.Resolver{
+					MockLookupRaw: func(ctx context.Context, domain string, qtype uint16) ([]*model.DNSRecord, error) {
+						return nil, errors.New("mocked error")
+					},
+				},
+			}
+			ctx := context.Background()
+			records, err := r.LookupRaw(ctx, "8.8.8.8", dns.TypeTXT)
+			if !errors.Is(err, ErrDNSIPAddress) {
+				t.Fatal("unexpected error", err)
+			}
+			if len(records) > 0 {
+				t.Fatal("invalid result")
+			}
+		})
+
+		t.Run("with IPv4 addr and PTR query type", func(t *testing.T) {
+			var gotDomain string
+			r := &ResolverShortCircuitIPAddr{
+				Resolver: &mocks.Resolver{
+					MockLookupRaw: func(ctx context.Context, domain string, qtype uint16) ([]*model.DNSRecord, error) {
+						gotDomain = domain
+						return nil, errors.New("mocked error")
+					},
+				},
+			}
+			ctx := context.Background()
+			_, err := r.LookupRaw(ctx, "8.8.4.4", dns.TypePTR)
+			if err == nil || err.Error() != "mocked error" {
+				t.Fatal("not the error we expected", err)
+			}
+			if gotDomain != "4.4.8.8.in-addr.arpa." {
+				t.Fatal("unexpected domain", gotDomain)
+			}
+		})
+
+		t.Run("with IPv6 addr and PTR query type", func(t *testing.T) {
+			var gotDomain string
+			r := &ResolverShortCircuitIPAddr{
+				Resolver: &mocks.Resolver{
+					MockLookupRaw: func(ctx context.Context, domain string, qtype uint16) ([]*model.DNSRecord, error) {
+						gotDomain = domain
+						return nil, errors.New("mocked error")
+					},
+				},
+			}
+			ctx := context.Background()
+			_, err := r.LookupRaw(ctx, "::1", dns.TypePTR)
+			if err == nil || err.Error() != "mocked error" {
+				t.Fatal("not the error we expected", err)
+			}
+			if !strings.HasSuffix(gotDomain, ".ip6.arpa.") {
+				t.Fatal("unexpected domain", gotDomain)
+			}
+		})
+
+		t.Run("with domain", func(t *testing.T) {
+			r := &ResolverShortCircuitIPAddr{
+				Resolver: &mocks.Resolver{
+					MockLookupRaw: func(ctx context.Context, domain string, qtype uint16) ([]*model.DNSRecord, error) {
+						return nil, errors.New("mocked error")
+					},
+				},
+			}
+			ctx := context.Background()
+			records, err := r.LookupRaw(ctx, "dns.google", dns.TypeTXT)
+			if err == nil || err.Error() != "mocked error" {
+				t.Fatal("not the error we expected", err)
+			}
+			if len(records) > 0 {
+				t.Fatal("invalid result")
+			}
+		})
+	})
+
 	t.Run("Network", func(t *testing.T) {
 		child := &mocks.Resolver{
 			MockNetwork: func() string {
@@ -1009,6 +1281,18 @@ func TestNullResolver(t *testing.T) {
 			t.Fatal("unexpected result")
 		}
 	})
+
+	t.Run("LookupRaw", func(t *testing.T) {
+		r := &NullResolver{}
+		ctx := context.Background()
+		records, err := r.LookupRaw(ctx, "dns.google", dns.TypeTXT)
+		if !errors.Is(err, ErrNoResolver) {
+			t.Fatal("unexpected error", err)
+		}
+		if len(records) > 0 {
+			t.Fatal("unexpected result")
+		}
+	})
 }
 
 func TestResolverErrWrapper(t *testing.T) {
@@ -1178,4 +1462,49 @@ func TestResolverErrWrapper(t *testing.T) {
 			}
 		})
 	})
+
+	t.Run("LookupRaw", func(t *testing.T) {
+		t.Run("on success", func(t *testing.T) {
+			expected := []*model.DNSRecord{{
+				Name: "antani.local.",
+				Type: "TXT",
+				TTL:  300,
+				Data: `"antani"`,
+			}}
+			reso := &resolverErrWrapper{
+				Resolver: &mocks.Resolver{
+					MockLookupRaw: func(ctx context.Context, domain string, qtype uint16) ([]*model.DNSRecord, error) {
+						return expected, nil
+					},
+				},
+			}
+			ctx := context.Background()
+			records, err := reso.LookupRaw(ctx, "antani.local", dns.TypeTXT)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(expected, records); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+
+		t.Run("on failure", func(t *testing.T) {
+			expected := io.EOF
+			reso := &resolverErrWrapper{
+				Resolver: &mocks.Resolver{
+					MockLookupRaw: func(ctx context.Context, domain string, qtype uint16) ([]*model.DNSRecord, error) {
+						return nil, expected
+					},
+				},
+			}
+			ctx := context.Background()
+			records, err := reso.LookupRaw(ctx, "", dns.TypeTXT)
+			if err == nil || err.Error() != FailureEOFError {
+				t.Fatal("unexpected err", err)
+			}
+			if len(records) > 0 {
+				t.Fatal("unexpected records")
+			}
+		})
+	})
 }
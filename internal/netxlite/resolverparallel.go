@@ -79,12 +79,18 @@ func (r *ParallelResolver) LookupHost(ctx context.Context, hostname string) ([]s
 func (r *ParallelResolver) LookupHTTPS(
 	ctx context.Context, hostname string) (*model.HTTPSSvc, error) {
 	encoder := &DNSEncoderMiekg{}
+	trace := ContextTraceOrDefault(ctx)
 	query := encoder.Encode(hostname, dns.TypeHTTPS, r.Txp.RequiresPadding())
+	started := trace.TimeNow()
 	response, err := r.Txp.RoundTrip(ctx, query)
+	finished := trace.TimeNow()
 	if err != nil {
+		trace.OnDNSRoundTripForLookupHost(started, r, query, response, []string{}, err, finished)
 		return nil, err
 	}
-	return response.DecodeHTTPS()
+	https, err := response.DecodeHTTPS()
+	trace.OnDNSRoundTripForLookupHost(started, r, query, response, []string{}, err, finished)
+	return https, err
 }
 
 // parallelResolverResult is the internal representation of a
@@ -130,3 +136,21 @@ func (r *ParallelResolver) LookupNS(
 	}
 	return response.DecodeNS()
 }
+
+// LookupRaw implements Resolver.LookupRaw.
+func (r *ParallelResolver) LookupRaw(
+	ctx context.Context, hostname string, qtype uint16) ([]*model.DNSRecord, error) {
+	encoder := &DNSEncoderMiekg{}
+	trace := ContextTraceOrDefault(ctx)
+	query := encoder.Encode(hostname, qtype, r.Txp.RequiresPadding())
+	started := trace.TimeNow()
+	response, err := r.Txp.RoundTrip(ctx, query)
+	finished := trace.TimeNow()
+	if err != nil {
+		trace.OnDNSRoundTripForLookupHost(started, r, query, response, []string{}, err, finished)
+		return nil, err
+	}
+	records, err := response.DecodeRecords()
+	trace.OnDNSRoundTripForLookupHost(started, r, query, response, []string{}, err, finished)
+	return records, err
+}
@@ -223,6 +223,174 @@ func TestParallelResolver(t *testing.T) {
 				t.Fatal("unexpected result")
 			}
 		})
+
+		t.Run("for success with a context-injected custom trace", func(t *testing.T) {
+			expected := &model.HTTPSSvc{
+				ALPN: []string{"h3", "h2"},
+				IPv4: []string{"93.184.216.34"},
+			}
+			var gotQueryType uint16
+			r := &ParallelResolver{
+				Txp: &mocks.DNSTransport{
+					MockRoundTrip: func(ctx context.Context, query model.DNSQuery) (model.DNSResponse, error) {
+						response := &mocks.DNSResponse{
+							MockDecodeHTTPS: func() (*model.HTTPSSvc, error) {
+								return expected, nil
+							},
+						}
+						return response, nil
+					},
+					MockRequiresPadding: func() bool {
+						return false
+					},
+				},
+			}
+			tx := &mocks.Trace{
+				MockTimeNow: time.Now,
+				MockOnDNSRoundTripForLookupHost: func(started time.Time, reso model.Resolver, query model.DNSQuery,
+					response model.DNSResponse, addrs []string, err error, finished time.Time) {
+					gotQueryType = query.Type()
+				},
+			}
+			ctx := ContextWithTrace(context.Background(), tx)
+			https, err := r.LookupHTTPS(ctx, "example.com")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(expected, https); diff != "" {
+				t.Fatal(diff)
+			}
+			if gotQueryType != dns.TypeHTTPS {
+				t.Fatal("unexpected query type", gotQueryType)
+			}
+		})
+
+		t.Run("for round-trip error with a context-injected custom trace", func(t *testing.T) {
+			expected := errors.New("mocked error")
+			var gotErr error
+			r := &ParallelResolver{
+				Txp: &mocks.DNSTransport{
+					MockRoundTrip: func(ctx context.Context, query model.DNSQuery) (model.DNSResponse, error) {
+						return nil, expected
+					},
+					MockRequiresPadding: func() bool {
+						return false
+					},
+				},
+			}
+			tx := &mocks.Trace{
+				MockTimeNow: time.Now,
+				MockOnDNSRoundTripForLookupHost: func(started time.Time, reso model.Resolver, query model.DNSQuery,
+					response model.DNSResponse, addrs []string, err error, finished time.Time) {
+					gotErr = err
+				},
+			}
+			ctx := ContextWithTrace(context.Background(), tx)
+			https, err := r.LookupHTTPS(ctx, "example.com")
+			if !errors.Is(err, expected) {
+				t.Fatal("unexpected err", err)
+			}
+			if https != nil {
+				t.Fatal("unexpected result")
+			}
+			if !errors.Is(gotErr, expected) {
+				t.Fatal("unexpected traced err", gotErr)
+			}
+		})
+	})
+
+	t.Run("LookupRaw", func(t *testing.T) {
+		t.Run("for round-trip error", func(t *testing.T) {
+			expected := errors.New("mocked error")
+			r := &ParallelResolver{
+				Txp: &mocks.DNSTransport{
+					MockRoundTrip: func(ctx context.Context, query model.DNSQuery) (model.DNSResponse, error) {
+						return nil, expected
+					},
+					MockRequiresPadding: func() bool {
+						return false
+					},
+				},
+			}
+			ctx := context.Background()
+			records, err := r.LookupRaw(ctx, "example.com", dns.TypeTXT)
+			if !errors.Is(err, expected) {
+				t.Fatal("unexpected err", err)
+			}
+			if records != nil {
+				t.Fatal("unexpected result")
+			}
+		})
+
+		t.Run("for decode error", func(t *testing.T) {
+			expected := errors.New("mocked error")
+			r := &ParallelResolver{
+				Txp: &mocks.DNSTransport{
+					MockRoundTrip: func(ctx context.Context, query model.DNSQuery) (model.DNSResponse, error) {
+						response := &mocks.DNSResponse{
+							MockDecodeRecords: func() ([]*model.DNSRecord, error) {
+								return nil, expected
+							},
+						}
+						return response, nil
+					},
+					MockRequiresPadding: func() bool {
+						return false
+					},
+				},
+			}
+			ctx := context.Background()
+			records, err := r.LookupRaw(ctx, "example.com", dns.TypeTXT)
+			if !errors.Is(err, expected) {
+				t.Fatal("unexpected err", err)
+			}
+			if records != nil {
+				t.Fatal("unexpected result")
+			}
+		})
+
+		t.Run("for success with a context-injected custom trace", func(t *testing.T) {
+			expected := []*model.DNSRecord{{
+				Name: "example.com.",
+				Type: "TXT",
+				TTL:  300,
+				Data: `"v=spf1 -all"`,
+			}}
+			var gotQueryType uint16
+			r := &ParallelResolver{
+				Txp: &mocks.DNSTransport{
+					MockRoundTrip: func(ctx context.Context, query model.DNSQuery) (model.DNSResponse, error) {
+						response := &mocks.DNSResponse{
+							MockDecodeRecords: func() ([]*model.DNSRecord, error) {
+								return expected, nil
+							},
+						}
+						return response, nil
+					},
+					MockRequiresPadding: func() bool {
+						return false
+					},
+				},
+			}
+			tx := &mocks.Trace{
+				MockTimeNow: time.Now,
+				MockOnDNSRoundTripForLookupHost: func(started time.Time, reso model.Resolver, query model.DNSQuery,
+					response model.DNSResponse, addrs []string, err error, finished time.Time) {
+					gotQueryType = query.Type()
+				},
+			}
+			ctx := ContextWithTrace(context.Background(), tx)
+			records, err := r.LookupRaw(ctx, "example.com", dns.TypeTXT)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(expected, records); diff != "" {
+				t.Fatal(diff)
+			}
+			if gotQueryType != dns.TypeTXT {
+				t.Fatal("unexpected query type", gotQueryType)
+			}
+		})
 	})
 
 	t.Run("LookupNS", func(t *testing.T) {
@@ -86,12 +86,18 @@ func (r *SerialResolver) LookupHost(ctx context.Context, hostname string) ([]str
 func (r *SerialResolver) LookupHTTPS(
 	ctx context.Context, hostname string) (*model.HTTPSSvc, error) {
 	encoder := &DNSEncoderMiekg{}
+	trace := ContextTraceOrDefault(ctx)
 	query := encoder.Encode(hostname, dns.TypeHTTPS, r.Txp.RequiresPadding())
+	started := trace.TimeNow()
 	response, err := r.Txp.RoundTrip(ctx, query)
+	finished := trace.TimeNow()
 	if err != nil {
+		trace.OnDNSRoundTripForLookupHost(started, r, query, response, []string{}, err, finished)
 		return nil, err
 	}
-	return response.DecodeHTTPS()
+	https, err := response.DecodeHTTPS()
+	trace.OnDNSRoundTripForLookupHost(started, r, query, response, []string{}, err, finished)
+	return https, err
 }
 
 func (r *SerialResolver) lookupHostWithRetry(
@@ -146,3 +152,21 @@ func (r *SerialResolver) LookupNS(
 	}
 	return response.DecodeNS()
 }
+
+// LookupRaw implements Resolver.LookupRaw.
+func (r *SerialResolver) LookupRaw(
+	ctx context.Context, hostname string, qtype uint16) ([]*model.DNSRecord, error) {
+	encoder := &DNSEncoderMiekg{}
+	trace := ContextTraceOrDefault(ctx)
+	query := encoder.Encode(hostname, qtype, r.Txp.RequiresPadding())
+	started := trace.TimeNow()
+	response, err := r.Txp.RoundTrip(ctx, query)
+	finished := trace.TimeNow()
+	if err != nil {
+		trace.OnDNSRoundTripForLookupHost(started, r, query, response, []string{}, err, finished)
+		return nil, err
+	}
+	records, err := response.DecodeRecords()
+	trace.OnDNSRoundTripForLookupHost(started, r, query, response, []string{}, err, finished)
+	return records, err
+}
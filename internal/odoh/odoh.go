@@ -0,0 +1,393 @@
+// Package odoh implements the message formats and the encryption scheme
+// of Oblivious DNS-over-HTTPS (ODoH) as specified by RFC 9230.
+//
+// This package only deals with encoding, encrypting, and decrypting. The
+// transport implementing ODoH lives in [netxlite] while test servers
+// implementing the proxy and the target live in [testingx] and [netemx].
+package odoh
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"errors"
+	"io"
+
+	"github.com/cloudflare/circl/hpke"
+	"github.com/cloudflare/circl/kem"
+	"golang.org/x/crypto/cryptobyte"
+)
+
+// ContentType is the content type used by ODoH requests and responses.
+const ContentType = "application/oblivious-dns-message"
+
+// ConfigsPath is the well-known path where a target publishes its configs.
+const ConfigsPath = "/.well-known/odohconfigs"
+
+// Version is the only ObliviousDoHConfig version we support.
+const Version = 0x0001
+
+// These are the message types defined by RFC 9230 Sect. 6.1.
+const (
+	messageTypeQuery    = 0x01
+	messageTypeResponse = 0x02
+)
+
+// These are the HPKE labels defined by RFC 9230 Sect. 6.
+var (
+	labelKeyID    = []byte("odoh key id")
+	labelQuery    = []byte("odoh query")
+	labelResponse = []byte("odoh response")
+	labelKey      = []byte("odoh key")
+	labelNonce    = []byte("odoh nonce")
+)
+
+var (
+	// ErrNoSupportedConfig indicates that none of the configs uses a supported version and suite.
+	ErrNoSupportedConfig = errors.New("odoh: no supported config")
+
+	// ErrInvalidMessage indicates that we could not parse an ODoH message.
+	ErrInvalidMessage = errors.New("odoh: invalid message")
+
+	// ErrUnexpectedMessageType indicates that a message has an unexpected type.
+	ErrUnexpectedMessageType = errors.New("odoh: unexpected message type")
+
+	// ErrUnknownKeyID indicates that a query is using an unknown key ID.
+	ErrUnknownKeyID = errors.New("odoh: unknown key ID")
+)
+
+// Config is the contents of an ObliviousDoHConfig (RFC 9230 Sect. 6.1).
+type Config struct {
+	// KEM is the HPKE KEM identifier.
+	KEM hpke.KEM
+
+	// KDF is the HPKE KDF identifier.
+	KDF hpke.KDF
+
+	// AEAD is the HPKE AEAD identifier.
+	AEAD hpke.AEAD
+
+	// PublicKey is the target's serialized public key.
+	PublicKey []byte
+}
+
+// isSupported returns whether we support the config's HPKE suite.
+func (c *Config) isSupported() bool {
+	return c.KEM.IsValid() && c.KDF.IsValid() && c.AEAD.IsValid()
+}
+
+// marshalContents serializes the ObliviousDoHConfigContents.
+func (c *Config) marshalContents() []byte {
+	var b cryptobyte.Builder
+	b.AddUint16(uint16(c.KEM))
+	b.AddUint16(uint16(c.KDF))
+	b.AddUint16(uint16(c.AEAD))
+	b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+		b.AddBytes(c.PublicKey)
+	})
+	return b.BytesOrPanic()
+}
+
+// KeyID returns the key ID identifying this config.
+func (c *Config) KeyID() []byte {
+	prk := c.KDF.Extract(c.marshalContents(), nil)
+	return c.KDF.Expand(prk, labelKeyID, uint(c.KDF.ExtractSize()))
+}
+
+// MarshalConfigs serializes the given configs as ObliviousDoHConfigs.
+func MarshalConfigs(configs ...*Config) []byte {
+	var b cryptobyte.Builder
+	b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+		for _, c := range configs {
+			b.AddUint16(Version)
+			b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+				b.AddBytes(c.marshalContents())
+			})
+		}
+	})
+	return b.BytesOrPanic()
+}
+
+// ParseConfigs parses ObliviousDoHConfigs and returns the configs using a
+// supported version and HPKE suite. We fail if there is no such config.
+func ParseConfigs(data []byte) ([]*Config, error) {
+	input := cryptobyte.String(data)
+	var list cryptobyte.String
+	if !input.ReadUint16LengthPrefixed(&list) || !input.Empty() {
+		return nil, ErrInvalidMessage
+	}
+	var out []*Config
+	for !list.Empty() {
+		var (
+			version  uint16
+			contents cryptobyte.String
+		)
+		if !list.ReadUint16(&version) || !list.ReadUint16LengthPrefixed(&contents) {
+			return nil, ErrInvalidMessage
+		}
+		if version != Version {
+			continue // as mandated by RFC 9230 Sect. 6.1
+		}
+		var (
+			kemID, kdfID, aeadID uint16
+			publicKey            cryptobyte.String
+		)
+		if !contents.ReadUint16(&kemID) || !contents.ReadUint16(&kdfID) ||
+			!contents.ReadUint16(&aeadID) || !contents.ReadUint16LengthPrefixed(&publicKey) ||
+			!contents.Empty() {
+			return nil, ErrInvalidMessage
+		}
+		config := &Config{
+			KEM:       hpke.KEM(kemID),
+			KDF:       hpke.KDF(kdfID),
+			AEAD:      hpke.AEAD(aeadID),
+			PublicKey: publicKey,
+		}
+		if config.isSupported() {
+			out = append(out, config)
+		}
+	}
+	if len(out) <= 0 {
+		return nil, ErrNoSupportedConfig
+	}
+	return out, nil
+}
+
+// marshalPlaintext serializes an ObliviousDoHMessagePlaintext without padding. We
+// do not need padding here because DNS messages already contain EDNS(0) padding.
+func marshalPlaintext(dnsMessage []byte) []byte {
+	var b cryptobyte.Builder
+	b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+		b.AddBytes(dnsMessage)
+	})
+	b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {})
+	return b.BytesOrPanic()
+}
+
+// parsePlaintext parses an ObliviousDoHMessagePlaintext and returns the DNS message.
+func parsePlaintext(data []byte) ([]byte, error) {
+	input := cryptobyte.String(data)
+	var dnsMessage, padding cryptobyte.String
+	if !input.ReadUint16LengthPrefixed(&dnsMessage) ||
+		!input.ReadUint16LengthPrefixed(&padding) || !input.Empty() {
+		return nil, ErrInvalidMessage
+	}
+	return dnsMessage, nil
+}
+
+// marshalMessage serializes an ObliviousDoHMessage.
+func marshalMessage(messageType uint8, keyID, encryptedMessage []byte) []byte {
+	var b cryptobyte.Builder
+	b.AddUint8(messageType)
+	b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+		b.AddBytes(keyID)
+	})
+	b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+		b.AddBytes(encryptedMessage)
+	})
+	return b.BytesOrPanic()
+}
+
+// parseMessage parses an ObliviousDoHMessage having the given type.
+func parseMessage(data []byte, messageType uint8) (keyID, encryptedMessage []byte, err error) {
+	input := cryptobyte.String(data)
+	var (
+		mtype         uint8
+		rawKeyID      cryptobyte.String
+		rawEncMessage cryptobyte.String
+	)
+	if !input.ReadUint8(&mtype) || !input.ReadUint16LengthPrefixed(&rawKeyID) ||
+		!input.ReadUint16LengthPrefixed(&rawEncMessage) || !input.Empty() {
+		return nil, nil, ErrInvalidMessage
+	}
+	if mtype != messageType {
+		return nil, nil, ErrUnexpectedMessageType
+	}
+	return rawKeyID, rawEncMessage, nil
+}
+
+// additionalData returns the AEAD additional data for the given message type and key ID.
+func additionalData(messageType uint8, keyID []byte) []byte {
+	var b cryptobyte.Builder
+	b.AddUint8(messageType)
+	b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+		b.AddBytes(keyID)
+	})
+	return b.BytesOrPanic()
+}
+
+// responseContext contains the state for encrypting or decrypting the
+// response to a query as described in RFC 9230 Sect. 6.4.
+type responseContext struct {
+	config    *Config
+	plaintext []byte
+	secret    []byte
+}
+
+// newResponseContext creates a [responseContext] from the HPKE context used for the query.
+func newResponseContext(config *Config, hctx hpke.Context, plaintext []byte) responseContext {
+	return responseContext{
+		config:    config,
+		plaintext: plaintext,
+		secret:    hctx.Export(labelResponse, config.AEAD.KeySize()),
+	}
+}
+
+// nonceSize returns the size of the response nonce, i.e., max(Nn, Nk).
+func (rc *responseContext) nonceSize() uint {
+	if nn, nk := rc.config.AEAD.NonceSize(), rc.config.AEAD.KeySize(); nn > nk {
+		return nn
+	}
+	return rc.config.AEAD.KeySize()
+}
+
+// cipher derives the AEAD and the nonce used for the response.
+func (rc *responseContext) cipher(responseNonce []byte) (cipher.AEAD, []byte, error) {
+	var b cryptobyte.Builder
+	b.AddBytes(rc.plaintext)
+	b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+		b.AddBytes(responseNonce)
+	})
+	prk := rc.config.KDF.Extract(rc.secret, b.BytesOrPanic())
+	key := rc.config.KDF.Expand(prk, labelKey, rc.config.AEAD.KeySize())
+	nonce := rc.config.KDF.Expand(prk, labelNonce, rc.config.AEAD.NonceSize())
+	aead, err := rc.config.AEAD.New(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	return aead, nonce, nil
+}
+
+// QueryContext is the client-side state required to decrypt the response to a query.
+type QueryContext struct {
+	responseContext
+}
+
+// EncryptQuery encrypts the given DNS query using the given source of randomness
+// and returns the serialized ObliviousDoHMessage and the related [*QueryContext].
+func (c *Config) EncryptQuery(rnd io.Reader, query []byte) ([]byte, *QueryContext, error) {
+	publicKey, err := c.KEM.Scheme().UnmarshalBinaryPublicKey(c.PublicKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	sender, err := hpke.NewSuite(c.KEM, c.KDF, c.AEAD).NewSender(publicKey, labelQuery)
+	if err != nil {
+		return nil, nil, err
+	}
+	enc, sealer, err := sender.Setup(rnd)
+	if err != nil {
+		return nil, nil, err
+	}
+	keyID := c.KeyID()
+	plaintext := marshalPlaintext(query)
+	ciphertext, err := sealer.Seal(plaintext, additionalData(messageTypeQuery, keyID))
+	if err != nil {
+		return nil, nil, err
+	}
+	message := marshalMessage(messageTypeQuery, keyID, append(enc, ciphertext...))
+	return message, &QueryContext{newResponseContext(c, sealer, plaintext)}, nil
+}
+
+// DecryptResponse decrypts the given serialized ObliviousDoHMessage and returns the DNS response.
+func (qc *QueryContext) DecryptResponse(data []byte) ([]byte, error) {
+	responseNonce, ciphertext, err := parseMessage(data, messageTypeResponse)
+	if err != nil {
+		return nil, err
+	}
+	aead, nonce, err := qc.cipher(responseNonce)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := aead.Open(nil, nonce, ciphertext, additionalData(messageTypeResponse, responseNonce))
+	if err != nil {
+		return nil, err
+	}
+	return parsePlaintext(plaintext)
+}
+
+// KeyPair is a target's key pair. The zero value is invalid; use [NewKeyPair].
+type KeyPair struct {
+	// Config is the config containing the public key.
+	Config *Config
+
+	privateKey kem.PrivateKey
+}
+
+// NewKeyPair generates a new [*KeyPair] using the X25519, HKDF-SHA256, and AES-128-GCM suite.
+func NewKeyPair() (*KeyPair, error) {
+	const (
+		kemID  = hpke.KEM_X25519_HKDF_SHA256
+		kdfID  = hpke.KDF_HKDF_SHA256
+		aeadID = hpke.AEAD_AES128GCM
+	)
+	publicKey, privateKey, err := kemID.Scheme().GenerateKeyPair()
+	if err != nil {
+		return nil, err
+	}
+	rawPublicKey, err := publicKey.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	config := &Config{
+		KEM:       kemID,
+		KDF:       kdfID,
+		AEAD:      aeadID,
+		PublicKey: rawPublicKey,
+	}
+	return &KeyPair{Config: config, privateKey: privateKey}, nil
+}
+
+// ResponseContext is the target-side state required to encrypt the response to a query.
+type ResponseContext struct {
+	responseContext
+}
+
+// DecryptQuery decrypts the given serialized ObliviousDoHMessage and returns
+// the DNS query along with the related [*ResponseContext].
+func (kp *KeyPair) DecryptQuery(data []byte) ([]byte, *ResponseContext, error) {
+	keyID, encryptedMessage, err := parseMessage(data, messageTypeQuery)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !bytes.Equal(keyID, kp.Config.KeyID()) {
+		return nil, nil, ErrUnknownKeyID
+	}
+	encSize := kp.Config.KEM.Scheme().CiphertextSize()
+	if len(encryptedMessage) < encSize {
+		return nil, nil, ErrInvalidMessage
+	}
+	enc, ciphertext := encryptedMessage[:encSize], encryptedMessage[encSize:]
+	suite := hpke.NewSuite(kp.Config.KEM, kp.Config.KDF, kp.Config.AEAD)
+	receiver, err := suite.NewReceiver(kp.privateKey, labelQuery)
+	if err != nil {
+		return nil, nil, err
+	}
+	opener, err := receiver.Setup(enc)
+	if err != nil {
+		return nil, nil, err
+	}
+	plaintext, err := opener.Open(ciphertext, additionalData(messageTypeQuery, keyID))
+	if err != nil {
+		return nil, nil, err
+	}
+	query, err := parsePlaintext(plaintext)
+	if err != nil {
+		return nil, nil, err
+	}
+	return query, &ResponseContext{newResponseContext(kp.Config, opener, plaintext)}, nil
+}
+
+// EncryptResponse encrypts the given DNS response using the given source of
+// randomness and returns the serialized ObliviousDoHMessage.
+func (rc *ResponseContext) EncryptResponse(rnd io.Reader, response []byte) ([]byte, error) {
+	responseNonce := make([]byte, rc.nonceSize())
+	if _, err := io.ReadFull(rnd, responseNonce); err != nil {
+		return nil, err
+	}
+	aead, nonce, err := rc.cipher(responseNonce)
+	if err != nil {
+		return nil, err
+	}
+	ciphertext := aead.Seal(nil, nonce, marshalPlaintext(response),
+		additionalData(messageTypeResponse, responseNonce))
+	return marshalMessage(messageTypeResponse, responseNonce, ciphertext), nil
+}
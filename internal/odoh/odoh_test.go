@@ -0,0 +1,192 @@
+package odoh
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	"github.com/cloudflare/circl/hpke"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestConfigs(t *testing.T) {
+	kp, err := NewKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("MarshalConfigs and ParseConfigs roundtrip", func(t *testing.T) {
+		configs, err := ParseConfigs(MarshalConfigs(kp.Config))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff([]*Config{kp.Config}, configs); diff != "" {
+			t.Fatal(diff)
+		}
+	})
+
+	t.Run("ParseConfigs skips unsupported suites", func(t *testing.T) {
+		unsupported := &Config{
+			KEM:       hpke.KEM(0xffff),
+			KDF:       kp.Config.KDF,
+			AEAD:      kp.Config.AEAD,
+			PublicKey: kp.Config.PublicKey,
+		}
+		configs, err := ParseConfigs(MarshalConfigs(unsupported, kp.Config))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(configs) != 1 || !bytes.Equal(configs[0].KeyID(), kp.Config.KeyID()) {
+			t.Fatal("unexpected configs", configs)
+		}
+	})
+
+	t.Run("ParseConfigs skips unknown versions", func(t *testing.T) {
+		data := MarshalConfigs(kp.Config)
+		data[2], data[3] = 0xff, 0xff // overwrite the version
+		configs, err := ParseConfigs(data)
+		if !errors.Is(err, ErrNoSupportedConfig) {
+			t.Fatal("unexpected error", err)
+		}
+		if len(configs) != 0 {
+			t.Fatal("expected no configs")
+		}
+	})
+
+	t.Run("ParseConfigs fails with invalid input", func(t *testing.T) {
+		data := MarshalConfigs(kp.Config)
+		for _, input := range [][]byte{nil, data[:len(data)-1], append(data, 0)} {
+			if _, err := ParseConfigs(input); !errors.Is(err, ErrInvalidMessage) {
+				t.Fatal("unexpected error", err)
+			}
+		}
+	})
+
+	t.Run("KeyID has the expected length", func(t *testing.T) {
+		if n := len(kp.Config.KeyID()); n != 32 {
+			t.Fatal("unexpected key ID length", n)
+		}
+	})
+}
+
+func TestRoundTrip(t *testing.T) {
+	kp, err := NewKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := []byte("this is the DNS query")
+	response := []byte("this is the DNS response")
+
+	t.Run("when everything works as intended", func(t *testing.T) {
+		encQuery, qctx, err := kp.Config.EncryptQuery(rand.Reader, query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		gotQuery, rctx, err := kp.DecryptQuery(encQuery)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(query, gotQuery); diff != "" {
+			t.Fatal(diff)
+		}
+		encResponse, err := rctx.EncryptResponse(rand.Reader, response)
+		if err != nil {
+			t.Fatal(err)
+		}
+		gotResponse, err := qctx.DecryptResponse(encResponse)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(response, gotResponse); diff != "" {
+			t.Fatal(diff)
+		}
+	})
+
+	t.Run("DecryptQuery fails with an unknown key ID", func(t *testing.T) {
+		other, err := NewKeyPair()
+		if err != nil {
+			t.Fatal(err)
+		}
+		encQuery, _, err := other.Config.EncryptQuery(rand.Reader, query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, _, err := kp.DecryptQuery(encQuery); !errors.Is(err, ErrUnknownKeyID) {
+			t.Fatal("unexpected error", err)
+		}
+	})
+
+	t.Run("DecryptQuery fails with a response message", func(t *testing.T) {
+		encQuery, _, err := kp.Config.EncryptQuery(rand.Reader, query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		encQuery[0] = messageTypeResponse
+		if _, _, err := kp.DecryptQuery(encQuery); !errors.Is(err, ErrUnexpectedMessageType) {
+			t.Fatal("unexpected error", err)
+		}
+	})
+
+	t.Run("DecryptQuery fails with a tampered ciphertext", func(t *testing.T) {
+		encQuery, _, err := kp.Config.EncryptQuery(rand.Reader, query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		encQuery[len(encQuery)-1] ^= 0xff
+		if _, _, err := kp.DecryptQuery(encQuery); err == nil {
+			t.Fatal("expected an error")
+		}
+	})
+
+	t.Run("DecryptResponse fails with a tampered ciphertext", func(t *testing.T) {
+		encQuery, qctx, err := kp.Config.EncryptQuery(rand.Reader, query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, rctx, err := kp.DecryptQuery(encQuery)
+		if err != nil {
+			t.Fatal(err)
+		}
+		encResponse, err := rctx.EncryptResponse(rand.Reader, response)
+		if err != nil {
+			t.Fatal(err)
+		}
+		encResponse[len(encResponse)-1] ^= 0xff
+		if _, err := qctx.DecryptResponse(encResponse); err == nil {
+			t.Fatal("expected an error")
+		}
+	})
+
+	t.Run("DecryptResponse fails with a query message", func(t *testing.T) {
+		encQuery, qctx, err := kp.Config.EncryptQuery(rand.Reader, query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := qctx.DecryptResponse(encQuery); !errors.Is(err, ErrUnexpectedMessageType) {
+			t.Fatal("unexpected error", err)
+		}
+	})
+
+	t.Run("EncryptQuery fails with an invalid public key", func(t *testing.T) {
+		config := *kp.Config
+		config.PublicKey = []byte{1, 2, 3}
+		if _, _, err := config.EncryptQuery(rand.Reader, query); err == nil {
+			t.Fatal("expected an error")
+		}
+	})
+
+	t.Run("EncryptResponse fails when we cannot read randomness", func(t *testing.T) {
+		encQuery, _, err := kp.Config.EncryptQuery(rand.Reader, query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, rctx, err := kp.DecryptQuery(encQuery)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := rctx.EncryptResponse(bytes.NewReader(nil), response); err == nil {
+			t.Fatal("expected an error")
+		}
+	})
+}
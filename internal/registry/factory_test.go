@@ -423,6 +423,12 @@ func TestNewFactory(t *testing.T) {
 			enabledByDefault: true,
 			inputPolicy:      model.InputOrStaticDefault,
 		},
+		"dsl": {
+			// Note: dsl is not enabled by default because it is experimental
+			// and runs measurement logic provided by the user.
+			//enabledByDefault: false,
+			inputPolicy: model.InputOptional,
+		},
 		"echcheck": {
 			// Note: echcheck is not enabled by default because we just introduced it
 			// into 3.19.0-alpha, which makes it a relatively new experiment.
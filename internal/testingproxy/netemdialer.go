@@ -0,0 +1,162 @@
+package testingproxy
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/apex/log"
+	"github.com/ooni/netem"
+	"github.com/ooni/probe-cli/v3/internal/netxlite"
+	"github.com/ooni/probe-cli/v3/internal/runtimex"
+	"github.com/ooni/probe-cli/v3/internal/testingsocks5"
+	"github.com/ooni/probe-cli/v3/internal/testingx"
+)
+
+// WithNetemProxyDialerAndURL returns a [TestCase] where:
+//
+// - we fetch a URL;
+//
+// - using the github.com/ooni.netem;
+//
+// - and a proxy with the given scheme (one of "socks5", "http", and "https");
+//
+// - by wrapping the dialer using [*netxlite.Netx.MaybeWrapWithProxyDialer] rather
+// than by configuring the proxy URL into the HTTP transport.
+//
+// Because this [TestCase] uses netem, it also runs in -short mode.
+func WithNetemProxyDialerAndURL(proxyScheme, URL string) TestCase {
+	return &netemTestCaseWithProxyDialer{
+		ProxyScheme: proxyScheme,
+		TargetURL:   URL,
+	}
+}
+
+type netemTestCaseWithProxyDialer struct {
+	ProxyScheme string
+	TargetURL   string
+}
+
+var _ TestCase = &netemTestCaseWithProxyDialer{}
+
+// Name implements TestCase.
+func (tc *netemTestCaseWithProxyDialer) Name() string {
+	return fmt.Sprintf("fetching %s using netem and a %s proxy dialer", tc.TargetURL, tc.ProxyScheme)
+}
+
+// Run implements TestCase.
+func (tc *netemTestCaseWithProxyDialer) Run(t *testing.T) {
+	topology := netem.MustNewStarTopology(log.Log)
+	defer topology.Close()
+
+	const (
+		wwwIPAddr    = "93.184.216.34"
+		proxyIPAddr  = "10.0.0.1"
+		clientIPAddr = "10.0.0.2"
+	)
+
+	// create:
+	//
+	// - a www stack modeling www.example.com
+	//
+	// - a proxy stack
+	//
+	// - a client stack
+	//
+	// Note that www.example.com's IP address is also the resolver used by everyone
+	wwwStack := runtimex.Try1(topology.AddHost(wwwIPAddr, wwwIPAddr, &netem.LinkConfig{}))
+	proxyStack := runtimex.Try1(topology.AddHost(proxyIPAddr, wwwIPAddr, &netem.LinkConfig{}))
+	clientStack := runtimex.Try1(topology.AddHost(clientIPAddr, wwwIPAddr, &netem.LinkConfig{}))
+
+	// configure the wwwStack as the DNS resolver with proper configuration
+	dnsConfig := netem.NewDNSConfig()
+	runtimex.Try0(dnsConfig.AddRecord("www.example.com.", "", wwwIPAddr))
+	dnsServer := runtimex.Try1(netem.NewDNSServer(log.Log, wwwStack, wwwIPAddr, dnsConfig))
+	defer dnsServer.Close()
+
+	// configure the wwwStack to respond to HTTP requests on port 80
+	wwwServer80 := testingx.MustNewHTTPServerEx(
+		&net.TCPAddr{IP: net.ParseIP(wwwIPAddr), Port: 80},
+		wwwStack,
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte("Bonsoir, Elliot!\r\n"))
+		}),
+	)
+	defer wwwServer80.Close()
+
+	// configure the wwwStack to respond to HTTPS requests on port 443
+	wwwServer443 := testingx.MustNewHTTPServerTLSEx(
+		&net.TCPAddr{IP: net.ParseIP(wwwIPAddr), Port: 443},
+		wwwStack,
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte("Bonsoir, Elliot!\r\n"))
+		}),
+		wwwStack,
+		"www.example.com",
+	)
+	defer wwwServer443.Close()
+
+	// configure the proxyStack to implement the proxy
+	proxyNetx := &netxlite.Netx{Underlying: &netxlite.NetemUnderlyingNetworkAdapter{UNet: proxyStack}}
+	var proxyURL *url.URL
+	switch tc.ProxyScheme {
+	case "socks5":
+		proxyServer := testingsocks5.MustNewServer(
+			log.Log, proxyNetx, &net.TCPAddr{IP: net.ParseIP(proxyIPAddr), Port: 9050})
+		defer proxyServer.Close()
+		proxyURL = proxyServer.URL()
+
+	case "http":
+		proxyServer := testingx.MustNewHTTPServerEx(
+			&net.TCPAddr{IP: net.ParseIP(proxyIPAddr), Port: 8080},
+			proxyStack,
+			testingx.NewHTTPProxyHandler(log.Log, proxyNetx),
+		)
+		defer proxyServer.Close()
+		proxyURL = runtimex.Try1(url.Parse(proxyServer.URL))
+
+	case "https":
+		proxyServer := testingx.MustNewHTTPServerTLSEx(
+			&net.TCPAddr{IP: net.ParseIP(proxyIPAddr), Port: 4443},
+			proxyStack,
+			testingx.NewHTTPProxyHandler(log.Log, proxyNetx),
+			proxyStack,
+			"proxy.local",
+		)
+		defer proxyServer.Close()
+		proxyURL = runtimex.Try1(url.Parse(proxyServer.URL))
+
+	default:
+		t.Fatal("unsupported proxy scheme", tc.ProxyScheme)
+	}
+
+	// create the netx instance for the client
+	netx := &netxlite.Netx{Underlying: &netxlite.NetemUnderlyingNetworkAdapter{UNet: clientStack}}
+
+	// create an HTTP client using a dialer that dials through the proxy
+	//
+	// note how we use a dialer that asserts that we're using the proxy IP address
+	// rather than the host address, so we're sure that we're using the proxy
+	dialer := netx.MaybeWrapWithProxyDialer(&dialerWithAssertions{
+		ExpectAddress: proxyIPAddr,
+		Dialer:        netx.NewDialerWithResolver(log.Log, netx.NewStdlibResolver(log.Log)),
+	}, proxyURL)
+	tlsDialer := netxlite.NewTLSDialerWithConfig(
+		dialer, netx.NewTLSHandshakerStdlib(log.Log),
+		&tls.Config{RootCAs: clientStack.DefaultCertPool()}, // #nosec G402 - code used for testing
+	)
+	txp := netxlite.NewHTTPTransportWithOptions(log.Log, dialer, tlsDialer)
+	client := &http.Client{Transport: txp}
+	defer client.CloseIdleConnections()
+
+	// get the homepage and assert we're getting a succesful response
+	httpCheckResponse(t, client, tc.TargetURL)
+}
+
+// Short implements TestCase.
+func (tc *netemTestCaseWithProxyDialer) Short() bool {
+	return true
+}
@@ -29,3 +29,15 @@ func TestSOCKSProxy(t *testing.T) {
 		})
 	}
 }
+
+func TestProxyDialer(t *testing.T) {
+	for _, testCase := range testingproxy.DialerTestCases {
+		t.Run(testCase.Name(), func(t *testing.T) {
+			short := testCase.Short()
+			if !short && testing.Short() {
+				t.Skip("skip test in short mode")
+			}
+			testCase.Run(t)
+		})
+	}
+}
@@ -47,3 +47,19 @@ var HTTPTestCases = []TestCase{
 	WithNetemHTTPWithTLSProxyAndURL("http://www.example.com/"),
 	WithNetemHTTPWithTLSProxyAndURL("https://www.example.com/"),
 }
+
+// DialerTestCases contains the test cases where we wrap the dialer
+// with a proxy dialer rather than configuring the HTTP transport.
+var DialerTestCases = []TestCase{
+	// with netem and a SOCKS5 proxy
+	WithNetemProxyDialerAndURL("socks5", "http://www.example.com/"),
+	WithNetemProxyDialerAndURL("socks5", "https://www.example.com/"),
+
+	// with netem and HTTP proxy
+	WithNetemProxyDialerAndURL("http", "http://www.example.com/"),
+	WithNetemProxyDialerAndURL("http", "https://www.example.com/"),
+
+	// with netem and HTTPS proxy
+	WithNetemProxyDialerAndURL("https", "http://www.example.com/"),
+	WithNetemProxyDialerAndURL("https", "https://www.example.com/"),
+}
@@ -0,0 +1,130 @@
+package testingx
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"net"
+	"sync"
+
+	"github.com/ooni/probe-cli/v3/internal/runtimex"
+	"github.com/quic-go/quic-go"
+)
+
+// DNSOverQUICListener is a DNS-over-QUIC (RFC 9250) listener. The zero value of
+// this struct is invalid, please use [MustNewDNSOverQUICListener].
+type DNSOverQUICListener struct {
+	cancel    context.CancelFunc
+	closeOnce sync.Once
+	listener  *quic.Listener
+	pconn     net.PacketConn
+	rtx       DNSRoundTripper
+	wg        sync.WaitGroup
+}
+
+// MustNewDNSOverQUICListener creates a new [DNSOverQUICListener] using the given
+// [DNSOverUDPUnderlyingListener], [*tls.Config], [DNSRoundTripper], and [*net.UDPAddr].
+//
+// We automatically add "doq" to the NextProtos of a clone of the given [*tls.Config].
+func MustNewDNSOverQUICListener(addr *net.UDPAddr, dul DNSOverUDPUnderlyingListener,
+	tlsConfig *tls.Config, rtx DNSRoundTripper) *DNSOverQUICListener {
+	pconn := runtimex.Try1(dul.ListenUDP("udp", addr))
+	tlsConfig = tlsConfig.Clone()
+	tlsConfig.NextProtos = []string{"doq"}
+	listener := runtimex.Try1(quic.Listen(pconn, tlsConfig, &quic.Config{}))
+	ctx, cancel := context.WithCancel(context.Background())
+	dl := &DNSOverQUICListener{
+		cancel:    cancel,
+		closeOnce: sync.Once{},
+		listener:  listener,
+		pconn:     pconn,
+		rtx:       rtx,
+		wg:        sync.WaitGroup{},
+	}
+	dl.wg.Add(1)
+	go dl.mainloop(ctx)
+	return dl
+}
+
+// LocalAddr returns the listener address.
+func (dl *DNSOverQUICListener) LocalAddr() net.Addr {
+	return dl.listener.Addr()
+}
+
+// Close implements io.Closer.
+func (dl *DNSOverQUICListener) Close() (err error) {
+	dl.closeOnce.Do(func() {
+		// close the listener to interrupt Accept
+		err = dl.listener.Close()
+
+		// cancel the context to interrupt connections and the round tripper
+		dl.cancel()
+
+		// wait for the background goroutines to join
+		dl.wg.Wait()
+
+		// the listener does not close the socket we passed to it
+		_ = dl.pconn.Close()
+	})
+	return err
+}
+
+func (dl *DNSOverQUICListener) mainloop(ctx context.Context) {
+	// synchronize with Close
+	defer dl.wg.Done()
+
+	for {
+		qconn, err := dl.listener.Accept(ctx)
+		if err != nil {
+			return // closed or canceled
+		}
+		dl.wg.Add(1)
+		go dl.serveConn(ctx, qconn)
+	}
+}
+
+func (dl *DNSOverQUICListener) serveConn(ctx context.Context, qconn quic.Connection) {
+	// synchronize with Close
+	defer dl.wg.Done()
+
+	// make sure we close the connection when done
+	defer qconn.CloseWithError(0, "")
+
+	for {
+		stream, err := qconn.AcceptStream(ctx)
+		if err != nil {
+			return // closed by peer or canceled
+		}
+		dl.wg.Add(1)
+		go dl.serveStream(ctx, stream)
+	}
+}
+
+func (dl *DNSOverQUICListener) serveStream(ctx context.Context, stream quic.Stream) {
+	// synchronize with Close
+	defer dl.wg.Done()
+
+	// make sure we close our side of the stream when done
+	defer stream.Close()
+
+	// read the length-prefixed query
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(stream, header); err != nil {
+		return
+	}
+	rawReq := make([]byte, int(header[0])<<8|int(header[1]))
+	if _, err := io.ReadFull(stream, rawReq); err != nil {
+		return
+	}
+
+	// perform the round trip and ignore the message on failure
+	rawResp, err := dl.rtx.RoundTrip(ctx, rawReq)
+	if err != nil || len(rawResp) > 0xffff {
+		return
+	}
+
+	// emit the length-prefixed response
+	buf := []byte{byte(len(rawResp) >> 8), byte(len(rawResp))}
+	buf = append(buf, rawResp...)
+	_, _ = stream.Write(buf)
+}
@@ -0,0 +1,61 @@
+package testingx
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"testing"
+
+	"github.com/apex/log"
+	"github.com/google/go-cmp/cmp"
+	"github.com/miekg/dns"
+	"github.com/ooni/netem"
+	"github.com/ooni/probe-cli/v3/internal/netxlite"
+	"github.com/ooni/probe-cli/v3/internal/runtimex"
+)
+
+func TestDNSOverQUICListener(t *testing.T) {
+	ca := netem.MustNewCA()
+	dnsConfig := netem.NewDNSConfig()
+	runtimex.Try0(dnsConfig.AddRecord("www.example.com", "", "93.184.216.34"))
+
+	listener := MustNewDNSOverQUICListener(
+		&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 0},
+		&DNSOverUDPListenerStdlib{},
+		ca.MustNewServerTLSConfig("dns.google"),
+		NewDNSRoundTripperWithDNSConfig(dnsConfig),
+	)
+	defer listener.Close()
+
+	netx := &netxlite.Netx{}
+	dialer := netx.NewQUICDialerWithoutResolver(netx.NewUDPListener(), log.Log)
+	txp := netxlite.NewUnwrappedDNSOverQUICTransportWithTLSConfig(
+		dialer, listener.LocalAddr().String(), &tls.Config{
+			RootCAs:    ca.DefaultCertPool(),
+			ServerName: "dns.google",
+		})
+	defer txp.CloseIdleConnections()
+
+	t.Run("when querying for an existing domain", func(t *testing.T) {
+		encoder := &netxlite.DNSEncoderMiekg{}
+		query := encoder.Encode("www.example.com", dns.TypeA, txp.RequiresPadding())
+		resp, err := txp.RoundTrip(context.Background(), query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		addrs, err := resp.DecodeLookupHost()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff([]string{"93.184.216.34"}, addrs); diff != "" {
+			t.Fatal(diff)
+		}
+	})
+
+	t.Run("Close is idempotent", func(t *testing.T) {
+		if err := listener.Close(); err != nil {
+			t.Fatal(err)
+		}
+		_ = listener.Close()
+	})
+}
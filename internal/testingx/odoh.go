@@ -0,0 +1,126 @@
+package testingx
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/ooni/probe-cli/v3/internal/odoh"
+	"github.com/ooni/probe-cli/v3/internal/runtimex"
+)
+
+// ObliviousDoHTargetHandler is an [http.Handler] implementing an Oblivious DoH target.
+//
+// The handler serves the configs when receiving a GET for [odoh.ConfigsPath] and
+// otherwise treats POST requests as encrypted queries regardless of the path.
+type ObliviousDoHTargetHandler struct {
+	// KeyPair is the MANDATORY key pair to use.
+	KeyPair *odoh.KeyPair
+
+	// RoundTripper is the MANDATORY round tripper to use.
+	RoundTripper DNSRoundTripper
+}
+
+var _ http.Handler = &ObliviousDoHTargetHandler{}
+
+// NewObliviousDoHTargetHandler creates a new [*ObliviousDoHTargetHandler] using
+// a freshly generated key pair and the given [DNSRoundTripper].
+func NewObliviousDoHTargetHandler(rtx DNSRoundTripper) *ObliviousDoHTargetHandler {
+	return &ObliviousDoHTargetHandler{
+		KeyPair:      runtimex.Try1(odoh.NewKeyPair()),
+		RoundTripper: rtx,
+	}
+}
+
+// ServeHTTP implements [http.Handler].
+func (p *ObliviousDoHTargetHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	defer p.handlePanic(w)
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == odoh.ConfigsPath:
+		_, _ = w.Write(odoh.MarshalConfigs(p.KeyPair.Config))
+	case r.Method == http.MethodPost:
+		p.query(w, r)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (p *ObliviousDoHTargetHandler) query(w http.ResponseWriter, r *http.Request) {
+	encQuery := runtimex.Try1(io.ReadAll(r.Body))
+	rawQuery, rctx, err := p.KeyPair.DecryptQuery(encQuery)
+	switch {
+	case errors.Is(err, odoh.ErrUnknownKeyID):
+		// RFC 9230 Sect. 4.3 says we should return 401 in this case
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	case err != nil:
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	rawResponse := runtimex.Try1(p.RoundTripper.RoundTrip(r.Context(), rawQuery))
+	encResponse := runtimex.Try1(rctx.EncryptResponse(rand.Reader, rawResponse))
+	w.Header().Add("content-type", odoh.ContentType)
+	_, _ = w.Write(encResponse)
+}
+
+func (p *ObliviousDoHTargetHandler) handlePanic(w http.ResponseWriter) {
+	if r := recover(); r != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+// ObliviousDoHProxyHandler is an [http.Handler] implementing an Oblivious DoH proxy.
+//
+// The handler forwards POST requests to https://{targethost}{targetpath} where
+// targethost and targetpath are the namesake query parameters.
+type ObliviousDoHProxyHandler struct {
+	// Transport is the MANDATORY [http.RoundTripper] used to reach the targets.
+	Transport http.RoundTripper
+}
+
+var _ http.Handler = &ObliviousDoHProxyHandler{}
+
+// ServeHTTP implements [http.Handler].
+func (p *ObliviousDoHProxyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	defer p.handlePanic(w)
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	targetHost := r.URL.Query().Get("targethost")
+	targetPath := r.URL.Query().Get("targetpath")
+	if targetHost == "" || targetPath == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	targetURL := &url.URL{
+		Scheme: "https",
+		Host:   targetHost,
+		Path:   targetPath,
+	}
+	body := runtimex.Try1(io.ReadAll(r.Body))
+	req := runtimex.Try1(http.NewRequestWithContext(
+		r.Context(), http.MethodPost, targetURL.String(), bytes.NewReader(body)))
+	req.Header.Set("content-type", odoh.ContentType)
+	req.Header.Set("accept", odoh.ContentType)
+	resp, err := p.Transport.RoundTrip(req)
+	if err != nil {
+		w.WriteHeader(http.StatusBadGateway)
+		return
+	}
+	defer resp.Body.Close()
+	if value := resp.Header.Get("content-type"); value != "" {
+		w.Header().Set("content-type", value)
+	}
+	w.WriteHeader(resp.StatusCode)
+	_, _ = io.Copy(w, resp.Body)
+}
+
+func (p *ObliviousDoHProxyHandler) handlePanic(w http.ResponseWriter) {
+	if r := recover(); r != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
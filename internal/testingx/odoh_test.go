@@ -0,0 +1,193 @@
+package testingx
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/miekg/dns"
+	"github.com/ooni/netem"
+	"github.com/ooni/probe-cli/v3/internal/odoh"
+	"github.com/ooni/probe-cli/v3/internal/runtimex"
+)
+
+func TestObliviousDoH(t *testing.T) {
+	// newQuery returns a raw query for the given domain.
+	newQuery := func(domain string) []byte {
+		query := &dns.Msg{}
+		query.SetQuestion(dns.Fqdn(domain), dns.TypeA)
+		return runtimex.Try1(query.Pack())
+	}
+
+	// newServers creates the target and the proxy servers.
+	newServers := func(rtx DNSRoundTripper) (*ObliviousDoHTargetHandler, *httptest.Server, *httptest.Server) {
+		handler := NewObliviousDoHTargetHandler(rtx)
+		target := httptest.NewTLSServer(handler)
+		proxy := httptest.NewServer(&ObliviousDoHProxyHandler{
+			Transport: target.Client().Transport,
+		})
+		return handler, target, proxy
+	}
+
+	// proxyURL returns the proxy URL for the given target.
+	proxyURL := func(proxy, target *httptest.Server) string {
+		URL := runtimex.Try1(url.Parse(proxy.URL))
+		URL.Path = "/dns-query"
+		URL.RawQuery = url.Values{
+			"targethost": {runtimex.Try1(url.Parse(target.URL)).Host},
+			"targetpath": {"/dns-query"},
+		}.Encode()
+		return URL.String()
+	}
+
+	// post sends the given body to the given URL.
+	post := func(URL string, body []byte) *http.Response {
+		req := runtimex.Try1(http.NewRequest("POST", URL, bytes.NewReader(body)))
+		req.Header.Set("content-type", odoh.ContentType)
+		return runtimex.Try1(http.DefaultClient.Do(req))
+	}
+
+	t.Run("the target serves its configs", func(t *testing.T) {
+		handler, target, proxy := newServers(NewDNSRoundTripperWithDNSConfig(netem.NewDNSConfig()))
+		defer target.Close()
+		defer proxy.Close()
+		resp, err := target.Client().Get(target.URL + odoh.ConfigsPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != 200 {
+			t.Fatal("unexpected status code", resp.StatusCode)
+		}
+		configs, err := odoh.ParseConfigs(runtimex.Try1(io.ReadAll(resp.Body)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff([]*odoh.Config{handler.KeyPair.Config}, configs); diff != "" {
+			t.Fatal(diff)
+		}
+	})
+
+	t.Run("the target rejects unsupported methods", func(t *testing.T) {
+		_, target, proxy := newServers(NewDNSRoundTripperWithDNSConfig(netem.NewDNSConfig()))
+		defer target.Close()
+		defer proxy.Close()
+		resp, err := target.Client().Get(target.URL + "/dns-query")
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusMethodNotAllowed {
+			t.Fatal("unexpected status code", resp.StatusCode)
+		}
+	})
+
+	t.Run("we can resolve a domain using the proxy", func(t *testing.T) {
+		config := netem.NewDNSConfig()
+		config.AddRecord("example.com", "", "93.184.216.34")
+		handler, target, proxy := newServers(NewDNSRoundTripperWithDNSConfig(config))
+		defer target.Close()
+		defer proxy.Close()
+		encQuery, qctx := runtimex.Try2(handler.KeyPair.Config.EncryptQuery(rand.Reader, newQuery("example.com")))
+		resp := post(proxyURL(proxy, target), encQuery)
+		defer resp.Body.Close()
+		if resp.StatusCode != 200 {
+			t.Fatal("unexpected status code", resp.StatusCode)
+		}
+		if value := resp.Header.Get("content-type"); value != odoh.ContentType {
+			t.Fatal("unexpected content-type", value)
+		}
+		rawResponse, err := qctx.DecryptResponse(runtimex.Try1(io.ReadAll(resp.Body)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		msg := &dns.Msg{}
+		if err := msg.Unpack(rawResponse); err != nil {
+			t.Fatal(err)
+		}
+		if len(msg.Answer) != 1 || msg.Answer[0].(*dns.A).A.String() != "93.184.216.34" {
+			t.Fatal("unexpected answer", msg.Answer)
+		}
+	})
+
+	t.Run("the target returns 401 with an unknown key", func(t *testing.T) {
+		_, target, proxy := newServers(NewDNSRoundTripperWithDNSConfig(netem.NewDNSConfig()))
+		defer target.Close()
+		defer proxy.Close()
+		other := runtimex.Try1(odoh.NewKeyPair())
+		encQuery, _ := runtimex.Try2(other.Config.EncryptQuery(rand.Reader, newQuery("example.com")))
+		resp := post(proxyURL(proxy, target), encQuery)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Fatal("unexpected status code", resp.StatusCode)
+		}
+	})
+
+	t.Run("the target returns 400 with an invalid query", func(t *testing.T) {
+		_, target, proxy := newServers(NewDNSRoundTripperWithDNSConfig(netem.NewDNSConfig()))
+		defer target.Close()
+		defer proxy.Close()
+		resp := post(proxyURL(proxy, target), []byte{0x22})
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Fatal("unexpected status code", resp.StatusCode)
+		}
+	})
+
+	t.Run("the target returns 500 on round trip error", func(t *testing.T) {
+		handler, target, proxy := newServers(
+			NewDNSRoundTripperSimulateTimeout(time.Millisecond, errors.New("antani")))
+		defer target.Close()
+		defer proxy.Close()
+		encQuery, _ := runtimex.Try2(handler.KeyPair.Config.EncryptQuery(rand.Reader, newQuery("example.com")))
+		resp := post(proxyURL(proxy, target), encQuery)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusInternalServerError {
+			t.Fatal("unexpected status code", resp.StatusCode)
+		}
+	})
+
+	t.Run("the proxy rejects unsupported methods", func(t *testing.T) {
+		_, target, proxy := newServers(NewDNSRoundTripperWithDNSConfig(netem.NewDNSConfig()))
+		defer target.Close()
+		defer proxy.Close()
+		resp, err := http.Get(proxyURL(proxy, target))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusMethodNotAllowed {
+			t.Fatal("unexpected status code", resp.StatusCode)
+		}
+	})
+
+	t.Run("the proxy returns 400 without targethost and targetpath", func(t *testing.T) {
+		_, target, proxy := newServers(NewDNSRoundTripperWithDNSConfig(netem.NewDNSConfig()))
+		defer target.Close()
+		defer proxy.Close()
+		resp := post(proxy.URL+"/dns-query", []byte{0x22})
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Fatal("unexpected status code", resp.StatusCode)
+		}
+	})
+
+	t.Run("the proxy returns 502 when it cannot reach the target", func(t *testing.T) {
+		_, target, proxy := newServers(NewDNSRoundTripperWithDNSConfig(netem.NewDNSConfig()))
+		defer proxy.Close()
+		URL := proxyURL(proxy, target)
+		target.Close()
+		resp := post(URL, []byte{0x22})
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadGateway {
+			t.Fatal("unexpected status code", resp.StatusCode)
+		}
+	})
+}
@@ -73,7 +73,7 @@ func compareTestKeys(expected, got *TestKeys) error {
 		// ignore the fields that are specific to LTE
 		options = append(options, cmpopts.IgnoreFields(TestKeys{}, "XDNSFlags", "XBlockingFlags", "XNullNullFlags"))
 
-	case "0.5.28":
+	case "0.5.31":
 		// ignore the fields that are specific to v0.4
 		options = append(options, cmpopts.IgnoreFields(TestKeys{}, "XStatus"))
 
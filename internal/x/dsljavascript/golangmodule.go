@@ -1,8 +1,6 @@
 package dsljavascript
 
 import (
-	"time"
-
 	"github.com/dop251/goja"
 	"github.com/ooni/probe-cli/v3/internal/runtimex"
 )
@@ -14,8 +12,9 @@ func (vm *VM) newModuleGolang(gojaVM *goja.Runtime, mod *goja.Object) {
 	runtimex.Try0(exports.Set("timeNow", vm.golangTimeNow))
 }
 
-// golangTimeNow returns the current time using golang [time.Now]
+// golangTimeNow returns the current time using golang [time.Now] or
+// the [VMConfig] TimeSource, when the latter is configured.
 func (vm *VM) golangTimeNow(call goja.FunctionCall) goja.Value {
 	runtimex.Assert(len(call.Arguments) == 0, "dsljavascript: _golang.timeNow expects zero arguments")
-	return vm.vm.ToValue(time.Now())
+	return vm.vm.ToValue(vm.timeNow())
 }
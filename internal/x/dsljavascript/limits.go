@@ -0,0 +1,378 @@
+package dsljavascript
+
+//
+// Resource limits for untrusted scripts
+//
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"runtime/metrics"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/dop251/goja"
+	"github.com/ooni/probe-cli/v3/internal/model"
+	"github.com/ooni/probe-cli/v3/internal/x/dsljson"
+	"github.com/quic-go/quic-go"
+)
+
+// VMLimits contains OPTIONAL limits that the [*VM] enforces when running scripts. The
+// zero value of each field means that the corresponding resource is not limited.
+type VMLimits struct {
+	// AllowedHosts contains the domain names, the IP addresses, and the wildcard
+	// domain names (e.g., "*.example.com", which does not match "example.com") that
+	// DSL operations may use as domains to resolve, DNS resolvers, TLS and QUIC
+	// server names, and HTTP host headers. If empty, we allow any host.
+	AllowedHosts []string
+
+	// MaxExecutionTime is the maximum time that each call into JavaScript may spend
+	// running JavaScript code, which does not include the time spent running the DSL.
+	MaxExecutionTime time.Duration
+
+	// MaxHeapBytes is the maximum number of bytes by which the Go heap may grow while
+	// running JavaScript code. Because we sample the heap of the whole process every
+	// few milliseconds, this is a coarse limit to stop runaway scripts.
+	MaxHeapBytes uint64
+
+	// MaxNetworkOperations is the maximum number of network operations that the DSL
+	// may perform during the whole VM lifetime. Each dial and each DNS lookup counts
+	// as an operation, so a DNS-over-UDP lookup counts as three operations (the
+	// lookup plus dialing the resolver for the A and AAAA queries).
+	MaxNetworkOperations int64
+}
+
+var (
+	// ErrVMExecutionTimeLimit indicates that a script ran for too much time.
+	ErrVMExecutionTimeLimit = errors.New("dsljavascript: script exceeded the execution time limit")
+
+	// ErrVMHeapLimit indicates that a script used too much memory.
+	ErrVMHeapLimit = errors.New("dsljavascript: script exceeded the heap limit")
+
+	// ErrVMNetworkLimit indicates that a script performed too many network operations.
+	ErrVMNetworkLimit = errors.New("dsljavascript: script exceeded the network operations limit")
+
+	// ErrVMHostNotAllowed indicates that a script used a host that is not allowed.
+	ErrVMHostNotAllowed = errors.New("dsljavascript: host not allowed")
+)
+
+// vmHeapSamplingInterval is the interval between heap samples.
+const vmHeapSamplingInterval = 10 * time.Millisecond
+
+// vmWatchdog interrupts the JavaScript VM when it exceeds the time or heap limits.
+type vmWatchdog struct {
+	// limits contains the limits.
+	limits *VMLimits
+
+	// mu provides mutual exclusion.
+	mu sync.Mutex
+
+	// paused indicates that we're not running JavaScript code.
+	paused bool
+
+	// remaining is the remaining execution time.
+	remaining time.Duration
+
+	// resumed is when we started or resumed running JavaScript code.
+	resumed time.Time
+
+	// timer is the timer interrupting the VM.
+	timer *time.Timer
+
+	// vm is the VM to interrupt.
+	vm *goja.Runtime
+}
+
+// enter is called when we start running JavaScript code and returns the function
+// to call when we're done running JavaScript code.
+func (w *vmWatchdog) enter() func() {
+	w.vm.ClearInterrupt()
+	w.mu.Lock()
+	w.paused = true
+	w.remaining = w.limits.MaxExecutionTime
+	w.mu.Unlock()
+	w.resume()
+
+	done := make(chan struct{})
+	wg := &sync.WaitGroup{}
+	if w.limits.MaxHeapBytes > 0 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.sampleHeap(done)
+		}()
+	}
+
+	return func() {
+		w.pause()
+		close(done)
+		wg.Wait()
+		w.vm.ClearInterrupt()
+	}
+}
+
+// pause is called when JavaScript calls into Go to run the DSL.
+func (w *vmWatchdog) pause() {
+	defer w.mu.Unlock()
+	w.mu.Lock()
+	if w.paused {
+		return
+	}
+	w.paused = true
+	if w.timer != nil {
+		w.timer.Stop()
+		w.timer = nil
+		w.remaining -= time.Since(w.resumed)
+	}
+}
+
+// resume is called when Go returns control to JavaScript.
+func (w *vmWatchdog) resume() {
+	defer w.mu.Unlock()
+	w.mu.Lock()
+	if !w.paused {
+		return
+	}
+	w.paused = false
+	w.resumed = time.Now()
+	if w.limits.MaxExecutionTime > 0 {
+		w.timer = time.AfterFunc(max(w.remaining, 0), func() {
+			w.vm.Interrupt(ErrVMExecutionTimeLimit)
+		})
+	}
+}
+
+// sampleHeap interrupts the VM if the heap grows too much until done is closed.
+func (w *vmWatchdog) sampleHeap(done <-chan struct{}) {
+	base := vmHeapObjectsBytes()
+	ticker := time.NewTicker(vmHeapSamplingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			w.mu.Lock()
+			paused := w.paused
+			w.mu.Unlock()
+			if current := vmHeapObjectsBytes(); !paused && current > base && current-base > w.limits.MaxHeapBytes {
+				w.vm.Interrupt(ErrVMHeapLimit)
+				return
+			}
+		}
+	}
+}
+
+// vmHeapObjectsBytes returns the bytes occupied by heap objects.
+func vmHeapObjectsBytes() uint64 {
+	samples := []metrics.Sample{{Name: "/memory/classes/heap/objects:bytes"}}
+	metrics.Read(samples)
+	if samples[0].Value.Kind() != metrics.KindUint64 {
+		return 0
+	}
+	return samples[0].Value.Uint64()
+}
+
+// vmMaybeUnwrapInterrupt returns the error that caused an interrupt, if possible.
+func vmMaybeUnwrapInterrupt(err error) error {
+	var ierr *goja.InterruptedError
+	if errors.As(err, &ierr) {
+		if cause, good := ierr.Value().(error); good {
+			return cause
+		}
+	}
+	return err
+}
+
+// vmCheckAllowedHosts returns an error if the DSL uses hosts that are not allowed.
+func vmCheckAllowedHosts(allowed []string, root *dsljson.RootNode) error {
+	if len(allowed) <= 0 {
+		return nil
+	}
+	for idx, stage := range root.Stages {
+		var value map[string]json.RawMessage
+		if err := json.Unmarshal(stage.Value, &value); err != nil {
+			return err
+		}
+		for _, key := range []string{"domain", "host", "resolver", "server_name", "url"} {
+			var entry string
+			if raw, found := value[key]; !found || json.Unmarshal(raw, &entry) != nil || entry == "" {
+				continue
+			}
+			host := vmHostFromDSLValue(entry)
+			if !vmHostIsAllowed(allowed, host) {
+				return fmt.Errorf("%w: stage #%d: %s: %s", ErrVMHostNotAllowed, idx, key, host)
+			}
+		}
+	}
+	return nil
+}
+
+// vmHostFromDSLValue extracts the host from a domain, an endpoint, or an URL.
+func vmHostFromDSLValue(value string) string {
+	if strings.Contains(value, "://") {
+		if URL, err := url.Parse(value); err == nil {
+			return URL.Hostname()
+		}
+	}
+	if host, _, err := net.SplitHostPort(value); err == nil {
+		return host
+	}
+	return value
+}
+
+// vmHostIsAllowed returns whether the host matches an allowed host.
+func vmHostIsAllowed(allowed []string, host string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	for _, pattern := range allowed {
+		pattern = strings.ToLower(pattern)
+		if suffix, found := strings.CutPrefix(pattern, "*"); found && strings.HasPrefix(suffix, ".") {
+			if strings.HasSuffix(host, suffix) && len(host) > len(suffix) {
+				return true
+			}
+			continue
+		}
+		if host == pattern {
+			return true
+		}
+	}
+	return false
+}
+
+// vmLimitedNetwork is a [model.MeasuringNetwork] that fails network operations
+// with [ErrVMNetworkLimit] once we have performed too many of them.
+type vmLimitedNetwork struct {
+	model.MeasuringNetwork
+
+	// count counts the network operations.
+	count *atomic.Int64
+
+	// max is the maximum number of network operations.
+	max int64
+}
+
+// check accounts for a new network operation and returns an error when
+// we have exceeded the maximum number of network operations.
+func (n *vmLimitedNetwork) check() error {
+	if n.count.Add(1) > n.max {
+		return ErrVMNetworkLimit
+	}
+	return nil
+}
+
+// NewDialerWithoutResolver implements model.MeasuringNetwork.
+func (n *vmLimitedNetwork) NewDialerWithoutResolver(dl model.DebugLogger, w ...model.DialerWrapper) model.Dialer {
+	return &vmLimitedDialer{n.MeasuringNetwork.NewDialerWithoutResolver(dl, w...), n}
+}
+
+// NewParallelDNSOverHTTPSResolver implements model.MeasuringNetwork.
+func (n *vmLimitedNetwork) NewParallelDNSOverHTTPSResolver(logger model.DebugLogger, URL string) model.Resolver {
+	return &vmLimitedResolver{n.MeasuringNetwork.NewParallelDNSOverHTTPSResolver(logger, URL), n}
+}
+
+// NewParallelDNSOverQUICResolver implements model.MeasuringNetwork.
+func (n *vmLimitedNetwork) NewParallelDNSOverQUICResolver(logger model.DebugLogger, address string) model.Resolver {
+	return &vmLimitedResolver{n.MeasuringNetwork.NewParallelDNSOverQUICResolver(logger, address), n}
+}
+
+// NewParallelDNSOverTLSResolver implements model.MeasuringNetwork.
+func (n *vmLimitedNetwork) NewParallelDNSOverTLSResolver(logger model.DebugLogger, address string) model.Resolver {
+	return &vmLimitedResolver{n.MeasuringNetwork.NewParallelDNSOverTLSResolver(logger, address), n}
+}
+
+// NewParallelTCPResolver implements model.MeasuringNetwork.
+func (n *vmLimitedNetwork) NewParallelTCPResolver(
+	logger model.DebugLogger, dialer model.Dialer, address string) model.Resolver {
+	return &vmLimitedResolver{n.MeasuringNetwork.NewParallelTCPResolver(logger, dialer, address), n}
+}
+
+// NewParallelUDPResolver implements model.MeasuringNetwork.
+func (n *vmLimitedNetwork) NewParallelUDPResolver(
+	logger model.DebugLogger, dialer model.Dialer, address string) model.Resolver {
+	return &vmLimitedResolver{n.MeasuringNetwork.NewParallelUDPResolver(logger, dialer, address), n}
+}
+
+// NewQUICDialerWithoutResolver implements model.MeasuringNetwork.
+func (n *vmLimitedNetwork) NewQUICDialerWithoutResolver(
+	listener model.UDPListener, logger model.DebugLogger, w ...model.QUICDialerWrapper) model.QUICDialer {
+	return &vmLimitedQUICDialer{n.MeasuringNetwork.NewQUICDialerWithoutResolver(listener, logger, w...), n}
+}
+
+// NewStdlibResolver implements model.MeasuringNetwork.
+func (n *vmLimitedNetwork) NewStdlibResolver(logger model.DebugLogger) model.Resolver {
+	return &vmLimitedResolver{n.MeasuringNetwork.NewStdlibResolver(logger), n}
+}
+
+// vmLimitedDialer is the [model.Dialer] returned by [*vmLimitedNetwork].
+type vmLimitedDialer struct {
+	model.Dialer
+	n *vmLimitedNetwork
+}
+
+// DialContext implements model.Dialer.
+func (d *vmLimitedDialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	if err := d.n.check(); err != nil {
+		return nil, err
+	}
+	return d.Dialer.DialContext(ctx, network, address)
+}
+
+// vmLimitedResolver is the [model.Resolver] returned by [*vmLimitedNetwork].
+type vmLimitedResolver struct {
+	model.Resolver
+	n *vmLimitedNetwork
+}
+
+// LookupHost implements model.Resolver.
+func (r *vmLimitedResolver) LookupHost(ctx context.Context, hostname string) ([]string, error) {
+	if err := r.n.check(); err != nil {
+		return nil, err
+	}
+	return r.Resolver.LookupHost(ctx, hostname)
+}
+
+// LookupHTTPS implements model.Resolver.
+func (r *vmLimitedResolver) LookupHTTPS(ctx context.Context, domain string) (*model.HTTPSSvc, error) {
+	if err := r.n.check(); err != nil {
+		return nil, err
+	}
+	return r.Resolver.LookupHTTPS(ctx, domain)
+}
+
+// LookupNS implements model.Resolver.
+func (r *vmLimitedResolver) LookupNS(ctx context.Context, domain string) ([]*net.NS, error) {
+	if err := r.n.check(); err != nil {
+		return nil, err
+	}
+	return r.Resolver.LookupNS(ctx, domain)
+}
+
+// LookupRaw implements model.Resolver.
+func (r *vmLimitedResolver) LookupRaw(ctx context.Context, domain string, qtype uint16) ([]*model.DNSRecord, error) {
+	if err := r.n.check(); err != nil {
+		return nil, err
+	}
+	return r.Resolver.LookupRaw(ctx, domain, qtype)
+}
+
+// vmLimitedQUICDialer is the [model.QUICDialer] returned by [*vmLimitedNetwork].
+type vmLimitedQUICDialer struct {
+	model.QUICDialer
+	n *vmLimitedNetwork
+}
+
+// DialContext implements model.QUICDialer.
+func (d *vmLimitedQUICDialer) DialContext(ctx context.Context, address string,
+	tlsConfig *tls.Config, quicConfig *quic.Config) (quic.EarlyConnection, error) {
+	if err := d.n.check(); err != nil {
+		return nil, err
+	}
+	return d.QUICDialer.DialContext(ctx, address, tlsConfig, quicConfig)
+}
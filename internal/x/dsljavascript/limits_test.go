@@ -0,0 +1,289 @@
+package dsljavascript
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dop251/goja"
+	"github.com/miekg/dns"
+	"github.com/ooni/probe-cli/v3/internal/mocks"
+	"github.com/ooni/probe-cli/v3/internal/model"
+	"github.com/ooni/probe-cli/v3/internal/runtimex"
+)
+
+// mustWriteScript writes a script inside a temporary directory and returns its path.
+func mustWriteScript(t *testing.T, content string) string {
+	scriptPath := filepath.Join(t.TempDir(), "script.js")
+	runtimex.Try0(os.WriteFile(scriptPath, []byte(content), 0600))
+	return scriptPath
+}
+
+// newTestVMConfig returns a config suitable for running scripts in tests.
+func newTestVMConfig(scriptPath string, limits VMLimits) *VMConfig {
+	return &VMConfig{
+		Limits:        limits,
+		Logger:        model.DiscardLogger,
+		ScriptBaseDir: filepath.Dir(scriptPath),
+	}
+}
+
+func TestVMLimits(t *testing.T) {
+	t.Run("we reject negative limits", func(t *testing.T) {
+		scriptPath := mustWriteScript(t, ``)
+		_, err := NewVM(newTestVMConfig(scriptPath, VMLimits{MaxExecutionTime: -1}), scriptPath)
+		if !errors.Is(err, errVMConfig) {
+			t.Fatal("unexpected error", err)
+		}
+	})
+
+	t.Run("we interrupt scripts running for too much time", func(t *testing.T) {
+		scriptPath := mustWriteScript(t, `for (;;) {}`)
+		config := newTestVMConfig(scriptPath, VMLimits{MaxExecutionTime: 100 * time.Millisecond})
+		if err := RunScript(config, scriptPath); !errors.Is(err, ErrVMExecutionTimeLimit) {
+			t.Fatal("unexpected error", err)
+		}
+	})
+
+	t.Run("we interrupt scripts using too much memory", func(t *testing.T) {
+		scriptPath := mustWriteScript(t, `const v = []; for (;;) { v.push("abcdefghijklmnopqrstuvwxyz" + v.length) }`)
+		config := newTestVMConfig(scriptPath, VMLimits{
+			MaxExecutionTime: 10 * time.Second,
+			MaxHeapBytes:     16 << 20,
+		})
+		if err := RunScript(config, scriptPath); !errors.Is(err, ErrVMHeapLimit) {
+			t.Fatal("unexpected error", err)
+		}
+	})
+
+	t.Run("we can reuse the VM after an interrupt", func(t *testing.T) {
+		scriptPath := mustWriteScript(t, `
+			exports.experimentName = function() { return "slow" }
+			exports.experimentVersion = function() { for (;;) {} }
+		`)
+		config := newTestVMConfig(scriptPath, VMLimits{MaxExecutionTime: 100 * time.Millisecond})
+		vm, err := LoadExperiment(config, scriptPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := vm.ExperimentVersion(); !errors.Is(err, ErrVMExecutionTimeLimit) {
+			t.Fatal("unexpected error", err)
+		}
+		name, err := vm.ExperimentName()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if name != "slow" {
+			t.Fatal("unexpected name", name)
+		}
+	})
+
+	t.Run("we refuse to run DSL using hosts that are not allowed", func(t *testing.T) {
+		scriptPath := mustWriteScript(t, `
+			const ooni = require("_ooni")
+			ooni.runDSL({"stages": [
+				{"name": "getaddrinfo", "value": {"domain": "www.example.org", "output": "a"}},
+				{"name": "drop", "value": {"input": "a", "output": "b"}}
+			]}, new Date())
+		`)
+		config := newTestVMConfig(scriptPath, VMLimits{AllowedHosts: []string{"*.example.com"}})
+		if err := RunScript(config, scriptPath); !errors.Is(err, ErrVMHostNotAllowed) {
+			t.Fatal("unexpected error", err)
+		}
+	})
+}
+
+func TestVMDeterministicExecution(t *testing.T) {
+	scriptPath := mustWriteScript(t, `
+		const golang = require("_golang")
+		exports.experimentName = function() {
+			return String(Math.random()) + "/" + new Date().toISOString() + "/" + golang.timeNow().Unix()
+		}
+		exports.experimentVersion = function() { return "0.1.0" }
+	`)
+	config := newTestVMConfig(scriptPath, VMLimits{})
+	config.RandSource = func() float64 { return 0.5 }
+	config.TimeSource = func() time.Time { return time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC) }
+	vm, err := LoadExperiment(config, scriptPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	value, err := vm.ExperimentName()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value != "0.5/2024-01-01T00:00:00.000Z/1704067200" {
+		t.Fatal("unexpected value", value)
+	}
+}
+
+func TestVMMaybeUnwrapInterrupt(t *testing.T) {
+	t.Run("with a non-interrupt error", func(t *testing.T) {
+		expected := errors.New("mocked error")
+		if err := vmMaybeUnwrapInterrupt(expected); err != expected {
+			t.Fatal("unexpected error", err)
+		}
+	})
+
+	t.Run("with an interrupt error", func(t *testing.T) {
+		gojaVM := goja.New()
+		gojaVM.Interrupt(ErrVMHeapLimit)
+		_, err := gojaVM.RunString(`for (;;) {}`)
+		if err := vmMaybeUnwrapInterrupt(err); err != ErrVMHeapLimit {
+			t.Fatal("unexpected error", err)
+		}
+	})
+}
+
+func TestVMHostIsAllowed(t *testing.T) {
+	type testcase struct {
+		host   string
+		expect bool
+	}
+
+	allowed := []string{"dns.google", "*.example.com", "8.8.8.8", "::1"}
+
+	cases := []testcase{
+		{host: "dns.google", expect: true},
+		{host: "DNS.Google.", expect: true},
+		{host: "dns.google.com", expect: false},
+		{host: "www.example.com", expect: true},
+		{host: "a.b.example.com", expect: true},
+		{host: "example.com", expect: false},
+		{host: "wwwexample.com", expect: false},
+		{host: "8.8.8.8", expect: true},
+		{host: "8.8.4.4", expect: false},
+		{host: "::1", expect: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.host, func(t *testing.T) {
+			if got := vmHostIsAllowed(allowed, tc.host); got != tc.expect {
+				t.Fatal("expected", tc.expect, "got", got)
+			}
+		})
+	}
+}
+
+func TestVMHostFromDSLValue(t *testing.T) {
+	cases := map[string]string{
+		"www.example.com":              "www.example.com",
+		"8.8.8.8:53":                   "8.8.8.8",
+		"[::1]:853":                    "::1",
+		"https://dns.google/dns-query": "dns.google",
+	}
+	for input, expect := range cases {
+		if got := vmHostFromDSLValue(input); got != expect {
+			t.Fatal("for", input, "expected", expect, "got", got)
+		}
+	}
+}
+
+func TestVMLimitedNetwork(t *testing.T) {
+	var dials, lookups int
+	underlying := &mocks.MeasuringNetwork{
+		MockNewDialerWithoutResolver: func(dl model.DebugLogger, w ...model.DialerWrapper) model.Dialer {
+			return &mocks.Dialer{
+				MockDialContext: func(ctx context.Context, network, address string) (net.Conn, error) {
+					dials++
+					return &mocks.Conn{}, nil
+				},
+			}
+		},
+		MockNewStdlibResolver: func(logger model.DebugLogger) model.Resolver {
+			return &mocks.Resolver{
+				MockLookupHost: func(ctx context.Context, domain string) ([]string, error) {
+					lookups++
+					return []string{"93.184.216.34"}, nil
+				},
+			}
+		},
+	}
+	netx := &vmLimitedNetwork{MeasuringNetwork: underlying, count: &atomic.Int64{}, max: 2}
+
+	dialer := netx.NewDialerWithoutResolver(model.DiscardLogger)
+	if _, err := dialer.DialContext(context.Background(), "tcp", "93.184.216.34:443"); err != nil {
+		t.Fatal(err)
+	}
+	reso := netx.NewStdlibResolver(model.DiscardLogger)
+	if _, err := reso.LookupHost(context.Background(), "www.example.com"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dialer.DialContext(context.Background(), "tcp", "93.184.216.34:443"); !errors.Is(err, ErrVMNetworkLimit) {
+		t.Fatal("unexpected error", err)
+	}
+	if _, err := reso.LookupHost(context.Background(), "www.example.com"); !errors.Is(err, ErrVMNetworkLimit) {
+		t.Fatal("unexpected error", err)
+	}
+	if dials != 1 || lookups != 1 {
+		t.Fatal("unexpected number of operations", dials, lookups)
+	}
+}
+
+func TestVMLimitedResolver(t *testing.T) {
+	var lookups int
+	underlying := &mocks.MeasuringNetwork{
+		MockNewStdlibResolver: func(logger model.DebugLogger) model.Resolver {
+			return &mocks.Resolver{
+				MockLookupHost: func(ctx context.Context, domain string) ([]string, error) {
+					lookups++
+					return []string{"93.184.216.34"}, nil
+				},
+				MockLookupHTTPS: func(ctx context.Context, domain string) (*model.HTTPSSvc, error) {
+					lookups++
+					return &model.HTTPSSvc{}, nil
+				},
+				MockLookupNS: func(ctx context.Context, domain string) ([]*net.NS, error) {
+					lookups++
+					return []*net.NS{}, nil
+				},
+				MockLookupRaw: func(ctx context.Context, domain string, qtype uint16) ([]*model.DNSRecord, error) {
+					lookups++
+					return []*model.DNSRecord{}, nil
+				},
+			}
+		},
+	}
+
+	// lookupFuncs contains a function for each network-facing method of model.Resolver
+	lookupFuncs := map[string]func(reso model.Resolver) error{
+		"LookupHost": func(reso model.Resolver) error {
+			_, err := reso.LookupHost(context.Background(), "www.example.com")
+			return err
+		},
+		"LookupHTTPS": func(reso model.Resolver) error {
+			_, err := reso.LookupHTTPS(context.Background(), "www.example.com")
+			return err
+		},
+		"LookupNS": func(reso model.Resolver) error {
+			_, err := reso.LookupNS(context.Background(), "www.example.com")
+			return err
+		},
+		"LookupRaw": func(reso model.Resolver) error {
+			_, err := reso.LookupRaw(context.Background(), "www.example.com", dns.TypeTXT)
+			return err
+		},
+	}
+
+	for name, lookup := range lookupFuncs {
+		t.Run(name, func(t *testing.T) {
+			lookups = 0
+			netx := &vmLimitedNetwork{MeasuringNetwork: underlying, count: &atomic.Int64{}, max: 1}
+			reso := netx.NewStdlibResolver(model.DiscardLogger)
+			if err := lookup(reso); err != nil {
+				t.Fatal(err)
+			}
+			if err := lookup(reso); !errors.Is(err, ErrVMNetworkLimit) {
+				t.Fatal("unexpected error", err)
+			}
+			if lookups != 1 {
+				t.Fatal("unexpected number of lookups", lookups)
+			}
+		})
+	}
+}
@@ -0,0 +1,280 @@
+package dsljavascript
+
+//
+// Measurements created by scripts
+//
+
+import (
+	"encoding/json"
+	"errors"
+	"runtime"
+	"time"
+
+	"github.com/dop251/goja"
+	"github.com/ooni/probe-cli/v3/internal/model"
+	"github.com/ooni/probe-cli/v3/internal/platform"
+	"github.com/ooni/probe-cli/v3/internal/runtimex"
+	"github.com/ooni/probe-cli/v3/internal/version"
+)
+
+// VMSession is the [*VM] view of the measurement session.
+type VMSession interface {
+	model.LocationProvider
+
+	// ResolverASNString returns the resolver ASN as a string.
+	ResolverASNString() string
+
+	// ResolverNetworkName returns the resolver network name.
+	ResolverNetworkName() string
+
+	// SoftwareName returns the name of the software.
+	SoftwareName() string
+
+	// SoftwareVersion returns the version of the software.
+	SoftwareVersion() string
+}
+
+var (
+	// ErrVMNoSaver indicates that a script tried to save a measurement
+	// but the [VMConfig] does not contain a [model.Saver].
+	ErrVMNoSaver = errors.New("dsljavascript: no saver configured")
+
+	// ErrVMNoSubmitter indicates that a script tried to submit a measurement
+	// but the [VMConfig] does not contain a [model.Submitter].
+	ErrVMNoSubmitter = errors.New("dsljavascript: no submitter configured")
+)
+
+// vmDefaultSession is the [VMSession] we use when the [VMConfig] does not
+// contain a session, which uses the default, unknown location.
+type vmDefaultSession struct{}
+
+var _ VMSession = vmDefaultSession{}
+
+// ProbeASN implements VMSession.
+func (vmDefaultSession) ProbeASN() uint {
+	return model.DefaultProbeASN
+}
+
+// ProbeASNString implements VMSession.
+func (vmDefaultSession) ProbeASNString() string {
+	return model.DefaultProbeASNString
+}
+
+// ProbeCC implements VMSession.
+func (vmDefaultSession) ProbeCC() string {
+	return model.DefaultProbeCC
+}
+
+// ProbeIP implements VMSession.
+func (vmDefaultSession) ProbeIP() string {
+	return model.DefaultProbeIP
+}
+
+// ProbeNetworkName implements VMSession.
+func (vmDefaultSession) ProbeNetworkName() string {
+	return model.DefaultProbeNetworkName
+}
+
+// ResolverASNString implements VMSession.
+func (vmDefaultSession) ResolverASNString() string {
+	return model.DefaultResolverASNString
+}
+
+// ResolverIP implements VMSession.
+func (vmDefaultSession) ResolverIP() string {
+	return model.DefaultResolverIP
+}
+
+// ResolverNetworkName implements VMSession.
+func (vmDefaultSession) ResolverNetworkName() string {
+	return model.DefaultResolverNetworkName
+}
+
+// SoftwareName implements VMSession.
+func (vmDefaultSession) SoftwareName() string {
+	return "miniooni"
+}
+
+// SoftwareVersion implements VMSession.
+func (vmDefaultSession) SoftwareVersion() string {
+	return version.Version
+}
+
+// vmMeasurementConfig is the configuration passed to _ooni.newMeasurement.
+type vmMeasurementConfig struct {
+	// Input is the OPTIONAL measurement input.
+	Input string `json:"input"`
+
+	// TestName is the MANDATORY test name.
+	TestName string `json:"test_name"`
+
+	// TestVersion is the MANDATORY test version.
+	TestVersion string `json:"test_version"`
+}
+
+// errVMMeasurementConfig indicates that the measurement config is invalid.
+var errVMMeasurementConfig = errors.New("dsljavascript: invalid measurement config")
+
+// ooniProbeLocation implements _ooni.probeLocation. We intentionally do not
+// include the probe IP address, which scripts do not need to know.
+func (vm *VM) ooniProbeLocation() map[string]any {
+	return map[string]any{
+		"probe_asn":             vm.session.ProbeASNString(),
+		"probe_cc":              vm.session.ProbeCC(),
+		"probe_network_name":    vm.session.ProbeNetworkName(),
+		"resolver_asn":          vm.session.ResolverASNString(),
+		"resolver_ip":           vm.session.ResolverIP(),
+		"resolver_network_name": vm.session.ResolverNetworkName(),
+	}
+}
+
+// ooniNewMeasurement implements _ooni.newMeasurement.
+func (vm *VM) ooniNewMeasurement(jsConfig *goja.Object) (*goja.Object, error) {
+	// parse the measurement config
+	rawConfig, err := jsConfig.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	var config vmMeasurementConfig
+	if err := json.Unmarshal(rawConfig, &config); err != nil {
+		return nil, err
+	}
+	if config.TestName == "" || config.TestVersion == "" {
+		return nil, errVMMeasurementConfig
+	}
+
+	// create the measurement
+	m := &vmMeasurement{
+		begin:    vm.timeNow(),
+		m:        vm.newMeasurement(&config),
+		testKeys: map[string]json.RawMessage{},
+		vm:       vm,
+	}
+
+	// create the JavaScript object wrapping the measurement
+	obj := vm.vm.NewObject()
+	runtimex.Try0(obj.Set("addTestKeys", m.addTestKeys))
+	runtimex.Try0(obj.Set("save", m.save))
+	runtimex.Try0(obj.Set("serialize", m.serialize))
+	runtimex.Try0(obj.Set("submit", m.submit))
+	return obj, nil
+}
+
+// newMeasurement creates a new measurement using the same metadata that
+// the engine uses when running experiments written in Go.
+func (vm *VM) newMeasurement(config *vmMeasurementConfig) *model.Measurement {
+	utctimenow := vm.timeNow().UTC()
+	m := &model.Measurement{
+		DataFormatVersion:         model.OOAPIReportDefaultDataFormatVersion,
+		Input:                     model.MeasurementInput(config.Input),
+		MeasurementStartTime:      utctimenow.Format(model.MeasurementDateFormat),
+		MeasurementStartTimeSaved: utctimenow,
+		ProbeIP:                   model.DefaultProbeIP,
+		ProbeASN:                  vm.session.ProbeASNString(),
+		ProbeCC:                   vm.session.ProbeCC(),
+		ProbeNetworkName:          vm.session.ProbeNetworkName(),
+		ReportID:                  "",
+		ResolverASN:               vm.session.ResolverASNString(),
+		ResolverIP:                vm.session.ResolverIP(),
+		ResolverNetworkName:       vm.session.ResolverNetworkName(),
+		SoftwareName:              vm.session.SoftwareName(),
+		SoftwareVersion:           vm.session.SoftwareVersion(),
+		TestName:                  config.TestName,
+		TestStartTime:             vm.testStartTime.UTC().Format(model.MeasurementDateFormat),
+		TestVersion:               config.TestVersion,
+	}
+	m.AddAnnotation("architecture", runtime.GOARCH)
+	m.AddAnnotation("engine_name", "ooniprobe-engine")
+	m.AddAnnotation("engine_version", version.Version)
+	m.AddAnnotation("go_version", runtimex.BuildInfo.GoVersion)
+	m.AddAnnotation("platform", platform.Name())
+	m.AddAnnotation("vcs_modified", runtimex.BuildInfo.VcsModified)
+	m.AddAnnotation("vcs_revision", runtimex.BuildInfo.VcsRevision)
+	m.AddAnnotation("vcs_time", runtimex.BuildInfo.VcsTime)
+	m.AddAnnotation("vcs_tool", runtimex.BuildInfo.VcsTool)
+	m.AddAnnotation("x_javascript", "true")
+	return m
+}
+
+// vmMeasurement is a measurement created by a script.
+type vmMeasurement struct {
+	// begin is when we created the measurement.
+	begin time.Time
+
+	// m is the underlying measurement.
+	m *model.Measurement
+
+	// testKeys contains the test keys added by the script.
+	testKeys map[string]json.RawMessage
+
+	// vm is the VM that created the measurement.
+	vm *VM
+}
+
+// addTestKeys merges the keys of the given object into the test keys.
+func (m *vmMeasurement) addTestKeys(jsKeys *goja.Object) error {
+	rawKeys, err := jsKeys.MarshalJSON()
+	if err != nil {
+		return err
+	}
+	var keys map[string]json.RawMessage
+	if err := json.Unmarshal(rawKeys, &keys); err != nil {
+		return err
+	}
+	for key, value := range keys {
+		m.testKeys[key] = value
+	}
+	return nil
+}
+
+// finalize updates the runtime and removes the probe IP from the measurement.
+func (m *vmMeasurement) finalize() error {
+	m.m.MeasurementRuntime = m.vm.timeNow().Sub(m.begin).Seconds()
+	m.m.TestKeys = m.testKeys // scrubbing replaces the test keys with a scrubbed copy
+	probeIP := m.vm.session.ProbeIP()
+	if probeIP == "" || probeIP == model.DefaultProbeIP {
+		return nil // nothing to scrub
+	}
+	return model.ScrubMeasurement(m.m, probeIP)
+}
+
+// save saves the measurement using the configured [model.Saver].
+func (m *vmMeasurement) save() error {
+	if m.vm.saver == nil {
+		return ErrVMNoSaver
+	}
+	if err := m.finalize(); err != nil {
+		return err
+	}
+	return m.vm.saver.SaveMeasurement(m.m)
+}
+
+// serialize returns the measurement serialized as JSON.
+func (m *vmMeasurement) serialize() (string, error) {
+	if err := m.finalize(); err != nil {
+		return "", err
+	}
+	data, err := json.Marshal(m.m)
+	return string(data), err
+}
+
+// submit submits the measurement using the configured [model.Submitter] and
+// returns the report ID assigned by the collector.
+func (m *vmMeasurement) submit() (string, error) {
+	if m.vm.submitter == nil {
+		return "", ErrVMNoSubmitter
+	}
+	if err := m.finalize(); err != nil {
+		return "", err
+	}
+
+	// the time spent submitting does not count as JavaScript execution time
+	m.vm.watchdog.pause()
+	defer m.vm.watchdog.resume()
+
+	// use the VM context such that [*VM.Interrupt] interrupts submitting
+	if err := m.vm.submitter.Submit(m.vm.ctx, m.m); err != nil {
+		return "", err
+	}
+	return m.m.ReportID, nil
+}
@@ -0,0 +1,192 @@
+package dsljavascript
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/ooni/probe-cli/v3/internal/mocks"
+	"github.com/ooni/probe-cli/v3/internal/model"
+)
+
+// testSession is the [VMSession] used by tests.
+type testSession struct{}
+
+var _ VMSession = testSession{}
+
+func (testSession) ProbeASN() uint              { return 30722 }
+func (testSession) ProbeASNString() string      { return "AS30722" }
+func (testSession) ProbeCC() string             { return "IT" }
+func (testSession) ProbeIP() string             { return "130.192.91.211" }
+func (testSession) ProbeNetworkName() string    { return "Vodafone Italia S.p.A." }
+func (testSession) ResolverASNString() string   { return "AS15169" }
+func (testSession) ResolverIP() string          { return "8.8.8.8" }
+func (testSession) ResolverNetworkName() string { return "Google LLC" }
+func (testSession) SoftwareName() string        { return "miniooni" }
+func (testSession) SoftwareVersion() string     { return "0.1.0-dev" }
+
+// measurementScript is a script creating, saving, and submitting a measurement.
+const measurementScript = `
+	const ooni = require("_ooni")
+
+	exports.experimentName = function() { return "js_example" }
+	exports.experimentVersion = function() { return "0.1.0" }
+
+	exports.run = function(input) {
+		const m = ooni.newMeasurement({
+			"input": input,
+			"test_name": exports.experimentName(),
+			"test_version": exports.experimentVersion()
+		})
+		const loc = ooni.probeLocation()
+		m.addTestKeys({"probe_cc": loc.probe_cc, "leaked": "130.192.91.211"})
+		m.addTestKeys({"counter": 1})
+		m.addTestKeys({"counter": 2})
+		m.save()
+		return m.submit()
+	}
+`
+
+func TestVMMeasurement(t *testing.T) {
+	t.Run("we can save and submit measurements", func(t *testing.T) {
+		scriptPath := mustWriteScript(t, measurementScript)
+		var saved, submitted *model.Measurement
+		config := newTestVMConfig(scriptPath, VMLimits{})
+		config.TimeSource = func() time.Time { return time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC) }
+		config.Saver = &mocks.Saver{
+			MockSaveMeasurement: func(m *model.Measurement) error {
+				saved = m
+				return nil
+			},
+		}
+		config.Session = testSession{}
+		config.Submitter = &mocks.Submitter{
+			MockSubmit: func(ctx context.Context, m *model.Measurement) error {
+				submitted = m
+				m.ReportID = "20240101T000000Z_jsexample_IT_30722_n1_abcdef"
+				return nil
+			},
+		}
+
+		vm, err := LoadExperiment(config, scriptPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		reportID, err := vm.Run("https://www.example.com/")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if reportID != "20240101T000000Z_jsexample_IT_30722_n1_abcdef" {
+			t.Fatal("unexpected report ID", reportID)
+		}
+		if saved == nil || saved != submitted {
+			t.Fatal("expected to save and submit the same measurement")
+		}
+
+		if saved.TestName != "js_example" || saved.TestVersion != "0.1.0" {
+			t.Fatal("unexpected test name or version")
+		}
+		if saved.Input != "https://www.example.com/" {
+			t.Fatal("unexpected input", saved.Input)
+		}
+		if saved.ProbeASN != "AS30722" || saved.ProbeCC != "IT" || saved.ProbeIP != model.DefaultProbeIP {
+			t.Fatal("unexpected probe location")
+		}
+		if saved.TestStartTime != "2024-01-01 00:00:00" || saved.MeasurementStartTime != "2024-01-01 00:00:00" {
+			t.Fatal("unexpected start times")
+		}
+		if saved.Annotations["x_javascript"] != "true" {
+			t.Fatal("missing x_javascript annotation")
+		}
+
+		data, err := json.Marshal(saved.TestKeys)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var testKeys map[string]any
+		if err := json.Unmarshal(data, &testKeys); err != nil {
+			t.Fatal(err)
+		}
+		expectTestKeys := map[string]any{
+			"counter":  float64(2),
+			"leaked":   model.Scrubbed,
+			"probe_cc": "IT",
+		}
+		if diff := cmp.Diff(expectTestKeys, testKeys); diff != "" {
+			t.Fatal(diff)
+		}
+	})
+
+	t.Run("we fail without saver and submitter", func(t *testing.T) {
+		scriptPath := mustWriteScript(t, measurementScript)
+		vm, err := LoadExperiment(newTestVMConfig(scriptPath, VMLimits{}), scriptPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := vm.Run(""); err == nil || !errors.Is(err, ErrVMNoSaver) {
+			t.Fatal("unexpected error", err)
+		}
+	})
+
+	t.Run("interrupting the VM interrupts submitting", func(t *testing.T) {
+		scriptPath := mustWriteScript(t, measurementScript)
+		config := newTestVMConfig(scriptPath, VMLimits{})
+		config.Saver = &mocks.Saver{
+			MockSaveMeasurement: func(m *model.Measurement) error {
+				return nil
+			},
+		}
+		var vm *VM
+		config.Submitter = &mocks.Submitter{
+			MockSubmit: func(ctx context.Context, m *model.Measurement) error {
+				vm.Interrupt()
+				<-ctx.Done()
+				return ctx.Err()
+			},
+		}
+
+		var err error
+		vm, err = LoadExperiment(config, scriptPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := vm.Run(""); !errors.Is(err, context.Canceled) {
+			t.Fatal("unexpected error", err)
+		}
+	})
+
+	t.Run("we reject measurements without test name", func(t *testing.T) {
+		scriptPath := mustWriteScript(t, `
+			const ooni = require("_ooni")
+			ooni.newMeasurement({"test_version": "0.1.0"})
+		`)
+		err := RunScript(newTestVMConfig(scriptPath, VMLimits{}), scriptPath)
+		if !errors.Is(err, errVMMeasurementConfig) {
+			t.Fatal("unexpected error", err)
+		}
+	})
+
+	t.Run("without a session we use the default location", func(t *testing.T) {
+		scriptPath := mustWriteScript(t, `
+			const ooni = require("_ooni")
+			exports.run = function(input) {
+				const loc = ooni.probeLocation()
+				return loc.probe_asn + "/" + loc.probe_cc
+			}
+		`)
+		vm, err := LoadExperiment(newTestVMConfig(scriptPath, VMLimits{}), scriptPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		value, err := vm.Run("")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if value != "AS0/ZZ" {
+			t.Fatal("unexpected value", value)
+		}
+	})
+}
@@ -1,7 +1,6 @@
 package dsljavascript
 
 import (
-	"context"
 	"encoding/json"
 	"time"
 
@@ -15,6 +14,8 @@ import (
 func (vm *VM) newModuleOONI(gojaVM *goja.Runtime, mod *goja.Object) {
 	runtimex.Assert(vm.vm == gojaVM, "dsljavascript: unexpected gojaVM pointer value")
 	exports := mod.Get("exports").(*goja.Object)
+	runtimex.Try0(exports.Set("newMeasurement", vm.ooniNewMeasurement))
+	runtimex.Try0(exports.Set("probeLocation", vm.ooniProbeLocation))
 	runtimex.Try0(exports.Set("runDSL", vm.ooniRunDSL))
 }
 
@@ -31,8 +32,13 @@ func (vm *VM) ooniRunDSL(jsAST *goja.Object, zeroTime time.Time) (string, error)
 		return "", err
 	}
 
-	// create a background context for now but ideally we should allow to interrupt
-	ctx := context.Background()
+	// make sure the DSL only uses the allowed hosts
+	if err := vmCheckAllowedHosts(vm.limits.AllowedHosts, &root); err != nil {
+		return "", err
+	}
+
+	// use the VM context such that [*VM.Interrupt] interrupts the DSL
+	ctx := vm.ctx
 
 	// create a runtime for executing the DSL
 	// TODO(bassosimone): maybe we should configure the parallelism?
@@ -40,8 +46,13 @@ func (vm *VM) ooniRunDSL(jsAST *goja.Object, zeroTime time.Time) (string, error)
 		vm.logger, zeroTime,
 		dslengine.OptionMaxActiveDNSLookups(4),
 		dslengine.OptionMaxActiveConns(16),
+		dslengine.OptionMeasuringNetwork(vm.netx),
 	)
 
+	// the time spent running the DSL does not count as JavaScript execution time
+	vm.watchdog.pause()
+	defer vm.watchdog.resume()
+
 	// interpret the JSON representation of the DSL
 	if err := dsljson.Run(ctx, rtx, &root); err != nil {
 		return "", err
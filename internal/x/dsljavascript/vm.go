@@ -1,15 +1,19 @@
 package dsljavascript
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sync/atomic"
+	"time"
 
 	"github.com/dop251/goja"
 	"github.com/dop251/goja_nodejs/require"
 	"github.com/dop251/goja_nodejs/util"
 	"github.com/ooni/probe-cli/v3/internal/model"
+	"github.com/ooni/probe-cli/v3/internal/netxlite"
 	"github.com/ooni/probe-cli/v3/internal/runtimex"
 )
 
@@ -18,8 +22,29 @@ type VMConfig struct {
 	// Logger is the MANDATORY logger to use.
 	Logger model.Logger
 
+	// Limits contains the OPTIONAL resource limits to enforce.
+	Limits VMLimits
+
+	// RandSource is the OPTIONAL source of random numbers for Math.random,
+	// which allows for deterministic execution of scripts.
+	RandSource goja.RandSource
+
+	// Saver is the OPTIONAL [model.Saver] that scripts use to save measurements.
+	Saver model.Saver
+
 	// ScriptBaseDir is the MANDATORY script base dir to use.
 	ScriptBaseDir string
+
+	// Session is the OPTIONAL session providing the probe location. When not
+	// set, the measurements created by scripts use the default location.
+	Session VMSession
+
+	// Submitter is the OPTIONAL [model.Submitter] that scripts use to submit measurements.
+	Submitter model.Submitter
+
+	// TimeSource is the OPTIONAL source of time for Date and _golang.timeNow,
+	// which allows for deterministic execution of scripts.
+	TimeSource goja.Now
 }
 
 // errVMConfig indicates that some setting in the [*VMConfig] is invalid.
@@ -35,26 +60,60 @@ func (cfg *VMConfig) check() error {
 		return fmt.Errorf("%w: the ScriptBaseDir field is empty", errVMConfig)
 	}
 
+	if cfg.Limits.MaxExecutionTime < 0 || cfg.Limits.MaxNetworkOperations < 0 {
+		return fmt.Errorf("%w: the Limits field contains negative values", errVMConfig)
+	}
+
 	return nil
 }
 
 // VM wraps the [*github.com/dop251/goja.Runtime]. The zero value of this
 // struct is invalid; please, use [NewVM] to construct.
 type VM struct {
+	// cancel cancels ctx.
+	cancel context.CancelFunc
+
+	// ctx is the context for network operations, which [*VM.Interrupt] cancels.
+	ctx context.Context
+
+	// limits contains the resource limits.
+	limits VMLimits
+
 	// logger is the logger to use.
 	logger model.Logger
 
+	// netx is the measuring network to use for running the DSL.
+	netx model.MeasuringNetwork
+
 	// registry is the JavaScript package registry to use.
 	registry *require.Registry
 
+	// saver is the OPTIONAL saver for measurements.
+	saver model.Saver
+
 	// scriptBaseDir is the base directory containing scripts.
 	scriptBaseDir string
 
+	// session provides the probe location.
+	session VMSession
+
+	// submitter is the OPTIONAL submitter for measurements.
+	submitter model.Submitter
+
+	// testStartTime is when we created the VM.
+	testStartTime time.Time
+
+	// timeNow returns the current time.
+	timeNow func() time.Time
+
 	// util is a reference to goja's util model.
 	util *goja.Object
 
 	// vm is a reference to goja's runtime.
 	vm *goja.Runtime
+
+	// watchdog enforces the time and heap limits.
+	watchdog *vmWatchdog
 }
 
 // RunScript runs the given script using a transient VM.
@@ -91,14 +150,54 @@ func NewVM(config *VMConfig, scriptPath string) (*VM, error) {
 	// enable 'require' for the virtual machine
 	registry.Enable(gojaVM)
 
+	// make execution deterministic when requested
+	timeNow := time.Now
+	if config.TimeSource != nil {
+		gojaVM.SetTimeSource(config.TimeSource)
+		timeNow = config.TimeSource
+	}
+	if config.RandSource != nil {
+		gojaVM.SetRandSource(config.RandSource)
+	}
+
+	// use the default location when we don't have a session
+	var session VMSession = vmDefaultSession{}
+	if config.Session != nil {
+		session = config.Session
+	}
+
+	// possibly limit the number of network operations
+	var netx model.MeasuringNetwork = &netxlite.Netx{Underlying: nil} // implies using the host's network
+	if config.Limits.MaxNetworkOperations > 0 {
+		netx = &vmLimitedNetwork{
+			MeasuringNetwork: netx,
+			count:            &atomic.Int64{},
+			max:              config.Limits.MaxNetworkOperations,
+		}
+	}
+
+	// create the context for network operations
+	ctx, cancel := context.WithCancel(context.Background())
+
 	// create the virtual machine wrapper
 	vm := &VM{
+		cancel:        cancel,
+		ctx:           ctx,
+		limits:        config.Limits,
 		logger:        config.Logger,
+		netx:          netx,
 		registry:      registry,
+		saver:         config.Saver,
 		scriptBaseDir: scriptBaseDir,
+		session:       session,
+		submitter:     config.Submitter,
+		testStartTime: timeNow(),
+		timeNow:       timeNow,
 		util:          require.Require(gojaVM, util.ModuleName).(*goja.Object),
 		vm:            gojaVM,
+		watchdog:      nil, // set below
 	}
+	vm.watchdog = &vmWatchdog{limits: &vm.limits, vm: gojaVM}
 
 	// register the console module in JavaScript
 	registry.RegisterNativeModule("console", vm.newModuleConsole)
@@ -135,6 +234,13 @@ func LoadExperiment(config *VMConfig, exPath string) (*VM, error) {
 	return vm, nil
 }
 
+// Interrupt interrupts the network operations started by scripts, such as
+// running the DSL or submitting measurements. After this method has been
+// called, any subsequent network operation fails immediately.
+func (vm *VM) Interrupt() {
+	vm.cancel()
+}
+
 func (vm *VM) RunScript(exPath string) error {
 	// read the file content
 	content, err := os.ReadFile(exPath) // #nosec G304 - this is working as intended
@@ -143,8 +249,10 @@ func (vm *VM) RunScript(exPath string) error {
 	}
 
 	// interpret the script defining the experiment
+	leave := vm.watchdog.enter()
+	defer leave()
 	if _, err = vm.vm.RunScript(exPath, string(content)); err != nil {
-		return err
+		return vmMaybeUnwrapInterrupt(err)
 	}
 
 	return nil
@@ -183,7 +291,10 @@ func (vm *VM) ExperimentName() (string, error) {
 	if err := vm.vm.ExportTo(value, &experimentName); err != nil {
 		return "", err
 	}
-	return experimentName()
+	leave := vm.watchdog.enter()
+	defer leave()
+	name, err := experimentName()
+	return name, vmMaybeUnwrapInterrupt(err)
 }
 
 // ExperimentVersion returns the experiment version. Invoking this method
@@ -197,7 +308,10 @@ func (vm *VM) ExperimentVersion() (string, error) {
 	if err := vm.vm.ExportTo(value, &experimentVersion); err != nil {
 		return "", err
 	}
-	return experimentVersion()
+	leave := vm.watchdog.enter()
+	defer leave()
+	version, err := experimentVersion()
+	return version, vmMaybeUnwrapInterrupt(err)
 }
 
 // Run performs a measurement and returns the test keys.
@@ -210,5 +324,8 @@ func (vm *VM) Run(input string) (string, error) {
 	if err := vm.vm.ExportTo(value, &run); err != nil {
 		return "", err
 	}
-	return run(input)
+	leave := vm.watchdog.enter()
+	defer leave()
+	tks, err := run(input)
+	return tks, vmMaybeUnwrapInterrupt(err)
 }
@@ -0,0 +1,40 @@
+package dsljson
+
+import (
+	"encoding/json"
+
+	"github.com/ooni/probe-cli/v3/internal/x/dslvm"
+)
+
+type dnsLookupHTTPSValue struct {
+	Domain string   `json:"domain"`
+	Output string   `json:"output"`
+	URL    string   `json:"url"`
+	Tags   []string `json:"tags"`
+}
+
+func (lx *loader) onDNSLookupHTTPS(raw json.RawMessage) error {
+	// parse the raw value
+	var value dnsLookupHTTPSValue
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return err
+	}
+
+	// create the required output registers
+	output, err := registerMakeOutput[string](lx, value.Output)
+	if err != nil {
+		return err
+	}
+
+	// instantiate the stage
+	sx := &dslvm.DNSLookupHTTPSStage{
+		Domain: value.Domain,
+		Output: output,
+		URL:    value.URL,
+		Tags:   value.Tags,
+	}
+
+	// remember the stage for later
+	lx.stages = append(lx.stages, sx)
+	return nil
+}
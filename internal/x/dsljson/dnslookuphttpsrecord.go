@@ -0,0 +1,50 @@
+package dsljson
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/ooni/probe-cli/v3/internal/x/dslvm"
+)
+
+type dnsLookupHTTPSRecordValue struct {
+	Domain   string   `json:"domain"`
+	Network  string   `json:"network"`
+	Output   string   `json:"output"`
+	Resolver string   `json:"resolver"`
+	Tags     []string `json:"tags"`
+}
+
+func (lx *loader) onDNSLookupHTTPSRecord(raw json.RawMessage) error {
+	// parse the raw value
+	var value dnsLookupHTTPSRecordValue
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return err
+	}
+
+	// make sure the network is one of the supported ones
+	switch value.Network {
+	case "udp", "tcp", "dot", "doh":
+	default:
+		return fmt.Errorf("dns_lookup_https_record: unsupported network: %s", value.Network)
+	}
+
+	// create the required output registers
+	output, err := registerMakeOutput[string](lx, value.Output)
+	if err != nil {
+		return err
+	}
+
+	// instantiate the stage
+	sx := &dslvm.DNSLookupHTTPSRecordStage{
+		Domain:   value.Domain,
+		Network:  value.Network,
+		Output:   output,
+		Resolver: value.Resolver,
+		Tags:     value.Tags,
+	}
+
+	// remember the stage for later
+	lx.stages = append(lx.stages, sx)
+	return nil
+}
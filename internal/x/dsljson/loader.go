@@ -35,6 +35,10 @@ func newLoader() *loader {
 	}
 
 	lx.loaders["drop"] = lx.onDrop
+	lx.loaders["dns_lookup_https"] = lx.onDNSLookupHTTPS
+	lx.loaders["dns_lookup_https_record"] = lx.onDNSLookupHTTPSRecord
+	lx.loaders["dns_lookup_tcp"] = lx.onDNSLookupTCP
+	lx.loaders["dns_lookup_tls"] = lx.onDNSLookupTLS
 	lx.loaders["dns_lookup_udp"] = lx.onDNSLookupUDP
 	lx.loaders["dedup_addrs"] = lx.onDedupAddrs
 	lx.loaders["getaddrinfo"] = lx.onGetaddrinfo
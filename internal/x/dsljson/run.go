@@ -6,8 +6,12 @@ import (
 	"github.com/ooni/probe-cli/v3/internal/x/dslvm"
 )
 
-// Run runs the DSL represented by the given [*RootNode].
+// Run runs the DSL represented by the given [*RootNode]. Before running, we
+// statically check the DSL using [Validate] and return its error, if any.
 func Run(ctx context.Context, rtx dslvm.Runtime, root *RootNode) error {
+	if _, err := Validate(root); err != nil {
+		return err
+	}
 	lx := newLoader()
 	if err := lx.load(rtx.Logger(), root); err != nil {
 		return err
@@ -0,0 +1,544 @@
+package dsljson
+
+//
+// Static validation and execution planning
+//
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// RegisterType is the type of the values flowing through a register.
+type RegisterType string
+
+const (
+	// RegisterTypeString is the type of registers containing IP addresses or endpoints.
+	RegisterTypeString = RegisterType("string")
+
+	// RegisterTypeTCPConnection is the type of registers containing TCP connections.
+	RegisterTypeTCPConnection = RegisterType("*dslvm.TCPConnection")
+
+	// RegisterTypeTLSConnection is the type of registers containing TLS connections.
+	RegisterTypeTLSConnection = RegisterType("*dslvm.TLSConnection")
+
+	// RegisterTypeQUICConnection is the type of registers containing QUIC connections.
+	RegisterTypeQUICConnection = RegisterType("*dslvm.QUICConnection")
+
+	// RegisterTypeDone is the type of registers signalling that a stage is done.
+	RegisterTypeDone = RegisterType("dslvm.Done")
+)
+
+// PlanRegister is a register read or written by a [*PlanStep].
+type PlanRegister struct {
+	// Name is the register name.
+	Name string `json:"name"`
+
+	// Type is the register type.
+	Type RegisterType `json:"type"`
+}
+
+// PlanStep is a stage inside a [*Plan].
+type PlanStep struct {
+	// Automatic indicates that we automatically added this stage to drop
+	// a register that the DSL document does not consume.
+	Automatic bool `json:"automatic"`
+
+	// Index is the index of the stage inside the DSL document or -1
+	// for stages we have automatically added.
+	Index int `json:"index"`
+
+	// Inputs contains the registers read by the stage.
+	Inputs []PlanRegister `json:"inputs"`
+
+	// Level is the depth of the stage inside the dataflow graph. Stages
+	// without inputs have level zero. All stages run concurrently, but a
+	// stage cannot make progress until the previous levels emit values.
+	Level int `json:"level"`
+
+	// Name is the stage name (e.g., "tcp_connect").
+	Name string `json:"name"`
+
+	// Outputs contains the registers written by the stage.
+	Outputs []PlanRegister `json:"outputs"`
+}
+
+// Plan is the execution plan of a DSL document.
+type Plan struct {
+	// Steps contains the stages sorted by level and then by index.
+	Steps []*PlanStep `json:"steps"`
+
+	// Warnings contains issues that do not prevent running the DSL.
+	Warnings []string `json:"warnings"`
+}
+
+// String returns a human readable representation of the plan.
+func (p *Plan) String() string {
+	var sb strings.Builder
+	for _, step := range p.Steps {
+		index := fmt.Sprintf("#%d", step.Index)
+		if step.Automatic {
+			index = "auto"
+		}
+		fmt.Fprintf(&sb, "[level %d] %s %s(%s) -> (%s)\n", step.Level, index,
+			step.Name, planFormatRegisters(step.Inputs), planFormatRegisters(step.Outputs))
+	}
+	for _, warning := range p.Warnings {
+		fmt.Fprintf(&sb, "warning: %s\n", warning)
+	}
+	return sb.String()
+}
+
+// planFormatRegisters formats a list of registers for [*Plan.String].
+func planFormatRegisters(registers []PlanRegister) string {
+	var out []string
+	for _, reg := range registers {
+		out = append(out, fmt.Sprintf("%s: %s", reg.Name, reg.Type))
+	}
+	return strings.Join(out, ", ")
+}
+
+// ValidationError is the error returned by [Validate] when the DSL is not valid.
+type ValidationError struct {
+	// Problems contains all the problems we have found.
+	Problems []string
+}
+
+// Error implements error.
+func (err *ValidationError) Error() string {
+	return "dsljson: invalid DSL: " + strings.Join(err.Problems, "; ")
+}
+
+// Validate statically checks the DSL represented by the given [*RootNode] without
+// creating any stage. We check that instructions exist, that each register is written
+// and read once, that a register is written before being read, that the dataflow graph
+// has no cycles, and that the register types match. On success, we return the execution
+// plan. Otherwise, we return a [*ValidationError] listing all the problems.
+func Validate(root *RootNode) (*Plan, error) {
+	vx := &validator{
+		consumers: map[string]int{},
+		problems:  []string{},
+		producers: map[string]int{},
+		sigs:      []*stageSignature{},
+	}
+	vx.parse(root.Stages...)
+	vx.link()
+	order := vx.sort()
+	types := vx.typecheck(order)
+	if len(vx.problems) > 0 {
+		return nil, &ValidationError{Problems: vx.problems}
+	}
+	return vx.plan(order, types), nil
+}
+
+// stageSignature describes the registers used by a stage.
+type stageSignature struct {
+	// index is the stage index inside the DSL.
+	index int
+
+	// infer returns the output types given the input types.
+	infer func(inputs []RegisterType) ([]RegisterType, error)
+
+	// inputs contains the input registers names.
+	inputs []string
+
+	// name is the stage name.
+	name string
+
+	// outputs contains the output registers names.
+	outputs []string
+}
+
+// stageSignatureFunc parses a stage value and returns its signature.
+type stageSignatureFunc func(raw json.RawMessage) (*stageSignature, error)
+
+// stageSignatures maps each instruction to the function returning its signature.
+var stageSignatures = map[string]stageSignatureFunc{
+	"dedup_addrs": func(raw json.RawMessage) (*stageSignature, error) {
+		var value dedupAddrsValue
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return nil, err
+		}
+		return newStageSignature(value.Inputs, []string{value.Output},
+			signatureSameTypes(RegisterTypeString, []RegisterType{RegisterTypeString})), nil
+	},
+	"dns_lookup_https": func(raw json.RawMessage) (*stageSignature, error) {
+		var value dnsLookupHTTPSValue
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return nil, err
+		}
+		return newSourceSignature(value.Output), nil
+	},
+	"dns_lookup_https_record": func(raw json.RawMessage) (*stageSignature, error) {
+		var value dnsLookupHTTPSRecordValue
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return nil, err
+		}
+		return newSourceSignature(value.Output), nil
+	},
+	"dns_lookup_tcp": func(raw json.RawMessage) (*stageSignature, error) {
+		var value dnsLookupTCPValue
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return nil, err
+		}
+		return newSourceSignature(value.Output), nil
+	},
+	"dns_lookup_tls": func(raw json.RawMessage) (*stageSignature, error) {
+		var value dnsLookupTLSValue
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return nil, err
+		}
+		return newSourceSignature(value.Output), nil
+	},
+	"dns_lookup_udp": func(raw json.RawMessage) (*stageSignature, error) {
+		var value dnsLookupUDPValue
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return nil, err
+		}
+		return newSourceSignature(value.Output), nil
+	},
+	"drop": func(raw json.RawMessage) (*stageSignature, error) {
+		var value dropValue
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return nil, err
+		}
+		return newStageSignature([]string{value.Input}, []string{value.Output},
+			signatureOneOf([]RegisterType{RegisterTypeString, RegisterTypeTCPConnection,
+				RegisterTypeTLSConnection, RegisterTypeQUICConnection}, RegisterTypeDone)), nil
+	},
+	"getaddrinfo": func(raw json.RawMessage) (*stageSignature, error) {
+		var value getaddrinfoValue
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return nil, err
+		}
+		return newSourceSignature(value.Output), nil
+	},
+	"http_round_trip": func(raw json.RawMessage) (*stageSignature, error) {
+		var value httpRoundTripValue
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return nil, err
+		}
+		return newStageSignature([]string{value.Input}, []string{value.Output},
+			signatureOneOf([]RegisterType{RegisterTypeTCPConnection,
+				RegisterTypeTLSConnection, RegisterTypeQUICConnection}, RegisterTypeDone)), nil
+	},
+	"make_endpoints": func(raw json.RawMessage) (*stageSignature, error) {
+		var value makeEndpointsValue
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return nil, err
+		}
+		return newStageSignature([]string{value.Input}, []string{value.Output},
+			signatureSameTypes(RegisterTypeString, []RegisterType{RegisterTypeString})), nil
+	},
+	"quic_handshake": func(raw json.RawMessage) (*stageSignature, error) {
+		var value quicHandshakeValue
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return nil, err
+		}
+		return newStageSignature([]string{value.Input}, []string{value.Output},
+			signatureSameTypes(RegisterTypeString, []RegisterType{RegisterTypeQUICConnection})), nil
+	},
+	"take_n": func(raw json.RawMessage) (*stageSignature, error) {
+		var value takeNValue
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return nil, err
+		}
+		return newStageSignature([]string{value.Input}, []string{value.Output},
+			signatureIdentity([]RegisterType{RegisterTypeString, RegisterTypeTCPConnection,
+				RegisterTypeTLSConnection, RegisterTypeQUICConnection})), nil
+	},
+	"tcp_connect": func(raw json.RawMessage) (*stageSignature, error) {
+		var value tcpConnectValue
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return nil, err
+		}
+		return newStageSignature([]string{value.Input}, []string{value.Output},
+			signatureSameTypes(RegisterTypeString, []RegisterType{RegisterTypeTCPConnection})), nil
+	},
+	"tee_addrs": func(raw json.RawMessage) (*stageSignature, error) {
+		var value teeAddrsValue
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return nil, err
+		}
+		outputs := []RegisterType{}
+		for range value.Outputs {
+			outputs = append(outputs, RegisterTypeString)
+		}
+		return newStageSignature([]string{value.Input}, value.Outputs,
+			signatureSameTypes(RegisterTypeString, outputs)), nil
+	},
+	"tls_handshake": func(raw json.RawMessage) (*stageSignature, error) {
+		var value tlsHandshakeValue
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return nil, err
+		}
+		return newStageSignature([]string{value.Input}, []string{value.Output},
+			signatureSameTypes(RegisterTypeTCPConnection, []RegisterType{RegisterTypeTLSConnection})), nil
+	},
+}
+
+// newStageSignature creates a new [*stageSignature].
+func newStageSignature(inputs, outputs []string,
+	infer func(inputs []RegisterType) ([]RegisterType, error)) *stageSignature {
+	return &stageSignature{infer: infer, inputs: inputs, outputs: outputs}
+}
+
+// newSourceSignature returns the signature of a stage emitting IP addresses without inputs.
+func newSourceSignature(output string) *stageSignature {
+	return newStageSignature([]string{}, []string{output},
+		signatureSameTypes(RegisterTypeString, []RegisterType{RegisterTypeString}))
+}
+
+// signatureSameTypes returns a function requiring all inputs to have the given type.
+func signatureSameTypes(expect RegisterType, outputs []RegisterType) func([]RegisterType) ([]RegisterType, error) {
+	return func(inputs []RegisterType) ([]RegisterType, error) {
+		for _, input := range inputs {
+			if input != expect {
+				return nil, fmt.Errorf("expected %s, got %s", expect, input)
+			}
+		}
+		return outputs, nil
+	}
+}
+
+// signatureOneOf returns a function requiring the single input to have one of the given types.
+func signatureOneOf(allowed []RegisterType, output RegisterType) func([]RegisterType) ([]RegisterType, error) {
+	return func(inputs []RegisterType) ([]RegisterType, error) {
+		if _, err := signatureIdentity(allowed)(inputs); err != nil {
+			return nil, err
+		}
+		return []RegisterType{output}, nil
+	}
+}
+
+// signatureIdentity returns a function emitting the type of the single input, which
+// must be one of the allowed types.
+func signatureIdentity(allowed []RegisterType) func([]RegisterType) ([]RegisterType, error) {
+	return func(inputs []RegisterType) ([]RegisterType, error) {
+		for _, candidate := range allowed {
+			if len(inputs) == 1 && inputs[0] == candidate {
+				return []RegisterType{candidate}, nil
+			}
+		}
+		return nil, fmt.Errorf("expected one of %v, got %v", allowed, inputs)
+	}
+}
+
+// validator implements [Validate].
+type validator struct {
+	// consumers maps a register name to the index of the stage reading it.
+	consumers map[string]int
+
+	// problems contains the problems we found.
+	problems []string
+
+	// producers maps a register name to the index of the stage writing it.
+	producers map[string]int
+
+	// sigs contains the signature of each valid stage.
+	sigs []*stageSignature
+}
+
+// addProblem records a problem.
+func (vx *validator) addProblem(format string, v ...any) {
+	vx.problems = append(vx.problems, fmt.Sprintf(format, v...))
+}
+
+// parse computes the signature of each stage.
+func (vx *validator) parse(stages ...StageNode) {
+	for idx, entry := range stages {
+		fx, good := stageSignatures[entry.Name]
+		if !good {
+			vx.addProblem("stage #%d: unknown instruction: %s", idx, entry.Name)
+			continue
+		}
+		sig, err := fx(entry.Value)
+		if err != nil {
+			vx.addProblem("stage #%d: %s: %s", idx, entry.Name, err.Error())
+			continue
+		}
+		sig.index, sig.name = idx, entry.Name
+		vx.sigs = append(vx.sigs, sig)
+	}
+}
+
+// link maps registers to their producers and consumers.
+func (vx *validator) link() {
+	for _, sig := range vx.sigs {
+		for _, name := range sig.outputs {
+			if prev, found := vx.producers[name]; found {
+				vx.addProblem("stage #%d: register already written by stage #%d: %s", sig.index, prev, name)
+				continue
+			}
+			vx.producers[name] = sig.index
+		}
+	}
+	for _, sig := range vx.sigs {
+		for _, name := range sig.inputs {
+			if prev, found := vx.consumers[name]; found {
+				vx.addProblem("stage #%d: register already read by stage #%d: %s", sig.index, prev, name)
+				continue
+			}
+			vx.consumers[name] = sig.index
+			producer, found := vx.producers[name]
+			switch {
+			case !found:
+				vx.addProblem("stage #%d: register does not exist: %s", sig.index, name)
+			case producer == sig.index:
+				// we'll report this as a cycle
+			case producer > sig.index:
+				vx.addProblem("stage #%d: register read before stage #%d writes it: %s", sig.index, producer, name)
+			}
+		}
+	}
+}
+
+// sort returns the stages in topological order and reports cycles.
+func (vx *validator) sort() (order []*stageSignature) {
+	// count the inputs of each stage that depend on another stage, ignoring the
+	// inputs for which link has already reported a problem
+	pending := map[int]int{}
+	byIndex := map[int]*stageSignature{}
+	for _, sig := range vx.sigs {
+		byIndex[sig.index] = sig
+		for _, name := range sig.inputs {
+			_, found := vx.producers[name]
+			if found && vx.consumers[name] == sig.index {
+				pending[sig.index]++
+			}
+		}
+	}
+
+	// repeatedly remove the stages without pending inputs (Kahn's algorithm)
+	var ready []*stageSignature
+	for _, sig := range vx.sigs {
+		if pending[sig.index] == 0 {
+			ready = append(ready, sig)
+		}
+	}
+	for len(ready) > 0 {
+		sig := ready[0]
+		ready = ready[1:]
+		order = append(order, sig)
+		for _, name := range sig.outputs {
+			consumer, found := vx.consumers[name]
+			if !found || vx.producers[name] != sig.index {
+				continue
+			}
+			if pending[consumer]--; pending[consumer] == 0 {
+				ready = append(ready, byIndex[consumer])
+			}
+		}
+	}
+
+	// the stages we could not sort are part of or depend on a cycle
+	if len(order) < len(vx.sigs) {
+		var stuck []string
+		for _, sig := range vx.sigs {
+			if pending[sig.index] > 0 {
+				stuck = append(stuck, fmt.Sprintf("#%d", sig.index))
+			}
+		}
+		vx.addProblem("cycle involving stages: %s", strings.Join(stuck, ", "))
+	}
+	return
+}
+
+// typecheck infers the type of each register and reports mismatches.
+func (vx *validator) typecheck(order []*stageSignature) map[string]RegisterType {
+	types := map[string]RegisterType{}
+	for _, sig := range order {
+		var inputs []RegisterType
+		known := true
+		for _, name := range sig.inputs {
+			t, found := types[name]
+			if !found {
+				known = false // we have already reported the problem
+				break
+			}
+			inputs = append(inputs, t)
+		}
+		if !known {
+			continue
+		}
+		outputs, err := sig.infer(inputs)
+		if err != nil {
+			vx.addProblem("stage #%d: %s: invalid input type: %s", sig.index, sig.name, err.Error())
+			continue
+		}
+		for idx, name := range sig.outputs {
+			if vx.producers[name] == sig.index {
+				types[name] = outputs[idx]
+			}
+		}
+	}
+	return types
+}
+
+// plan creates the execution plan.
+func (vx *validator) plan(order []*stageSignature, types map[string]RegisterType) *Plan {
+	plan := &Plan{
+		Steps:    []*PlanStep{},
+		Warnings: []string{},
+	}
+
+	// create a step for each stage and compute its level
+	levels := map[string]int{}
+	for _, sig := range order {
+		step := &PlanStep{
+			Automatic: false,
+			Index:     sig.index,
+			Inputs:    []PlanRegister{},
+			Level:     0,
+			Name:      sig.name,
+			Outputs:   []PlanRegister{},
+		}
+		for _, name := range sig.inputs {
+			step.Inputs = append(step.Inputs, PlanRegister{Name: name, Type: types[name]})
+			if level := levels[name] + 1; level > step.Level {
+				step.Level = level
+			}
+		}
+		for _, name := range sig.outputs {
+			step.Outputs = append(step.Outputs, PlanRegister{Name: name, Type: types[name]})
+			levels[name] = step.Level
+		}
+		plan.Steps = append(plan.Steps, step)
+	}
+
+	// like the loader does, automatically drop the registers nobody reads
+	var unused []string
+	for name := range vx.producers {
+		if _, found := vx.consumers[name]; !found && types[name] != RegisterTypeDone {
+			unused = append(unused, name)
+		}
+	}
+	sort.Strings(unused)
+	for _, name := range unused {
+		plan.Warnings = append(plan.Warnings, fmt.Sprintf(
+			"register %s with type %s is not dropped: adding automatic drop", name, types[name]))
+		plan.Steps = append(plan.Steps, &PlanStep{
+			Automatic: true,
+			Index:     -1,
+			Inputs:    []PlanRegister{{Name: name, Type: types[name]}},
+			Level:     levels[name] + 1,
+			Name:      "drop",
+			Outputs:   []PlanRegister{{Name: name + "__autodrop", Type: RegisterTypeDone}},
+		})
+	}
+
+	// sort by level and then by index, keeping automatic drops last within a level
+	sort.SliceStable(plan.Steps, func(i, j int) bool {
+		left, right := plan.Steps[i], plan.Steps[j]
+		if left.Level != right.Level {
+			return left.Level < right.Level
+		}
+		if left.Automatic != right.Automatic {
+			return right.Automatic
+		}
+		return left.Index < right.Index
+	})
+	return plan
+}
@@ -0,0 +1,197 @@
+package dsljson
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/ooni/probe-cli/v3/internal/model"
+	"github.com/ooni/probe-cli/v3/internal/runtimex"
+)
+
+// mustParseRootNode parses a JSON document into a *RootNode.
+func mustParseRootNode(document string) *RootNode {
+	var root RootNode
+	runtimex.Try0(json.Unmarshal([]byte(document), &root))
+	return &root
+}
+
+func TestValidate(t *testing.T) {
+	type testcase struct {
+		// name is the name of the test case
+		name string
+
+		// document is the DSL document
+		document string
+
+		// expectProblems contains the expected problems or nil
+		expectProblems []string
+
+		// expectPlan is the expected plan formatted as a string
+		expectPlan string
+	}
+
+	cases := []testcase{{
+		name: "with a valid document",
+		document: `{"stages": [
+			{"name": "getaddrinfo", "value": {"domain": "www.example.com", "output": "a"}},
+			{"name": "dns_lookup_https", "value": {"domain": "www.example.com", "output": "b", "url": "https://dns.google/dns-query"}},
+			{"name": "dedup_addrs", "value": {"inputs": ["a", "b"], "output": "c"}},
+			{"name": "make_endpoints", "value": {"input": "c", "output": "d", "port": "443"}},
+			{"name": "tcp_connect", "value": {"input": "d", "output": "e"}},
+			{"name": "tls_handshake", "value": {"input": "e", "output": "f"}},
+			{"name": "take_n", "value": {"input": "f", "n": 1, "output": "g"}},
+			{"name": "http_round_trip", "value": {"input": "g", "output": "h"}}
+		]}`,
+		expectProblems: nil,
+		expectPlan: strings.Join([]string{
+			"[level 0] #0 getaddrinfo() -> (a: string)",
+			"[level 0] #1 dns_lookup_https() -> (b: string)",
+			"[level 1] #2 dedup_addrs(a: string, b: string) -> (c: string)",
+			"[level 2] #3 make_endpoints(c: string) -> (d: string)",
+			"[level 3] #4 tcp_connect(d: string) -> (e: *dslvm.TCPConnection)",
+			"[level 4] #5 tls_handshake(e: *dslvm.TCPConnection) -> (f: *dslvm.TLSConnection)",
+			"[level 5] #6 take_n(f: *dslvm.TLSConnection) -> (g: *dslvm.TLSConnection)",
+			"[level 6] #7 http_round_trip(g: *dslvm.TLSConnection) -> (h: dslvm.Done)",
+			"",
+		}, "\n"),
+	}, {
+		name: "with registers that are not consumed",
+		document: `{"stages": [
+			{"name": "getaddrinfo", "value": {"domain": "www.example.com", "output": "a"}},
+			{"name": "tee_addrs", "value": {"input": "a", "outputs": ["b", "c"]}},
+			{"name": "drop", "value": {"input": "b", "output": "d"}}
+		]}`,
+		expectProblems: nil,
+		expectPlan: strings.Join([]string{
+			"[level 0] #0 getaddrinfo() -> (a: string)",
+			"[level 1] #1 tee_addrs(a: string) -> (b: string, c: string)",
+			"[level 2] #2 drop(b: string) -> (d: dslvm.Done)",
+			"[level 2] auto drop(c: string) -> (c__autodrop: dslvm.Done)",
+			"warning: register c with type string is not dropped: adding automatic drop",
+			"",
+		}, "\n"),
+	}, {
+		name: "with unknown instruction and invalid JSON",
+		document: `{"stages": [
+			{"name": "nonexistent", "value": {}},
+			{"name": "getaddrinfo", "value": []}
+		]}`,
+		expectProblems: []string{
+			"stage #0: unknown instruction: nonexistent",
+			"stage #1: getaddrinfo: json: cannot unmarshal array into Go value of type dsljson.getaddrinfoValue",
+		},
+	}, {
+		name: "with registers written or read more than once",
+		document: `{"stages": [
+			{"name": "getaddrinfo", "value": {"domain": "www.example.com", "output": "a"}},
+			{"name": "getaddrinfo", "value": {"domain": "www.example.org", "output": "a"}},
+			{"name": "drop", "value": {"input": "a", "output": "b"}},
+			{"name": "drop", "value": {"input": "a", "output": "c"}}
+		]}`,
+		expectProblems: []string{
+			"stage #1: register already written by stage #0: a",
+			"stage #3: register already read by stage #2: a",
+		},
+	}, {
+		name: "with missing registers and registers read before being written",
+		document: `{"stages": [
+			{"name": "make_endpoints", "value": {"input": "a", "output": "b", "port": "443"}},
+			{"name": "getaddrinfo", "value": {"domain": "www.example.com", "output": "a"}},
+			{"name": "drop", "value": {"input": "c", "output": "d"}}
+		]}`,
+		expectProblems: []string{
+			"stage #0: register read before stage #1 writes it: a",
+			"stage #2: register does not exist: c",
+		},
+	}, {
+		name: "with a cycle",
+		document: `{"stages": [
+			{"name": "dedup_addrs", "value": {"inputs": ["b"], "output": "a"}},
+			{"name": "make_endpoints", "value": {"input": "a", "output": "b", "port": "443"}}
+		]}`,
+		expectProblems: []string{
+			"stage #0: register read before stage #1 writes it: b",
+			"cycle involving stages: #0, #1",
+		},
+	}, {
+		name: "with invalid types",
+		document: `{"stages": [
+			{"name": "getaddrinfo", "value": {"domain": "www.example.com", "output": "a"}},
+			{"name": "tls_handshake", "value": {"input": "a", "output": "b"}},
+			{"name": "http_round_trip", "value": {"input": "b", "output": "c"}},
+			{"name": "getaddrinfo", "value": {"domain": "www.example.com", "output": "d"}},
+			{"name": "http_round_trip", "value": {"input": "d", "output": "e"}}
+		]}`,
+		expectProblems: []string{
+			"stage #1: tls_handshake: invalid input type: expected *dslvm.TCPConnection, got string",
+			"stage #4: http_round_trip: invalid input type: expected one of " +
+				"[*dslvm.TCPConnection *dslvm.TLSConnection *dslvm.QUICConnection], got [string]",
+		},
+	}}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			plan, err := Validate(mustParseRootNode(tc.document))
+
+			if tc.expectProblems != nil {
+				var verr *ValidationError
+				if !errors.As(err, &verr) {
+					t.Fatal("expected a *ValidationError, got", err)
+				}
+				if diff := cmp.Diff(tc.expectProblems, verr.Problems); diff != "" {
+					t.Fatal(diff)
+				}
+				if plan != nil {
+					t.Fatal("expected nil plan")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.expectPlan, plan.String()); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
+func TestValidationError(t *testing.T) {
+	err := &ValidationError{Problems: []string{"a", "b"}}
+	if err.Error() != "dsljson: invalid DSL: a; b" {
+		t.Fatal("unexpected error string", err.Error())
+	}
+}
+
+func TestValidateAgreesWithLoader(t *testing.T) {
+	// make sure the validator knows about all the instructions
+	lx := newLoader()
+	for name := range lx.loaders {
+		if _, found := stageSignatures[name]; !found {
+			t.Fatal("validator does not know about", name)
+		}
+	}
+	for name := range stageSignatures {
+		if _, found := lx.loaders[name]; !found {
+			t.Fatal("loader does not know about", name)
+		}
+	}
+
+	// make sure that a document passing validation also loads
+	root := mustParseRootNode(`{"stages": [
+		{"name": "getaddrinfo", "value": {"domain": "www.example.com", "output": "a"}},
+		{"name": "make_endpoints", "value": {"input": "a", "output": "b", "port": "443"}},
+		{"name": "quic_handshake", "value": {"input": "b", "output": "c"}},
+		{"name": "http_round_trip", "value": {"input": "c", "output": "d"}}
+	]}`)
+	if _, err := Validate(root); err != nil {
+		t.Fatal(err)
+	}
+	if err := lx.load(model.DiscardLogger, root); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,121 @@
+package dslvm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ooni/probe-cli/v3/internal/logx"
+	"github.com/ooni/probe-cli/v3/internal/model"
+)
+
+// ErrDNSLookupUnsupportedNetwork indicates that we don't support the given DNS network.
+var ErrDNSLookupUnsupportedNetwork = errors.New("dslvm: unsupported DNS network")
+
+// dnsLookupFunc is the type of the function performing the DNS lookup.
+type dnsLookupFunc func(ctx context.Context, reso model.Resolver, domain string) ([]string, error)
+
+// dnsLookupHost is the [dnsLookupFunc] resolving a domain to IP addresses.
+func dnsLookupHost(ctx context.Context, reso model.Resolver, domain string) ([]string, error) {
+	return reso.LookupHost(ctx, domain)
+}
+
+// dnsLookupHTTPSRecordHints is the [dnsLookupFunc] returning the IPv4 and IPv6
+// hints contained inside the HTTPS record of a domain.
+func dnsLookupHTTPSRecordHints(ctx context.Context, reso model.Resolver, domain string) ([]string, error) {
+	https, err := reso.LookupHTTPS(ctx, domain)
+	if err != nil {
+		return nil, err
+	}
+	return append(append([]string{}, https.IPv4...), https.IPv6...), nil
+}
+
+// dnsLookupWithTransport is the common implementation of the stages resolving a domain
+// using a DNS transport with the given network ("udp", "tcp", "dot", or "doh") and
+// endpoint (i.e., the resolver address or, when using DNS-over-HTTPS, its URL).
+//
+// This function streams the results on output, which is closed when we're done, and
+// honours the semaphore returned by the [Runtime] ActiveDNSLookups method.
+func dnsLookupWithTransport(ctx context.Context, rtx Runtime, operation string, lookup dnsLookupFunc,
+	network, endpoint, domain string, tags []string, output chan<- string) {
+	// wait for permission to lookup and signal when done
+	rtx.ActiveDNSLookups().Wait()
+	defer rtx.ActiveDNSLookups().Signal()
+
+	// make sure we close output when done
+	defer close(output)
+
+	// create trace
+	trace := rtx.NewTrace(rtx.IDGenerator().Add(1), rtx.ZeroTime(), tags...)
+
+	// start operation logger
+	ol := logx.NewOperationLogger(
+		rtx.Logger(),
+		"[#%d] %s[%s/%s] %s",
+		trace.Index(),
+		operation,
+		endpoint,
+		network,
+		domain,
+	)
+
+	// setup
+	ctx, cancel := context.WithTimeout(ctx, dnsLookupTimeout(network))
+	defer cancel()
+
+	// create the resolver
+	resolver, err := newDNSResolver(rtx, trace, network, endpoint)
+	if err != nil {
+		ol.Stop(err)
+		return
+	}
+
+	// lookup
+	addrs, err := lookup(ctx, resolver, domain)
+
+	// stop the operation logger
+	ol.Stop(err)
+
+	// save the observations
+	rtx.SaveObservations(maybeTraceToObservations(trace)...)
+
+	// handle error case
+	if err != nil {
+		return
+	}
+
+	// handle success
+	for _, addr := range addrs {
+		output <- addr
+	}
+}
+
+// newDNSResolver creates a trace-aware resolver for the given network and endpoint.
+func newDNSResolver(rtx Runtime, trace Trace, network, endpoint string) (model.Resolver, error) {
+	switch network {
+	case "udp":
+		return trace.NewParallelUDPResolver(
+			rtx.Logger(), trace.NewDialerWithoutResolver(rtx.Logger()), endpoint), nil
+	case "tcp":
+		return trace.NewParallelTCPResolver(
+			rtx.Logger(), trace.NewDialerWithoutResolver(rtx.Logger()), endpoint), nil
+	case "dot":
+		return trace.NewParallelDNSOverTLSResolver(rtx.Logger(), endpoint), nil
+	case "doh":
+		return trace.NewParallelDNSOverHTTPSResolver(rtx.Logger(), endpoint), nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrDNSLookupUnsupportedNetwork, network)
+	}
+}
+
+// dnsLookupTimeout returns the timeout for a DNS lookup using the given network.
+func dnsLookupTimeout(network string) time.Duration {
+	switch network {
+	case "udp":
+		return 4 * time.Second
+	default:
+		// TCP, DoT, and DoH lookups also need to establish a connection
+		return 10 * time.Second
+	}
+}
@@ -0,0 +1,32 @@
+package dslvm
+
+import (
+	"context"
+)
+
+// DNSLookupHTTPSStage is a [Stage] that resolves domain names using a DNS-over-HTTPS resolver.
+type DNSLookupHTTPSStage struct {
+	// Domain is the MANDATORY domain to resolve using this DNS resolver.
+	Domain string
+
+	// Output is the MANDATORY channel emitting IP addresses. We will close this
+	// channel when we have finished streaming the resolved addresses.
+	Output chan<- string
+
+	// URL is the MANDATORY resolver URL (e.g., https://dns.google/dns-query).
+	URL string
+
+	// Tags contains OPTIONAL tags for the DNS observations.
+	Tags []string
+}
+
+var _ Stage = &DNSLookupHTTPSStage{}
+
+// Run resolves a Domain using the given DNS-over-HTTPS URL and streams the
+// results on Output, which is closed when we're done.
+//
+// This function honours the semaphore returned by the [Runtime] ActiveDNSLookups
+// method and waits until it's given the permission to start a lookup.
+func (sx *DNSLookupHTTPSStage) Run(ctx context.Context, rtx Runtime) {
+	dnsLookupWithTransport(ctx, rtx, "DNSLookup", dnsLookupHost, "doh", sx.URL, sx.Domain, sx.Tags, sx.Output)
+}
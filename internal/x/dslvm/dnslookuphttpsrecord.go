@@ -0,0 +1,38 @@
+package dslvm
+
+import (
+	"context"
+)
+
+// DNSLookupHTTPSRecordStage is a [Stage] that looks up the HTTPS record of a
+// domain name and emits the IPv4 and IPv6 hints contained inside it.
+type DNSLookupHTTPSRecordStage struct {
+	// Domain is the MANDATORY domain whose HTTPS record we should lookup.
+	Domain string
+
+	// Network is the MANDATORY resolver network ("udp", "tcp", "dot", or "doh").
+	Network string
+
+	// Output is the MANDATORY channel emitting IP addresses. We will close this
+	// channel when we have finished streaming the resolved addresses.
+	Output chan<- string
+
+	// Resolver is the MANDATORY resolver endpoint (e.g., [::1]:53) or, when
+	// using DNS-over-HTTPS, the resolver URL (e.g., https://dns.google/dns-query).
+	Resolver string
+
+	// Tags contains OPTIONAL tags for the DNS observations.
+	Tags []string
+}
+
+var _ Stage = &DNSLookupHTTPSRecordStage{}
+
+// Run looks up the HTTPS record of Domain using the given Network and Resolver and
+// streams the IPv4 and IPv6 hints on Output, which is closed when we're done.
+//
+// This function honours the semaphore returned by the [Runtime] ActiveDNSLookups
+// method and waits until it's given the permission to start a lookup.
+func (sx *DNSLookupHTTPSRecordStage) Run(ctx context.Context, rtx Runtime) {
+	dnsLookupWithTransport(ctx, rtx, "DNSLookupHTTPSRecord", dnsLookupHTTPSRecordHints,
+		sx.Network, sx.Resolver, sx.Domain, sx.Tags, sx.Output)
+}
@@ -0,0 +1,32 @@
+package dslvm
+
+import (
+	"context"
+)
+
+// DNSLookupTLSStage is a [Stage] that resolves domain names using a DNS-over-TLS resolver.
+type DNSLookupTLSStage struct {
+	// Domain is the MANDATORY domain to resolve using this DNS resolver.
+	Domain string
+
+	// Output is the MANDATORY channel emitting IP addresses. We will close this
+	// channel when we have finished streaming the resolved addresses.
+	Output chan<- string
+
+	// Resolver is the MANDATORY resolver endpoint (e.g., 1.1.1.1:853).
+	Resolver string
+
+	// Tags contains OPTIONAL tags for the DNS observations.
+	Tags []string
+}
+
+var _ Stage = &DNSLookupTLSStage{}
+
+// Run resolves a Domain using the given DNS-over-TLS Resolver and streams the
+// results on Output, which is closed when we're done.
+//
+// This function honours the semaphore returned by the [Runtime] ActiveDNSLookups
+// method and waits until it's given the permission to start a lookup.
+func (sx *DNSLookupTLSStage) Run(ctx context.Context, rtx Runtime) {
+	dnsLookupWithTransport(ctx, rtx, "DNSLookup", dnsLookupHost, "dot", sx.Resolver, sx.Domain, sx.Tags, sx.Output)
+}
@@ -2,9 +2,6 @@ package dslvm
 
 import (
 	"context"
-	"time"
-
-	"github.com/ooni/probe-cli/v3/internal/logx"
 )
 
 // DNSLookupUDPStage is a [Stage] that resolves domain names using an UDP resolver.
@@ -31,53 +28,5 @@ var _ Stage = &DNSLookupUDPStage{}
 // This function honours the semaphore returned by the [Runtime] ActiveDNSLookups
 // method and waits until it's given the permission to start a lookup.
 func (sx *DNSLookupUDPStage) Run(ctx context.Context, rtx Runtime) {
-	// wait for permission to lookup and signal when done
-	rtx.ActiveDNSLookups().Wait()
-	defer rtx.ActiveDNSLookups().Signal()
-
-	// make sure we close output when done
-	defer close(sx.Output)
-
-	// create trace
-	trace := rtx.NewTrace(rtx.IDGenerator().Add(1), rtx.ZeroTime(), sx.Tags...)
-
-	// start operation logger
-	ol := logx.NewOperationLogger(
-		rtx.Logger(),
-		"[#%d] DNSLookup[%s/udp] %s",
-		trace.Index(),
-		sx.Resolver,
-		sx.Domain,
-	)
-
-	// setup
-	const timeout = 4 * time.Second
-	ctx, cancel := context.WithTimeout(ctx, timeout)
-	defer cancel()
-
-	// create the resolver
-	resolver := trace.NewParallelUDPResolver(
-		rtx.Logger(),
-		trace.NewDialerWithoutResolver(rtx.Logger()),
-		sx.Resolver,
-	)
-
-	// lookup
-	addrs, err := resolver.LookupHost(ctx, sx.Domain)
-
-	// stop the operation logger
-	ol.Stop(err)
-
-	// save the observations
-	rtx.SaveObservations(maybeTraceToObservations(trace)...)
-
-	// handle error case
-	if err != nil {
-		return
-	}
-
-	// handle success
-	for _, addr := range addrs {
-		sx.Output <- addr
-	}
+	dnsLookupWithTransport(ctx, rtx, "DNSLookup", dnsLookupHost, "udp", sx.Resolver, sx.Domain, sx.Tags, sx.Output)
 }
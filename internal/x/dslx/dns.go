@@ -7,9 +7,11 @@ package dslx
 import (
 	"context"
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/ooni/probe-cli/v3/internal/logx"
+	"github.com/ooni/probe-cli/v3/internal/model"
 )
 
 // DomainName is a domain name to resolve.
@@ -132,6 +134,30 @@ func DNSLookupGetaddrinfo(rt Runtime) Func[*DomainToResolve, *ResolvedAddresses]
 // DNSLookupUDP returns a function that resolves a domain name to
 // IP addresses using the given DNS-over-UDP resolver.
 func DNSLookupUDP(rt Runtime, endpoint string) Func[*DomainToResolve, *ResolvedAddresses] {
+	return dnsLookupWithTransport(rt, "udp", endpoint)
+}
+
+// DNSLookupTCP returns a function that resolves a domain name to
+// IP addresses using the given DNS-over-TCP resolver.
+func DNSLookupTCP(rt Runtime, endpoint string) Func[*DomainToResolve, *ResolvedAddresses] {
+	return dnsLookupWithTransport(rt, "tcp", endpoint)
+}
+
+// DNSLookupTLS returns a function that resolves a domain name to
+// IP addresses using the given DNS-over-TLS resolver.
+func DNSLookupTLS(rt Runtime, endpoint string) Func[*DomainToResolve, *ResolvedAddresses] {
+	return dnsLookupWithTransport(rt, "dot", endpoint)
+}
+
+// DNSLookupHTTPS returns a function that resolves a domain name to
+// IP addresses using the given DNS-over-HTTPS resolver URL.
+func DNSLookupHTTPS(rt Runtime, URL string) Func[*DomainToResolve, *ResolvedAddresses] {
+	return dnsLookupWithTransport(rt, "doh", URL)
+}
+
+// dnsLookupWithTransport is the common implementation of the DNS lookup functions
+// using a DNS transport with the given network and endpoint.
+func dnsLookupWithTransport(rt Runtime, network, endpoint string) Func[*DomainToResolve, *ResolvedAddresses] {
 	return Operation[*DomainToResolve, *ResolvedAddresses](func(ctx context.Context, input *DomainToResolve) (*ResolvedAddresses, error) {
 		// create trace
 		trace := rt.NewTrace(rt.IDGenerator().Add(1), rt.ZeroTime(), input.Tags...)
@@ -139,23 +165,23 @@ func DNSLookupUDP(rt Runtime, endpoint string) Func[*DomainToResolve, *ResolvedA
 		// start the operation logger
 		ol := logx.NewOperationLogger(
 			rt.Logger(),
-			"[#%d] DNSLookup[%s/udp] %s",
+			"[#%d] DNSLookup[%s/%s] %s",
 			trace.Index(),
 			endpoint,
+			network,
 			input.Domain,
 		)
 
 		// setup
-		const timeout = 4 * time.Second
-		ctx, cancel := context.WithTimeout(ctx, timeout)
+		ctx, cancel := context.WithTimeout(ctx, dnsLookupTimeout(network))
 		defer cancel()
 
 		// create the resolver
-		resolver := trace.NewParallelUDPResolver(
-			rt.Logger(),
-			trace.NewDialerWithoutResolver(rt.Logger()),
-			endpoint,
-		)
+		resolver, err := newDNSResolver(rt, trace, network, endpoint)
+		if err != nil {
+			ol.Stop(err)
+			return nil, err
+		}
 
 		// lookup
 		addrs, err := resolver.LookupHost(ctx, input.Domain)
@@ -179,6 +205,118 @@ func DNSLookupUDP(rt Runtime, endpoint string) Func[*DomainToResolve, *ResolvedA
 	})
 }
 
+// ErrDNSLookupUnsupportedNetwork indicates that we don't support the given DNS network.
+var ErrDNSLookupUnsupportedNetwork = errors.New("dslx: unsupported DNS network")
+
+// newDNSResolver creates a trace-aware resolver for the given network, which must be
+// one of "udp", "tcp", "dot", and "doh", and the given endpoint (i.e., the address of
+// the resolver or, when using DNS-over-HTTPS, its URL).
+func newDNSResolver(rt Runtime, trace Trace, network, endpoint string) (model.Resolver, error) {
+	switch network {
+	case "udp":
+		return trace.NewParallelUDPResolver(
+			rt.Logger(), trace.NewDialerWithoutResolver(rt.Logger()), endpoint), nil
+	case "tcp":
+		return trace.NewParallelTCPResolver(
+			rt.Logger(), trace.NewDialerWithoutResolver(rt.Logger()), endpoint), nil
+	case "dot":
+		return trace.NewParallelDNSOverTLSResolver(rt.Logger(), endpoint), nil
+	case "doh":
+		return trace.NewParallelDNSOverHTTPSResolver(rt.Logger(), endpoint), nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrDNSLookupUnsupportedNetwork, network)
+	}
+}
+
+// dnsLookupTimeout returns the timeout for a DNS lookup using the given network.
+func dnsLookupTimeout(network string) time.Duration {
+	switch network {
+	case "udp":
+		return 4 * time.Second
+	default:
+		// TCP, DoT, and DoH lookups also need to establish a connection
+		return 10 * time.Second
+	}
+}
+
+// ResolvedHTTPSRecord contains the results of an HTTPS record lookup. To initialize
+// this struct manually, follow specific instructions for each field.
+type ResolvedHTTPSRecord struct {
+	// ALPN contains the ALPNs advertised by the HTTPS record.
+	ALPN []string
+
+	// Addresses contains the IPv4 and IPv6 hints inside the HTTPS record.
+	Addresses []string
+
+	// Domain is the domain we resolved. We inherit this field
+	// from the value inside the DomainToResolve.
+	Domain string
+}
+
+// Flatten transforms a [ResolvedHTTPSRecord] into a slice of zero or more [ResolvedAddress]
+// using the IPv4 and IPv6 hints contained inside the HTTPS record.
+func (rr *ResolvedHTTPSRecord) Flatten() (out []*ResolvedAddress) {
+	for _, ipAddr := range rr.Addresses {
+		out = append(out, &ResolvedAddress{
+			Address: ipAddr,
+			Domain:  rr.Domain,
+		})
+	}
+	return
+}
+
+// DNSLookupHTTPSRecord returns a function that looks up the HTTPS record of a domain
+// name using the given network ("udp", "tcp", "dot", or "doh") and endpoint (i.e., the
+// address of the resolver or, when using DNS-over-HTTPS, its URL).
+func DNSLookupHTTPSRecord(rt Runtime, network, endpoint string) Func[*DomainToResolve, *ResolvedHTTPSRecord] {
+	return Operation[*DomainToResolve, *ResolvedHTTPSRecord](func(ctx context.Context, input *DomainToResolve) (*ResolvedHTTPSRecord, error) {
+		// create trace
+		trace := rt.NewTrace(rt.IDGenerator().Add(1), rt.ZeroTime(), input.Tags...)
+
+		// start the operation logger
+		ol := logx.NewOperationLogger(
+			rt.Logger(),
+			"[#%d] DNSLookupHTTPSRecord[%s/%s] %s",
+			trace.Index(),
+			endpoint,
+			network,
+			input.Domain,
+		)
+
+		// setup
+		ctx, cancel := context.WithTimeout(ctx, dnsLookupTimeout(network))
+		defer cancel()
+
+		// create the resolver
+		resolver, err := newDNSResolver(rt, trace, network, endpoint)
+		if err != nil {
+			ol.Stop(err)
+			return nil, err
+		}
+
+		// lookup
+		https, err := resolver.LookupHTTPS(ctx, input.Domain)
+
+		// save the observations
+		rt.SaveObservations(maybeTraceToObservations(trace)...)
+
+		// handle error case
+		if err != nil {
+			ol.Stop(err)
+			return nil, err
+		}
+
+		// handle success
+		state := &ResolvedHTTPSRecord{
+			ALPN:      https.ALPN,
+			Addresses: append(append([]string{}, https.IPv4...), https.IPv6...),
+			Domain:    input.Domain,
+		}
+		ol.Stop(state.Addresses)
+		return state, nil
+	})
+}
+
 // ErrDNSLookupParallel indicates that DNSLookupParallel failed.
 var ErrDNSLookupParallel = errors.New("dslx: DNSLookupParallel failed")
 
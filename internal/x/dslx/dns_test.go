@@ -208,3 +208,193 @@ func TestLookupUDP(t *testing.T) {
 		})
 	})
 }
+
+func TestDNSLookupWithTransport(t *testing.T) {
+	domain := &DomainToResolve{
+		Domain: "example.com",
+		Tags:   []string{"antani"},
+	}
+
+	// newRuntime creates a runtime where all the resolvers use the given mocked resolver
+	// and records in *network the network of the resolver that we created.
+	newRuntime := func(reso model.Resolver, network *string) Runtime {
+		return NewRuntimeMeasurexLite(model.DiscardLogger, time.Now(), RuntimeMeasurexLiteOptionMeasuringNetwork(&mocks.MeasuringNetwork{
+			MockNewDialerWithoutResolver: func(dl model.DebugLogger, w ...model.DialerWrapper) model.Dialer {
+				return &mocks.Dialer{}
+			},
+			MockNewParallelDNSOverHTTPSResolver: func(logger model.DebugLogger, URL string) model.Resolver {
+				*network = "doh"
+				return reso
+			},
+			MockNewParallelDNSOverTLSResolver: func(logger model.DebugLogger, address string) model.Resolver {
+				*network = "dot"
+				return reso
+			},
+			MockNewParallelTCPResolver: func(logger model.DebugLogger, dialer model.Dialer, address string) model.Resolver {
+				*network = "tcp"
+				return reso
+			},
+			MockNewParallelUDPResolver: func(logger model.DebugLogger, dialer model.Dialer, address string) model.Resolver {
+				*network = "udp"
+				return reso
+			},
+		}))
+	}
+
+	type testcase struct {
+		network string
+		newFunc func(rt Runtime) Func[*DomainToResolve, *ResolvedAddresses]
+	}
+
+	cases := []testcase{{
+		network: "tcp",
+		newFunc: func(rt Runtime) Func[*DomainToResolve, *ResolvedAddresses] {
+			return DNSLookupTCP(rt, "1.1.1.1:53")
+		},
+	}, {
+		network: "dot",
+		newFunc: func(rt Runtime) Func[*DomainToResolve, *ResolvedAddresses] {
+			return DNSLookupTLS(rt, "1.1.1.1:853")
+		},
+	}, {
+		network: "doh",
+		newFunc: func(rt Runtime) Func[*DomainToResolve, *ResolvedAddresses] {
+			return DNSLookupHTTPS(rt, "https://1.1.1.1/dns-query")
+		},
+	}}
+
+	for _, tc := range cases {
+		t.Run(tc.network, func(t *testing.T) {
+			t.Run("with lookup error", func(t *testing.T) {
+				mockedErr := errors.New("mocked")
+				var network string
+				rt := newRuntime(&mocks.Resolver{
+					MockLookupHost: func(ctx context.Context, domain string) ([]string, error) {
+						return nil, mockedErr
+					},
+				}, &network)
+				res := tc.newFunc(rt).Apply(context.Background(), NewMaybeWithValue(domain))
+				if res.Error != mockedErr {
+					t.Fatalf("unexpected error type: %s", res.Error)
+				}
+				if res.State != nil {
+					t.Fatal("expected nil state")
+				}
+				if network != tc.network {
+					t.Fatal("expected", tc.network, "got", network)
+				}
+			})
+
+			t.Run("with success", func(t *testing.T) {
+				var network string
+				rt := newRuntime(&mocks.Resolver{
+					MockLookupHost: func(ctx context.Context, domain string) ([]string, error) {
+						return []string{"93.184.216.34"}, nil
+					},
+				}, &network)
+				res := tc.newFunc(rt).Apply(context.Background(), NewMaybeWithValue(domain))
+				if res.Error != nil {
+					t.Fatalf("unexpected error: %s", res.Error)
+				}
+				if res.State == nil {
+					t.Fatal("unexpected nil state")
+				}
+				if len(res.State.Addresses) != 1 || res.State.Addresses[0] != "93.184.216.34" {
+					t.Fatal("unexpected addresses")
+				}
+				if network != tc.network {
+					t.Fatal("expected", tc.network, "got", network)
+				}
+			})
+		})
+	}
+
+	t.Run("with an unsupported network", func(t *testing.T) {
+		rt := NewMinimalRuntime(model.DiscardLogger, time.Now())
+		res := dnsLookupWithTransport(rt, "doq", "1.1.1.1:853").Apply(
+			context.Background(), NewMaybeWithValue(domain))
+		if !errors.Is(res.Error, ErrDNSLookupUnsupportedNetwork) {
+			t.Fatal("unexpected error", res.Error)
+		}
+	})
+}
+
+func TestDNSLookupHTTPSRecord(t *testing.T) {
+	domain := &DomainToResolve{
+		Domain: "example.com",
+		Tags:   []string{"antani"},
+	}
+
+	t.Run("with an unsupported network", func(t *testing.T) {
+		rt := NewMinimalRuntime(model.DiscardLogger, time.Now())
+		res := DNSLookupHTTPSRecord(rt, "doq", "1.1.1.1:853").Apply(
+			context.Background(), NewMaybeWithValue(domain))
+		if !errors.Is(res.Error, ErrDNSLookupUnsupportedNetwork) {
+			t.Fatal("unexpected error", res.Error)
+		}
+	})
+
+	t.Run("with lookup error", func(t *testing.T) {
+		mockedErr := errors.New("mocked")
+		rt := NewRuntimeMeasurexLite(model.DiscardLogger, time.Now(), RuntimeMeasurexLiteOptionMeasuringNetwork(&mocks.MeasuringNetwork{
+			MockNewParallelDNSOverHTTPSResolver: func(logger model.DebugLogger, URL string) model.Resolver {
+				return &mocks.Resolver{
+					MockLookupHTTPS: func(ctx context.Context, domain string) (*model.HTTPSSvc, error) {
+						return nil, mockedErr
+					},
+				}
+			},
+		}))
+		res := DNSLookupHTTPSRecord(rt, "doh", "https://1.1.1.1/dns-query").Apply(
+			context.Background(), NewMaybeWithValue(domain))
+		if res.Error != mockedErr {
+			t.Fatalf("unexpected error type: %s", res.Error)
+		}
+		if res.State != nil {
+			t.Fatal("expected nil state")
+		}
+	})
+
+	t.Run("with success", func(t *testing.T) {
+		rt := NewRuntimeMeasurexLite(model.DiscardLogger, time.Now(), RuntimeMeasurexLiteOptionMeasuringNetwork(&mocks.MeasuringNetwork{
+			MockNewDialerWithoutResolver: func(dl model.DebugLogger, w ...model.DialerWrapper) model.Dialer {
+				return &mocks.Dialer{}
+			},
+			MockNewParallelUDPResolver: func(logger model.DebugLogger, dialer model.Dialer, address string) model.Resolver {
+				return &mocks.Resolver{
+					MockLookupHTTPS: func(ctx context.Context, domain string) (*model.HTTPSSvc, error) {
+						out := &model.HTTPSSvc{
+							ALPN: []string{"h3", "h2"},
+							IPv4: []string{"93.184.216.34"},
+							IPv6: []string{"2606:2800:220:1:248:1893:25c8:1946"},
+						}
+						return out, nil
+					},
+				}
+			},
+		}))
+		res := DNSLookupHTTPSRecord(rt, "udp", "1.1.1.1:53").Apply(
+			context.Background(), NewMaybeWithValue(domain))
+		if res.Error != nil {
+			t.Fatalf("unexpected error: %s", res.Error)
+		}
+		expect := &ResolvedHTTPSRecord{
+			ALPN:      []string{"h3", "h2"},
+			Addresses: []string{"93.184.216.34", "2606:2800:220:1:248:1893:25c8:1946"},
+			Domain:    "example.com",
+		}
+		if diff := cmp.Diff(expect, res.State); diff != "" {
+			t.Fatal(diff)
+		}
+		expectFlat := []*ResolvedAddress{{
+			Address: "93.184.216.34",
+			Domain:  "example.com",
+		}, {
+			Address: "2606:2800:220:1:248:1893:25c8:1946",
+			Domain:  "example.com",
+		}}
+		if diff := cmp.Diff(expectFlat, res.State.Flatten()); diff != "" {
+			t.Fatal(diff)
+		}
+	})
+}
@@ -0,0 +1,88 @@
+package dslx
+
+//
+// Functional extensions (timeout, retry, and fallback combinators)
+//
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ooni/probe-cli/v3/internal/measurexlite"
+)
+
+// WithTimeout returns a [Func] that applies fx using a context bounded by the given timeout.
+func WithTimeout[A, B any](timeout time.Duration, fx Func[A, B]) Func[A, B] {
+	return FuncAdapter[A, B](func(ctx context.Context, input *Maybe[A]) *Maybe[B] {
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		return fx.Apply(ctx, input)
+	})
+}
+
+// Retry returns a [Func] that applies fx until it succeeds or until we have performed the
+// given number of attempts (we'll always perform at least one attempt). Between attempts, we
+// wait for backoff, which we double after each failed attempt. We stop early when the context
+// is done. We return the result of the last attempt. We do not apply fx when the input
+// already contains an error. We save a "retry_attempt" network event for each attempt.
+func Retry[A, B any](rt Runtime, attempts int, backoff time.Duration, fx Func[A, B]) Func[A, B] {
+	return FuncAdapter[A, B](func(ctx context.Context, input *Maybe[A]) *Maybe[B] {
+		if err := input.Error; err != nil {
+			return NewMaybeWithError[B](err)
+		}
+		index := rt.IDGenerator().Add(1)
+		var output *Maybe[B]
+		for idx := 0; ; idx++ {
+			output = fxRecordAttempt(ctx, rt, index, "retry_attempt", fx, input)
+			if output.Error == nil || idx+1 >= attempts {
+				return output
+			}
+			timer := time.NewTimer(backoff)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return output
+			case <-timer.C:
+				backoff *= 2
+			}
+		}
+	})
+}
+
+// ErrFirstSuccessNoFuncs indicates that FirstSuccess was not given any function.
+var ErrFirstSuccessNoFuncs = errors.New("dslx: FirstSuccess called without functions")
+
+// FirstSuccess returns a [Func] that applies each fx in fxs in sequence and returns the
+// first successful result. If all of them fail, we return the result of the last one. We
+// do not apply any fx when the input already contains an error. We save a
+// "first_success_attempt" network event for each attempt.
+func FirstSuccess[A, B any](rt Runtime, fxs ...Func[A, B]) Func[A, B] {
+	return FuncAdapter[A, B](func(ctx context.Context, input *Maybe[A]) *Maybe[B] {
+		if err := input.Error; err != nil {
+			return NewMaybeWithError[B](err)
+		}
+		index := rt.IDGenerator().Add(1)
+		output := NewMaybeWithError[B](ErrFirstSuccessNoFuncs)
+		for _, fx := range fxs {
+			output = fxRecordAttempt(ctx, rt, index, "first_success_attempt", fx, input)
+			if output.Error == nil {
+				break
+			}
+		}
+		return output
+	})
+}
+
+// fxRecordAttempt applies fx and saves a network event describing the attempt.
+func fxRecordAttempt[A, B any](
+	ctx context.Context, rt Runtime, index int64, operation string, fx Func[A, B], input *Maybe[A]) *Maybe[B] {
+	started := time.Since(rt.ZeroTime())
+	output := fx.Apply(ctx, input)
+	finished := time.Since(rt.ZeroTime())
+	observations := NewObservations()
+	observations.NetworkEvents = append(observations.NetworkEvents,
+		measurexlite.NewArchivalNetworkEvent(index, started, operation, "", "", 0, output.Error, finished))
+	rt.SaveObservations(observations)
+	return output
+}
@@ -0,0 +1,161 @@
+package dslx
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ooni/probe-cli/v3/internal/model"
+)
+
+// newCountingFunc returns a Func that fails with err for the first failures calls
+// and then succeeds returning the number of calls, along with the calls counter.
+func newCountingFunc(failures int, err error) (Func[int, int], *int) {
+	var count int
+	fx := Operation[int, int](func(ctx context.Context, input int) (int, error) {
+		count++
+		if count <= failures {
+			return 0, err
+		}
+		return count, nil
+	})
+	return fx, &count
+}
+
+// countNetworkEvents counts the network events with the given operation.
+func countNetworkEvents(rt Runtime, operation string) (count int) {
+	for _, ev := range rt.Observations().NetworkEvents {
+		if ev.Operation == operation {
+			count++
+		}
+	}
+	return
+}
+
+func TestWithTimeout(t *testing.T) {
+	fx := WithTimeout[int, int](time.Millisecond, Operation[int, int](func(ctx context.Context, input int) (int, error) {
+		<-ctx.Done()
+		return 0, ctx.Err()
+	}))
+	res := fx.Apply(context.Background(), NewMaybeWithValue(0))
+	if !errors.Is(res.Error, context.DeadlineExceeded) {
+		t.Fatal("unexpected error", res.Error)
+	}
+}
+
+func TestRetry(t *testing.T) {
+	expected := errors.New("mocked error")
+
+	t.Run("with eventual success", func(t *testing.T) {
+		rt := NewMinimalRuntime(model.DiscardLogger, time.Now())
+		inner, count := newCountingFunc(2, expected)
+		res := Retry(rt, 3, time.Microsecond, inner).Apply(context.Background(), NewMaybeWithValue(0))
+		if res.Error != nil {
+			t.Fatal(res.Error)
+		}
+		if res.State != 3 || *count != 3 {
+			t.Fatal("unexpected number of attempts")
+		}
+		if n := countNetworkEvents(rt, "retry_attempt"); n != 3 {
+			t.Fatal("unexpected number of network events", n)
+		}
+	})
+
+	t.Run("when all attempts fail", func(t *testing.T) {
+		rt := NewMinimalRuntime(model.DiscardLogger, time.Now())
+		inner, count := newCountingFunc(10, expected)
+		res := Retry(rt, 3, time.Microsecond, inner).Apply(context.Background(), NewMaybeWithValue(0))
+		if !errors.Is(res.Error, expected) {
+			t.Fatal("unexpected error", res.Error)
+		}
+		if *count != 3 {
+			t.Fatal("unexpected number of attempts")
+		}
+		for _, ev := range rt.Observations().NetworkEvents {
+			if ev.Failure == nil || *ev.Failure != "unknown_failure: mocked error" {
+				t.Fatal("unexpected failure", ev.Failure)
+			}
+		}
+	})
+
+	t.Run("with zero attempts", func(t *testing.T) {
+		rt := NewMinimalRuntime(model.DiscardLogger, time.Now())
+		inner, count := newCountingFunc(10, expected)
+		res := Retry(rt, 0, time.Microsecond, inner).Apply(context.Background(), NewMaybeWithValue(0))
+		if !errors.Is(res.Error, expected) || *count != 1 {
+			t.Fatal("expected exactly one attempt")
+		}
+	})
+
+	t.Run("when the context is done", func(t *testing.T) {
+		rt := NewMinimalRuntime(model.DiscardLogger, time.Now())
+		inner, count := newCountingFunc(10, expected)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		res := Retry(rt, 3, time.Hour, inner).Apply(ctx, NewMaybeWithValue(0))
+		if !errors.Is(res.Error, expected) || *count != 1 {
+			t.Fatal("expected exactly one attempt")
+		}
+	})
+
+	t.Run("with an input error", func(t *testing.T) {
+		rt := NewMinimalRuntime(model.DiscardLogger, time.Now())
+		inner, count := newCountingFunc(0, nil)
+		res := Retry(rt, 3, time.Microsecond, inner).Apply(context.Background(), NewMaybeWithError[int](expected))
+		if !errors.Is(res.Error, expected) || *count != 0 {
+			t.Fatal("expected no attempts")
+		}
+		if n := countNetworkEvents(rt, "retry_attempt"); n != 0 {
+			t.Fatal("unexpected number of network events", n)
+		}
+	})
+}
+
+func TestFirstSuccess(t *testing.T) {
+	expected := errors.New("mocked error")
+
+	t.Run("with success", func(t *testing.T) {
+		rt := NewMinimalRuntime(model.DiscardLogger, time.Now())
+		first, firstCount := newCountingFunc(1, expected)
+		second, secondCount := newCountingFunc(0, nil)
+		third, thirdCount := newCountingFunc(0, nil)
+		res := FirstSuccess(rt, first, second, third).Apply(context.Background(), NewMaybeWithValue(0))
+		if res.Error != nil {
+			t.Fatal(res.Error)
+		}
+		if *firstCount != 1 || *secondCount != 1 || *thirdCount != 0 {
+			t.Fatal("unexpected calls")
+		}
+		if n := countNetworkEvents(rt, "first_success_attempt"); n != 2 {
+			t.Fatal("unexpected number of network events", n)
+		}
+	})
+
+	t.Run("when all functions fail", func(t *testing.T) {
+		rt := NewMinimalRuntime(model.DiscardLogger, time.Now())
+		first, _ := newCountingFunc(1, errors.New("first error"))
+		second, _ := newCountingFunc(1, expected)
+		res := FirstSuccess(rt, first, second).Apply(context.Background(), NewMaybeWithValue(0))
+		if !errors.Is(res.Error, expected) {
+			t.Fatal("unexpected error", res.Error)
+		}
+	})
+
+	t.Run("without functions", func(t *testing.T) {
+		rt := NewMinimalRuntime(model.DiscardLogger, time.Now())
+		res := FirstSuccess[int, int](rt).Apply(context.Background(), NewMaybeWithValue(0))
+		if !errors.Is(res.Error, ErrFirstSuccessNoFuncs) {
+			t.Fatal("unexpected error", res.Error)
+		}
+	})
+
+	t.Run("with an input error", func(t *testing.T) {
+		rt := NewMinimalRuntime(model.DiscardLogger, time.Now())
+		first, count := newCountingFunc(0, nil)
+		res := FirstSuccess(rt, first).Apply(context.Background(), NewMaybeWithError[int](expected))
+		if !errors.Is(res.Error, expected) || *count != 0 {
+			t.Fatal("expected no attempts")
+		}
+	})
+}
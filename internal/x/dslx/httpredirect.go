@@ -0,0 +1,299 @@
+package dslx
+
+//
+// HTTP redirects
+//
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+
+	"github.com/ooni/probe-cli/v3/internal/netxlite"
+	"github.com/ooni/probe-cli/v3/internal/runtimex"
+	"golang.org/x/net/publicsuffix"
+)
+
+// ErrHTTPTooManyRedirects indicates that we exceeded the maximum redirect depth.
+var ErrHTTPTooManyRedirects = errors.New("dslx: too many HTTP redirects")
+
+// ErrHTTPRedirectInvalidLocation indicates that the Location header is missing or invalid.
+var ErrHTTPRedirectInvalidLocation = errors.New("dslx: invalid HTTP redirect location")
+
+// ErrHTTPRedirectUnsupportedScheme indicates that we cannot follow a redirect
+// because the Location's scheme is neither "http" nor "https".
+var ErrHTTPRedirectUnsupportedScheme = errors.New("dslx: unsupported HTTP redirect scheme")
+
+// HTTPRedirectOption is an option you can pass to HTTPFollowRedirects.
+type HTTPRedirectOption func(config *httpRedirectConfig)
+
+// httpRedirectConfig contains the config for HTTPFollowRedirects.
+type httpRedirectConfig struct {
+	dnsLookup      Func[*DomainToResolve, *ResolvedAddresses]
+	jar            http.CookieJar
+	maxDepth       int
+	requestOptions []HTTPRequestOption
+	tags           []string
+	tlsOptions     []TLSHandshakeOption
+}
+
+// HTTPRedirectOptionCookieJar sets the cookie jar shared by all the hops. By default
+// we create a new cookie jar every time we start following a redirect chain.
+func HTTPRedirectOptionCookieJar(value http.CookieJar) HTTPRedirectOption {
+	return func(config *httpRedirectConfig) {
+		config.jar = value
+	}
+}
+
+// HTTPRedirectOptionDNSLookup sets the function to resolve each hop's domain. By
+// default we use [DNSLookupGetaddrinfo].
+func HTTPRedirectOptionDNSLookup(value Func[*DomainToResolve, *ResolvedAddresses]) HTTPRedirectOption {
+	return func(config *httpRedirectConfig) {
+		config.dnsLookup = value
+	}
+}
+
+// HTTPRedirectOptionMaxDepth sets the maximum number of redirects to follow.
+func HTTPRedirectOptionMaxDepth(value int) HTTPRedirectOption {
+	return func(config *httpRedirectConfig) {
+		config.maxDepth = value
+	}
+}
+
+// HTTPRedirectOptionRequestOptions sets options for each hop's HTTP request (e.g.,
+// [HTTPRequestOptionUserAgent]). We apply these options after setting the method,
+// the host and the URL path derived from the Location header.
+func HTTPRedirectOptionRequestOptions(value ...HTTPRequestOption) HTTPRedirectOption {
+	return func(config *httpRedirectConfig) {
+		config.requestOptions = append(config.requestOptions, value...)
+	}
+}
+
+// HTTPRedirectOptionTags allows to set tags to tag each hop's observations.
+func HTTPRedirectOptionTags(value ...string) HTTPRedirectOption {
+	return func(config *httpRedirectConfig) {
+		config.tags = append(config.tags, value...)
+	}
+}
+
+// HTTPRedirectOptionTLSOptions sets options for each hop's TLS handshake.
+func HTTPRedirectOptionTLSOptions(value ...TLSHandshakeOption) HTTPRedirectOption {
+	return func(config *httpRedirectConfig) {
+		config.tlsOptions = append(config.tlsOptions, value...)
+	}
+}
+
+// HTTPRedirectChain is the result of following HTTP redirects.
+type HTTPRedirectChain struct {
+	// Responses contains the response that started the redirect chain followed by
+	// the response of each hop. It always contains at least one entry.
+	Responses []*HTTPResponse
+}
+
+// Final returns the last response in the chain.
+func (c *HTTPRedirectChain) Final() *HTTPResponse {
+	return c.Responses[len(c.Responses)-1]
+}
+
+// HTTPFollowRedirects returns a function that follows the redirects starting from
+// the given [*HTTPResponse]. For each hop, we parse the Location header and run a new
+// DNS, TCP/TLS, and HTTP sub-pipeline using fresh traces, such that observations
+// are saved separately for each hop. We try the resolved addresses in sequence and
+// stop at the first one for which the HTTP round trip succeeds. All the hops share
+// the same cookie jar. We fail with [ErrHTTPTooManyRedirects] if the chain is longer
+// than the configured maximum depth, which by default is ten redirects.
+//
+// When following the chain fails, the returned [*Maybe] contains both the error and
+// the [*HTTPRedirectChain] with all the responses we received before failing.
+func HTTPFollowRedirects(rt Runtime, options ...HTTPRedirectOption) Func[*HTTPResponse, *HTTPRedirectChain] {
+	return FuncAdapter[*HTTPResponse, *HTTPRedirectChain](func(ctx context.Context, input *Maybe[*HTTPResponse]) *Maybe[*HTTPRedirectChain] {
+		if err := input.Error; err != nil {
+			return NewMaybeWithError[*HTTPRedirectChain](err)
+		}
+
+		// create the config
+		config := &httpRedirectConfig{
+			dnsLookup:      DNSLookupGetaddrinfo(rt),
+			jar:            nil,
+			maxDepth:       10,
+			requestOptions: []HTTPRequestOption{},
+			tags:           []string{},
+			tlsOptions:     []TLSHandshakeOption{},
+		}
+		for _, option := range options {
+			option(config)
+		}
+		if config.jar == nil {
+			// Note: cookiejar.New cannot fail, so we're using runtimex.Try1 here
+			config.jar = runtimex.Try1(cookiejar.New(&cookiejar.Options{
+				PublicSuffixList: publicsuffix.List,
+			}))
+		}
+
+		// follow the chain and return the partial chain along with the error, if any
+		chain := &HTTPRedirectChain{
+			Responses: []*HTTPResponse{input.State},
+		}
+		err := httpRedirectFollow(ctx, rt, config, chain)
+		return &Maybe[*HTTPRedirectChain]{
+			Error: err,
+			State: chain,
+		}
+	})
+}
+
+// httpRedirectFollow follows the redirects appending each hop's response to the chain.
+func httpRedirectFollow(ctx context.Context, rt Runtime, config *httpRedirectConfig, chain *HTTPRedirectChain) error {
+	for {
+		// remember the cookies set by the current response
+		current := chain.Final()
+		if current.HTTPRequest == nil || current.HTTPResponse == nil {
+			return nil
+		}
+		config.jar.SetCookies(current.HTTPRequest.URL, current.HTTPResponse.Cookies())
+
+		// determine whether we should redirect
+		if !httpRedirectIsRedirect(current.HTTPResponse.StatusCode) {
+			return nil
+		}
+		if len(chain.Responses) > config.maxDepth {
+			return ErrHTTPTooManyRedirects
+		}
+
+		// figure out the next URL
+		location, err := httpRedirectLocation(current)
+		if err != nil {
+			return err
+		}
+
+		// perform the next hop
+		method := httpRedirectMethod(current.HTTPRequest.Method, current.HTTPResponse.StatusCode)
+		next, err := httpRedirectHop(ctx, rt, config, method, location)
+		if err != nil {
+			return err
+		}
+		chain.Responses = append(chain.Responses, next)
+	}
+}
+
+// httpRedirectIsRedirect returns whether the status code is a redirect.
+func httpRedirectIsRedirect(code int) bool {
+	switch code {
+	case 301, 302, 303, 307, 308:
+		return true
+	default:
+		return false
+	}
+}
+
+// httpRedirectMethod returns the method to use for the next hop. Like browsers do, we
+// switch to GET except for 307 and 308, which require to preserve the method.
+func httpRedirectMethod(method string, code int) string {
+	switch code {
+	case 307, 308:
+		return method
+	default:
+		return "GET"
+	}
+}
+
+// httpRedirectLocation resolves the Location header relative to the request URL.
+func httpRedirectLocation(resp *HTTPResponse) (*url.URL, error) {
+	value := resp.HTTPResponse.Header.Get("Location")
+	if value == "" {
+		return nil, ErrHTTPRedirectInvalidLocation
+	}
+	location, err := resp.HTTPRequest.URL.Parse(value)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrHTTPRedirectInvalidLocation, err.Error())
+	}
+	if location.Hostname() == "" {
+		return nil, ErrHTTPRedirectInvalidLocation
+	}
+	return location, nil
+}
+
+// httpRedirectHop runs the DNS, TCP/TLS, and HTTP sub-pipeline for the given location.
+func httpRedirectHop(
+	ctx context.Context, rt Runtime, config *httpRedirectConfig, method string, location *url.URL) (*HTTPResponse, error) {
+	// create the sub-pipeline
+	requestOptions := []HTTPRequestOption{
+		HTTPRequestOptionMethod(method),
+		HTTPRequestOptionHost(location.Host),
+		httpRequestOptionURLPathAndQuery(location),
+	}
+	requestOptions = append(requestOptions, config.requestOptions...)
+	requestOptions = append(requestOptions, httpRequestOptionCookieJar(config.jar))
+
+	var (
+		fx   Func[*Endpoint, *HTTPResponse]
+		port string
+	)
+	switch location.Scheme {
+	case "http":
+		fx = Compose2(TCPConnect(rt), HTTPRequestOverTCP(rt, requestOptions...))
+		port = "80"
+	case "https":
+		fx = Compose3(TCPConnect(rt), TLSHandshake(rt, config.tlsOptions...), HTTPRequestOverTLS(rt, requestOptions...))
+		port = "443"
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrHTTPRedirectUnsupportedScheme, location.Scheme)
+	}
+	if value := location.Port(); value != "" {
+		port = value
+	}
+
+	// resolve the domain unless it's already an IP address
+	domain := location.Hostname()
+	addrs := []string{domain}
+	if net.ParseIP(domain) == nil {
+		dnsInput := NewDomainToResolve(DomainName(domain), DNSLookupOptionTags(config.tags...))
+		dnsResult := config.dnsLookup.Apply(ctx, NewMaybeWithValue(dnsInput))
+		if dnsResult.Error != nil {
+			return nil, dnsResult.Error
+		}
+		addrs = dnsResult.State.Addresses
+	}
+
+	// try each address in sequence until we succeed
+	var err error = netxlite.ErrOODNSNoAnswer // only returned if there are no addresses
+	for _, addr := range addrs {
+		endpoint := NewEndpoint(
+			"tcp",
+			EndpointAddress(net.JoinHostPort(addr, port)),
+			EndpointOptionDomain(domain),
+			EndpointOptionTags(config.tags...),
+		)
+		result := fx.Apply(ctx, NewMaybeWithValue(endpoint))
+		if result.Error == nil {
+			return result.State, nil
+		}
+		err = result.Error
+	}
+	return nil, err
+}
+
+// httpRequestOptionURLPathAndQuery sets the URL path and query from the given URL.
+func httpRequestOptionURLPathAndQuery(value *url.URL) HTTPRequestOption {
+	return func(req *http.Request) {
+		req.URL.Path = value.Path
+		req.URL.RawPath = value.RawPath
+		req.URL.RawQuery = value.RawQuery
+		if req.URL.Path == "" {
+			req.URL.Path = "/"
+		}
+	}
+}
+
+// httpRequestOptionCookieJar adds the cookies in the jar matching the request URL.
+func httpRequestOptionCookieJar(jar http.CookieJar) HTTPRequestOption {
+	return func(req *http.Request) {
+		for _, cookie := range jar.Cookies(req.URL) {
+			req.AddCookie(cookie)
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package dslx
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/ooni/probe-cli/v3/internal/model"
+	"github.com/ooni/probe-cli/v3/internal/netxlite"
+	"github.com/ooni/probe-cli/v3/internal/runtimex"
+)
+
+func TestHTTPFollowRedirects(t *testing.T) {
+	// newResponse creates an *HTTPResponse with the given status and location
+	newResponse := func(code int, location string) *HTTPResponse {
+		req := runtimex.Try1(http.NewRequest("GET", "https://www.example.com/foo", nil))
+		resp := &http.Response{
+			StatusCode: code,
+			Header:     http.Header{},
+		}
+		if location != "" {
+			resp.Header.Set("Location", location)
+		}
+		resp.Header.Add("Set-Cookie", "name=value")
+		return &HTTPResponse{
+			Address:      "93.184.216.34:443",
+			Domain:       "www.example.com",
+			HTTPRequest:  req,
+			HTTPResponse: resp,
+			Network:      "tcp",
+		}
+	}
+
+	t.Run("with a non-redirect response", func(t *testing.T) {
+		rt := NewMinimalRuntime(model.DiscardLogger, time.Now())
+		input := newResponse(200, "")
+		jar := runtimex.Try1(cookiejar.New(nil))
+		fx := HTTPFollowRedirects(rt, HTTPRedirectOptionCookieJar(jar))
+		res := fx.Apply(context.Background(), NewMaybeWithValue(input))
+		if res.Error != nil {
+			t.Fatal(res.Error)
+		}
+		if len(res.State.Responses) != 1 || res.State.Final() != input {
+			t.Fatal("unexpected responses")
+		}
+		cookies := jar.Cookies(input.HTTPRequest.URL)
+		if len(cookies) != 1 || cookies[0].Name != "name" || cookies[0].Value != "value" {
+			t.Fatal("unexpected cookies", cookies)
+		}
+	})
+
+	t.Run("with a missing Location header", func(t *testing.T) {
+		rt := NewMinimalRuntime(model.DiscardLogger, time.Now())
+		fx := HTTPFollowRedirects(rt)
+		res := fx.Apply(context.Background(), NewMaybeWithValue(newResponse(302, "")))
+		if !errors.Is(res.Error, ErrHTTPRedirectInvalidLocation) {
+			t.Fatal("unexpected error", res.Error)
+		}
+	})
+
+	t.Run("with an unparseable Location header", func(t *testing.T) {
+		rt := NewMinimalRuntime(model.DiscardLogger, time.Now())
+		fx := HTTPFollowRedirects(rt)
+		res := fx.Apply(context.Background(), NewMaybeWithValue(newResponse(302, "\t")))
+		if !errors.Is(res.Error, ErrHTTPRedirectInvalidLocation) {
+			t.Fatal("unexpected error", res.Error)
+		}
+	})
+
+	t.Run("with an unsupported scheme", func(t *testing.T) {
+		rt := NewMinimalRuntime(model.DiscardLogger, time.Now())
+		fx := HTTPFollowRedirects(rt)
+		res := fx.Apply(context.Background(), NewMaybeWithValue(newResponse(302, "ftp://www.example.com/")))
+		if !errors.Is(res.Error, ErrHTTPRedirectUnsupportedScheme) {
+			t.Fatal("unexpected error", res.Error)
+		}
+	})
+
+	t.Run("when exceeding the maximum depth", func(t *testing.T) {
+		rt := NewMinimalRuntime(model.DiscardLogger, time.Now())
+		fx := HTTPFollowRedirects(rt, HTTPRedirectOptionMaxDepth(0))
+		res := fx.Apply(context.Background(), NewMaybeWithValue(newResponse(302, "/bar")))
+		if !errors.Is(res.Error, ErrHTTPTooManyRedirects) {
+			t.Fatal("unexpected error", res.Error)
+		}
+		if res.State == nil || len(res.State.Responses) != 1 {
+			t.Fatal("expected the partial chain")
+		}
+	})
+
+	t.Run("when the DNS lookup fails", func(t *testing.T) {
+		rt := NewMinimalRuntime(model.DiscardLogger, time.Now())
+		expected := errors.New("mocked error")
+		dnsLookup := Operation[*DomainToResolve, *ResolvedAddresses](
+			func(ctx context.Context, input *DomainToResolve) (*ResolvedAddresses, error) {
+				if input.Domain != "www.example.org" {
+					t.Fatal("unexpected domain", input.Domain)
+				}
+				return nil, expected
+			})
+		fx := HTTPFollowRedirects(rt, HTTPRedirectOptionDNSLookup(dnsLookup))
+		res := fx.Apply(context.Background(), NewMaybeWithValue(newResponse(301, "http://www.example.org/")))
+		if !errors.Is(res.Error, expected) {
+			t.Fatal("unexpected error", res.Error)
+		}
+	})
+
+	t.Run("with a previous stage error", func(t *testing.T) {
+		rt := NewMinimalRuntime(model.DiscardLogger, time.Now())
+		expected := errors.New("mocked error")
+		fx := HTTPFollowRedirects(rt)
+		res := fx.Apply(context.Background(), NewMaybeWithError[*HTTPResponse](expected))
+		if !errors.Is(res.Error, expected) {
+			t.Fatal("unexpected error", res.Error)
+		}
+		if res.State != nil {
+			t.Fatal("expected nil state")
+		}
+	})
+
+	t.Run("when a hop fails in the middle of the chain", func(t *testing.T) {
+		// figure out the URL of a closed port
+		listener := runtimex.Try1(net.Listen("tcp", "127.0.0.1:0"))
+		closedURL := "http://" + listener.Addr().String() + "/"
+		listener.Close()
+
+		// create a server redirecting to the closed port
+		srvr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Redirect(w, r, closedURL, http.StatusFound)
+		}))
+		defer srvr.Close()
+
+		rt := NewMinimalRuntime(model.DiscardLogger, time.Now())
+		fx := HTTPFollowRedirects(rt)
+		res := fx.Apply(context.Background(), NewMaybeWithValue(newResponse(302, srvr.URL+"/")))
+		if res.Error == nil || res.Error.Error() != netxlite.FailureConnectionRefused {
+			t.Fatal("unexpected error", res.Error)
+		}
+		chain := res.State
+		if chain == nil || len(chain.Responses) != 2 {
+			t.Fatal("expected the partial chain")
+		}
+		final := chain.Final()
+		if final.HTTPResponse.StatusCode != http.StatusFound {
+			t.Fatal("unexpected status code", final.HTTPResponse.StatusCode)
+		}
+		if location := final.HTTPResponse.Header.Get("Location"); location != closedURL {
+			t.Fatal("unexpected location", location)
+		}
+	})
+}
+
+func TestHTTPRedirectMethod(t *testing.T) {
+	cases := []struct {
+		method string
+		code   int
+		expect string
+	}{
+		{"POST", 301, "GET"},
+		{"POST", 302, "GET"},
+		{"POST", 303, "GET"},
+		{"POST", 307, "POST"},
+		{"HEAD", 308, "HEAD"},
+	}
+	for _, tc := range cases {
+		if got := httpRedirectMethod(tc.method, tc.code); got != tc.expect {
+			t.Fatal("for", tc.method, tc.code, "expected", tc.expect, "got", got)
+		}
+	}
+}
+
+func TestHTTPRequestOptionURLPathAndQuery(t *testing.T) {
+	t.Run("with path and query", func(t *testing.T) {
+		req := runtimex.Try1(http.NewRequest("GET", "https://www.example.com/", nil))
+		httpRequestOptionURLPathAndQuery(&url.URL{Path: "/foo", RawQuery: "bar=baz"})(req)
+		if req.URL.String() != "https://www.example.com/foo?bar=baz" {
+			t.Fatal("unexpected URL", req.URL.String())
+		}
+	})
+
+	t.Run("with empty path", func(t *testing.T) {
+		req := runtimex.Try1(http.NewRequest("GET", "https://www.example.com/foo", nil))
+		httpRequestOptionURLPathAndQuery(&url.URL{})(req)
+		if req.URL.String() != "https://www.example.com/" {
+			t.Fatal("unexpected URL", req.URL.String())
+		}
+	})
+}
+
+func TestHTTPRequestOptionCookieJar(t *testing.T) {
+	jar := runtimex.Try1(cookiejar.New(nil))
+	URL := runtimex.Try1(url.Parse("https://www.example.com/"))
+	jar.SetCookies(URL, []*http.Cookie{{Name: "name", Value: "value"}})
+	req := runtimex.Try1(http.NewRequest("GET", "https://www.example.com/foo", nil))
+	httpRequestOptionCookieJar(jar)(req)
+	if value := req.Header.Get("Cookie"); value != "name=value" {
+		t.Fatal("unexpected Cookie header", value)
+	}
+}
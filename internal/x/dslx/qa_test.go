@@ -309,3 +309,95 @@ func TestMeasureResolvedAddressesQA(t *testing.T) {
 		})
 	}
 }
+
+func TestDNSLookupHTTPSQA(t *testing.T) {
+	// create an internet testing scenario
+	env := netemx.MustNewScenario(netemx.InternetScenario)
+	defer env.Close()
+
+	// create a dslx.Runtime using the client stack
+	rt := dslx.NewRuntimeMeasurexLite(log.Log, time.Now(), dslx.RuntimeMeasurexLiteOptionMeasuringNetwork(&netxlite.Netx{
+		Underlying: &netxlite.NetemUnderlyingNetworkAdapter{UNet: env.ClientStack},
+	}))
+	defer rt.Close()
+
+	// resolve using the DNS-over-HTTPS server
+	function := dslx.DNSLookupHTTPS(rt, "https://dns.google/dns-query")
+	results := function.Apply(context.Background(), dslx.NewMaybeWithValue(dslx.NewDomainToResolve("dns.google")))
+	if results.Error != nil {
+		t.Fatal(results.Error)
+	}
+
+	// make sure we resolved the expected IP addresses
+	expectAddrs := []string{"8.8.8.8", "8.8.4.4"}
+	if diff := cmp.Diff(expectAddrs, results.State.Addresses, cmpopts.SortSlices(qaStringLessFunc)); diff != "" {
+		t.Fatal(diff)
+	}
+
+	// make sure we have queries using the DNS-over-HTTPS engine
+	var queries int
+	for _, query := range rt.Observations().Queries {
+		if query.Engine == "doh" && query.ResolverAddress == "https://dns.google/dns-query" {
+			queries++
+		}
+	}
+	if queries != 2 {
+		t.Fatal("expected two DNS-over-HTTPS queries, got", queries)
+	}
+}
+
+func TestHTTPFollowRedirectsQA(t *testing.T) {
+	// create an internet testing scenario
+	env := netemx.MustNewScenario(netemx.InternetScenario)
+	defer env.Close()
+
+	// create a dslx.Runtime using the client stack
+	rt := dslx.NewRuntimeMeasurexLite(log.Log, time.Now(), dslx.RuntimeMeasurexLiteOptionMeasuringNetwork(&netxlite.Netx{
+		Underlying: &netxlite.NetemUnderlyingNetworkAdapter{UNet: env.ClientStack},
+	}))
+	defer rt.Close()
+
+	// fetch http://example.com/, which redirects to https://www.example.com/
+	function := dslx.Compose3(
+		dslx.TCPConnect(rt),
+		dslx.HTTPRequestOverTCP(rt),
+		dslx.HTTPFollowRedirects(rt, dslx.HTTPRedirectOptionMaxDepth(2)),
+	)
+	endpoint := dslx.NewEndpoint(
+		"tcp",
+		dslx.EndpointAddress(net.JoinHostPort(netemx.AddressWwwExampleCom, "80")),
+		dslx.EndpointOptionDomain("example.com"),
+	)
+	results := function.Apply(context.Background(), dslx.NewMaybeWithValue(endpoint))
+	if results.Error != nil {
+		t.Fatal(results.Error)
+	}
+
+	// make sure we followed the redirect
+	chain := results.State
+	if len(chain.Responses) != 2 {
+		t.Fatal("expected two responses, got", len(chain.Responses))
+	}
+	final := chain.Final()
+	if final.HTTPResponse.StatusCode != 200 {
+		t.Fatal("unexpected status code", final.HTTPResponse.StatusCode)
+	}
+	if URL := final.HTTPRequest.URL.String(); URL != "https://www.example.com/" {
+		t.Fatal("unexpected final URL", URL)
+	}
+	if string(final.HTTPResponseBodySnapshot) != netemx.ExampleWebPage {
+		t.Fatal("unexpected final body")
+	}
+
+	// make sure we have observations for each hop
+	observations := rt.Observations()
+	if len(observations.Requests) != 2 {
+		t.Fatal("expected two HTTP requests, got", len(observations.Requests))
+	}
+	if len(observations.TLSHandshakes) < 1 {
+		t.Fatal("expected at least a TLS handshake")
+	}
+	if len(observations.Queries) < 1 {
+		t.Fatal("expected at least a DNS query")
+	}
+}